@@ -87,6 +87,9 @@ func toAddVErr(err error) errhand.VerboseError {
 
 		return bdr.Build()
 
+	case err == env.ErrRepoStateConflict || err == env.ErrRepoStateLocked:
+		return repoStateUpdateVErr(err)
+
 	default:
 		return errhand.BuildDError("Unknown error").AddCause(err).Build()
 	}
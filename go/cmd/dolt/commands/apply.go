@@ -0,0 +1,103 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/merge"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+)
+
+var applyShortDesc = "Apply a patch to the working tables"
+var applyLongDesc = "Applies the changes in <patchfile> to the working tables. A patch is the output of " +
+	"<b>dolt diff -r json</b>, and can be sent to another copy of the repository by email or any other means.\n" +
+	"\n" +
+	"Each changed row is compared with the version of the row the patch was made against. Rows which match are " +
+	"changed, rows which already have the patch's changes are left alone, and all other rows are recorded as " +
+	"conflicts to be resolved with <b>dolt conflicts</b>. Changes to table schemas must apply cleanly or nothing " +
+	"is applied."
+
+var applySynopsis = []string{
+	"<patchfile>",
+}
+
+func Apply(commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := argparser.NewArgParser()
+	ap.ArgListHelp["patchfile"] = "A file holding the output of dolt diff -r json."
+	help, usage := cli.HelpAndUsagePrinters(commandStr, applyShortDesc, applyLongDesc, applySynopsis, ap)
+	apr := cli.ParseArgs(ap, args, help)
+
+	if apr.NArg() != 1 {
+		usage()
+		return 1
+	}
+
+	verr := applyPatch(context.TODO(), dEnv, apr.Arg(0))
+
+	if verr != nil {
+		cli.PrintErrln(verr.Verbose())
+		return 1
+	}
+
+	return 0
+}
+
+func applyPatch(ctx context.Context, dEnv *env.DoltEnv, patchFile string) errhand.VerboseError {
+	root, verr := GetWorkingWithVErr(dEnv)
+
+	if verr != nil {
+		return verr
+	}
+
+	if has, err := root.HasConflicts(ctx); err != nil {
+		return errhand.BuildDError("error: failed to get conflicts").AddCause(err).Build()
+	} else if has {
+		return errhand.BuildDError("error: Applying a patch is not possible because you have unmerged tables.").
+			AddDetails("hint: Fix them up in the work tree, and then use 'dolt add <table>'").Build()
+	}
+
+	rd, err := dEnv.FS.OpenForRead(patchFile)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to open '%s'", patchFile).AddCause(err).Build()
+	}
+
+	defer rd.Close()
+
+	patch, err := diff.ReadJsonDiff(rd)
+
+	if err != nil {
+		return errhand.BuildDError("error: '%s' is not a valid patch", patchFile).AddCause(err).Build()
+	}
+
+	root, tblToStats, err := merge.ApplyPatch(ctx, dEnv.DoltDB, root, patch)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to apply '%s'", patchFile).AddCause(err).Build()
+	}
+
+	verr = UpdateWorkingWithVErr(dEnv, root)
+
+	if verr == nil && printSuccessStats(tblToStats) {
+		cli.Println("The patch applied with conflicts; fix conflicts and then commit the result.")
+	}
+
+	return verr
+}
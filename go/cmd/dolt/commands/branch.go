@@ -43,7 +43,9 @@ With a <b>-m</b>, <oldbranch> will be renamed to <newbranch>. If <newbranch> exi
 
 The <b>-c</b> options have the exact same semantics as <b>-m</b>, except instead of the branch being renamed it will be copied to a new name.
 
-With a <b>-d</b>, <branchname> will be deleted. You may specify more than one branch for deletion.`
+With a <b>-d</b>, <branchname> will be deleted. You may specify more than one branch for deletion.
+
+With <b>--set-upstream-to</b>, the upstream of <branchname>, or the current branch if no branch is given, is set to the remote-tracking branch given, e.g. origin/master. The upstream is used by argument-less <b>dolt push</b> and <b>dolt pull</b>, and <b>dolt status</b> and <b>dolt branch -vv</b> report how far a branch has diverged from it. <b>--unset-upstream</b> removes the upstream of a branch.`
 
 var branchForceFlagDesc = "Reset <branchname> to <startpoint>, even if <branchname> exists already. Without -f, dolt branch " +
 	"refuses to change an existing branch. In combination with -d (or --delete), allow deleting the branch irrespective " +
@@ -51,11 +53,13 @@ var branchForceFlagDesc = "Reset <branchname> to <startpoint>, even if <branchna
 	"already exists, the same applies for -c (or --copy)."
 
 var branchSynopsis = []string{
-	`[--list] [-v] [-a]`,
+	`[--list] [-v | -vv] [-a]`,
 	`[-f] <branchname> [<start-point>]`,
 	`-m [-f] [<oldbranch>] <newbranch>`,
 	`-c [-f] [<oldbranch>] <newbranch>`,
 	`-d [-f] <branchname>...`,
+	`--set-upstream-to=<remote>/<branch> [<branchname>]`,
+	`--unset-upstream [<branchname>]`,
 }
 
 const (
@@ -66,7 +70,10 @@ const (
 	deleteFlag      = "delete"
 	deleteForceFlag = "D"
 	verboseFlag     = "verbose"
+	veryVerboseFlag = "very-verbose"
 	allFlag         = "all"
+	setUpstreamTo   = "set-upstream-to"
+	unsetUpstream   = "unset-upstream"
 )
 
 func Branch(commandStr string, args []string, dEnv *env.DoltEnv) int {
@@ -79,11 +86,18 @@ func Branch(commandStr string, args []string, dEnv *env.DoltEnv) int {
 	ap.SupportsFlag(deleteFlag, "d", "Delete a branch. The branch must be fully merged in its upstream branch.")
 	ap.SupportsFlag(deleteForceFlag, "", "Shortcut for --delete --force.")
 	ap.SupportsFlag(verboseFlag, "v", "When in list mode, show the hash and commit subject line for each head")
+	ap.SupportsFlag(veryVerboseFlag, "vv", "When in list mode, show the hash of each head, and the upstream of each branch along with how many commits it is ahead of and behind its upstream")
 	ap.SupportsFlag(allFlag, "a", "When in list mode, shows remote tracked branches")
+	ap.SupportsString(setUpstreamTo, "u", "upstream", "Set the upstream of the branch to the remote-tracking branch given, e.g. origin/master")
+	ap.SupportsFlag(unsetUpstream, "", "Remove the upstream of the branch")
 	help, usage := cli.HelpAndUsagePrinters(commandStr, branchShortDesc, branchLongDesc, branchSynopsis, ap)
 	apr := cli.ParseArgs(ap, args, help)
 
 	switch {
+	case apr.Contains(setUpstreamTo):
+		return setBranchUpstream(dEnv, apr, usage)
+	case apr.Contains(unsetUpstream):
+		return unsetBranchUpstream(dEnv, apr, usage)
 	case apr.Contains(moveFlag):
 		return moveBranch(dEnv, apr, usage)
 	case apr.Contains(copyFlag):
@@ -104,7 +118,8 @@ func Branch(commandStr string, args []string, dEnv *env.DoltEnv) int {
 func printBranches(dEnv *env.DoltEnv, apr *argparser.ArgParseResults, _ cli.UsagePrinter) int {
 	branchSet := set.NewStrSet(apr.Args())
 
-	verbose := apr.Contains(verboseFlag)
+	veryVerbose := apr.Contains(veryVerboseFlag)
+	verbose := apr.Contains(verboseFlag) || veryVerbose
 	printAll := apr.Contains(allParam)
 
 	branches, err := dEnv.DoltDB.GetRefs(context.TODO())
@@ -154,6 +169,16 @@ func printBranches(dEnv *env.DoltEnv, apr *argparser.ArgParseResults, _ cli.Usag
 			}
 		}
 
+		if veryVerbose && branch.GetType() == ref.BranchRefType {
+			upstream, verr := getUpstreamInfo(context.TODO(), dEnv, branch)
+
+			if verr != nil {
+				return HandleVErrAndExitCode(verr, nil)
+			} else if upstream != nil {
+				commitStr += " " + color.BlueString(upstream.shortDesc())
+			}
+		}
+
 		fmtStr := fmt.Sprintf("%%s%%%ds\t%%s", 48-branchLen)
 		line := fmt.Sprintf(fmtStr, branchName, "", commitStr)
 
@@ -184,6 +209,8 @@ func moveBranch(dEnv *env.DoltEnv, apr *argparser.ArgParseResults, usage cli.Usa
 			verr = errhand.BuildDError("fatal: '%s' is not a valid branch name.", dest).Build()
 		} else if err == actions.ErrCOBranchDelete {
 			verr = errhand.BuildDError("error: Cannot delete checked out branch '%s'", src).Build()
+		} else if err == env.ErrRepoStateConflict || err == env.ErrRepoStateLocked {
+			verr = repoStateUpdateVErr(err)
 		} else {
 			bdr := errhand.BuildDError("fatal: Unexpected error moving branch from '%s' to '%s'", src, dest)
 			verr = bdr.AddCause(err).Build()
@@ -245,6 +272,8 @@ func handleDeleteBranches(dEnv *env.DoltEnv, apr *argparser.ArgParseResults, usa
 			verr = errhand.BuildDError("fatal: branch '%s' not found", brName).Build()
 		} else if err == actions.ErrCOBranchDelete {
 			verr = errhand.BuildDError("error: Cannot delete checked out branch '%s'", brName).Build()
+		} else if err == env.ErrRepoStateConflict || err == env.ErrRepoStateLocked {
+			verr = repoStateUpdateVErr(err)
 		} else {
 			bdr := errhand.BuildDError("fatal: Unexpected error deleting '%s'", brName)
 			verr = bdr.AddCause(err).Build()
@@ -308,3 +337,113 @@ func HandleVErrAndExitCode(verr errhand.VerboseError, usage cli.UsagePrinter) in
 
 	return 0
 }
+
+// branchForUpstream returns the branch whose upstream is being changed, which is the branch given as an argument or
+// the current branch.
+func branchForUpstream(dEnv *env.DoltEnv, apr *argparser.ArgParseResults) (ref.DoltRef, errhand.VerboseError) {
+	if apr.NArg() > 1 {
+		return nil, errhand.BuildDError("").SetPrintUsage().Build()
+	} else if apr.NArg() == 0 {
+		return dEnv.RepoState.Head.Ref, nil
+	}
+
+	branch := ref.NewBranchRef(apr.Arg(0))
+	hasRef, err := dEnv.DoltDB.HasRef(context.TODO(), branch)
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: failed to read from db").AddCause(err).Build()
+	} else if !hasRef {
+		return nil, errhand.BuildDError("fatal: branch '%s' does not exist", apr.Arg(0)).Build()
+	}
+
+	return branch, nil
+}
+
+func setBranchUpstream(dEnv *env.DoltEnv, apr *argparser.ArgParseResults, usage cli.UsagePrinter) int {
+	branch, verr := branchForUpstream(dEnv, apr)
+
+	if verr != nil {
+		return HandleVErrAndExitCode(verr, usage)
+	}
+
+	upstreamStr := apr.MustGetValue(setUpstreamTo)
+
+	if ref.IsRef(upstreamStr) && !strings.HasPrefix(upstreamStr, ref.PrefixForType(ref.RemoteRefType)) {
+		return HandleVErrAndExitCode(errhand.BuildDError("fatal: '%s' is not a remote-tracking branch", upstreamStr).Build(), usage)
+	}
+
+	trackingRef, err := ref.NewRemoteRefFromPathStr(upstreamStr)
+
+	if err != nil {
+		return HandleVErrAndExitCode(errhand.BuildDError("fatal: '%s' is not a valid remote-tracking branch", upstreamStr).Build(), usage)
+	}
+
+	remoteName := trackingRef.(ref.RemoteRef).GetRemote()
+	remote, ok := dEnv.RepoState.Remotes[remoteName]
+
+	if !ok {
+		return HandleVErrAndExitCode(errhand.BuildDError("fatal: unknown remote '%s'", remoteName).Build(), usage)
+	}
+
+	hasRef, err := dEnv.DoltDB.HasRef(context.TODO(), trackingRef)
+
+	if err != nil {
+		return HandleVErrAndExitCode(errhand.BuildDError("error: failed to read from db").AddCause(err).Build(), nil)
+	} else if !hasRef {
+		bdr := errhand.BuildDError("fatal: the requested upstream branch '%s' does not exist", upstreamStr)
+		bdr.AddDetails("If you are planning to push out a new local branch that will track its remote counterpart, you may want to use \"dolt push --set-upstream\" to push and set the upstream at the same time.")
+		return HandleVErrAndExitCode(bdr.Build(), nil)
+	}
+
+	// the remote branch fetched into the remote-tracking branch given by the default fetch spec
+	remoteBranch := ref.NewBranchRef(strings.TrimPrefix(trackingRef.GetPath(), remoteName+"/"))
+
+	if mapped, verr := getTrackingRef(remoteBranch, remote); verr != nil {
+		return HandleVErrAndExitCode(verr, nil)
+	} else if mapped == nil || !ref.Equals(mapped, trackingRef) {
+		return HandleVErrAndExitCode(errhand.BuildDError("fatal: '%s' is not fetched from remote '%s' by its fetch specs", upstreamStr, remoteName).Build(), nil)
+	}
+
+	if dEnv.RepoState.Branches == nil {
+		dEnv.RepoState.Branches = map[string]env.BranchConfig{}
+	}
+
+	dEnv.RepoState.Branches[branch.GetPath()] = env.BranchConfig{
+		Merge:  ref.MarshalableRef{Ref: remoteBranch},
+		Remote: remoteName,
+	}
+
+	err = dEnv.RepoState.Save()
+
+	if err == env.ErrRepoStateConflict || err == env.ErrRepoStateLocked {
+		return HandleVErrAndExitCode(repoStateUpdateVErr(err), nil)
+	} else if err != nil {
+		return HandleVErrAndExitCode(errhand.BuildDError("error: failed to save repo state").AddCause(err).Build(), nil)
+	}
+
+	cli.Printf("Branch '%s' set up to track remote branch '%s' from '%s'.\n", branch.GetPath(), remoteBranch.GetPath(), remoteName)
+	return 0
+}
+
+func unsetBranchUpstream(dEnv *env.DoltEnv, apr *argparser.ArgParseResults, usage cli.UsagePrinter) int {
+	branch, verr := branchForUpstream(dEnv, apr)
+
+	if verr != nil {
+		return HandleVErrAndExitCode(verr, usage)
+	}
+
+	if _, ok := dEnv.RepoState.Branches[branch.GetPath()]; !ok {
+		return HandleVErrAndExitCode(errhand.BuildDError("fatal: branch '%s' has no upstream information", branch.GetPath()).Build(), nil)
+	}
+
+	delete(dEnv.RepoState.Branches, branch.GetPath())
+	err := dEnv.RepoState.Save()
+
+	if err == env.ErrRepoStateConflict || err == env.ErrRepoStateLocked {
+		return HandleVErrAndExitCode(repoStateUpdateVErr(err), nil)
+	} else if err != nil {
+		return HandleVErrAndExitCode(errhand.BuildDError("error: failed to save repo state").AddCause(err).Build(), nil)
+	}
+
+	return 0
+}
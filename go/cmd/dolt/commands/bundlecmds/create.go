@@ -0,0 +1,154 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundlecmds
+
+import (
+	"context"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/bundle"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+const baseParam = "base"
+
+var createShortDesc = "Write branches to a bundle file"
+var createLongDesc = "Writes the branches given, along with every commit and table needed to read them, to a single " +
+	"bundle file. If no branches are given every branch is written.\n" +
+	"\n" +
+	"With --base only the changes since the base commit are written, and the bundle can only be read into a repository " +
+	"which already has the base commit.\n" +
+	"\n" +
+	"A bundle can be cloned or fetched from using a url in the format bundle://path to bundle file, or read into an " +
+	"existing repository with dolt bundle unbundle."
+var createSynopsis = []string{
+	"[--base <commit>] <file> [<branch>...]",
+}
+
+func Create(commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := argparser.NewArgParser()
+	ap.ArgListHelp["file"] = "The bundle file to write."
+	ap.ArgListHelp["branch"] = "The branches to write to the bundle."
+	ap.SupportsString(baseParam, "", "commit", "Leave out everything which can be reached from the commit given.")
+	help, usage := cli.HelpAndUsagePrinters(commandStr, createShortDesc, createLongDesc, createSynopsis, ap)
+	apr := cli.ParseArgs(ap, args, help)
+
+	if apr.NArg() == 0 {
+		usage()
+		return 1
+	}
+
+	heads, verr := getBundleHeads(dEnv, apr.Args()[1:])
+
+	var prereqs []hash.Hash
+	if verr == nil {
+		if baseStr, ok := apr.GetValue(baseParam); ok {
+			var h hash.Hash
+			h, verr = resolveHash(dEnv, baseStr)
+			prereqs = append(prereqs, h)
+		}
+	}
+
+	if verr == nil {
+		verr = createBundle(dEnv, apr.Arg(0), heads, prereqs)
+	}
+
+	return commands.HandleVErrAndExitCode(verr, usage)
+}
+
+func getBundleHeads(dEnv *env.DoltEnv, branches []string) ([]bundle.Head, errhand.VerboseError) {
+	ctx := context.TODO()
+
+	var drefs []ref.DoltRef
+	if len(branches) == 0 {
+		var err error
+		drefs, err = dEnv.DoltDB.GetBranches(ctx)
+
+		if err != nil {
+			return nil, errhand.BuildDError("error: failed to read branches").AddCause(err).Build()
+		}
+	} else {
+		for _, branch := range branches {
+			drefs = append(drefs, ref.NewBranchRef(branch))
+		}
+	}
+
+	var heads []bundle.Head
+	for _, dref := range drefs {
+		hasRef, err := dEnv.DoltDB.HasRef(ctx, dref)
+
+		if err != nil {
+			return nil, errhand.BuildDError("error: failed to read from db").AddCause(err).Build()
+		} else if !hasRef {
+			return nil, errhand.BuildDError("fatal: unknown branch " + dref.GetPath()).Build()
+		}
+
+		h, verr := resolveHash(dEnv, dref.GetPath())
+
+		if verr != nil {
+			return nil, verr
+		}
+
+		heads = append(heads, bundle.Head{Name: dref.String(), Hash: h})
+	}
+
+	return heads, nil
+}
+
+func resolveHash(dEnv *env.DoltEnv, cSpecStr string) (hash.Hash, errhand.VerboseError) {
+	cm, verr := commands.ResolveCommitWithVErr(dEnv, cSpecStr, dEnv.RepoState.Head.Ref.String())
+
+	if verr != nil {
+		return hash.Hash{}, verr
+	}
+
+	h, err := cm.HashOf()
+
+	if err != nil {
+		return hash.Hash{}, errhand.BuildDError("error: failed to get the hash of '%s'", cSpecStr).AddCause(err).Build()
+	}
+
+	return h, nil
+}
+
+func createBundle(dEnv *env.DoltEnv, path string, heads []bundle.Head, prereqs []hash.Hash) errhand.VerboseError {
+	wr, err := dEnv.FS.OpenForWrite(path)
+
+	if err != nil {
+		return errhand.BuildDError("error: unable to open '%s' for writing", path).AddCause(err).Build()
+	}
+
+	numChunks, err := bundle.Create(context.TODO(), dEnv.DoltDB.ValueReadWriter(), wr, heads, prereqs)
+
+	if err == nil {
+		err = wr.Close()
+	} else {
+		_ = wr.Close()
+	}
+
+	if err != nil {
+		_ = dEnv.FS.DeleteFile(path)
+		return errhand.BuildDError("error: failed to write bundle '%s'", path).AddCause(err).Build()
+	}
+
+	cli.Printf("Wrote %d chunks for %d branches to %s\n", numChunks, len(heads), path)
+
+	return nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundlecmds
+
+import (
+	"context"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+)
+
+var unbundleShortDesc = "Read the commits in a bundle file into this repository"
+var unbundleLongDesc = "Checks a bundle file as dolt bundle verify does, and then copies the commits of each branch " +
+	"in the bundle into this repository. No branches are created or updated. The commit of each branch in the bundle is " +
+	"printed, and can be used to create or merge a branch.\n" +
+	"\n" +
+	"To update remote-tracking branches from a bundle, add it as a remote with a url in the format " +
+	"bundle://path to bundle file and use dolt fetch."
+var unbundleSynopsis = []string{
+	"<file>",
+}
+
+func Unbundle(commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := argparser.NewArgParser()
+	ap.ArgListHelp["file"] = "The bundle file to read."
+	help, usage := cli.HelpAndUsagePrinters(commandStr, unbundleShortDesc, unbundleLongDesc, unbundleSynopsis, ap)
+	apr := cli.ParseArgs(ap, args, help)
+
+	if apr.NArg() != 1 {
+		usage()
+		return 1
+	}
+
+	verr := unbundle(dEnv, apr.Arg(0))
+
+	return commands.HandleVErrAndExitCode(verr, usage)
+}
+
+func unbundle(dEnv *env.DoltEnv, path string) errhand.VerboseError {
+	ctx := context.TODO()
+	hdr, verr := verifyBundle(dEnv, path)
+
+	if verr != nil {
+		return verr
+	}
+
+	absPath, err := dEnv.FS.Abs(path)
+
+	if err != nil {
+		return errhand.BuildDError("error: unable to find '%s'", path).AddCause(err).Build()
+	}
+
+	srcDB, err := doltdb.LoadDoltDB(ctx, dEnv.DoltDB.ValueReadWriter().Format(), dbfactory.BundleScheme+"://"+absPath)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to read bundle '%s'", path).AddCause(err).Build()
+	}
+
+	for _, head := range hdr.Heads {
+		cs, _ := doltdb.NewCommitSpec("HEAD", head.Name)
+		cm, err := srcDB.Resolve(ctx, cs)
+
+		if err != nil {
+			return errhand.BuildDError("error: unable to find the commit for %s", head.Name).AddCause(err).Build()
+		}
+
+		err = dEnv.DoltDB.PullChunks(ctx, srcDB, cm, nil, nil)
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to read %s from the bundle", head.Name).AddCause(err).Build()
+		}
+
+		cli.Printf("%s %s\n", head.Hash.String(), head.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundlecmds
+
+import (
+	"context"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/bundle"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+)
+
+var verifyShortDesc = "Check a bundle file"
+var verifyLongDesc = "Checks that a bundle file is not corrupt, and that every commit it depends on is in this " +
+	"repository, so that it can be read into it. The branches in the bundle, and the commits it depends on, are listed."
+var verifySynopsis = []string{
+	"<file>",
+}
+
+func Verify(commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := argparser.NewArgParser()
+	ap.ArgListHelp["file"] = "The bundle file to check."
+	help, usage := cli.HelpAndUsagePrinters(commandStr, verifyShortDesc, verifyLongDesc, verifySynopsis, ap)
+	apr := cli.ParseArgs(ap, args, help)
+
+	if apr.NArg() != 1 {
+		usage()
+		return 1
+	}
+
+	hdr, verr := verifyBundle(dEnv, apr.Arg(0))
+
+	if verr == nil {
+		cli.Println("The bundle contains these branches:")
+		for _, head := range hdr.Heads {
+			cli.Printf("%s %s\n", head.Hash.String(), head.Name)
+		}
+
+		if len(hdr.Prereqs) > 0 {
+			cli.Println("The bundle requires these commits:")
+			for _, h := range hdr.Prereqs {
+				cli.Println(h.String())
+			}
+		}
+
+		cli.Printf("%s is okay\n", apr.Arg(0))
+	}
+
+	return commands.HandleVErrAndExitCode(verr, usage)
+}
+
+func verifyBundle(dEnv *env.DoltEnv, path string) (*bundle.Header, errhand.VerboseError) {
+	rd, err := dEnv.FS.OpenForRead(path)
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: unable to open '%s'", path).AddCause(err).Build()
+	}
+
+	defer rd.Close()
+
+	hdr, err := bundle.Verify(context.TODO(), rd, dEnv.DoltDB.ValueReadWriter())
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: '%s' is not a valid bundle for this repository", path).AddCause(err).Build()
+	}
+
+	return hdr, nil
+}
@@ -143,6 +143,8 @@ func checkoutBranch(dEnv *env.DoltEnv, name string) errhand.VerboseError {
 			return bdr.Build()
 		} else if err == doltdb.ErrAlreadyOnBranch {
 			return errhand.BuildDError("Already on branch '%s'", name).Build()
+		} else if err == env.ErrRepoStateConflict || err == env.ErrRepoStateLocked {
+			return repoStateUpdateVErr(err)
 		} else {
 			bdr := errhand.BuildDError("fatal: Unexpected error checking out branch '%s'", name)
 			bdr.AddCause(err)
@@ -19,9 +19,11 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/bundle"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
@@ -39,6 +41,8 @@ import (
 const (
 	remoteParam = "remote"
 	branchParam = "branch"
+	depthParam  = "depth"
+	tablesParam = "tables"
 )
 
 var cloneShortDesc = "Clone a data repository into a new directory"
@@ -50,15 +54,24 @@ var cloneLongDesc = "Clones a repository into a newly created directory, creates
 	"pull</b> without arguments will in addition merge the remote branch into the current branch\n" +
 	"\n" +
 	"This default configuration is achieved by creating references to the remote branch heads under refs/remotes/origin " +
-	"and by creating a remote named 'origin'."
+	"and by creating a remote named 'origin'.\n" +
+	"\n" +
+	"When <b>--depth</b> is given only the chunks reachable from the last <depth> commits of each branch are copied, " +
+	"creating a shallow clone. The history of a shallow clone ends at the boundary of the commits copied, and can be " +
+	"deepened later with <b>dolt fetch --depth</b>.\n" +
+	"\n" +
+	"When <b>--tables</b> is given the full commit history is copied, but row data is only copied for the tables " +
+	"listed, creating a partial clone. The row data of other tables is read from the remote if it is needed later."
 var cloneSynopsis = []string{
-	"[-remote <remote>] [-branch <branch>]  [--aws-region <region>] [--aws-creds-type <creds-type>] [--aws-creds-file <file>] [--aws-creds-profile <profile>] <remote-url> <new-dir>",
+	"[-remote <remote>] [-branch <branch>] [--depth <depth> | --tables <table>,...] [--aws-region <region>] [--aws-creds-type <creds-type>] [--aws-creds-file <file>] [--aws-creds-profile <profile>] <remote-url> <new-dir>",
 }
 
 func Clone(commandStr string, args []string, dEnv *env.DoltEnv) int {
 	ap := argparser.NewArgParser()
 	ap.SupportsString(remoteParam, "", "name", "Name of the remote to be added. Default will be 'origin'.")
 	ap.SupportsString(branchParam, "b", "branch", "The branch to be cloned.  If not specified all branches will be cloned.")
+	ap.SupportsInt(depthParam, "", "depth", "Create a shallow clone holding only the last <depth> commits of each branch.")
+	ap.SupportsString(tablesParam, "", "tables", "Create a partial clone holding the row data of only the comma separated list of tables given.")
 	ap.SupportsString(dbfactory.AWSRegionParam, "", "region", "")
 	ap.SupportsValidatedString(dbfactory.AWSCredsTypeParam, "", "creds-type", "", argparser.ValidatorFromStrList(dbfactory.AWSCredsTypeParam, credTypes))
 	ap.SupportsString(dbfactory.AWSCredsFileParam, "", "file", "AWS credentials file.")
@@ -70,6 +83,16 @@ func Clone(commandStr string, args []string, dEnv *env.DoltEnv) int {
 	branch := apr.GetValueOrDefault(branchParam, "")
 	dir, urlStr, verr := parseArgs(apr)
 
+	var depth int
+	if verr == nil {
+		depth, verr = parseDepth(apr)
+	}
+
+	var tables []string
+	if verr == nil {
+		tables, verr = parsePartialTables(apr)
+	}
+
 	scheme, remoteUrl, err := getAbsRemoteUrl(dEnv.FS, dEnv.Config, urlStr)
 
 	if err != nil {
@@ -89,13 +112,15 @@ func Clone(commandStr string, args []string, dEnv *env.DoltEnv) int {
 				dEnv, verr = envForClone(srcDB.ValueReadWriter().Format(), r, dir, dEnv.FS)
 
 				if verr == nil {
-					verr = cloneRemote(context.Background(), srcDB, remoteName, branch, dEnv)
+					verr = cloneRemote(context.Background(), srcDB, remoteName, branch, depth, tables, dEnv)
 
 					// Make best effort to delete the directory we created.
 					if verr != nil {
 						_ = os.Chdir("../")
 						_ = dEnv.FS.Delete(dir, true)
 					}
+
+					verr = closeRemoteDB(srcDB, verr)
 				}
 			}
 		}
@@ -120,7 +145,7 @@ func parseArgs(apr *argparser.ArgParseResults) (string, string, errhand.VerboseE
 	if apr.NArg() == 2 {
 		dir = apr.Arg(1)
 	} else {
-		dir = path.Base(urlStr)
+		dir = strings.TrimSuffix(path.Base(urlStr), bundle.Extension)
 		if dir == "." {
 			dir = path.Dir(urlStr)
 		} else if dir == "/" {
@@ -131,6 +156,45 @@ func parseArgs(apr *argparser.ArgParseResults) (string, string, errhand.VerboseE
 	return dir, urlStr, nil
 }
 
+// parseDepth returns the value of the depth argument, or 0 if it was not given.
+func parseDepth(apr *argparser.ArgParseResults) (int, errhand.VerboseError) {
+	if !apr.Contains(depthParam) {
+		return 0, nil
+	}
+
+	depth, ok := apr.GetInt(depthParam)
+
+	if !ok || depth < 1 {
+		return 0, errhand.BuildDError("error: depth must be a positive number of commits").Build()
+	}
+
+	return depth, nil
+}
+
+// parsePartialTables returns the tables listed in the tables argument, or nil if it was not given.
+func parsePartialTables(apr *argparser.ArgParseResults) ([]string, errhand.VerboseError) {
+	tablesStr, ok := apr.GetValue(tablesParam)
+
+	if !ok {
+		return nil, nil
+	} else if apr.Contains(depthParam) {
+		return nil, errhand.BuildDError("error: --%s and --%s can't be used together", depthParam, tablesParam).Build()
+	}
+
+	var tables []string
+	for _, tbl := range strings.Split(tablesStr, ",") {
+		tbl = strings.TrimSpace(tbl)
+
+		if !doltdb.IsValidTableName(tbl) {
+			return nil, errhand.BuildDError("error: '%s' is not a valid table name", tbl).Build()
+		}
+
+		tables = append(tables, tbl)
+	}
+
+	return tables, nil
+}
+
 func envForClone(nbf *types.NomsBinFormat, r env.Remote, dir string, fs filesys.Filesys) (*env.DoltEnv, errhand.VerboseError) {
 	exists, _ := fs.Exists(filepath.Join(dir, dbfactory.DoltDir))
 
@@ -188,7 +252,7 @@ func createRemote(remoteName, remoteUrl string, params map[string]string) (env.R
 	return r, ddb, nil
 }
 
-func cloneRemote(ctx context.Context, srcDB *doltdb.DoltDB, remoteName, branch string, dEnv *env.DoltEnv) errhand.VerboseError {
+func cloneRemote(ctx context.Context, srcDB *doltdb.DoltDB, remoteName, branch string, depth int, tables []string, dEnv *env.DoltEnv) errhand.VerboseError {
 	var branches []ref.DoltRef
 	if len(branch) > 0 {
 		branches = []ref.DoltRef{ref.NewBranchRef(branch)}
@@ -201,13 +265,59 @@ func cloneRemote(ctx context.Context, srcDB *doltdb.DoltDB, remoteName, branch s
 		}
 	}
 
-	return cloneAllBranchRefs(branches, srcDB, ctx, remoteName, dEnv)
+	if tables != nil {
+		err := actions.UpdatePartialTables(dEnv, remoteName, tables)
+
+		if err == env.ErrRepoStateConflict || err == env.ErrRepoStateLocked {
+			return repoStateUpdateVErr(err)
+		} else if err != nil {
+			return errhand.BuildDError("error: failed to record the tables of the partial clone").AddCause(err).Build()
+		}
+	}
+
+	if depth == 0 && tables == nil {
+		verr := cloneTableFiles(ctx, srcDB, dEnv)
+
+		if verr != nil {
+			return verr
+		}
+	}
+
+	return cloneAllBranchRefs(branches, srcDB, ctx, remoteName, depth, tables, dEnv)
+}
+
+// cloneTableFiles copies the table files of the remote whole, rather than chunk by chunk, when both the remote and the
+// new repository support it. The branches fetched afterwards then find their chunks already present.
+func cloneTableFiles(ctx context.Context, srcDB *doltdb.DoltDB, dEnv *env.DoltEnv) errhand.VerboseError {
+	concurrency, err := dEnv.TableFileConcurrency()
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to read the table file concurrency").AddCause(err).Build()
+	} else if concurrency <= 0 {
+		return nil
+	}
+
+	progChan := make(chan datas.PullProgress)
+	doneChan := make(chan struct{})
+	go progFunc(progChan, doneChan)
+
+	_, err = dEnv.DoltDB.PullTableFiles(ctx, srcDB, concurrency, progChan)
+
+	close(progChan)
+	<-doneChan
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to copy table files").AddCause(err).Build()
+	}
+
+	return nil
 }
 
-func cloneAllBranchRefs(branches []ref.DoltRef, srcDB *doltdb.DoltDB, ctx context.Context, remoteName string, dEnv *env.DoltEnv) errhand.VerboseError {
+func cloneAllBranchRefs(branches []ref.DoltRef, srcDB *doltdb.DoltDB, ctx context.Context, remoteName string, depth int, tables []string, dEnv *env.DoltEnv) errhand.VerboseError {
 	var dref ref.DoltRef
 	var masterHash hash.Hash
 	var h hash.Hash
+	var boundary []hash.Hash
 
 	for i := 0; i < len(branches); i++ {
 		dref = branches[i]
@@ -234,7 +344,16 @@ func cloneAllBranchRefs(branches []ref.DoltRef, srcDB *doltdb.DoltDB, ctx contex
 		go progFunc(progChan, doneChan)
 
 		remoteBranch := ref.NewRemoteRef(remoteName, branch)
-		err = actions.Fetch(ctx, remoteBranch, srcDB, dEnv.DoltDB, cm, progChan)
+		if depth > 0 {
+			var branchBoundary []hash.Hash
+			branchBoundary, err = actions.FetchShallow(ctx, remoteBranch, srcDB, dEnv.DoltDB, cm, depth, progChan)
+			boundary = append(boundary, branchBoundary...)
+		} else if tables != nil {
+			err = actions.FetchPartial(ctx, remoteBranch, srcDB, dEnv.DoltDB, cm, tables, progChan)
+		} else {
+			err = actions.Fetch(ctx, remoteBranch, srcDB, dEnv.DoltDB, cm, nil, progChan)
+		}
+
 		close(progChan)
 		<-doneChan
 
@@ -276,9 +395,28 @@ func cloneAllBranchRefs(branches []ref.DoltRef, srcDB *doltdb.DoltDB, ctx contex
 	dEnv.RepoState.Head = ref.MarshalableRef{Ref: dref}
 	dEnv.RepoState.Staged = h.String()
 	dEnv.RepoState.Working = h.String()
+	dEnv.RepoState.Branches = map[string]env.BranchConfig{
+		dref.GetPath(): {Merge: ref.MarshalableRef{Ref: dref}, Remote: remoteName},
+	}
+
+	if len(boundary) > 0 {
+		// UpdateShallowCommits saves the repo state along with the boundary
+		err := actions.UpdateShallowCommits(ctx, dEnv, boundary)
+
+		if err == env.ErrRepoStateConflict || err == env.ErrRepoStateLocked {
+			return repoStateUpdateVErr(err)
+		} else if err != nil {
+			return errhand.BuildDError("error: failed to record the boundary of the shallow clone").AddCause(err).Build()
+		}
+
+		return nil
+	}
+
 	err := dEnv.RepoState.Save()
 
-	if err != nil {
+	if err == env.ErrRepoStateConflict || err == env.ErrRepoStateLocked {
+		return repoStateUpdateVErr(err)
+	} else if err != nil {
 		return errhand.BuildDError("error: failed to write repo state").AddCause(err).Build()
 	}
 
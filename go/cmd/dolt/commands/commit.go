@@ -88,9 +88,13 @@ func handleCommitErr(err error, usage cli.UsagePrinter) int {
 		return HandleVErrAndExitCode(bdr.Build(), usage)
 	}
 
+	if err == env.ErrRepoStateConflict || err == env.ErrRepoStateLocked {
+		return HandleVErrAndExitCode(repoStateUpdateVErr(err), usage)
+	}
+
 	if actions.IsNothingStaged(err) {
 		notStaged := actions.NothingStagedDiffs(err)
-		n := printDiffsNotStaged(cli.CliOut, notStaged, false, 0, []string{})
+		n := printDiffsNotStaged(cli.CliOut, notStaged, false, 0, []string{}, nil)
 
 		if n == 0 {
 			bdr := errhand.BuildDError(`no changes added to commit (use "dolt add")`)
@@ -132,8 +136,8 @@ func buildInitalCommitMsg(dEnv *env.DoltEnv) string {
 		workingInConflict = []string{}
 	}
 
-	n := printStagedDiffs(buf, stagedDiffs, true)
-	n = printDiffsNotStaged(buf, notStagedDiffs, true, n, workingInConflict)
+	n := printStagedDiffs(buf, stagedDiffs, true, nil)
+	n = printDiffsNotStaged(buf, notStagedDiffs, true, n, workingInConflict, nil)
 
 	initialCommitMessage := "\n" + "# Please enter the commit message for your changes. Lines starting" + "\n" +
 		"# with '#' will be ignored, and an empty message aborts the commit." + "\n# On branch " + currBranch.GetPath() + "\n#" + "\n"
@@ -0,0 +1,74 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
+)
+
+var compactShortDesc = "Rewrite the data files of the repository"
+var compactLongDesc = "Rewrites all of the table files of the repository's database into new table files of a similar " +
+	"size, storing the data of each value near each other in the order it is reached from the latest root of the " +
+	"database. Data no longer reachable is kept, after the reachable data.\n" +
+	"\n" +
+	"The table files which were rewritten are deleted once the repository no longer uses them.\n" +
+	"\n" +
+	"This is an offline operation. It fails if another dolt command is using the repository, and other dolt commands " +
+	"wait for it to finish before using the repository."
+var compactSynopsis = []string{""}
+
+func Compact(commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := argparser.NewArgParser()
+	help, usage := cli.HelpAndUsagePrinters(commandStr, compactShortDesc, compactLongDesc, compactSynopsis, ap)
+	apr := cli.ParseArgs(ap, args, help)
+
+	if apr.NArg() != 0 {
+		usage()
+		return 1
+	}
+
+	verr := compactRepo(context.Background(), dEnv)
+
+	if verr != nil {
+		cli.PrintErrln(verr.Verbose())
+		return 1
+	}
+
+	return 0
+}
+
+func compactRepo(ctx context.Context, dEnv *env.DoltEnv) errhand.VerboseError {
+	err := dEnv.DoltDB.Compact(ctx)
+
+	if err == nbs.ErrStoreInUse {
+		return errhand.BuildDError("error: the repository is in use by another dolt command").AddDetails("Make sure no other dolt command is using the repository and try again.").Build()
+	} else if err == doltdb.ErrCompactionNotSupported {
+		return errhand.BuildDError("error: only repositories on the local filesystem can be compacted").Build()
+	} else if err == nbs.ErrCompactionConflict {
+		return errhand.BuildDError("error: the repository was modified while it was compacted").AddDetails("Make sure no other dolt command is using the repository and try again.").Build()
+	} else if err != nil {
+		return errhand.BuildDError("error: failed to compact the repository").AddCause(err).Build()
+	}
+
+	cli.Println("Compacted the repository.")
+	return nil
+}
@@ -16,14 +16,18 @@ package commands
 
 import (
 	"context"
+	"encoding/csv"
+	"fmt"
 	"reflect"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/fatih/color"
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
@@ -47,8 +51,21 @@ const (
 	DataOnlyDiff      = 2
 	SchemaAndDataDiff = SchemaOnlyDiff | DataOnlyDiff
 
-	DataFlag   = "data"
-	SchemaFlag = "schema"
+	DataFlag    = "data"
+	SchemaFlag  = "schema"
+	FormatFlag  = "result-format"
+	DialectFlag = "dialect"
+	SummaryFlag = "summary"
+	StatFlag    = "stat"
+	WhereFlag   = "where"
+	LimitFlag   = "limit"
+	PKStartFlag = "pk-start"
+	PKEndFlag   = "pk-end"
+
+	tabularDiffFormat = "tabular"
+	sqlDiffFormat     = "sql"
+	jsonDiffFormat    = "json"
+	csvDiffFormat     = "csv"
 )
 
 var diffShortDesc = "Show changes between commits, commit and working tree, etc"
@@ -62,17 +79,43 @@ dolt diff [--options] <commit> [<tables>...]
 
 dolt diff [--options] <commit> <commit> [<tables>...]
    This is to view the changes between two arbitrary <commit>.
+
+The --result-format option controls how the changes are written. The default, tabular, shows colored tables of the rows
+which changed. sql writes the CREATE, DROP and ALTER TABLE statements, and the INSERT, UPDATE and DELETE statements,
+which turn the old version of the tables into the new version. They are written for MySQL unless --dialect postgres is
+given. json writes a document listing the changes to each table's columns and the old and new values of each row which
+changed, which can be applied to another copy of the tables with dolt apply. csv writes the rows which changed in a
+single table with a diff_type column holding added, removed or modified.
+
+The --summary option, or its alias --stat, shows the number of rows added, deleted and modified, the number of cells
+modified, and the percentage of rows changed in each table instead of the changes themselves.
+
+The rows shown can be restricted. --where takes a SQL expression, e.g. "customer_id = 42", and shows a changed row if
+either its old or new version matches it. --limit shows at most that many changed rows per table. --pk-start and
+--pk-end give an inclusive range of primary keys to diff, seeking directly to the start of the range rather than
+diffing the whole table. For tables with multi-column primary keys they take comma separated values for a prefix of
+the primary key columns.
 `
 
 var diffSynopsis = []string{
-	"[options] [<commit>] [--data|--schema] [<tables>...]",
-	"[options] <commit> <commit> [--data|--schema] [<tables>...]",
+	"[options] [<commit>] [--data|--schema] [-r tabular|sql|json|csv] [--dialect mysql|postgres] [<tables>...]",
+	"[options] <commit> <commit> [--data|--schema] [-r tabular|sql|json|csv] [<tables>...]",
+	"[options] [<commit>] [<commit>] --summary [<tables>...]",
+	"[options] [<commit>] [<commit>] [--where <expr>] [--limit <n>] [--pk-start <pk>] [--pk-end <pk>] [<tables>...]",
 }
 
 func Diff(commandStr string, args []string, dEnv *env.DoltEnv) int {
 	ap := argparser.NewArgParser()
 	ap.SupportsFlag(DataFlag, "d", "Show only the data changes, do not show the schema changes (Both shown by default).")
 	ap.SupportsFlag(SchemaFlag, "s", "Show only the schema changes, do not show the data changes (Both shown by default).")
+	ap.SupportsString(FormatFlag, "r", "format", "How to format the diff. One of tabular, sql, json or csv. Defaults to tabular.")
+	ap.SupportsString(DialectFlag, "", "dialect", "The SQL dialect of the sql format. One of mysql or postgres. Defaults to mysql.")
+	ap.SupportsFlag(SummaryFlag, "", "Show the number of rows and cells changed in each table instead of the changes.")
+	ap.SupportsFlag(StatFlag, "", "Alias for --summary.")
+	ap.SupportsString(WhereFlag, "", "expr", "Only show rows whose old or new version matches the SQL expression given.")
+	ap.SupportsInt(LimitFlag, "", "n", "Show at most this many changed rows per table.")
+	ap.SupportsString(PKStartFlag, "", "pk", "Only show rows whose primary key is at or after this one.")
+	ap.SupportsString(PKEndFlag, "", "pk", "Only show rows whose primary key is at or before this one.")
 	help, _ := cli.HelpAndUsagePrinters(commandStr, diffShortDesc, diffLongDesc, diffSynopsis, ap)
 	apr := cli.ParseArgs(ap, args, help)
 
@@ -83,10 +126,45 @@ func Diff(commandStr string, args []string, dEnv *env.DoltEnv) int {
 		diffParts = SchemaOnlyDiff
 	}
 
+	var dw diff.DiffWriter
+	wr := iohelp.NopWrCloser(cli.CliOut)
+	switch format := apr.GetValueOrDefault(FormatFlag, tabularDiffFormat); format {
+	case tabularDiffFormat:
+	case sqlDiffFormat:
+		dialect, err := diff.ParseSqlDialect(apr.GetValueOrDefault(DialectFlag, "mysql"))
+
+		if err != nil {
+			cli.PrintErrln(color.RedString("Invalid sql dialect: %s", apr.GetValueOrDefault(DialectFlag, "")))
+			return 1
+		}
+
+		dw = diff.NewSqlDiffWriter(wr, dialect)
+	case jsonDiffFormat:
+		dw = diff.NewJsonDiffWriter(wr)
+	case csvDiffFormat:
+		dw = diff.NewCsvDiffWriter(wr)
+	default:
+		cli.PrintErrln(color.RedString("Invalid result format: %s", format))
+		return 1
+	}
+
+	filterOpts := diffFilterOpts{
+		where:   apr.GetValueOrDefault(WhereFlag, ""),
+		limit:   apr.GetIntOrDefault(LimitFlag, 0),
+		pkStart: apr.GetValueOrDefault(PKStartFlag, ""),
+		pkEnd:   apr.GetValueOrDefault(PKEndFlag, ""),
+	}
+
 	r1, r2, tables, verr := getRoots(apr.Args(), dEnv)
 
 	if verr == nil {
-		verr = diffRoots(r1, r2, tables, diffParts, dEnv)
+		if apr.Contains(SummaryFlag) || apr.Contains(StatFlag) {
+			verr = printDiffSummary(context.TODO(), r1, r2, tables)
+		} else if dw != nil {
+			verr = writeRootsDiff(context.TODO(), r1, r2, tables, diffParts, filterOpts, dEnv, dw)
+		} else {
+			verr = diffRoots(r1, r2, tables, diffParts, filterOpts, dEnv)
+		}
 	}
 
 	if verr != nil {
@@ -186,7 +264,7 @@ func getRootForCommitSpecStr(csStr string, dEnv *env.DoltEnv) (string, *doltdb.R
 	return h.String(), r, nil
 }
 
-func diffRoots(r1, r2 *doltdb.RootValue, tblNames []string, diffParts int, dEnv *env.DoltEnv) errhand.VerboseError {
+func diffRoots(r1, r2 *doltdb.RootValue, tblNames []string, diffParts int, filterOpts diffFilterOpts, dEnv *env.DoltEnv) errhand.VerboseError {
 	var err error
 	if len(tblNames) == 0 {
 		tblNames, err = actions.AllTables(context.TODO(), r1, r2)
@@ -302,17 +380,293 @@ func diffRoots(r1, r2 *doltdb.RootValue, tblNames []string, diffParts int, dEnv
 		}
 
 		if diffParts&DataOnlyDiff != 0 {
-			verr = diffRows(rowData1, rowData2, sch1, sch2)
+			verr = diffRows(tblName, rowData1, rowData2, sch1, sch2, filterOpts)
+		}
+
+		if verr != nil {
+			return verr
+		}
+	}
+
+	return nil
+}
+
+// writeRootsDiff writes the differences between the tables of newRoot and oldRoot with the DiffWriter given. All tables
+// which differ are written when tblNames is empty.
+func writeRootsDiff(ctx context.Context, newRoot, oldRoot *doltdb.RootValue, tblNames []string, diffParts int, filterOpts diffFilterOpts, dEnv *env.DoltEnv, dw diff.DiffWriter) errhand.VerboseError {
+	var err error
+	if len(tblNames) == 0 {
+		tblNames, err = actions.AllTables(ctx, newRoot, oldRoot)
+
+		if err != nil {
+			return errhand.BuildDError("error: unable to read tables").AddCause(err).Build()
 		}
+	}
+
+	for _, tblName := range tblNames {
+		newSch, newRows, newHash, verr := getTableSchemaAndRows(ctx, newRoot, tblName, dEnv)
 
 		if verr != nil {
 			return verr
 		}
+
+		oldSch, oldRows, oldHash, verr := getTableSchemaAndRows(ctx, oldRoot, tblName, dEnv)
+
+		if verr != nil {
+			return verr
+		}
+
+		if newHash == oldHash {
+			continue
+		}
+
+		beginOldSch := oldSch
+		if diffParts&SchemaOnlyDiff == 0 && oldSch != nil && newSch != nil {
+			// report no schema changes by diffing the new schema against itself
+			beginOldSch = newSch
+		}
+
+		err = dw.BeginTable(ctx, tblName, beginOldSch, newSch)
+
+		if err == nil && diffParts&DataOnlyDiff != 0 {
+			differ, verr := newRowDiffer(ctx, tblName, oldRows, newRows, oldSch, newSch, filterOpts)
+
+			if verr != nil {
+				return verr
+			}
+
+			err = diff.IterRowDiffs(differ, oldSch, newSch, func(rd diff.RowDiff) (stop bool, err error) {
+				return false, dw.WriteRowDiff(ctx, rd)
+			})
+		}
+
+		if err == nil {
+			err = dw.EndTable(ctx)
+		}
+
+		if err != nil {
+			return errhand.BuildDError("error: failed to write diff of table '%s'", tblName).AddCause(err).Build()
+		}
+	}
+
+	if err = dw.Close(ctx); err != nil {
+		return errhand.BuildDError("error: failed to write diff").AddCause(err).Build()
+	}
+
+	return nil
+}
+
+// printDiffSummary prints the number of rows and cells which changed in each table which differs between newRoot and
+// oldRoot, followed by the totals. All tables are summarized when tblNames is empty.
+func printDiffSummary(ctx context.Context, newRoot, oldRoot *doltdb.RootValue, tblNames []string) errhand.VerboseError {
+	var err error
+	if len(tblNames) == 0 {
+		tblNames, err = actions.AllTables(ctx, newRoot, oldRoot)
+
+		if err != nil {
+			return errhand.BuildDError("error: unable to read tables").AddCause(err).Build()
+		}
+	}
+
+	tblToSummary, err := actions.GetTableDiffSummaries(ctx, newRoot, oldRoot, tblNames)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to summarize diff").AddCause(err).Build()
+	}
+
+	var total diff.DiffSummary
+	tblsChanged := 0
+	for _, tblName := range tblNames {
+		summary := tblToSummary[tblName]
+
+		if summary.RowsChanged() == 0 {
+			continue
+		}
+
+		tblsChanged++
+		total = total.Add(summary)
+
+		cli.Println(color.New(color.Bold).Sprint(tblName))
+		cli.Printf("  %d rows added(+), %d rows modified(*), %d rows deleted(-), %d cells modified\n", summary.RowsAdded, summary.RowsModified, summary.RowsDeleted, summary.CellsModified)
+		cli.Printf("  %d rows changed (%.2f%%), %d rows before, %d rows after\n", summary.RowsChanged(), summary.PercentChanged(), summary.OldRowCount, summary.NewRowCount)
 	}
 
+	cli.Printf("%d tables changed, %d rows added(+), %d rows modified(*), %d rows deleted(-), %d cells modified\n", tblsChanged, total.RowsAdded, total.RowsModified, total.RowsDeleted, total.CellsModified)
 	return nil
 }
 
+// diffFilterOpts restricts the rows shown by a diff. Empty strings and a zero limit apply no restriction.
+type diffFilterOpts struct {
+	where   string
+	limit   int
+	pkStart string
+	pkEnd   string
+}
+
+// newRowDiffer returns a RowDiffer for the changes between the old and new row data of a table, restricted by the
+// filter options given. Either schema may be nil if the table does not exist in that version.
+func newRowDiffer(ctx context.Context, tblName string, oldRows, newRows types.Map, oldSch, newSch schema.Schema, filterOpts diffFilterOpts) (diff.RowDiffer, errhand.VerboseError) {
+	keySch := newSch
+	if keySch == nil {
+		keySch = oldSch
+	}
+
+	var differ diff.RowDiffer
+	if filterOpts.pkStart != "" || filterOpts.pkEnd != "" {
+		start, err := parsePKBound(filterOpts.pkStart, keySch, newRows.Format())
+
+		if err != nil {
+			return nil, errhand.BuildDError("error: invalid --%s for table '%s'", PKStartFlag, tblName).AddCause(err).Build()
+		}
+
+		end, err := parsePKBound(filterOpts.pkEnd, keySch, newRows.Format())
+
+		if err != nil {
+			return nil, errhand.BuildDError("error: invalid --%s for table '%s'", PKEndFlag, tblName).AddCause(err).Build()
+		}
+
+		differ, err = diff.NewRangeDiffer(ctx, oldRows, newRows, start, end)
+
+		if err != nil {
+			return nil, errhand.BuildDError("error: failed to read rows of table '%s'", tblName).AddCause(err).Build()
+		}
+	} else {
+		ad := diff.NewAsyncDiffer(1024)
+		ad.Start(ctx, newRows, oldRows)
+		differ = ad
+	}
+
+	if filterOpts.where == "" && filterOpts.limit <= 0 {
+		return differ, nil
+	}
+
+	var filter diff.RowDiffFilter
+	if filterOpts.where != "" {
+		var err error
+		filter, err = newWhereRowDiffFilter(filterOpts.where, tblName, oldSch, newSch)
+
+		if err != nil {
+			differ.Close()
+			return nil, errhand.BuildDError("error: invalid --%s for table '%s'", WhereFlag, tblName).AddCause(err).Build()
+		}
+	}
+
+	return diff.NewFilteredDiffer(differ, oldSch, newSch, filter, filterOpts.limit), nil
+}
+
+// newWhereRowDiffFilter returns a filter matching row changes where either the old row matches the where clause given
+// under the old schema, or the new row matches it under the new schema. The where clause only needs to be valid for one
+// of the schemas, so columns which were added or dropped can be filtered on.
+func newWhereRowDiffFilter(where, tblName string, oldSch, newSch schema.Schema) (diff.RowDiffFilter, error) {
+	var oldFilter, newFilter sql.RowFilterFn
+	var err error
+	if oldSch != nil {
+		oldFilter, err = sql.CreateFilterForWhereStr(where, tblName, oldSch)
+	}
+
+	if newSch != nil {
+		var newErr error
+		newFilter, newErr = sql.CreateFilterForWhereStr(where, tblName, newSch)
+
+		if oldFilter == nil {
+			err = newErr
+		}
+	}
+
+	if oldFilter == nil && newFilter == nil {
+		return nil, err
+	}
+
+	return func(rd diff.RowDiff) bool {
+		return (rd.Old != nil && oldFilter != nil && oldFilter(rd.Old)) || (rd.New != nil && newFilter != nil && newFilter(rd.New))
+	}, nil
+}
+
+// parsePKBound parses comma separated values for a prefix of the primary key columns of a schema into a key tuple. An
+// empty string returns nil.
+func parsePKBound(str string, sch schema.Schema, nbf *types.NomsBinFormat) (*types.Tuple, error) {
+	if str == "" {
+		return nil, nil
+	}
+
+	strVals, err := csv.NewReader(strings.NewReader(str)).Read()
+
+	if err != nil {
+		return nil, err
+	}
+
+	pkCols := sch.GetPKCols()
+	if len(strVals) > pkCols.Size() {
+		return nil, fmt.Errorf("%d values given for a primary key with %d columns", len(strVals), pkCols.Size())
+	}
+
+	var vals []types.Value
+	for i, strVal := range strVals {
+		col, _ := pkCols.GetByTag(pkCols.Tags[i])
+		convFn := doltcore.GetConvFunc(types.StringKind, col.Kind)
+
+		if convFn == nil {
+			return nil, fmt.Errorf("unable to convert '%s' to the type of column '%s'", strVal, col.Name)
+		}
+
+		val, err := convFn(types.String(strVal))
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert '%s' to the type of column '%s': %v", strVal, col.Name, err)
+		}
+
+		vals = append(vals, types.Uint(col.Tag), val)
+	}
+
+	t, err := types.NewTuple(nbf, vals...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// getTableSchemaAndRows returns the schema, row data and hash of a table. If the table does not exist in the root the
+// schema is nil, the row data is an empty map and the hash is empty.
+func getTableSchemaAndRows(ctx context.Context, root *doltdb.RootValue, tblName string, dEnv *env.DoltEnv) (schema.Schema, types.Map, hash.Hash, errhand.VerboseError) {
+	tbl, ok, err := root.GetTable(ctx, tblName)
+
+	if err != nil {
+		return nil, types.EmptyMap, emptyHash, errhand.BuildDError("error: failed to get table '%s'", tblName).AddCause(err).Build()
+	}
+
+	if !ok {
+		rows, err := types.NewMap(ctx, dEnv.DoltDB.ValueReadWriter())
+
+		if err != nil {
+			return nil, types.EmptyMap, emptyHash, errhand.BuildDError("").AddCause(err).Build()
+		}
+
+		return nil, rows, emptyHash, nil
+	}
+
+	h, err := tbl.HashOf()
+
+	if err != nil {
+		return nil, types.EmptyMap, emptyHash, errhand.BuildDError("error: failed to get table hash").AddCause(err).Build()
+	}
+
+	sch, err := tbl.GetSchema(ctx)
+
+	if err != nil {
+		return nil, types.EmptyMap, emptyHash, errhand.BuildDError("error: failed to get schema").AddCause(err).Build()
+	}
+
+	rows, err := tbl.GetRowData(ctx)
+
+	if err != nil {
+		return nil, types.EmptyMap, emptyHash, errhand.BuildDError("error: failed to get row data").AddCause(err).Build()
+	}
+
+	return sch, rows, h, nil
+}
+
 func diffSchemas(tableName string, sch1 schema.Schema, sch2 schema.Schema) errhand.VerboseError {
 	diffs, err := diff.DiffSchemas(sch1, sch2)
 
@@ -394,7 +748,7 @@ func dumbDownSchema(in schema.Schema) (schema.Schema, error) {
 	return schema.SchemaFromCols(dumbColColl), nil
 }
 
-func diffRows(newRows, oldRows types.Map, newSch, oldSch schema.Schema) errhand.VerboseError {
+func diffRows(tblName string, newRows, oldRows types.Map, newSch, oldSch schema.Schema, filterOpts diffFilterOpts) errhand.VerboseError {
 	dumbNewSch, err := dumbDownSchema(newSch)
 
 	if err != nil {
@@ -435,11 +789,15 @@ func diffRows(newRows, oldRows types.Map, newSch, oldSch schema.Schema) errhand.
 		oldToUnionConv, _ = rowconv.NewRowConverter(oldToUnionMapping)
 	}
 
-	ad := diff.NewAsyncDiffer(1024)
-	ad.Start(context.TODO(), newRows, oldRows)
-	defer ad.Close()
+	differ, verr := newRowDiffer(context.TODO(), tblName, oldRows, newRows, oldSch, newSch, filterOpts)
+
+	if verr != nil {
+		return verr
+	}
+
+	defer differ.Close()
 
-	src := diff.NewRowDiffSource(ad, oldToUnionConv, newToUnionConv, untypedUnionSch)
+	src := diff.NewRowDiffSource(differ, oldToUnionConv, newToUnionConv, untypedUnionSch)
 	defer src.Close()
 
 	oldColNames := make(map[uint64]string)
@@ -488,7 +846,6 @@ func diffRows(newRows, oldRows types.Map, newSch, oldSch schema.Schema) errhand.
 		pipeline.NamedTransform{Name: fwtStageName, Func: fwtTr.TransformToFWT},
 	)
 
-	var verr errhand.VerboseError
 	badRowCallback := func(trf *pipeline.TransformRowFailure) (quit bool) {
 		verr = errhand.BuildDError("Failed transforming row").AddDetails(trf.TransformName).AddDetails(trf.Details).Build()
 		return true
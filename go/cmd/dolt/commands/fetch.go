@@ -25,8 +25,11 @@ import (
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
 	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/hash"
 )
 
+const resumableFlag = "resumable"
+
 var fetchShortDesc = "Download objects and refs from another repository"
 var fetchLongDesc = "Fetch refs, along with the objects necessary to complete their histories and update " +
 	"remote-tracking branches." +
@@ -34,21 +37,54 @@ var fetchLongDesc = "Fetch refs, along with the objects necessary to complete th
 	"\n By default dolt will attempt to fetch from a remote named 'origin'.  The <remote> parameter allows you to " +
 	"specify the name of a different remote you wish to pull from by the remote's name." +
 	"\n" +
-	"\nWhen no refspec(s) are specified on the command line, the fetch_specs for the default remote are used."
+	"\nWhen no refspec(s) are specified on the command line, the fetch_specs for the default remote are used." +
+	"\n" +
+	"\nWhen <b>--depth</b> is given only the last <depth> commits of each ref fetched, and the chunks reachable from " +
+	"them, are fetched. Fetching with a greater depth deepens the history of a shallow repository." +
+	"\n" +
+	"\nWhen <b>--tables</b> is given the row data of only the tables listed is fetched, and the row data of other tables " +
+	"is read from the remote if it is needed later. A fetch into a partial clone fetches the row data of the tables " +
+	"already being fetched in addition to those listed." +
+	"\n" +
+	"\nWhen <b>--resumable</b> is given the progress of the fetch is saved as it is made, and a fetch which is " +
+	"interrupted continues from where it stopped when it is run again with <b>--resumable</b>."
 var fetchSynopsis = []string{
-	"[<remote>] [<refspec> ...]",
+	"[--depth <depth> | --tables <table>,... | --resumable] [<remote>] [<refspec> ...]",
 }
 
 func Fetch(commandStr string, args []string, dEnv *env.DoltEnv) int {
 	ap := argparser.NewArgParser()
+	ap.SupportsInt(depthParam, "", "depth", "Fetch only the last <depth> commits of each ref.")
+	ap.SupportsString(tablesParam, "", "tables", "Fetch the row data of only the comma separated list of tables given.")
+	ap.SupportsFlag(resumableFlag, "", "Save the progress of the fetch so that it can be resumed if it is interrupted.")
 	help, usage := cli.HelpAndUsagePrinters(commandStr, fetchShortDesc, fetchLongDesc, fetchSynopsis, ap)
 	apr := cli.ParseArgs(ap, args, help)
 
 	remotes, _ := dEnv.GetRemotes()
-	r, refSpecs, verr := getRefSpecs(apr.Args(), dEnv, remotes)
+	depth, verr := parseDepth(apr)
+
+	var tables []string
+	if verr == nil {
+		tables, verr = parsePartialTables(apr)
+	}
+
+	resumable := apr.Contains(resumableFlag)
+	if verr == nil && resumable && (depth > 0 || tables != nil) {
+		verr = errhand.BuildDError("error: --%s can't be used with --%s or --%s", resumableFlag, depthParam, tablesParam).SetPrintUsage().Build()
+	}
 
 	if verr == nil {
-		verr = fetchRefSpecs(dEnv, r, refSpecs)
+		var r env.Remote
+		var refSpecs []ref.RemoteRefSpec
+		r, refSpecs, verr = getRefSpecs(apr.Args(), dEnv, remotes)
+
+		if verr == nil {
+			tables, verr = partialFetchTables(dEnv, r.Name, tables)
+		}
+
+		if verr == nil {
+			verr = fetchRefSpecs(dEnv, r, refSpecs, depth, tables, resumable)
+		}
 	}
 
 	return HandleVErrAndExitCode(verr, usage)
@@ -127,9 +163,31 @@ func mapRefspecsToRemotes(refSpecs []ref.RemoteRefSpec, dEnv *env.DoltEnv) (map[
 	return rsToRem, nil
 }
 
-func fetchRefSpecs(dEnv *env.DoltEnv, rem env.Remote, refSpecs []ref.RemoteRefSpec) errhand.VerboseError {
+// partialFetchTables returns the tables whose row data should be fetched from the remote given, or nil if the row data
+// of every table should be. The tables given are fetched along with those already fetched into a partial clone.
+func partialFetchTables(dEnv *env.DoltEnv, remoteName string, tables []string) ([]string, errhand.VerboseError) {
+	partial := dEnv.RepoState.Partial
+
+	if tables == nil && (partial == nil || partial.Remote != remoteName) {
+		return nil, nil
+	}
+
+	err := actions.UpdatePartialTables(dEnv, remoteName, tables)
+
+	if err == env.ErrRepoStateConflict || err == env.ErrRepoStateLocked {
+		return nil, repoStateUpdateVErr(err)
+	} else if err != nil {
+		return nil, errhand.BuildDError("error: failed to record the tables of the partial fetch").AddCause(err).Build()
+	}
+
+	return dEnv.RepoState.Partial.Tables, nil
+}
+
+func fetchRefSpecs(dEnv *env.DoltEnv, rem env.Remote, refSpecs []ref.RemoteRefSpec, depth int, tables []string, resumable bool) errhand.VerboseError {
 	ctx := context.TODO()
 
+	var boundary []hash.Hash
+
 	for _, rs := range refSpecs {
 		srcDB, err := rem.GetRemoteDB(context.TODO(), dEnv.DoltDB.ValueReadWriter().Format())
 
@@ -137,48 +195,104 @@ func fetchRefSpecs(dEnv *env.DoltEnv, rem env.Remote, refSpecs []ref.RemoteRefSp
 			return errhand.BuildDError("error: failed to get remote db").AddCause(err).Build()
 		}
 
-		branchRefs, err := srcDB.GetRefs(ctx)
+		refSpecBoundary, verr := fetchRefSpec(ctx, dEnv, rem, srcDB, rs, depth, tables, resumable)
+		verr = closeRemoteDB(srcDB, verr)
 
-		if err != nil {
-			return errhand.BuildDError("error: failed to read from ").AddCause(err).Build()
+		if verr != nil {
+			return verr
 		}
 
-		for _, branchRef := range branchRefs {
-			remoteTrackRef := rs.DestRef(branchRef)
+		boundary = append(boundary, refSpecBoundary...)
+	}
 
-			if remoteTrackRef != nil {
-				verr := fetchRemoteBranch(rem, srcDB, dEnv.DoltDB, branchRef, remoteTrackRef)
+	if depth > 0 || len(dEnv.RepoState.Shallow) > 0 {
+		err := actions.UpdateShallowCommits(ctx, dEnv, boundary)
 
-				if verr != nil {
-					return verr
-				}
-			}
+		if err == env.ErrRepoStateConflict || err == env.ErrRepoStateLocked {
+			return repoStateUpdateVErr(err)
+		} else if err != nil {
+			return errhand.BuildDError("error: failed to record the boundary of the shallow history").AddCause(err).Build()
 		}
 	}
 
 	return nil
 }
 
-func fetchRemoteBranch(rem env.Remote, srcDB, destDB *doltdb.DoltDB, srcRef, destRef ref.DoltRef) errhand.VerboseError {
+// fetchRefSpec fetches the branches of the remote which the refspec given maps to remote tracking branches, returning
+// the commits at the boundary of the history fetched if it is shallow. If resumable is true the progress of the fetch
+// is checkpointed.
+func fetchRefSpec(ctx context.Context, dEnv *env.DoltEnv, rem env.Remote, srcDB *doltdb.DoltDB, rs ref.RemoteRefSpec, depth int, tables []string, resumable bool) ([]hash.Hash, errhand.VerboseError) {
+	branchRefs, err := srcDB.GetRefs(ctx)
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: failed to read from ").AddCause(err).Build()
+	}
+
+	var cp datas.PullCheckpointer
+	if resumable {
+		cp = dEnv.FetchCheckpointer(rem)
+	}
+
+	var boundary []hash.Hash
+	for _, branchRef := range branchRefs {
+		remoteTrackRef := rs.DestRef(branchRef)
+
+		if remoteTrackRef != nil {
+			branchBoundary, verr := fetchRemoteBranch(rem, srcDB, dEnv.DoltDB, branchRef, remoteTrackRef, depth, tables, cp)
+
+			if verr != nil {
+				return nil, verr
+			}
+
+			boundary = append(boundary, branchBoundary...)
+		}
+	}
+
+	return boundary, nil
+}
+
+// closeRemoteDB closes a database opened with GetRemoteDB, which writes the chunks it read to the chunk cache. Returns
+// verr, or the error closing the database if verr is nil.
+func closeRemoteDB(db *doltdb.DoltDB, verr errhand.VerboseError) errhand.VerboseError {
+	err := db.Close()
+
+	if verr == nil && err != nil {
+		return errhand.BuildDError("error: failed to close remote db").AddCause(err).Build()
+	}
+
+	return verr
+}
+
+// fetchRemoteBranch fetches srcRef from the remote into destRef. If depth is greater than 0 only the last depth commits
+// are fetched, and the commits at the boundary of the history fetched are returned. If tables is not nil only the row
+// data of the tables listed is fetched. Otherwise the progress of the fetch is saved with cp, if it is not nil.
+func fetchRemoteBranch(rem env.Remote, srcDB, destDB *doltdb.DoltDB, srcRef, destRef ref.DoltRef, depth int, tables []string, cp datas.PullCheckpointer) ([]hash.Hash, errhand.VerboseError) {
 	cs, _ := doltdb.NewCommitSpec("HEAD", srcRef.String())
 	cm, err := srcDB.Resolve(context.TODO(), cs)
 
 	if err != nil {
-		return errhand.BuildDError("error: unable to find '%s' on '%s'", srcRef.GetPath(), rem.Name).Build()
-	} else {
-		progChan := make(chan datas.PullProgress)
-		stopChan := make(chan struct{})
-		go progFunc(progChan, stopChan)
+		return nil, errhand.BuildDError("error: unable to find '%s' on '%s'", srcRef.GetPath(), rem.Name).Build()
+	}
 
-		err = actions.Fetch(context.TODO(), destRef, srcDB, destDB, cm, progChan)
+	progChan := make(chan datas.PullProgress)
+	stopChan := make(chan struct{})
+	go progFunc(progChan, stopChan)
 
-		close(progChan)
-		<-stopChan
+	var boundary []hash.Hash
+	if depth > 0 {
+		boundary, err = actions.FetchShallow(context.TODO(), destRef, srcDB, destDB, cm, depth, progChan)
+	} else if tables != nil {
+		err = actions.FetchPartial(context.TODO(), destRef, srcDB, destDB, cm, tables, progChan)
+	} else {
+		err = actions.Fetch(context.TODO(), destRef, srcDB, destDB, cm, cp, progChan)
+	}
 
-		if err != nil {
-			return errhand.BuildDError("error: fetch failed").AddCause(err).Build()
-		}
+	close(progChan)
+	<-stopChan
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: fetch failed").AddCause(err).Build()
 	}
 
-	return nil
+	return boundary, nil
 }
@@ -18,6 +18,7 @@ import (
 	"github.com/fatih/color"
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
@@ -25,123 +26,560 @@ import (
 	"github.com/liquidata-inc/dolt/go/store/hash"
 
 	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
 	"strings"
+	"time"
 )
 
 const (
 	numLinesParam = "number"
+	authorParam   = "author"
+	sinceParam    = "since"
+	untilParam    = "until"
+	graphParam    = "graph"
+	onelineParam  = "oneline"
+	formatParam   = "format"
+
+	jsonLogFormat = "json"
 )
 
 var logShortDesc = `Show commit logs`
 var logLongDesc = "Shows the commit logs.\n" +
 	"\n" +
-	"The command takes options to control what is shown and how."
+	"The command takes options to control what is shown and how.\n" +
+	"\n" +
+	"By default the commits reachable from HEAD are shown. Commits may be given to show the commits reachable from\n" +
+	"them instead. <commit1>..<commit2> shows the commits reachable from <commit2> but not <commit1>, and\n" +
+	"<commit1>...<commit2> shows the commits reachable from either commit but not both. A commit prefixed with ^ is\n" +
+	"excluded along with its ancestors.\n" +
+	"\n" +
+	"Any argument which is not a commit is taken to be a table name, and only commits which changed one of the\n" +
+	"tables given are shown."
 
 var logSynopsis = []string{
-	"[-n <num_commits>] [<commit>]",
+	"[-n <num_commits>] [--author <pattern>] [--since <date>] [--until <date>] [--graph] [--oneline | --format json] [<revision range>...] [<table>...]",
 }
 
-type commitLoggerFunc func(*doltdb.CommitMeta, []hash.Hash, hash.Hash)
-
-func logToStdOutFunc(cm *doltdb.CommitMeta, parentHashes []hash.Hash, ch hash.Hash) {
-	cli.Println(color.YellowString("commit %s", ch.String()))
+// commitLogLines returns the lines printed for a commit in the default log format.
+func commitLogLines(cm *doltdb.CommitMeta, parentHashes []hash.Hash, ch hash.Hash) []string {
+	lines := []string{color.YellowString("commit %s", ch.String())}
 
 	if len(parentHashes) > 1 {
-		printMerge(parentHashes)
+		lines = append(lines, mergeLine(parentHashes))
+	}
+
+	lines = append(lines, authorLine(cm), dateLine(cm), "")
+
+	for _, descLine := range strings.Split(cm.Description, "\n") {
+		lines = append(lines, "\t"+descLine)
+	}
+
+	return append(lines, "")
+}
+
+// onelineLogLines returns the line printed for a commit with --oneline.
+func onelineLogLines(cm *doltdb.CommitMeta, _ []hash.Hash, ch hash.Hash) []string {
+	summary := cm.Description
+	if idx := strings.Index(summary, "\n"); idx != -1 {
+		summary = summary[:idx]
 	}
 
-	printAuthor(cm)
-	printDate(cm)
-	printDesc(cm)
+	return []string{color.YellowString(ch.String()) + " " + summary}
 }
 
-func printMerge(hashes []hash.Hash) {
-	cli.Print("Merge:")
+func mergeLine(hashes []hash.Hash) string {
+	line := "Merge:"
 	for _, h := range hashes {
-		cli.Print(" " + h.String())
+		line += " " + h.String()
 	}
-	cli.Println()
+
+	return line
 }
 
-func printAuthor(cm *doltdb.CommitMeta) {
-	cli.Printf("Author: %s <%s>\n", cm.Name, cm.Email)
+func authorLine(cm *doltdb.CommitMeta) string {
+	return fmt.Sprintf("Author: %s <%s>", cm.Name, cm.Email)
 }
 
-func printDate(cm *doltdb.CommitMeta) {
-	timeStr := cm.FormatTS()
-	cli.Println("Date:  ", timeStr)
+func dateLine(cm *doltdb.CommitMeta) string {
+	return "Date:   " + cm.FormatTS()
 }
 
-func printDesc(cm *doltdb.CommitMeta) {
-	formattedDesc := "\n\t" + strings.Replace(cm.Description, "\n", "\n\t", -1) + "\n"
-	cli.Println(formattedDesc)
+// jsonLogEntry is the form of each commit in the output of --format json
+type jsonLogEntry struct {
+	Hash    string   `json:"hash"`
+	Parents []string `json:"parents"`
+	Author  string   `json:"author"`
+	Email   string   `json:"email"`
+	Date    string   `json:"date"`
+	Message string   `json:"message"`
 }
 
-func Log(commandStr string, args []string, dEnv *env.DoltEnv) int {
-	return logWithLoggerFunc(commandStr, args, dEnv, logToStdOutFunc)
+func newJsonLogEntry(cm *doltdb.CommitMeta, parentHashes []hash.Hash, ch hash.Hash) jsonLogEntry {
+	parents := make([]string, len(parentHashes))
+	for i, h := range parentHashes {
+		parents[i] = h.String()
+	}
+
+	return jsonLogEntry{
+		Hash:    ch.String(),
+		Parents: parents,
+		Author:  cm.Name,
+		Email:   cm.Email,
+		Date:    cm.Time().Format(time.RFC3339),
+		Message: cm.Description,
+	}
 }
 
-func logWithLoggerFunc(commandStr string, args []string, dEnv *env.DoltEnv, loggerFunc commitLoggerFunc) int {
+func Log(commandStr string, args []string, dEnv *env.DoltEnv) int {
 	ap := argparser.NewArgParser()
 	ap.SupportsInt(numLinesParam, "n", "num_commits", "Limit the number of commits to output")
+	ap.SupportsString(authorParam, "", "pattern", "Only show commits whose author name or email matches the regular expression given.")
+	ap.SupportsString(sinceParam, "", "date", "Only show commits made at or after the date given, e.g. \"2019-07-04\" or \"2 weeks ago\".")
+	ap.SupportsString(untilParam, "", "date", "Only show commits made at or before the date given.")
+	ap.SupportsFlag(graphParam, "", "Draw a text based graph of the commit history next to the log, showing where branches were merged.")
+	ap.SupportsFlag(onelineParam, "", "Show each commit on a single line, as its hash followed by the first line of its message.")
+	ap.SupportsValidatedString(formatParam, "", "format", "Output the log in the format given. Valid values are json.", argparser.ValidatorFromStrList(formatParam, []string{jsonLogFormat}))
 	help, usage := cli.HelpAndUsagePrinters(commandStr, logShortDesc, logLongDesc, logSynopsis, ap)
 	apr := cli.ParseArgs(ap, args, help)
 
-	var cs *doltdb.CommitSpec
-	if apr.NArg() == 0 {
-		cs = dEnv.RepoState.CWBHeadSpec()
-	} else if apr.NArg() == 1 {
-		var err error
-		comSpecStr := apr.Arg(0)
-		cs, err = doltdb.NewCommitSpec(comSpecStr, dEnv.RepoState.Head.Ref.String())
-
-		if err != nil {
-			cli.PrintErrf("Invalid commit %s\n", comSpecStr)
-			return 1
-		}
-	} else {
+	if apr.Contains(formatParam) && apr.ContainsAny(graphParam, onelineParam) {
+		cli.PrintErrln(color.HiRedString("--format cannot be combined with --graph or --oneline"))
 		usage()
 		return 1
 	}
 
-	commit, err := dEnv.DoltDB.Resolve(context.TODO(), cs)
+	verr := logCommits(context.TODO(), dEnv, apr)
 
-	if err != nil {
-		cli.PrintErrln(color.HiRedString("Fatal error: cannot get HEAD commit for current branch."))
+	if verr != nil {
+		cli.PrintErrln(verr.Verbose())
 		return 1
 	}
 
+	return 0
+}
+
+func logCommits(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResults) errhand.VerboseError {
+	include, exclude, tables, verr := parseLogArgs(ctx, dEnv, apr.Args())
+
+	if verr != nil {
+		return verr
+	}
+
+	filter, verr := parseLogFilter(apr, tables)
+
+	if verr != nil {
+		return verr
+	}
+
 	n := apr.GetIntOrDefault(numLinesParam, -1)
-	commits, err := actions.TimeSortedCommits(context.TODO(), dEnv.DoltDB, commit, n)
+	commits, err := actions.Log(ctx, dEnv.DoltDB, include, exclude, filter, n)
 
 	if err != nil {
-		cli.PrintErrln("Error retrieving commit.")
-		return 1
+		return errhand.BuildDError("Error retrieving commits.").AddCause(err).Build()
+	}
+
+	if apr.Contains(formatParam) {
+		return printJsonLog(ctx, commits)
+	}
+
+	linesFunc := commitLogLines
+	if apr.Contains(onelineParam) {
+		linesFunc = onelineLogLines
+	}
+
+	var graph *logGraph
+	if apr.Contains(graphParam) {
+		graph = newLogGraph(commits)
 	}
 
 	for _, comm := range commits {
-		meta, err := comm.GetCommitMeta()
+		meta, pHashes, cmHash, verr := commitLogInfo(ctx, comm)
 
-		if err != nil {
-			cli.PrintErrln("error: failed to get commit metadata")
-			return 1
+		if verr != nil {
+			return verr
 		}
 
-		pHashes, err := comm.ParentHashes(context.TODO())
+		lines := linesFunc(meta, pHashes, cmHash)
 
-		if err != nil {
-			cli.PrintErrln("error: failed to get parent hashes")
-			return 1
+		if dEnv.RepoState.IsShallowCommit(cmHash) {
+			// the commit's parents were left out of a shallow clone or fetch
+			lines[0] += " " + color.CyanString("(shallow)")
+		}
+
+		if graph != nil {
+			lines = graph.addCommit(cmHash, pHashes, lines)
+		}
+
+		for _, line := range lines {
+			cli.Println(line)
+		}
+	}
+
+	return nil
+}
+
+// parseLogArgs splits the arguments to log into the commits whose history should be shown, the commits whose history
+// should be excluded, and the tables to filter by.
+func parseLogArgs(ctx context.Context, dEnv *env.DoltEnv, args []string) (include, exclude []*doltdb.Commit, tables []string, verr errhand.VerboseError) {
+	for _, arg := range args {
+		if idx := strings.Index(arg, "..."); idx != -1 {
+			cm1, verr := resolveLogCommit(ctx, dEnv, arg[:idx])
+
+			if verr != nil {
+				return nil, nil, nil, verr
+			}
+
+			cm2, verr := resolveLogCommit(ctx, dEnv, arg[idx+3:])
+
+			if verr != nil {
+				return nil, nil, nil, verr
+			}
+
+			inc, exc, err := actions.SymmetricDifference(ctx, cm1, cm2)
+
+			if err != nil {
+				return nil, nil, nil, errhand.BuildDError("error: failed to find the common ancestor of '%s'", arg).AddCause(err).Build()
+			}
+
+			include = append(include, inc...)
+			exclude = append(exclude, exc...)
+		} else if idx := strings.Index(arg, ".."); idx != -1 {
+			from, verr := resolveLogCommit(ctx, dEnv, arg[:idx])
+
+			if verr != nil {
+				return nil, nil, nil, verr
+			}
+
+			to, verr := resolveLogCommit(ctx, dEnv, arg[idx+2:])
+
+			if verr != nil {
+				return nil, nil, nil, verr
+			}
+
+			include = append(include, to)
+			exclude = append(exclude, from)
+		} else if strings.HasPrefix(arg, "^") {
+			cm, verr := resolveLogCommit(ctx, dEnv, arg[1:])
+
+			if verr != nil {
+				return nil, nil, nil, verr
+			}
+
+			exclude = append(exclude, cm)
+		} else {
+			cm, err := actions.MaybeGetCommit(ctx, dEnv, arg)
+
+			if err != nil {
+				return nil, nil, nil, errhand.BuildDError("error: failed to resolve '%s'", arg).AddCause(err).Build()
+			} else if cm != nil {
+				include = append(include, cm)
+			} else if doltdb.IsValidTableName(arg) {
+				tables = append(tables, arg)
+			} else {
+				return nil, nil, nil, errhand.BuildDError("error: '%s' is neither a commit nor a table", arg).Build()
+			}
+		}
+	}
+
+	if len(include) == 0 {
+		head, verr := resolveLogCommit(ctx, dEnv, "")
+
+		if verr != nil {
+			return nil, nil, nil, verr
 		}
 
-		cmHash, err := comm.HashOf()
+		include = append(include, head)
+	}
+
+	return include, exclude, tables, nil
+}
+
+// resolveLogCommit resolves a commit given in a log argument, where an empty string refers to the head of the current
+// branch, as in "master..".
+func resolveLogCommit(ctx context.Context, dEnv *env.DoltEnv, str string) (*doltdb.Commit, errhand.VerboseError) {
+	if str == "" {
+		str = "HEAD"
+	}
+
+	cs, err := doltdb.NewCommitSpec(str, dEnv.RepoState.Head.Ref.String())
+
+	if err != nil {
+		return nil, errhand.BuildDError("Invalid commit %s", str).Build()
+	}
+
+	cm, err := dEnv.DoltDB.Resolve(ctx, cs)
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: unknown commit '%s'", str).AddCause(err).Build()
+	}
+
+	return cm, nil
+}
+
+func parseLogFilter(apr *argparser.ArgParseResults, tables []string) (*actions.LogFilter, errhand.VerboseError) {
+	filter := &actions.LogFilter{Tables: tables}
+
+	if author, ok := apr.GetValue(authorParam); ok {
+		re, err := regexp.Compile(author)
 
 		if err != nil {
-			cli.PrintErrln("error: failed to get commit hash")
-			return 1
+			return nil, errhand.BuildDError("error: invalid --%s pattern '%s'", authorParam, author).AddCause(err).Build()
+		}
+
+		filter.Author = re
+	}
+
+	now := time.Now()
+	for param, dest := range map[string]*time.Time{sinceParam: &filter.Since, untilParam: &filter.Until} {
+		if dateStr, ok := apr.GetValue(param); ok {
+			t, err := doltdb.ParseDate(dateStr, now)
+
+			if err != nil {
+				return nil, errhand.BuildDError("error: invalid --%s date '%s'", param, dateStr).AddCause(err).Build()
+			}
+
+			*dest = t
 		}
-		loggerFunc(meta, pHashes, cmHash)
 	}
 
-	return 0
+	return filter, nil
+}
+
+func commitLogInfo(ctx context.Context, comm *doltdb.Commit) (*doltdb.CommitMeta, []hash.Hash, hash.Hash, errhand.VerboseError) {
+	meta, err := comm.GetCommitMeta()
+
+	if err != nil {
+		return nil, nil, hash.Hash{}, errhand.BuildDError("error: failed to get commit metadata").AddCause(err).Build()
+	}
+
+	pHashes, err := comm.ParentHashes(ctx)
+
+	if err != nil {
+		return nil, nil, hash.Hash{}, errhand.BuildDError("error: failed to get parent hashes").AddCause(err).Build()
+	}
+
+	cmHash, err := comm.HashOf()
+
+	if err != nil {
+		return nil, nil, hash.Hash{}, errhand.BuildDError("error: failed to get commit hash").AddCause(err).Build()
+	}
+
+	return meta, pHashes, cmHash, nil
+}
+
+func printJsonLog(ctx context.Context, commits []*doltdb.Commit) errhand.VerboseError {
+	entries := make([]jsonLogEntry, 0, len(commits))
+	for _, comm := range commits {
+		meta, pHashes, cmHash, verr := commitLogInfo(ctx, comm)
+
+		if verr != nil {
+			return verr
+		}
+
+		entries = append(entries, newJsonLogEntry(meta, pHashes, cmHash))
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to serialize log").AddCause(err).Build()
+	}
+
+	cli.Println(string(data))
+	return nil
+}
+
+// logGraph draws the lines of history next to the commits of a log. Each lane of the graph is waiting for the next
+// commit in that line of history. Only edges between commits that are part of the log are drawn, so history
+// filtered out of the log shows up as the end of a lane.
+type logGraph struct {
+	lanes []hash.Hash
+	shown map[hash.Hash]bool
+}
+
+func newLogGraph(commits []*doltdb.Commit) *logGraph {
+	shown := make(map[hash.Hash]bool, len(commits))
+	for _, cm := range commits {
+		if h, err := cm.HashOf(); err == nil {
+			shown[h] = true
+		}
+	}
+
+	return &logGraph{shown: shown}
+}
+
+// addCommit advances the graph past the commit given, and returns the lines of the commit's log output prefixed with
+// the graph.
+func (g *logGraph) addCommit(h hash.Hash, parents []hash.Hash, lines []string) []string {
+	col := -1
+	var merged []int
+	for i, l := range g.lanes {
+		if l == h {
+			if col == -1 {
+				col = i
+			} else {
+				merged = append(merged, i)
+			}
+		}
+	}
+
+	if col == -1 {
+		col = g.emptyLane(0)
+	}
+
+	commitLine := g.render(col)
+
+	for _, i := range merged {
+		g.lanes[i] = hash.Hash{}
+	}
+
+	var shownParents []hash.Hash
+	for _, p := range parents {
+		if g.shown[p] {
+			shownParents = append(shownParents, p)
+		}
+	}
+
+	g.lanes[col] = hash.Hash{}
+	if len(shownParents) > 0 {
+		g.lanes[col] = shownParents[0]
+	}
+
+	var forks, newLanes []int
+	for i := 1; i < len(shownParents); i++ {
+		p := shownParents[i]
+		if lane := g.laneOf(p); lane != -1 {
+			forks = append(forks, lane)
+		} else {
+			lane = g.emptyLane(col + 1)
+			g.lanes[lane] = p
+			forks = append(forks, lane)
+			newLanes = append(newLanes, lane)
+		}
+	}
+
+	var connectLine string
+	if len(merged) > 0 || len(forks) > 0 {
+		chars := []byte(g.render(-1))
+		for len(chars) < 2*len(g.lanes) {
+			chars = append(chars, ' ')
+		}
+
+		for _, j := range merged {
+			drawEdge(chars, col, j, '/')
+		}
+
+		for _, j := range newLanes {
+			chars[2*j] = ' '
+		}
+
+		for _, j := range forks {
+			drawEdge(chars, col, j, '\\')
+		}
+
+		connectLine = strings.TrimRight(string(chars), " ")
+	}
+
+	for len(g.lanes) > 0 && g.lanes[len(g.lanes)-1].IsEmpty() {
+		g.lanes = g.lanes[:len(g.lanes)-1]
+	}
+
+	contLine := g.render(-1)
+
+	prefixes := []string{commitLine}
+	if connectLine != "" {
+		prefixes = append(prefixes, connectLine)
+	}
+
+	if len(lines) < len(prefixes) {
+		lines = append(lines, "")
+	}
+
+	width := len(commitLine)
+	if len(connectLine) > width {
+		width = len(connectLine)
+	}
+	if len(contLine) > width {
+		width = len(contLine)
+	}
+
+	graphLines := make([]string, len(lines))
+	for i, line := range lines {
+		prefix := contLine
+		if i < len(prefixes) {
+			prefix = prefixes[i]
+		}
+
+		graphLines[i] = strings.TrimRight(fmt.Sprintf("%-*s %s", width, prefix, line), " ")
+	}
+
+	return graphLines
+}
+
+// render draws a line of the graph with a '|' for each active lane, and a '*' in the lane of a commit if col is not -1.
+func (g *logGraph) render(col int) string {
+	chars := make([]byte, 0, 2*len(g.lanes))
+	for i, l := range g.lanes {
+		if i > 0 {
+			chars = append(chars, ' ')
+		}
+
+		if i == col {
+			chars = append(chars, '*')
+		} else if !l.IsEmpty() {
+			chars = append(chars, '|')
+		} else {
+			chars = append(chars, ' ')
+		}
+	}
+
+	return strings.TrimRight(string(chars), " ")
+}
+
+// drawEdge draws an edge on a connecting line of the graph between the lane of a commit and another lane, using '_'
+// to cross the lanes in between. Edges to lanes on the right end with the diagonal given, and edges to lanes on the
+// left always start with a '/'.
+func drawEdge(chars []byte, col, lane int, diagonal byte) {
+	if lane > col {
+		for pos := 2*col + 1; pos < 2*lane-1; pos += 2 {
+			chars[pos] = '_'
+		}
+
+		chars[2*lane-1] = diagonal
+	} else {
+		chars[2*lane+1] = '/'
+
+		for pos := 2*lane + 3; pos < 2*col; pos += 2 {
+			chars[pos] = '_'
+		}
+	}
+}
+
+// laneOf returns the lane waiting for the commit given, or -1 if there isn't one.
+func (g *logGraph) laneOf(h hash.Hash) int {
+	for i, l := range g.lanes {
+		if l == h {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// emptyLane returns the first empty lane at or after start, adding a lane if needed.
+func (g *logGraph) emptyLane(start int) int {
+	for i := start; i < len(g.lanes); i++ {
+		if g.lanes[i].IsEmpty() {
+			return i
+		}
+	}
+
+	for len(g.lanes) < start {
+		g.lanes = append(g.lanes, hash.Hash{})
+	}
+
+	g.lanes = append(g.lanes, hash.Hash{})
+	return len(g.lanes) - 1
 }
@@ -18,8 +18,11 @@ import (
 	"context"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/store/hash"
 	"github.com/liquidata-inc/dolt/go/store/types"
 )
 
@@ -36,3 +39,50 @@ func TestLog(t *testing.T) {
 
 	cli.Println(commit)
 }
+
+func TestLogGraph(t *testing.T) {
+	// commits in log order, each followed by its parents
+	history := [][]string{
+		{"c", "m"},
+		{"m", "a", "b"},
+		{"b", "b0"},
+		{"a", "base"},
+		{"b0", "base"},
+		{"base", "root"},
+		{"root"},
+	}
+
+	hashes := make(map[string]hash.Hash)
+	shown := make(map[hash.Hash]bool)
+	for _, cm := range history {
+		hashes[cm[0]] = hash.Of([]byte(cm[0]))
+		shown[hashes[cm[0]]] = true
+	}
+
+	g := &logGraph{shown: shown}
+
+	var actual []string
+	for _, cm := range history {
+		var parents []hash.Hash
+		for _, p := range cm[1:] {
+			parents = append(parents, hashes[p])
+		}
+
+		actual = append(actual, g.addCommit(hashes[cm[0]], parents, []string{cm[0]})...)
+	}
+
+	expected := []string{
+		"* c",
+		"*   m",
+		"|\\",
+		"| * b",
+		"* | a",
+		"| * b0",
+		"* | base",
+		"|/",
+		"* root",
+	}
+
+	assert.Equal(t, expected, actual)
+	assert.Empty(t, g.lanes)
+}
@@ -135,6 +135,10 @@ func abortMerge(doltEnv *env.DoltEnv) errhand.VerboseError {
 		}
 	}
 
+	if err == env.ErrRepoStateConflict || err == env.ErrRepoStateLocked {
+		return repoStateUpdateVErr(err)
+	}
+
 	return errhand.BuildDError("fatal: failed to revert changes").AddCause(err).Build()
 }
 
@@ -171,7 +175,7 @@ func mergeBranch(dEnv *env.DoltEnv, dref ref.DoltRef) errhand.VerboseError {
 	cli.Println("Updating", h1.String()+".."+h2.String())
 
 	if ok, err := cm1.CanFastForwardTo(context.TODO(), cm2); ok {
-		return executeFFMerge(dEnv, cm2)
+		return executeFFMerge(dEnv, cm1, cm2)
 	} else if err == doltdb.ErrUpToDate || err == doltdb.ErrIsAhead {
 		cli.Println("Already up to date.")
 		return nil
@@ -180,7 +184,7 @@ func mergeBranch(dEnv *env.DoltEnv, dref ref.DoltRef) errhand.VerboseError {
 	}
 }
 
-func executeFFMerge(dEnv *env.DoltEnv, cm2 *doltdb.Commit) errhand.VerboseError {
+func executeFFMerge(dEnv *env.DoltEnv, cm1, cm2 *doltdb.Commit) errhand.VerboseError {
 	cli.Println("Fast-forward")
 
 	rv, err := cm2.GetRootValue()
@@ -189,6 +193,18 @@ func executeFFMerge(dEnv *env.DoltEnv, cm2 *doltdb.Commit) errhand.VerboseError
 		return errhand.BuildDError("error: failed to get root value").AddCause(err).Build()
 	}
 
+	headRoot, err := cm1.GetRootValue()
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to get root value").AddCause(err).Build()
+	}
+
+	tblToStats, err := fastForwardStats(context.TODO(), rv, headRoot)
+
+	if err != nil {
+		return errhand.BuildDError("error: failed to diff commits").AddCause(err).Build()
+	}
+
 	h, err := dEnv.DoltDB.WriteRootValue(context.Background(), rv)
 
 	if err != nil {
@@ -205,7 +221,9 @@ func executeFFMerge(dEnv *env.DoltEnv, cm2 *doltdb.Commit) errhand.VerboseError
 	dEnv.RepoState.Staged = h.String()
 	err = dEnv.RepoState.Save()
 
-	if err != nil {
+	if err == env.ErrRepoStateConflict || err == env.ErrRepoStateLocked {
+		return repoStateUpdateVErr(err)
+	} else if err != nil {
 		return errhand.BuildDError("unable to execute repo state update.").
 			AddDetails(`As a result your .dolt/repo_state.json file may have invalid values for "staged" and "working".
 At the moment the best way to fix this is to run:
@@ -216,9 +234,45 @@ and take the hash for your current branch and use it for the value for "staged"
 			AddCause(err).Build()
 	}
 
+	printSuccessStats(tblToStats)
 	return nil
 }
 
+// fastForwardStats returns the MergeStats of each table changed by fast-forwarding from headRoot to mergeRoot.
+func fastForwardStats(ctx context.Context, mergeRoot, headRoot *doltdb.RootValue) (map[string]*merge.MergeStats, error) {
+	added, modified, removed, err := mergeRoot.TableDiff(ctx, headRoot)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tblToStats := make(map[string]*merge.MergeStats)
+	for _, tblName := range added {
+		tblToStats[tblName] = &merge.MergeStats{Operation: merge.TableAdded}
+	}
+
+	for _, tblName := range removed {
+		tblToStats[tblName] = &merge.MergeStats{Operation: merge.TableRemoved}
+	}
+
+	tblToSummary, err := actions.GetTableDiffSummaries(ctx, mergeRoot, headRoot, modified)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for tblName, summary := range tblToSummary {
+		tblToStats[tblName] = &merge.MergeStats{
+			Operation:     merge.TableModified,
+			Adds:          int(summary.RowsAdded),
+			Deletes:       int(summary.RowsDeleted),
+			Modifications: int(summary.RowsModified),
+		}
+	}
+
+	return tblToStats, nil
+}
+
 func executeMerge(dEnv *env.DoltEnv, cm1, cm2 *doltdb.Commit, dref ref.DoltRef) errhand.VerboseError {
 	mergedRoot, tblToStats, err := actions.MergeCommits(context.Background(), dEnv.DoltDB, cm1, cm2)
 
@@ -228,6 +282,14 @@ func executeMerge(dEnv *env.DoltEnv, cm1, cm2 *doltdb.Commit, dref ref.DoltRef)
 			return errhand.BuildDError("Already up to date.").AddCause(err).Build()
 		case merge.ErrFastForward:
 			panic("fast forward merge")
+		case doltdb.ErrNoCommonAnscestor, doltdb.ErrBeyondShallowBoundary:
+			bdr := errhand.BuildDError("Bad merge").AddCause(err)
+
+			if len(dEnv.RepoState.Shallow) > 0 {
+				bdr.AddDetails("the common ancestor may be beyond the boundary of this shallow repository. Run 'dolt fetch --depth <depth>' with a greater depth and try again.")
+			}
+
+			return bdr.Build()
 		default:
 			return errhand.BuildDError("Bad merge").AddCause(err).Build()
 		}
@@ -241,7 +303,9 @@ func executeMerge(dEnv *env.DoltEnv, cm1, cm2 *doltdb.Commit, dref ref.DoltRef)
 
 	err = dEnv.RepoState.StartMerge(dref, h2.String())
 
-	if err != nil {
+	if err == env.ErrRepoStateConflict || err == env.ErrRepoStateLocked {
+		return repoStateUpdateVErr(err)
+	} else if err != nil {
 		return errhand.BuildDError("Unable to update the repo state").AddCause(err).Build()
 	}
 
@@ -267,7 +331,7 @@ func printSuccessStats(tblToStats map[string]*merge.MergeStats) bool {
 
 func printAdditions(tblToStats map[string]*merge.MergeStats) {
 	for tblName, stats := range tblToStats {
-		if stats.Operation == merge.TableRemoved {
+		if stats.Operation == merge.TableAdded {
 			cli.Println(tblName, "added")
 		}
 	}
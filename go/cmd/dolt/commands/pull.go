@@ -22,6 +22,7 @@ import (
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
+	"github.com/liquidata-inc/dolt/go/store/datas"
 )
 
 var pullShortDesc = "Fetch from and integrate with another repository or a local branch"
@@ -29,25 +30,44 @@ var pullLongDesc = "Incorporates changes from a remote repository into the curre
 	"<b>dolt pull</b> is shorthand for <b>dolt fetch</b> followed by <b>dolt merge <remote>/<branch></b>." +
 	"\n" +
 	"\nMore precisely, dolt pull runs dolt fetch with the given parameters and calls dolt merge to merge the retrieved " +
-	"branch heads into the current branch."
+	"branch heads into the current branch." +
+	"\n" +
+	"\nWithout arguments the upstream of the current branch is fetched and merged. The upstream is set with " +
+	"<b>dolt push --set-upstream</b> or <b>dolt branch --set-upstream-to</b>." +
+	"\n" +
+	"\nWhen <b>--resumable</b> is given the progress of the fetch is saved as it is made, and a pull which is " +
+	"interrupted continues fetching from where it stopped when it is run again with <b>--resumable</b>."
 var pullSynopsis = []string{
-	"<remote>",
+	"[--resumable] [<remote>]",
 }
 
 func Pull(commandStr string, args []string, dEnv *env.DoltEnv) int {
 	ap := argparser.NewArgParser()
+	ap.SupportsFlag(resumableFlag, "", "Save the progress of the fetch so that it can be resumed if it is interrupted.")
 	help, usage := cli.HelpAndUsagePrinters(commandStr, pullShortDesc, pullLongDesc, pullSynopsis, ap)
 	apr := cli.ParseArgs(ap, args, help)
 	branch := dEnv.RepoState.Head.Ref
+	resumable := apr.Contains(resumableFlag)
 
 	var verr errhand.VerboseError
 	var remoteName string
-	if apr.NArg() > 1 {
+	if apr.NArg() == 0 {
+		upstream, trackingRef, ok, verr := getUpstreamTrackingRef(dEnv, branch)
+
+		if verr != nil {
+			return HandleVErrAndExitCode(verr, usage)
+		} else if !ok {
+			printNoUpstream(dEnv, branch)
+			return 1
+		}
+
+		remote := dEnv.RepoState.Remotes[upstream.Remote]
+		verr = pullRemoteBranch(dEnv, remote, upstream.Merge.Ref, trackingRef, resumable)
+		return HandleVErrAndExitCode(verr, usage)
+	} else if apr.NArg() > 1 {
 		verr = errhand.BuildDError("").SetPrintUsage().Build()
 	} else {
-		if apr.NArg() == 1 {
-			remoteName = apr.Arg(0)
-		}
+		remoteName = apr.Arg(0)
 
 		var refSpecs []ref.RemoteRefSpec
 		refSpecs, verr = dEnv.GetRefSpecs(remoteName)
@@ -60,7 +80,7 @@ func Pull(commandStr string, args []string, dEnv *env.DoltEnv) int {
 
 				for _, refSpec := range refSpecs {
 					if remoteTrackRef := refSpec.DestRef(branch); remoteTrackRef != nil {
-						verr = pullRemoteBranch(dEnv, remote, branch, remoteTrackRef)
+						verr = pullRemoteBranch(dEnv, remote, branch, remoteTrackRef, resumable)
 
 						if verr != nil {
 							break
@@ -74,14 +94,26 @@ func Pull(commandStr string, args []string, dEnv *env.DoltEnv) int {
 	return HandleVErrAndExitCode(verr, usage)
 }
 
-func pullRemoteBranch(dEnv *env.DoltEnv, r env.Remote, srcRef, destRef ref.DoltRef) errhand.VerboseError {
+func pullRemoteBranch(dEnv *env.DoltEnv, r env.Remote, srcRef, destRef ref.DoltRef, resumable bool) errhand.VerboseError {
+	tables, verr := partialFetchTables(dEnv, r.Name, nil)
+
+	if verr != nil {
+		return verr
+	}
+
 	srcDB, err := r.GetRemoteDB(context.TODO(), dEnv.DoltDB.ValueReadWriter().Format())
 
 	if err != nil {
 		return errhand.BuildDError("error: failed to get remote db").AddCause(err).Build()
 	}
 
-	verr := fetchRemoteBranch(r, srcDB, dEnv.DoltDB, srcRef, destRef)
+	var cp datas.PullCheckpointer
+	if resumable {
+		cp = dEnv.FetchCheckpointer(r)
+	}
+
+	_, verr = fetchRemoteBranch(r, srcDB, dEnv.DoltDB, srcRef, destRef, 0, tables, cp)
+	verr = closeRemoteDB(srcDB, verr)
 
 	if verr != nil {
 		return verr
@@ -35,6 +35,7 @@ import (
 
 const (
 	SetUpstreamFlag = "set-upstream"
+	tableFilesFlag  = "table-files"
 )
 
 var pushShortDesc = "Update remote refs along with associated objects"
@@ -50,15 +51,21 @@ var pushLongDesc = "Updates remote refs using local refs, while sending objects
 	"\n" +
 	"\nWhen neither the command-line does not specify what to push, the default behavior is used, which corresponds to the " +
 	"current branch being pushed to the corresponding upstream branch, but as a safety measure, the push is aborted if " +
-	"the upstream branch does not have the same name as the local one."
+	"the upstream branch does not have the same name as the local one." +
+	"\n" +
+	"\nWhen --table-files is given and the remote holds less than half as many chunks as the local repository, the " +
+	"table files of the local repository are uploaded whole before the refs are pushed. This sends every chunk of the " +
+	"local repository, including the chunks of other branches and of data which is no longer referenced, so it is " +
+	"only worth using for the first push to an empty remote."
 
 var pushSynopsis = []string{
-	"[-u | --set-upstream] [<remote>] [<refspec>]",
+	"[-u | --set-upstream] [--table-files] [<remote>] [<refspec>]",
 }
 
 func Push(commandStr string, args []string, dEnv *env.DoltEnv) int {
 	ap := argparser.NewArgParser()
 	ap.SupportsFlag(SetUpstreamFlag, "u", "For every branch that is up to date or successfully pushed, add upstream (tracking) reference, used by argument-less dolt pull and other commands.")
+	ap.SupportsFlag(tableFilesFlag, "", "Upload the table files of the repository whole if the remote is far behind it. Every chunk of the repository is uploaded, including those of other branches.")
 	help, usage := cli.HelpAndUsagePrinters(commandStr, pushShortDesc, pushLongDesc, pushSynopsis, ap)
 	apr := cli.ParseArgs(ap, args, help)
 
@@ -139,6 +146,15 @@ func Push(commandStr string, args []string, dEnv *env.DoltEnv) int {
 		return 1
 	}
 
+	var tfConcurrency int
+	if verr == nil && apr.Contains(tableFilesFlag) {
+		tfConcurrency, err = dEnv.TableFileConcurrency()
+
+		if err != nil {
+			verr = errhand.BuildDError("error: failed to read the table file concurrency").AddCause(err).Build()
+		}
+	}
+
 	if verr == nil {
 		hasRef, err := dEnv.DoltDB.HasRef(context.TODO(), currentBranch)
 
@@ -173,10 +189,14 @@ func Push(commandStr string, args []string, dEnv *env.DoltEnv) int {
 					}
 
 					verr = bdr.Build()
-				} else if src == ref.EmptyBranchRef {
-					verr = deleteRemoteBranch(ctx, dest, remoteRef, dEnv.DoltDB, destDB, remote)
 				} else {
-					verr = pushToRemoteBranch(ctx, src, dest, remoteRef, dEnv.DoltDB, destDB, remote)
+					if src == ref.EmptyBranchRef {
+						verr = deleteRemoteBranch(ctx, dest, remoteRef, dEnv.DoltDB, destDB, remote)
+					} else {
+						verr = pushToRemoteBranch(ctx, src, dest, remoteRef, dEnv.DoltDB, destDB, remote, tfConcurrency, dEnv.PushCheckpointer(remote))
+					}
+
+					verr = closeRemoteDB(destDB, verr)
 				}
 			}
 
@@ -192,7 +212,9 @@ func Push(commandStr string, args []string, dEnv *env.DoltEnv) int {
 
 				err := dEnv.RepoState.Save()
 
-				if err != nil {
+				if err == env.ErrRepoStateConflict || err == env.ErrRepoStateLocked {
+					verr = repoStateUpdateVErr(err)
+				} else if err != nil {
 					verr = errhand.BuildDError("error: failed to save repo state").AddCause(err).Build()
 				}
 			}
@@ -230,7 +252,7 @@ func deleteRemoteBranch(ctx context.Context, toDelete, remoteRef ref.DoltRef, lo
 	return nil
 }
 
-func pushToRemoteBranch(ctx context.Context, srcRef, destRef, remoteRef ref.DoltRef, localDB, remoteDB *doltdb.DoltDB, remote env.Remote) errhand.VerboseError {
+func pushToRemoteBranch(ctx context.Context, srcRef, destRef, remoteRef ref.DoltRef, localDB, remoteDB *doltdb.DoltDB, remote env.Remote, tfConcurrency int, cp datas.PullCheckpointer) errhand.VerboseError {
 	cs, _ := doltdb.NewCommitSpec("HEAD", srcRef.GetPath())
 	cm, err := localDB.Resolve(ctx, cs)
 
@@ -241,7 +263,7 @@ func pushToRemoteBranch(ctx context.Context, srcRef, destRef, remoteRef ref.Dolt
 		stopChan := make(chan struct{})
 		go progFunc(progChan, stopChan)
 
-		err = actions.Push(ctx, destRef.(ref.BranchRef), remoteRef.(ref.RemoteRef), localDB, remoteDB, cm, progChan)
+		err = actions.Push(ctx, destRef.(ref.BranchRef), remoteRef.(ref.RemoteRef), localDB, remoteDB, cm, tfConcurrency, cp, progChan)
 
 		close(progChan)
 		<-stopChan
@@ -45,7 +45,7 @@ var remoteLongDesc = "With no arguments, shows a list of existing remotes. Sever
 	"Adds a remote named <name> for the repository at <url>. The command dolt fetch <name> can " +
 	"then be used to create and update remote-tracking branches <name>/<branch>." +
 	"\n" +
-	"\nThe <url> parameter supports url schemes of http, https, aws, gs, and file.  If a url scheme does not prefix the " +
+	"\nThe <url> parameter supports url schemes of http, https, aws, gs, file, and bundle.  If a url scheme does not prefix the " +
 	"url then https is assumed.  If the <url> paramenter is in the format <organization>/<repository> then dolt will use " +
 	"the remotes.default_host from your configuration file (Which will be dolthub.com unless changed).\n" +
 	"\n" +
@@ -65,6 +65,9 @@ var remoteLongDesc = "With no arguments, shows a list of existing remotes. Sever
 	"The local filesystem can be used as a remote by providing a repository url in the format file://absolute path. See" +
 	"https://en.wikipedia.org/wiki/File_URI_scheme for details." +
 	"\n" +
+	"\nA bundle file created with dolt bundle create can be used as a read only remote by providing a url in the " +
+	"format bundle://path to bundle file." +
+	"\n" +
 	"\n<b>remove, rm</b>\n" +
 	"Remove the remote named <name>. All remote-tracking branches and configuration settings" +
 	"for the remote are removed."
@@ -154,7 +157,9 @@ func removeRemote(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgPars
 	delete(dEnv.RepoState.Remotes, old)
 	err = dEnv.RepoState.Save()
 
-	if err != nil {
+	if err == env.ErrRepoStateConflict || err == env.ErrRepoStateLocked {
+		return repoStateUpdateVErr(err)
+	} else if err != nil {
 		return errhand.BuildDError("error: unable to save changes.").AddCause(err).Build()
 	}
 
@@ -177,6 +182,14 @@ func getAbsRemoteUrl(fs filesys.Filesys, cfg config.ReadableConfig, urlArg strin
 			}
 
 			return dbfactory.FileScheme, absUrl, err
+		} else if u.Scheme == dbfactory.BundleScheme {
+			absUrl, err := getAbsBundleRemoteUrl(u.Host+u.Path, fs)
+
+			if err != nil {
+				return "", "", err
+			}
+
+			return dbfactory.BundleScheme, absUrl, err
 		}
 
 		return u.Scheme, urlArg, nil
@@ -200,6 +213,16 @@ func getAbsRemoteUrl(fs filesys.Filesys, cfg config.ReadableConfig, urlArg strin
 }
 
 func getAbsFileRemoteUrl(urlStr string, fs filesys.Filesys) (string, error) {
+	return getAbsLocalRemoteUrl(dbfactory.FileScheme, urlStr, fs, true)
+}
+
+func getAbsBundleRemoteUrl(urlStr string, fs filesys.Filesys) (string, error) {
+	return getAbsLocalRemoteUrl(dbfactory.BundleScheme, urlStr, fs, false)
+}
+
+// getAbsLocalRemoteUrl returns the url with the scheme given for the absolute path of a local directory, or file if
+// isDir is false.
+func getAbsLocalRemoteUrl(scheme, urlStr string, fs filesys.Filesys, isDir bool) (string, error) {
 	var err error
 	urlStr = filepath.Clean(urlStr)
 	urlStr, err = fs.Abs(urlStr)
@@ -208,19 +231,21 @@ func getAbsFileRemoteUrl(urlStr string, fs filesys.Filesys) (string, error) {
 		return "", err
 	}
 
-	exists, isDir := fs.Exists(urlStr)
+	exists, existsAsDir := fs.Exists(urlStr)
 
 	if !exists {
 		return "", os.ErrNotExist
-	} else if !isDir {
+	} else if isDir && !existsAsDir {
 		return "", filesys.ErrIsFile
+	} else if !isDir && existsAsDir {
+		return "", filesys.ErrIsDir
 	}
 
 	urlStr = strings.ReplaceAll(urlStr, `\`, "/")
 	if !strings.HasPrefix(urlStr, "/") {
 		urlStr = "/" + urlStr
 	}
-	return dbfactory.FileScheme + "://" + urlStr, nil
+	return scheme + "://" + urlStr, nil
 }
 
 func addRemote(dEnv *env.DoltEnv, apr *argparser.ArgParseResults) errhand.VerboseError {
@@ -255,7 +280,9 @@ func addRemote(dEnv *env.DoltEnv, apr *argparser.ArgParseResults) errhand.Verbos
 	dEnv.RepoState.AddRemote(r)
 	err = dEnv.RepoState.Save()
 
-	if err != nil {
+	if err == env.ErrRepoStateConflict || err == env.ErrRepoStateLocked {
+		return repoStateUpdateVErr(err)
+	} else if err != nil {
 		return errhand.BuildDError("error: Unable to save changes.").AddCause(err).Build()
 	}
 
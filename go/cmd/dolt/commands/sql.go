@@ -30,6 +30,7 @@ import (
 	"github.com/liquidata-inc/ishell"
 	sqle "github.com/src-d/go-mysql-server"
 	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/analyzer"
 	"vitess.io/vitess/go/vt/sqlparser"
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
@@ -40,6 +41,7 @@ import (
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
 	dsql "github.com/liquidata-inc/dolt/go/libraries/doltcore/sql"
 	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle/privileges"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/pipeline"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped/fwt"
@@ -71,6 +73,11 @@ Reasonably well supported functionality:
 * ORDER BY and LIMIT clauses
 * GROUP BY
 * Aggregate functions, e.g. SUM 
+* EXPLAIN <select>, which shows whether each table is fully scanned or read via its primary key,
+  and the kind of each join, as it does for dolt sql-server clients
+* CREATE USER / DROP USER / GRANT / REVOKE statements, which manage the accounts allowed to
+  connect to dolt sql-server. A running server applies grants, revokes and dropped users to its
+  next query, but new users and passwords only once it is restarted
 
 Known limitations:
 * Some expressions in SELECT statements
@@ -373,6 +380,19 @@ func prepend(s string, ss []string) []string {
 
 // Processes a single query and returns the new root value of the DB, or an error encountered.
 func processQuery(query string, dEnv *env.DoltEnv, root *doltdb.RootValue) (*doltdb.RootValue, error) {
+	if privileges.IsAccountStatement(query) {
+		return nil, sqlAccountStatement(dEnv, query)
+	}
+
+	// EXPLAIN is run by the engine, as it is for sql-server clients
+	if _, ok := dsqle.ParseExplain(query); ok {
+		sqlSch, rowIter, err := sqlNewEngine(query, root)
+		if err == nil {
+			err = prettyPrintResults(root.VRW().Format(), sqlSch, rowIter)
+		}
+		return nil, err
+	}
+
 	sqlStatement, err := sqlparser.Parse(query)
 	if err != nil {
 		return nil, fmt.Errorf("Error parsing SQL: %v.", err.Error())
@@ -436,23 +456,47 @@ func processBatchQuery(query string, dEnv *env.DoltEnv, root *doltdb.RootValue,
 	}
 }
 
+// Executes a CREATE USER, ALTER USER, DROP USER, GRANT or REVOKE statement against the repository's sql-server users.
+func sqlAccountStatement(dEnv *env.DoltEnv, query string) error {
+	userStore, err := privileges.LoadUserStore(dEnv.FS)
+	if err != nil {
+		return fmt.Errorf("Error loading sql users: %v.", err.Error())
+	}
+
+	if err := privileges.ExecuteAccountStatement(userStore, query); err != nil {
+		return err
+	}
+
+	return userStore.Save()
+}
+
 // Executes a SQL statement of either SHOW or SELECT and returns values for printing if applicable.
 func sqlNewEngine(query string, root *doltdb.RootValue) (sql.Schema, sql.RowIter, error) {
+	engine, err := newSqlEngine(root)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return engine.Query(sql.NewEmptyContext(), query)
+}
+
+// Returns a new SQL engine serving the tables of the root value given.
+func newSqlEngine(root *doltdb.RootValue) (*sqle.Engine, error) {
 	db := dsqle.NewDatabase("dolt", root)
-	engine := sqle.NewDefault()
+	catalog := sql.NewCatalog()
+	engine := sqle.New(catalog, analyzer.NewBuilder(catalog).AddPostAnalyzeRule("explain", dsqle.ExplainRule).Build(), nil)
 	engine.AddDatabase(db)
-	ctx := sql.NewEmptyContext()
 
 	// Indexes are not well tested enough to use in production yet.
 	if _, ok := os.LookupEnv(UseIndexedJoinsEnv); ok {
 		engine.Catalog.RegisterIndexDriver(dsqle.NewDoltIndexDriver(db))
 		err := engine.Init()
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 	}
 
-	return engine.Query(ctx, query)
+	return engine, nil
 }
 
 // Executes a SQL show statement and prints the result to the CLI.
@@ -0,0 +1,182 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/liquidata-inc/dolt/go/store/metrics"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
+)
+
+// queryLatencyBuckets are the upper bounds, in seconds, of the query latency histogram buckets.
+var queryLatencyBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30}
+
+// serverMetrics collects the server's connection and query statistics and writes them, along with the statistics of
+// the underlying chunk store, in the Prometheus text exposition format.
+type serverMetrics struct {
+	mu *sync.Mutex
+
+	connections       uint64
+	failedConnections uint64
+	queries           uint64
+	failedQueries     uint64
+
+	latencyBuckets []uint64
+	latencySum     float64
+
+	storeStats func() interface{}
+}
+
+func newServerMetrics(storeStats func() interface{}) *serverMetrics {
+	return &serverMetrics{
+		mu:             &sync.Mutex{},
+		latencyBuckets: make([]uint64, len(queryLatencyBuckets)),
+		storeStats:     storeStats,
+	}
+}
+
+func (m *serverMetrics) connectionAttempted(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err != nil {
+		m.failedConnections++
+	} else {
+		m.connections++
+	}
+}
+
+func (m *serverMetrics) queryCompleted(d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.queries++
+	if err != nil {
+		m.failedQueries++
+	}
+
+	secs := d.Seconds()
+	m.latencySum += secs
+	for i, le := range queryLatencyBuckets {
+		if secs <= le {
+			m.latencyBuckets[i]++
+		}
+	}
+}
+
+// ServeHTTP implements http.Handler, responding with the current metrics.
+func (m *serverMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_ = m.write(w)
+}
+
+func (m *serverMetrics) write(w io.Writer) error {
+	m.mu.Lock()
+	lines := []string{
+		"# HELP dolt_sql_connections_total Connections successfully authenticated.",
+		"# TYPE dolt_sql_connections_total counter",
+		fmt.Sprintf("dolt_sql_connections_total %d", m.connections),
+		"# HELP dolt_sql_failed_connections_total Connections that failed to authenticate.",
+		"# TYPE dolt_sql_failed_connections_total counter",
+		fmt.Sprintf("dolt_sql_failed_connections_total %d", m.failedConnections),
+		"# HELP dolt_sql_queries_total Queries run.",
+		"# TYPE dolt_sql_queries_total counter",
+		fmt.Sprintf("dolt_sql_queries_total %d", m.queries),
+		"# HELP dolt_sql_failed_queries_total Queries which returned an error.",
+		"# TYPE dolt_sql_failed_queries_total counter",
+		fmt.Sprintf("dolt_sql_failed_queries_total %d", m.failedQueries),
+		"# HELP dolt_sql_query_duration_seconds Query latency.",
+		"# TYPE dolt_sql_query_duration_seconds histogram",
+	}
+
+	for i, le := range queryLatencyBuckets {
+		lines = append(lines, fmt.Sprintf(`dolt_sql_query_duration_seconds_bucket{le="%s"} %d`, formatFloat(le), m.latencyBuckets[i]))
+	}
+
+	lines = append(lines,
+		fmt.Sprintf(`dolt_sql_query_duration_seconds_bucket{le="+Inf"} %d`, m.queries),
+		fmt.Sprintf("dolt_sql_query_duration_seconds_sum %s", formatFloat(m.latencySum)),
+		fmt.Sprintf("dolt_sql_query_duration_seconds_count %d", m.queries),
+	)
+	m.mu.Unlock()
+
+	if m.storeStats != nil {
+		if stats, ok := m.storeStats().(nbs.Stats); ok {
+			lines = append(lines, nbsStatsLines(stats)...)
+		}
+	}
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// nbsStatsLines writes each histogram of the nbs.Stats as a summary with a sum and count. Latencies are converted from
+// nanoseconds to seconds.
+func nbsStatsLines(stats nbs.Stats) []string {
+	var lines []string
+	histType := reflect.TypeOf(metrics.Histogram{})
+	val := reflect.ValueOf(stats)
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Type().Field(i)
+		if field.Type != histType {
+			continue
+		}
+
+		hist := val.Field(i).Interface().(metrics.Histogram)
+		name := "dolt_nbs_" + toSnakeCase(field.Name)
+		sum := float64(hist.Sum())
+		if strings.HasSuffix(field.Name, "Latency") {
+			name += "_seconds"
+			sum = sum / float64(time.Second)
+		}
+
+		lines = append(lines,
+			fmt.Sprintf("# TYPE %s summary", name),
+			fmt.Sprintf("%s_sum %s", name, formatFloat(sum)),
+			fmt.Sprintf("%s_count %d", name, hist.Samples()),
+		)
+	}
+
+	return lines
+}
+
+func toSnakeCase(str string) string {
+	var sb strings.Builder
+	runes := []rune(str)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				sb.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,105 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/utils/iohelp"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
+)
+
+func TestServerMetricsWrite(t *testing.T) {
+	stats := nbs.NewStats()
+	stats.GetLatency.Sample(uint64(2 * time.Second))
+	stats.FileBytesPerRead.Sample(1024)
+
+	m := newServerMetrics(func() interface{} { return *stats })
+	m.connectionAttempted(nil)
+	m.connectionAttempted(errors.New("bad password"))
+	m.queryCompleted(2*time.Millisecond, nil)
+	m.queryCompleted(2*time.Second, errors.New("table not found"))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, m.write(buf))
+	lines := strings.Split(buf.String(), "\n")
+
+	expectedLines := []string{
+		"dolt_sql_connections_total 1",
+		"dolt_sql_failed_connections_total 1",
+		"dolt_sql_queries_total 2",
+		"dolt_sql_failed_queries_total 1",
+		`dolt_sql_query_duration_seconds_bucket{le="0.001"} 0`,
+		`dolt_sql_query_duration_seconds_bucket{le="0.005"} 1`,
+		`dolt_sql_query_duration_seconds_bucket{le="1"} 1`,
+		`dolt_sql_query_duration_seconds_bucket{le="5"} 2`,
+		`dolt_sql_query_duration_seconds_bucket{le="+Inf"} 2`,
+		"dolt_sql_query_duration_seconds_sum 2.002",
+		"dolt_sql_query_duration_seconds_count 2",
+		"dolt_nbs_get_latency_seconds_sum 2",
+		"dolt_nbs_get_latency_seconds_count 1",
+		"dolt_nbs_file_bytes_per_read_sum 1024",
+		"dolt_nbs_file_bytes_per_read_count 1",
+	}
+
+	for _, expected := range expectedLines {
+		assert.Contains(t, lines, expected)
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"GetLatency":          "get_latency",
+		"S3BytesPerRead":      "s3_bytes_per_read",
+		"ReadManifestLatency": "read_manifest_latency",
+		"IO":                  "io",
+	}
+
+	for in, expected := range tests {
+		assert.Equal(t, expected, toSnakeCase(in))
+	}
+}
+
+func TestQueryLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	ql := newQueryLogger(iohelp.NopWrCloser(buf))
+
+	entries := []queryLogEntry{
+		{Client: "127.0.0.1:5000", User: "root", Database: "dolt", Query: "select * from people", DurationMs: 1.5, Rows: 3},
+		{Client: "127.0.0.1:5001", User: "root", Database: "dolt", Query: "select * from nope", Error: "table not found: nope"},
+	}
+
+	for _, entry := range entries {
+		require.NoError(t, ql.log(entry))
+	}
+	require.NoError(t, ql.Close())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, len(entries))
+
+	for i, line := range lines {
+		var entry queryLogEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		assert.Equal(t, entries[i], entry)
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/analyzer"
+
+	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+)
+
+// queryLogEntry is a single line of the JSON query log.
+type queryLogEntry struct {
+	Time       time.Time `json:"time"`
+	Client     string    `json:"client"`
+	User       string    `json:"user"`
+	Database   string    `json:"database"`
+	Query      string    `json:"query"`
+	DurationMs float64   `json:"duration_ms"`
+	Rows       uint64    `json:"rows"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// queryLogger writes a JSON object per line for every query run against the server.
+type queryLogger struct {
+	mu *sync.Mutex
+	wr io.WriteCloser
+}
+
+func newQueryLogger(wr io.WriteCloser) *queryLogger {
+	return &queryLogger{&sync.Mutex{}, wr}
+}
+
+func (ql *queryLogger) log(entry queryLogEntry) error {
+	data, err := json.Marshal(entry)
+
+	if err != nil {
+		return err
+	}
+
+	ql.mu.Lock()
+	defer ql.mu.Unlock()
+
+	_, err = ql.wr.Write(append(data, '\n'))
+	return err
+}
+
+func (ql *queryLogger) Close() error {
+	ql.mu.Lock()
+	defer ql.mu.Unlock()
+
+	return ql.wr.Close()
+}
+
+// serverAuditor receives the audit events of the engine. Events are forwarded to the logrus audit log, and queries are
+// recorded in the query log and server metrics. The engine reports a query once it is planned, before its results are
+// read, so successful queries are recorded when the row iterator of the query's plan is closed. Plans are wrapped in a
+// queryLogNode by the rule returned by queryLogRule to find out when that happens.
+type serverAuditor struct {
+	auditLog   auth.AuditMethod
+	queryLog   *queryLogger
+	metrics    *serverMetrics
+	rowCounter *dsqle.RowsReadCounter
+	database   string
+
+	mu     *sync.Mutex
+	active map[uint32]*loggedQuery
+}
+
+// loggedQuery is a query whose results are being read
+type loggedQuery struct {
+	query string
+	start time.Time
+	once  *sync.Once
+}
+
+// Authentication implements auth.AuditMethod.
+func (a *serverAuditor) Authentication(user, address string, err error) {
+	a.auditLog.Authentication(user, address, err)
+	a.metrics.connectionAttempted(err)
+}
+
+// Authorization implements auth.AuditMethod.
+func (a *serverAuditor) Authorization(ctx *sql.Context, p auth.Permission, err error) {
+	a.auditLog.Authorization(ctx, p, err)
+}
+
+// Query implements auth.AuditMethod. d is the time taken to plan the query.
+func (a *serverAuditor) Query(ctx *sql.Context, d time.Duration, err error) {
+	a.auditLog.Query(ctx, d, err)
+
+	a.mu.Lock()
+	q, ok := a.active[ctx.Session.ID()]
+	if ok && err != nil {
+		delete(a.active, ctx.Session.ID())
+	}
+	a.mu.Unlock()
+
+	if err != nil || !ok {
+		a.record(ctx, &loggedQuery{ctx.Query(), time.Now().Add(-d), &sync.Once{}}, err)
+		return
+	}
+
+	// planning started before the rows were requested
+	a.mu.Lock()
+	q.start = time.Now().Add(-d)
+	a.mu.Unlock()
+}
+
+// startQuery begins tracking a query of the session of the context given, whose rows are about to be read. Returns
+// false if the session is already running a query, whose subqueries are not tracked separately.
+func (a *serverAuditor) startQuery(ctx *sql.Context) (*loggedQuery, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.active[ctx.Session.ID()]; ok {
+		return nil, false
+	}
+
+	q := &loggedQuery{ctx.Query(), time.Now(), &sync.Once{}}
+	a.active[ctx.Session.ID()] = q
+
+	return q, true
+}
+
+// finishQuery records a query whose rows have been read.
+func (a *serverAuditor) finishQuery(ctx *sql.Context, q *loggedQuery, err error) {
+	a.mu.Lock()
+	if a.active[ctx.Session.ID()] == q {
+		delete(a.active, ctx.Session.ID())
+	}
+	a.mu.Unlock()
+
+	a.record(ctx, q, err)
+}
+
+func (a *serverAuditor) record(ctx *sql.Context, q *loggedQuery, err error) {
+	q.once.Do(func() {
+		a.mu.Lock()
+		d := time.Since(q.start)
+		a.mu.Unlock()
+
+		a.metrics.queryCompleted(d, err)
+		rows := a.rowCounter.Take(ctx)
+
+		if a.queryLog != nil {
+			entry := queryLogEntry{
+				Time:       time.Now().UTC(),
+				Client:     ctx.Client().Address,
+				User:       ctx.Client().User,
+				Database:   a.database,
+				Query:      q.query,
+				DurationMs: float64(d) / float64(time.Millisecond),
+				Rows:       rows,
+			}
+
+			if err != nil {
+				entry.Error = err.Error()
+			}
+
+			// a failure to write the query log should not fail the query
+			_ = a.queryLog.log(entry)
+		}
+	})
+}
+
+// queryLogRule returns an analyzer rule which wraps the plan of every query in a queryLogNode, so that the query is
+// recorded by the auditor given once its rows have been read.
+func queryLogRule(a *serverAuditor) analyzer.RuleFunc {
+	return func(ctx *sql.Context, _ *analyzer.Analyzer, n sql.Node) (sql.Node, error) {
+		if _, ok := n.(*queryLogNode); ok {
+			return n, nil
+		}
+
+		return &queryLogNode{n, a}, nil
+	}
+}
+
+// queryLogNode is a sql.Node which records the query it is the plan of when its rows have been read.
+type queryLogNode struct {
+	child   sql.Node
+	auditor *serverAuditor
+}
+
+// Resolved implements sql.Node.
+func (n *queryLogNode) Resolved() bool {
+	return n.child.Resolved()
+}
+
+// String implements sql.Node.
+func (n *queryLogNode) String() string {
+	return n.child.String()
+}
+
+// Schema implements sql.Node.
+func (n *queryLogNode) Schema() sql.Schema {
+	return n.child.Schema()
+}
+
+// Children implements sql.Node.
+func (n *queryLogNode) Children() []sql.Node {
+	return []sql.Node{n.child}
+}
+
+// WithChildren implements sql.Node.
+func (n *queryLogNode) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 1 {
+		return nil, sql.ErrInvalidChildrenNumber.New(n, len(children), 1)
+	}
+
+	return &queryLogNode{children[0], n.auditor}, nil
+}
+
+// RowIter implements sql.Node.
+func (n *queryLogNode) RowIter(ctx *sql.Context) (sql.RowIter, error) {
+	q, ok := n.auditor.startQuery(ctx)
+	iter, err := n.child.RowIter(ctx)
+
+	// errors creating the iterator are returned by the engine, and recorded when the auditor is told about them
+	if !ok || err != nil {
+		return iter, err
+	}
+
+	return &queryLogIter{ctx, iter, n.auditor, q, nil}, nil
+}
+
+// queryLogIter is the sql.RowIter of a queryLogNode
+type queryLogIter struct {
+	ctx     *sql.Context
+	iter    sql.RowIter
+	auditor *serverAuditor
+	query   *loggedQuery
+	err     error
+}
+
+// Next implements sql.RowIter.
+func (itr *queryLogIter) Next() (sql.Row, error) {
+	row, err := itr.iter.Next()
+
+	if err != nil && err != io.EOF && itr.err == nil {
+		itr.err = err
+	}
+
+	return row, err
+}
+
+// Close implements sql.RowIter. The rows read by the query are counted once the iterators of its tables are closed.
+func (itr *queryLogIter) Close() error {
+	err := itr.iter.Close()
+
+	queryErr := itr.err
+	if queryErr == nil {
+		queryErr = err
+	}
+
+	itr.auditor.finishQuery(itr.ctx, itr.query, queryErr)
+	return err
+}
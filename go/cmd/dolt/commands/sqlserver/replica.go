@@ -0,0 +1,265 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/src-d/go-mysql-server/sql"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+const (
+	// ReplicatedCommitVar is the system variable holding the hash of the commit a replica is serving.
+	ReplicatedCommitVar = "dolt_replicated_commit"
+	// ReplicaSyncFunc is the name of the SQL function which makes a replica pull from its remote immediately.
+	ReplicaSyncFunc = "dolt_replica_sync"
+)
+
+// replicator keeps a database up to date with a branch of a remote. Each sync fetches the branch from the remote,
+// sets the local branch to its head, and then swaps the root served by the database to the root of the new head
+// commit, so queries always see the data of a single commit. The replica follows the remote's head even when the
+// branch was force pushed, since it never has commits of its own.
+type replicator struct {
+	dEnv   *env.DoltEnv
+	remote env.Remote
+	branch ref.BranchRef
+	db     *dsqle.Database
+
+	// syncMu serializes syncs, while mu only guards the commit being served, so that the network I/O of a sync does not
+	// block readers of the commit
+	syncMu *sync.Mutex
+	mu     *sync.Mutex
+	commit hash.Hash
+
+	stopChan chan struct{}
+	stopOnce *sync.Once
+}
+
+func newReplicator(dEnv *env.DoltEnv, remoteName, branch string, db *dsqle.Database) (*replicator, error) {
+	remotes, err := dEnv.GetRemotes()
+
+	if err != nil {
+		return nil, err
+	}
+
+	remote, ok := remotes[remoteName]
+	if !ok {
+		return nil, fmt.Errorf("unknown remote '%s'", remoteName)
+	}
+
+	if !doltdb.IsValidUserBranchName(branch) {
+		return nil, fmt.Errorf("'%s' is not a valid branch name", branch)
+	}
+
+	return &replicator{
+		dEnv:     dEnv,
+		remote:   remote,
+		branch:   ref.NewBranchRef(branch),
+		db:       db,
+		syncMu:   &sync.Mutex{},
+		mu:       &sync.Mutex{},
+		stopChan: make(chan struct{}),
+		stopOnce: &sync.Once{},
+	}, nil
+}
+
+// Commit returns the hash of the commit currently being served.
+func (r *replicator) Commit() hash.Hash {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.commit
+}
+
+// Sync fetches the replicated branch from the remote and, if it has changed, begins serving its head commit. Returns
+// the hash of the commit being served.
+func (r *replicator) Sync(ctx context.Context) (_ hash.Hash, err error) {
+	r.syncMu.Lock()
+	defer r.syncMu.Unlock()
+
+	current := r.Commit()
+	srcDB, err := r.remote.GetRemoteDB(ctx, r.dEnv.DoltDB.ValueReadWriter().Format())
+
+	if err != nil {
+		return current, err
+	}
+
+	defer func() {
+		closeErr := srcDB.Close()
+
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	cs, _ := doltdb.NewCommitSpec("HEAD", r.branch.String())
+	cm, err := srcDB.Resolve(ctx, cs)
+
+	if err != nil {
+		return current, fmt.Errorf("unable to find '%s' on '%s': %v", r.branch.GetPath(), r.remote.Name, err)
+	}
+
+	h, err := cm.HashOf()
+
+	if err != nil {
+		return current, err
+	}
+
+	if h == current {
+		return current, nil
+	}
+
+	err = r.dEnv.DoltDB.PullChunks(ctx, srcDB, cm, nil, nil)
+
+	if err != nil {
+		return current, err
+	}
+
+	// the head is set rather than fast forwarded, as the branch may have been force pushed to the remote
+	remoteTrackRef := ref.NewRemoteRef(r.remote.Name, r.branch.GetPath())
+	for _, dref := range []ref.DoltRef{remoteTrackRef, r.branch} {
+		err = r.dEnv.DoltDB.SetHead(ctx, dref, cm)
+
+		if err != nil {
+			return current, err
+		}
+	}
+
+	root, err := cm.GetRootValue()
+
+	if err != nil {
+		return current, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.db.SetRoot(root)
+	r.commit = h
+
+	return r.commit, nil
+}
+
+// Start syncs with the remote every interval until Stop is called. Failures are logged, and the previously
+// replicated commit continues to be served.
+func (r *replicator) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stopChan:
+				return
+			case <-ticker.C:
+				if _, err := r.Sync(context.Background()); err != nil {
+					logrus.Errorf("replication from '%s' failed: %v", r.remote.Name, err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop ends periodic syncing. It is safe to call multiple times.
+func (r *replicator) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopChan)
+	})
+}
+
+// replicaSession is a sql.Session which reports the commit being served by the replica as a system variable.
+type replicaSession struct {
+	sql.Session
+	replica *replicator
+}
+
+// Get implements sql.Session.
+func (s *replicaSession) Get(key string) (sql.Type, interface{}) {
+	if key == ReplicatedCommitVar {
+		return sql.Text, s.replica.Commit().String()
+	}
+
+	return s.Session.Get(key)
+}
+
+// replicaSyncExpression is the expression for the ReplicaSyncFunc, which syncs the replica and returns the hash of the
+// commit being served.
+type replicaSyncExpression struct {
+	replica *replicator
+}
+
+func newReplicaSyncFunction(replica *replicator) sql.Function0 {
+	return sql.Function0{
+		Name: ReplicaSyncFunc,
+		Fn: func() sql.Expression {
+			return &replicaSyncExpression{replica}
+		},
+	}
+}
+
+// Resolved implements sql.Expression.
+func (e *replicaSyncExpression) Resolved() bool {
+	return true
+}
+
+// String implements sql.Expression.
+func (e *replicaSyncExpression) String() string {
+	return ReplicaSyncFunc + "()"
+}
+
+// Type implements sql.Expression.
+func (e *replicaSyncExpression) Type() sql.Type {
+	return sql.Text
+}
+
+// IsNullable implements sql.Expression.
+func (e *replicaSyncExpression) IsNullable() bool {
+	return false
+}
+
+// Eval implements sql.Expression.
+func (e *replicaSyncExpression) Eval(ctx *sql.Context, _ sql.Row) (interface{}, error) {
+	h, err := e.replica.Sync(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return h.String(), nil
+}
+
+// Children implements sql.Expression.
+func (e *replicaSyncExpression) Children() []sql.Expression {
+	return nil
+}
+
+// WithChildren implements sql.Expression.
+func (e *replicaSyncExpression) WithChildren(children ...sql.Expression) (sql.Expression, error) {
+	if len(children) != 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(e, len(children), 0)
+	}
+
+	return e, nil
+}
@@ -15,8 +15,13 @@
 package sqlserver
 
 import (
+	"context"
+	"io"
 	"net"
+	"net/http"
+	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -24,15 +29,20 @@ import (
 	"github.com/src-d/go-mysql-server/auth"
 	"github.com/src-d/go-mysql-server/server"
 	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/analyzer"
 	"vitess.io/vitess/go/mysql"
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
 	dsqle "github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle/privileges"
 )
 
-// serve starts a MySQL-compatible server. Returns any errors that were encountered.
-func serve(serverConfig *ServerConfig, rootValue *doltdb.RootValue, serverController *ServerController) (startError error, closeError error) {
+// serve starts a MySQL-compatible server. If the user store given has any users, they are allowed to connect in addition
+// to the configured user, with access limited to the tables they have been granted privileges on. Returns any errors
+// that were encountered.
+func serve(serverConfig *ServerConfig, dEnv *env.DoltEnv, rootValue *doltdb.RootValue, userStore *privileges.UserStore, serverController *ServerController) (startError error, closeError error) {
 	if serverConfig == nil {
 		cli.Println("No configuration given, using defaults")
 		serverConfig = DefaultServerConfig()
@@ -43,11 +53,37 @@ func serve(serverConfig *ServerConfig, rootValue *doltdb.RootValue, serverContro
 	}
 
 	var mySQLServer *server.Server
+	var metricsServer *http.Server
+	var queryLog *queryLogger
+	var replica *replicator
+	// Stops replication and the metrics server, and closes the query log in addition to the MySQL server, returning the first error
+	closeAll := func() error {
+		var errs []error
+		if replica != nil {
+			replica.Stop()
+		}
+		if mySQLServer != nil {
+			errs = append(errs, mySQLServer.Close())
+		}
+		if metricsServer != nil {
+			errs = append(errs, metricsServer.Close())
+		}
+		if queryLog != nil {
+			errs = append(errs, queryLog.Close())
+		}
+		for _, err := range errs {
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 	// This guarantees unblocking on any routines with a waiting `ServerController`
 	defer func() {
 		if mySQLServer != nil {
-			serverController.registerCloseFunction(startError, mySQLServer.Close)
+			serverController.registerCloseFunction(startError, closeAll)
 		} else {
+			_ = closeAll()
 			serverController.registerCloseFunction(startError, func() error { return nil })
 		}
 		serverController.StopServer()
@@ -68,14 +104,84 @@ func serve(serverConfig *ServerConfig, rootValue *doltdb.RootValue, serverContro
 		logrus.SetLevel(level)
 	}
 
-	permissions := auth.AllPermissions
-	if serverConfig.ReadOnly {
-		permissions = auth.ReadPerm
+	tlsConfig, startError := serverConfig.TLSConfig()
+	if startError != nil {
+		cli.PrintErr(startError)
+		return
+	}
+
+	if len(serverConfig.QueryLog) > 0 {
+		var wr io.WriteCloser
+		// the log holds the text of every query, including any literal values, so only the owner may read it
+		wr, startError = os.OpenFile(serverConfig.QueryLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if startError != nil {
+			cli.PrintErr(startError)
+			return
+		}
+		queryLog = newQueryLogger(wr)
+	}
+
+	serverMetrics := newServerMetrics(dEnv.DoltDB.Stats)
+	rowCounter := dsqle.NewRowsReadCounter()
+	auditor := &serverAuditor{
+		auditLog:   auth.NewAuditLog(logrus.StandardLogger()),
+		queryLog:   queryLog,
+		metrics:    serverMetrics,
+		rowCounter: rowCounter,
+		database:   "dolt",
+		mu:         &sync.Mutex{},
+		active:     make(map[uint32]*loggedQuery),
+	}
+
+	// Replicas serve the data of the remote, so they never accept writes
+	readOnly := serverConfig.ReadOnly || serverConfig.IsReplica()
+
+	// The authorizer reloads the users before each query, so privileges changed by dolt sql apply to running servers
+	var userAuth auth.Auth
+	var db *dsqle.Database
+	if userStore != nil && !userStore.IsEmpty() {
+		authorizer := privileges.NewAuthorizer(userStore, "dolt", serverConfig.User, serverConfig.Password, readOnly)
+		userAuth = auth.NewAudit(authorizer, auditor)
+		db = dsqle.NewDatabaseWithAuthorizer("dolt", rootValue, authorizer)
+	} else {
+		permissions := auth.AllPermissions
+		if readOnly {
+			permissions = auth.ReadPerm
+		}
+
+		userAuth = auth.NewAudit(auth.NewNativeSingle(serverConfig.User, serverConfig.Password, permissions), auditor)
+		db = dsqle.NewDatabase("dolt", rootValue)
 	}
+	db.SetRowsReadCounter(rowCounter)
 
-	userAuth := auth.NewAudit(auth.NewNativeSingle(serverConfig.User, serverConfig.Password, permissions), auth.NewAuditLog(logrus.StandardLogger()))
-	sqlEngine := sqle.NewDefault()
-	sqlEngine.AddDatabase(dsqle.NewDatabase("dolt", rootValue))
+	catalog := sql.NewCatalog()
+	// The root of the database is replaced as a replica syncs, so each query reads all of its tables from one root
+	sqlAnalyzer := analyzer.NewBuilder(catalog).
+		AddPostAnalyzeRule("pin_root", dsqle.PinRootRule(db)).
+		AddPostAnalyzeRule("explain", dsqle.ExplainRule).
+		AddPostAnalyzeRule("query_log", queryLogRule(auditor)).
+		Build()
+	sqlEngine := sqle.New(catalog, sqlAnalyzer, &sqle.Config{Auth: userAuth})
+	sqlEngine.AddDatabase(db)
+
+	if serverConfig.IsReplica() {
+		replica, startError = newReplicator(dEnv, serverConfig.ReplicaRemote, serverConfig.ReplicaBranch, db)
+		if startError != nil {
+			cli.PrintErr(startError)
+			return
+		}
+
+		// The replica must be serving the remote's data before any client can connect
+		if _, startError = replica.Sync(context.Background()); startError != nil {
+			cli.PrintErr(startError)
+			return
+		}
+
+		catalog.MustRegister(newReplicaSyncFunction(replica))
+		if serverConfig.ReplicaInterval > 0 {
+			replica.Start(time.Second * time.Duration(serverConfig.ReplicaInterval))
+		}
+	}
 
 	hostPort := net.JoinHostPort(serverConfig.Host, strconv.Itoa(serverConfig.Port))
 	timeout := time.Second * time.Duration(serverConfig.Timeout)
@@ -89,14 +195,43 @@ func serve(serverConfig *ServerConfig, rootValue *doltdb.RootValue, serverContro
 		},
 		sqlEngine,
 		func(conn *mysql.Conn, host string) sql.Session {
-			return sql.NewSession(host, conn.RemoteAddr().String(), conn.User, conn.ConnectionID)
+			sess := sql.NewSession(host, conn.RemoteAddr().String(), conn.User, conn.ConnectionID)
+			if replica != nil {
+				return &replicaSession{sess, replica}
+			}
+			return sess
 		},
 	)
 	if startError != nil {
 		cli.PrintErr(startError)
 		return
 	}
-	serverController.registerCloseFunction(startError, mySQLServer.Close)
+	mySQLServer.Listener.TLSConfig = tlsConfig
+	mySQLServer.Listener.RequireSecureTransport = serverConfig.RequireSecureTransport
+
+	if serverConfig.MetricsPort != 0 {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", serverMetrics)
+		metricsServer = &http.Server{
+			Addr:    net.JoinHostPort(serverConfig.Host, strconv.Itoa(serverConfig.MetricsPort)),
+			Handler: mux,
+		}
+
+		var metricsListener net.Listener
+		metricsListener, startError = net.Listen("tcp", metricsServer.Addr)
+		if startError != nil {
+			cli.PrintErr(startError)
+			return
+		}
+
+		go func() {
+			if err := metricsServer.Serve(metricsListener); err != nil && err != http.ErrServerClosed {
+				logrus.Errorf("metrics server stopped: %v", err)
+			}
+		}()
+	}
+
+	serverController.registerCloseFunction(startError, closeAll)
 	closeError = mySQLServer.Start()
 	if closeError != nil {
 		cli.PrintErr(closeError)
@@ -15,8 +15,20 @@
 package sqlserver
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/gocraft/dbr"
@@ -25,10 +37,15 @@ import (
 	"golang.org/x/net/context"
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle/privileges"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/typed/noms"
+	"github.com/liquidata-inc/dolt/go/store/types"
 )
 
 type testPerson struct {
@@ -79,6 +96,8 @@ func TestServerBadArgs(t *testing.T) {
 		{"-u", ""},
 		{"-t", "-1"},
 		{"-l", "everything"},
+		{"--tls-key", "key.pem"},
+		{"--require-secure-transport"},
 	}
 
 	for _, test := range tests {
@@ -121,7 +140,7 @@ func TestServerGoodParams(t *testing.T) {
 		t.Run(test.String(), func(t *testing.T) {
 			sc := CreateServerController()
 			go func(config *ServerConfig, sc *ServerController) {
-				serve(config, root, sc)
+				serve(config, env, root, nil, sc)
 			}(test, sc)
 			err := sc.WaitForStart()
 			require.NoError(t, err)
@@ -145,7 +164,7 @@ func TestServerSelect(t *testing.T) {
 	sc := CreateServerController()
 	defer sc.StopServer()
 	go func() {
-		serve(serverConfig, root, sc)
+		serve(serverConfig, env, root, nil, sc)
 	}()
 	err := sc.WaitForStart()
 	require.NoError(t, err)
@@ -188,6 +207,321 @@ func TestServerSelect(t *testing.T) {
 	}
 }
 
+func TestServerUserPrivileges(t *testing.T) {
+	env := createEnvWithSeedData(t)
+	root, verr := commands.GetWorkingWithVErr(env)
+	require.NoError(t, verr)
+
+	us := privileges.NewUserStore(env.FS)
+	require.NoError(t, us.CreateUser("reader", "readpass"))
+	require.NoError(t, us.Grant("reader", "dolt", "people", privileges.Select))
+	require.NoError(t, us.CreateUser("other", "otherpass"))
+	require.NoError(t, us.Grant("other", "dolt", "other_table", privileges.AllPrivileges))
+
+	serverConfig := DefaultServerConfig().WithLogLevel(LogLevel_Fatal).WithPort(15500)
+	sc := CreateServerController()
+	defer sc.StopServer()
+	go func() {
+		serve(serverConfig, env, root, us, sc)
+	}()
+	err := sc.WaitForStart()
+	require.NoError(t, err)
+
+	tests := []struct {
+		user        string
+		password    string
+		expectedErr bool
+	}{
+		{serverConfig.User, serverConfig.Password, false},
+		{"reader", "readpass", false},
+		{"other", "otherpass", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.user, func(t *testing.T) {
+			conn, err := dbr.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/dolt", test.user, test.password, serverConfig.Host, serverConfig.Port), nil)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			var peoples []testPerson
+			_, err = conn.NewSession(nil).Select("*").From("people").LoadContext(context.Background(), &peoples)
+			if test.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, peoples, []testPerson{bill, john, rob})
+			}
+		})
+	}
+
+	t.Run("bad password", func(t *testing.T) {
+		conn, err := dbr.Open("mysql", fmt.Sprintf("reader:wrong@tcp(%s:%d)/dolt", serverConfig.Host, serverConfig.Port), nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		assert.Error(t, conn.Ping())
+	})
+}
+
+func TestServerQueryLog(t *testing.T) {
+	env := createEnvWithSeedData(t)
+	root, verr := commands.GetWorkingWithVErr(env)
+	require.NoError(t, verr)
+
+	logFile, err := ioutil.TempFile("", "dolt_query_log")
+	require.NoError(t, err)
+	require.NoError(t, logFile.Close())
+	defer os.Remove(logFile.Name())
+
+	serverConfig := DefaultServerConfig().WithLogLevel(LogLevel_Fatal).WithQueryLog(logFile.Name()).WithPort(15650)
+	sc := CreateServerController()
+	defer sc.StopServer()
+	go func() {
+		serve(serverConfig, env, root, nil, sc)
+	}()
+	require.NoError(t, sc.WaitForStart())
+
+	conn, err := dbr.Open("mysql", serverConfig.ConnectionString(), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	queries := []struct {
+		query       string
+		rows        uint64
+		expectedErr bool
+	}{
+		{"select * from people", 3, false},
+		{"select 1", 0, false},
+		{"select * from nope", 0, true},
+	}
+
+	for _, test := range queries {
+		rows, err := conn.QueryContext(context.Background(), test.query)
+		if test.expectedErr {
+			require.Error(t, err)
+			continue
+		}
+
+		require.NoError(t, err)
+		for rows.Next() {
+		}
+		require.NoError(t, rows.Err())
+		require.NoError(t, rows.Close())
+	}
+
+	// queries are logged once their results have been read, which may be after the client has received them
+	var lines []string
+	for i := 0; i < 50 && len(lines) < len(queries); i++ {
+		time.Sleep(20 * time.Millisecond)
+		data, err := ioutil.ReadFile(logFile.Name())
+		require.NoError(t, err)
+		lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+
+	require.Len(t, lines, len(queries))
+	for i, line := range lines {
+		var entry queryLogEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		assert.Equal(t, queries[i].query, entry.Query)
+		assert.Equal(t, queries[i].rows, entry.Rows)
+		assert.Equal(t, queries[i].expectedErr, entry.Error != "")
+	}
+}
+
+func TestServerTLS(t *testing.T) {
+	env := createEnvWithSeedData(t)
+	root, verr := commands.GetWorkingWithVErr(env)
+	require.NoError(t, verr)
+
+	keyFile, certFile := writeSelfSignedCert(t)
+	defer os.Remove(keyFile)
+	defer os.Remove(certFile)
+
+	tests := []struct {
+		config         *ServerConfig
+		plainTextFails bool
+	}{
+		{DefaultServerConfig().WithTLS(keyFile, certFile).WithPort(15600), false},
+		{DefaultServerConfig().WithTLS(keyFile, certFile).WithRequireSecureTransport(true).WithPort(15601), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.config.String(), func(t *testing.T) {
+			sc := CreateServerController()
+			go func(config *ServerConfig, sc *ServerController) {
+				serve(config, env, root, nil, sc)
+			}(test.config, sc)
+			err := sc.WaitForStart()
+			require.NoError(t, err)
+			defer func() {
+				sc.StopServer()
+				assert.NoError(t, sc.WaitForClose())
+			}()
+
+			tlsConn, err := dbr.Open("mysql", test.config.ConnectionString()+"?tls=skip-verify", nil)
+			require.NoError(t, err)
+			defer tlsConn.Close()
+
+			var peoples []testPerson
+			_, err = tlsConn.NewSession(nil).Select("*").From("people").LoadContext(context.Background(), &peoples)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, peoples, []testPerson{bill, john, rob})
+
+			plainConn, err := dbr.Open("mysql", test.config.ConnectionString()+"?tls=false", nil)
+			require.NoError(t, err)
+			defer plainConn.Close()
+
+			if test.plainTextFails {
+				assert.Error(t, plainConn.Ping())
+			} else {
+				assert.NoError(t, plainConn.Ping())
+			}
+		})
+	}
+}
+
+func TestServerBadTLSConfig(t *testing.T) {
+	keyFile, certFile := writeSelfSignedCert(t)
+	defer os.Remove(keyFile)
+	defer os.Remove(certFile)
+
+	tests := []*ServerConfig{
+		DefaultServerConfig().WithTLS(keyFile, ""),
+		DefaultServerConfig().WithTLS("", certFile),
+		DefaultServerConfig().WithRequireSecureTransport(true),
+	}
+
+	for _, test := range tests {
+		assert.Error(t, test.Validate(), test.String())
+	}
+
+	_, err := DefaultServerConfig().WithTLS(certFile, keyFile).TLSConfig()
+	assert.Error(t, err)
+}
+
+func TestServerReplica(t *testing.T) {
+	ctx := context.Background()
+	masterRef := ref.NewBranchRef("master")
+	masterSpec, _ := doltdb.NewCommitSpec("HEAD", masterRef.String())
+
+	primary := createEnvWithSeedData(t)
+	require.NoError(t, actions.StageAllTables(ctx, primary, false))
+	require.NoError(t, actions.CommitStaged(ctx, primary, "add people", false))
+
+	remoteDir, err := ioutil.TempDir("", "dolt_replica_remote")
+	require.NoError(t, err)
+	defer os.RemoveAll(remoteDir)
+	remoteUrl := "file://" + remoteDir
+	remoteDB, err := doltdb.LoadDoltDB(ctx, types.Format_7_18, remoteUrl)
+	require.NoError(t, err)
+
+	pushMaster := func() *doltdb.Commit {
+		cm, err := primary.DoltDB.Resolve(ctx, masterSpec)
+		require.NoError(t, err)
+		require.NoError(t, actions.Push(ctx, masterRef, ref.NewRemoteRef("origin", "master"), primary.DoltDB, remoteDB, cm, 0, nil, nil))
+		return cm
+	}
+	firstCommit := pushMaster()
+	firstHash, err := firstCommit.HashOf()
+	require.NoError(t, err)
+
+	// the replica starts as a clone of the remote
+	replicaEnv := dtestutils.CreateTestEnv()
+	replicaEnv.RepoState.AddRemote(env.NewRemote("origin", remoteUrl, nil))
+	require.NoError(t, replicaEnv.DoltDB.PullChunks(ctx, remoteDB, firstCommit, nil, nil))
+	require.NoError(t, replicaEnv.DoltDB.NewBranchAtCommit(ctx, masterRef, firstCommit))
+	root, verr := commands.GetWorkingWithVErr(replicaEnv)
+	require.NoError(t, verr)
+
+	serverConfig := DefaultServerConfig().WithLogLevel(LogLevel_Fatal).WithPort(15700).WithReplica("origin", "master", 0)
+	sc := CreateServerController()
+	defer sc.StopServer()
+	go func() {
+		serve(serverConfig, replicaEnv, root, nil, sc)
+	}()
+	require.NoError(t, sc.WaitForStart())
+
+	conn, err := dbr.Open("mysql", serverConfig.ConnectionString(), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	sess := conn.NewSession(nil)
+
+	var peoples []testPerson
+	_, err = sess.Select("*").From("people").LoadContext(ctx, &peoples)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, peoples, []testPerson{bill, john, rob})
+
+	var replicated string
+	require.NoError(t, sess.SelectBySql("SELECT @@"+ReplicatedCommitVar).LoadOneContext(ctx, &replicated))
+	assert.Equal(t, firstHash.String(), replicated)
+
+	// drop the table on the primary and push
+	working, err := primary.WorkingRoot(ctx)
+	require.NoError(t, err)
+	working, err = working.RemoveTables(ctx, "people")
+	require.NoError(t, err)
+	require.NoError(t, primary.UpdateWorkingRoot(ctx, working))
+	require.NoError(t, actions.StageAllTables(ctx, primary, false))
+	require.NoError(t, actions.CommitStaged(ctx, primary, "remove people", false))
+	secondHash, err := pushMaster().HashOf()
+	require.NoError(t, err)
+
+	// the replica keeps serving the first commit until it syncs
+	_, err = sess.Select("*").From("people").LoadContext(ctx, &peoples)
+	require.NoError(t, err)
+
+	var synced string
+	require.NoError(t, sess.SelectBySql("SELECT "+ReplicaSyncFunc+"()").LoadOneContext(ctx, &synced))
+	assert.Equal(t, secondHash.String(), synced)
+	require.NoError(t, sess.SelectBySql("SELECT @@"+ReplicatedCommitVar).LoadOneContext(ctx, &replicated))
+	assert.Equal(t, secondHash.String(), replicated)
+
+	_, err = sess.Select("*").From("people").LoadContext(ctx, &peoples)
+	assert.Error(t, err)
+
+	// the replica follows the remote's branch when it is force pushed back to the first commit
+	require.NoError(t, remoteDB.SetHead(ctx, masterRef, firstCommit))
+	require.NoError(t, sess.SelectBySql("SELECT "+ReplicaSyncFunc+"()").LoadOneContext(ctx, &synced))
+	assert.Equal(t, firstHash.String(), synced)
+
+	_, err = sess.Select("*").From("people").LoadContext(ctx, &peoples)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, peoples, []testPerson{bill, john, rob})
+}
+
+// writeSelfSignedCert writes a PEM encoded key and self signed certificate for localhost to temp files, returning the
+// paths of the key and the certificate.
+func writeSelfSignedCert(t *testing.T) (string, string) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{Organization: []string{"Dolt Test"}},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	require.NoError(t, err)
+	keyBytes, err := x509.MarshalECPrivateKey(priv)
+	require.NoError(t, err)
+
+	certFile, err := ioutil.TempFile("", "dolt_test_cert")
+	require.NoError(t, err)
+	defer certFile.Close()
+	require.NoError(t, pem.Encode(certFile, &pem.Block{Type: "CERTIFICATE", Bytes: certBytes}))
+
+	keyFile, err := ioutil.TempFile("", "dolt_test_key")
+	require.NoError(t, err)
+	defer keyFile.Close()
+	require.NoError(t, pem.Encode(keyFile, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}))
+
+	return keyFile.Name(), certFile.Name()
+}
+
 func createEnvWithSeedData(t *testing.T) *env.DoltEnv {
 	dEnv := dtestutils.CreateTestEnv()
 	imt, sch := dtestutils.CreateTestDataTable(true)
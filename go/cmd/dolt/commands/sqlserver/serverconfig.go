@@ -15,6 +15,7 @@
 package sqlserver
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net"
 )
@@ -39,18 +40,28 @@ type ServerConfig struct {
 	Timeout  int      // The read and write timeouts.
 	ReadOnly bool     // Whether the server will only accept read statements or all statements.
 	LogLevel LogLevel // Specifies the level of logging that the server will use.
+	TLSKey   string   // The path to the PEM encoded private key used for SSL connections. Requires TLSCert.
+	TLSCert  string   // The path to the PEM encoded certificate chain used for SSL connections. Requires TLSKey.
+	// Whether clients must connect using SSL. Requires TLSKey and TLSCert.
+	RequireSecureTransport bool
+	QueryLog               string // The path of a file to which a JSON record of every query is appended. Empty disables the log.
+	MetricsPort            int    // The port serving HTTP /metrics. The valid range is [1024, 65535], or 0 to disable.
+	ReplicaRemote          string // The remote a read replica pulls from. Empty if the server is not a replica.
+	ReplicaBranch          string // The branch of the remote which a read replica serves.
+	ReplicaInterval        int    // The seconds between a read replica's pulls. 0 pulls only on demand.
 }
 
 // DefaultServerConfig creates a `*ServerConfig` that has all of the options set to their default values.
 func DefaultServerConfig() *ServerConfig {
 	return &ServerConfig{
-		Host:     "localhost",
-		Port:     3306,
-		User:     "root",
-		Password: "",
-		Timeout:  30,
-		ReadOnly: false,
-		LogLevel: LogLevel_Info,
+		Host:          "localhost",
+		Port:          3306,
+		User:          "root",
+		Password:      "",
+		Timeout:       30,
+		ReadOnly:      false,
+		LogLevel:      LogLevel_Info,
+		ReplicaBranch: "master",
 	}
 }
 
@@ -74,9 +85,41 @@ func (config *ServerConfig) Validate() error {
 	if config.LogLevel.String() == "unknown" {
 		return fmt.Errorf("loglevel is invalid: %v\n", string(config.LogLevel))
 	}
+	if (len(config.TLSKey) == 0) != (len(config.TLSCert) == 0) {
+		return fmt.Errorf("tls_key and tls_cert must both be provided to enable SSL")
+	}
+	if config.MetricsPort != 0 && (config.MetricsPort < 1024 || config.MetricsPort > 65535) {
+		return fmt.Errorf("metrics port is not in the range between 1024-65535: %v\n", config.MetricsPort)
+	}
+	if config.MetricsPort == config.Port {
+		return fmt.Errorf("metrics port must differ from the server port: %v\n", config.MetricsPort)
+	}
+	if config.IsReplica() && len(config.ReplicaBranch) == 0 {
+		return fmt.Errorf("replica branch cannot be empty")
+	}
+	if config.ReplicaInterval < 0 {
+		return fmt.Errorf("replica interval cannot be less than 0: %v\n", config.ReplicaInterval)
+	}
+	if config.RequireSecureTransport && len(config.TLSKey) == 0 {
+		return fmt.Errorf("require_secure_transport requires tls_key and tls_cert to be provided")
+	}
 	return nil
 }
 
+// TLSConfig returns the `*tls.Config` used for SSL connections, or nil if SSL has not been configured.
+func (config *ServerConfig) TLSConfig() (*tls.Config, error) {
+	if len(config.TLSKey) == 0 && len(config.TLSCert) == 0 {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(config.TLSCert, config.TLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the tls_key and tls_cert: %v", err)
+	}
+
+	return &tls.Config{Certificates: []tls.Certificate{cert}}, nil
+}
+
 // WithHost updates the host and returns the called `*ServerConfig`, which is useful for chaining calls.
 func (config *ServerConfig) WithHost(host string) *ServerConfig {
 	config.Host = host
@@ -119,6 +162,47 @@ func (config *ServerConfig) WithLogLevel(loglevel LogLevel) *ServerConfig {
 	return config
 }
 
+// WithTLS updates the paths of the private key and certificate used for SSL and returns the called `*ServerConfig`,
+// which is useful for chaining calls.
+func (config *ServerConfig) WithTLS(tlsKey string, tlsCert string) *ServerConfig {
+	config.TLSKey = tlsKey
+	config.TLSCert = tlsCert
+	return config
+}
+
+// WithRequireSecureTransport updates the secure transport flag and returns the called `*ServerConfig`, which is useful
+// for chaining calls.
+func (config *ServerConfig) WithRequireSecureTransport(requireSecureTransport bool) *ServerConfig {
+	config.RequireSecureTransport = requireSecureTransport
+	return config
+}
+
+// WithQueryLog updates the query log path and returns the called `*ServerConfig`, which is useful for chaining calls.
+func (config *ServerConfig) WithQueryLog(queryLog string) *ServerConfig {
+	config.QueryLog = queryLog
+	return config
+}
+
+// WithMetricsPort updates the metrics port and returns the called `*ServerConfig`, which is useful for chaining calls.
+func (config *ServerConfig) WithMetricsPort(port int) *ServerConfig {
+	config.MetricsPort = port
+	return config
+}
+
+// WithReplica makes the server a read replica of the branch of the remote given, pulling every interval seconds, and
+// returns the called `*ServerConfig`, which is useful for chaining calls.
+func (config *ServerConfig) WithReplica(remote string, branch string, interval int) *ServerConfig {
+	config.ReplicaRemote = remote
+	config.ReplicaBranch = branch
+	config.ReplicaInterval = interval
+	return config
+}
+
+// IsReplica returns whether the server is a read replica of a remote.
+func (config *ServerConfig) IsReplica() bool {
+	return len(config.ReplicaRemote) > 0
+}
+
 // ConnectionString returns a Data Source Name (DSN) to be used by go clients for connecting to a running server.
 func (config *ServerConfig) ConnectionString() string {
 	return fmt.Sprintf("%v:%v@tcp(%v:%v)/dolt", config.User, config.Password, config.Host, config.Port)
@@ -126,8 +210,10 @@ func (config *ServerConfig) ConnectionString() string {
 
 // String implements `fmt.Stringer`.
 func (config *ServerConfig) String() string {
-	return fmt.Sprintf(`HP="%v:%v"|U="%v"|P="%v"|T="%v"|R="%v"|L="%v"`, config.Host, config.Port, config.User,
-		config.Password, config.Timeout, config.ReadOnly, config.LogLevel)
+	return fmt.Sprintf(`HP="%v:%v"|U="%v"|P="%v"|T="%v"|R="%v"|L="%v"|K="%v"|C="%v"|S="%v"|Q="%v"|M="%v"|RR="%v/%v@%v"`,
+		config.Host, config.Port, config.User, config.Password, config.Timeout, config.ReadOnly, config.LogLevel,
+		config.TLSKey, config.TLSCert, config.RequireSecureTransport, config.QueryLog, config.MetricsPort,
+		config.ReplicaRemote, config.ReplicaBranch, config.ReplicaInterval)
 }
 
 // String returns the string representation of the log level.
@@ -19,18 +19,28 @@ import (
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sqle/privileges"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
 )
 
 const (
-	hostFlag     = "host"
-	portFlag     = "port"
-	userFlag     = "user"
-	passwordFlag = "password"
-	timeoutFlag  = "timeout"
-	readonlyFlag = "readonly"
-	logLevelFlag = "loglevel"
+	hostFlag                   = "host"
+	portFlag                   = "port"
+	userFlag                   = "user"
+	passwordFlag               = "password"
+	timeoutFlag                = "timeout"
+	readonlyFlag               = "readonly"
+	logLevelFlag               = "loglevel"
+	tlsKeyFlag                 = "tls-key"
+	tlsCertFlag                = "tls-cert"
+	requireSecureTransportFlag = "require-secure-transport"
+	queryLogFlag               = "query-log"
+	metricsPortFlag            = "metrics-port"
+	replicaRemoteFlag          = "replica-remote"
+	replicaBranchFlag          = "replica-branch"
+	replicaIntervalFlag        = "replica-interval"
 )
 
 var sqlServerShortDesc = "Start a MySQL-compatible server."
@@ -38,9 +48,28 @@ var sqlServerLongDesc = `Start a MySQL-compatible server which can be connected
 
 Currently, only SELECT statements are operational, as support for other statements is
 still being developed.
+
+In addition to the user given on the command line, which has every privilege, accounts created
+with CREATE USER and GRANT statements run through 'dolt sql' may connect. Those users may only
+access the tables they have been granted privileges on. These statements cannot be run by clients
+of the server. Privileges granted or revoked, and users dropped, while the server is running apply
+to the next query. Users created and passwords changed while the server is running cannot log in
+with them until the server is restarted, and if no users existed when the server started only the
+user given on the command line may connect until it is restarted.
+
+When a private key and certificate are provided, clients may connect using SSL. Plaintext
+connections may be rejected entirely with --require-secure-transport.
+
+For operating the server, --query-log records every query as a line of JSON, and --metrics-port
+serves connection, query latency and storage statistics in the Prometheus text format.
+
+With --replica-remote the server is a read replica. It fetches the branch of the remote and
+serves the data of its head commit, pulling again every --replica-interval seconds or whenever
+SELECT dolt_replica_sync() is run. The commit being served is available as @@dolt_replicated_commit.
 `
 var sqlServerSynopsis = []string{
-	"[-H <host>] [-P <port>] [-u <user>] [-p <password>] [-t <timeout>] [-l <loglevel>] [-r]",
+	"[-H <host>] [-P <port>] [-u <user>] [-p <password>] [-t <timeout>] [-l <loglevel>] [-r] [--tls-key <key_file> --tls-cert <cert_file> [--require-secure-transport]] [--query-log <file>] [--metrics-port <port>]",
+	"--replica-remote <remote> [--replica-branch <branch>] [--replica-interval <seconds>] [<options>]",
 }
 
 func SqlServer(commandStr string, args []string, dEnv *env.DoltEnv) int {
@@ -58,6 +87,14 @@ func sqlServerImpl(commandStr string, args []string, dEnv *env.DoltEnv, serverCo
 	ap.SupportsInt(timeoutFlag, "t", "Connection timeout", fmt.Sprintf("Defines the timeout, in seconds, used for connections\nA value of `0` represents an infinite timeout (default `%v`)", serverConfig.Timeout))
 	ap.SupportsFlag(readonlyFlag, "r", "Disables modification of the database")
 	ap.SupportsString(logLevelFlag, "l", "Log level", fmt.Sprintf("Defines the level of logging provided\nOptions are: `debug`, `info`, `warning`, `error`, `fatal` (default `%v`)", serverConfig.LogLevel))
+	ap.SupportsString(tlsKeyFlag, "", "Key file", "Defines the PEM encoded private key used for SSL connections")
+	ap.SupportsString(tlsCertFlag, "", "Certificate file", "Defines the PEM encoded certificate chain used for SSL connections")
+	ap.SupportsFlag(requireSecureTransportFlag, "", "Rejects connections from clients that do not use SSL")
+	ap.SupportsString(queryLogFlag, "", "Query log file", "Appends a JSON record of every query, including the client, duration, rows read and any error, to the file given")
+	ap.SupportsUint(metricsPortFlag, "", "Metrics port", "Serves Prometheus metrics for connections, query latency and storage over HTTP at /metrics on the port given")
+	ap.SupportsString(replicaRemoteFlag, "", "Remote", "Runs the server as a read replica which serves the data of a branch of the remote given")
+	ap.SupportsString(replicaBranchFlag, "", "Branch", fmt.Sprintf("Defines the branch of the remote served by a read replica (default `%v`)", serverConfig.ReplicaBranch))
+	ap.SupportsUint(replicaIntervalFlag, "", "Seconds", "Defines how often a read replica pulls from its remote\nA value of `0` only pulls when SELECT dolt_replica_sync() is run (default `0`)")
 	help, usage := cli.HelpAndUsagePrinters(commandStr, sqlServerShortDesc, sqlServerLongDesc, sqlServerSynopsis, ap)
 
 	apr := cli.ParseArgs(ap, args, help)
@@ -89,7 +126,37 @@ func sqlServerImpl(commandStr string, args []string, dEnv *env.DoltEnv, serverCo
 	if logLevel, ok := apr.GetValue(logLevelFlag); ok {
 		serverConfig.LogLevel = LogLevel(logLevel)
 	}
-	if startError, closeError := serve(serverConfig, root, serverController); startError != nil || closeError != nil {
+	if tlsKey, ok := apr.GetValue(tlsKeyFlag); ok {
+		serverConfig.TLSKey = tlsKey
+	}
+	if tlsCert, ok := apr.GetValue(tlsCertFlag); ok {
+		serverConfig.TLSCert = tlsCert
+	}
+	if apr.Contains(requireSecureTransportFlag) {
+		serverConfig.RequireSecureTransport = true
+	}
+	if queryLog, ok := apr.GetValue(queryLogFlag); ok {
+		serverConfig.QueryLog = queryLog
+	}
+	if metricsPort, ok := apr.GetInt(metricsPortFlag); ok {
+		serverConfig.MetricsPort = metricsPort
+	}
+	if replicaRemote, ok := apr.GetValue(replicaRemoteFlag); ok {
+		serverConfig.ReplicaRemote = replicaRemote
+	}
+	if replicaBranch, ok := apr.GetValue(replicaBranchFlag); ok {
+		serverConfig.ReplicaBranch = replicaBranch
+	}
+	if replicaInterval, ok := apr.GetInt(replicaIntervalFlag); ok {
+		serverConfig.ReplicaInterval = replicaInterval
+	}
+	userStore, err := privileges.LoadUserStore(dEnv.FS)
+	if err != nil {
+		verr := errhand.BuildDError("error: failed to load sql users").AddCause(err).Build()
+		return commands.HandleVErrAndExitCode(verr, usage)
+	}
+
+	if startError, closeError := serve(serverConfig, dEnv, root, userStore, serverController); startError != nil || closeError != nil {
 		if startError != nil {
 			cli.PrintErrln(startError)
 		}
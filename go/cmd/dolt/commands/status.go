@@ -23,6 +23,8 @@ import (
 	"github.com/fatih/color"
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/argparser"
@@ -46,19 +48,82 @@ func Status(commandStr string, args []string, dEnv *env.DoltEnv) int {
 	stagedDiffs, notStagedDiffs, err := actions.GetTableDiffs(context.Background(), dEnv)
 
 	if err != nil {
-		panic(err) // fix
+		verr := errhand.BuildDError("error: failed to get the table diffs").AddCause(err).Build()
+		return HandleVErrAndExitCode(verr, nil)
 	}
 
 	workingInConflict, _, _, err := actions.GetTablesInConflict(context.Background(), dEnv)
 
 	if err != nil {
-		panic(err) // fix
+		verr := errhand.BuildDError("error: failed to get the tables in conflict").AddCause(err).Build()
+		return HandleVErrAndExitCode(verr, nil)
 	}
 
-	printStatus(dEnv, stagedDiffs, notStagedDiffs, workingInConflict)
+	stagedSummaries, notStagedSummaries, err := getStatusDiffSummaries(context.Background(), dEnv, stagedDiffs, notStagedDiffs)
+
+	if err != nil {
+		verr := errhand.BuildDError("error: failed to summarize the table diffs").AddCause(err).Build()
+		return HandleVErrAndExitCode(verr, nil)
+	}
+
+	upstream, verr := getUpstreamInfo(context.Background(), dEnv, dEnv.RepoState.Head.Ref)
+
+	if verr != nil {
+		return HandleVErrAndExitCode(verr, nil)
+	}
+
+	printStatus(dEnv, upstream, stagedDiffs, notStagedDiffs, workingInConflict, stagedSummaries, notStagedSummaries)
 	return 0
 }
 
+// getStatusDiffSummaries summarizes the row changes to the staged tables relative to HEAD, and to the working tables
+// relative to the staged tables.
+func getStatusDiffSummaries(ctx context.Context, dEnv *env.DoltEnv, staged, notStaged *actions.TableDiffs) (map[string]diff.DiffSummary, map[string]diff.DiffSummary, error) {
+	headRoot, err := dEnv.HeadRoot(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stagedRoot, err := dEnv.StagedRoot(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	workingRoot, err := dEnv.WorkingRoot(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stagedSummaries, err := actions.GetTableDiffSummaries(ctx, stagedRoot, headRoot, staged.Tables)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	notStagedSummaries, err := actions.GetTableDiffSummaries(ctx, workingRoot, stagedRoot, notStaged.Tables)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return stagedSummaries, notStagedSummaries, nil
+}
+
+// compactDiffSummary returns the row counts shown after a table name, e.g. " (+3 *2 -1)", or an empty string if there
+// is no summary for the table or none of its rows changed.
+func compactDiffSummary(tblToSummary map[string]diff.DiffSummary, tblName string) string {
+	summary, ok := tblToSummary[tblName]
+
+	if !ok || summary.RowsChanged() == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" (+%d *%d -%d)", summary.RowsAdded, summary.RowsModified, summary.RowsDeleted)
+}
+
 var tblDiffTypeToLabel = map[actions.TableDiffType]string{
 	actions.ModifiedTable: "modified:",
 	actions.RemovedTable:  "deleted:",
@@ -99,7 +164,7 @@ const (
 	bothModifiedLabel = "both modified:"
 )
 
-func printStagedDiffs(wr io.Writer, staged *actions.TableDiffs, printHelp bool) int {
+func printStagedDiffs(wr io.Writer, staged *actions.TableDiffs, printHelp bool, tblToSummary map[string]diff.DiffSummary) int {
 	if staged.Len() > 0 {
 		iohelp.WriteLine(wr, stagedHeader)
 
@@ -110,7 +175,7 @@ func printStagedDiffs(wr io.Writer, staged *actions.TableDiffs, printHelp bool)
 		lines := make([]string, 0, staged.Len())
 		for _, tblName := range staged.Tables {
 			tdt := staged.TableToType[tblName]
-			lines = append(lines, fmt.Sprintf(statusFmt, tblDiffTypeToLabel[tdt], tblName))
+			lines = append(lines, fmt.Sprintf(statusFmt, tblDiffTypeToLabel[tdt], tblName)+compactDiffSummary(tblToSummary, tblName))
 		}
 
 		iohelp.WriteLine(wr, color.GreenString(strings.Join(lines, "\n")))
@@ -120,7 +185,7 @@ func printStagedDiffs(wr io.Writer, staged *actions.TableDiffs, printHelp bool)
 	return 0
 }
 
-func printDiffsNotStaged(wr io.Writer, notStaged *actions.TableDiffs, printHelp bool, linesPrinted int, workingInConflict []string) int {
+func printDiffsNotStaged(wr io.Writer, notStaged *actions.TableDiffs, printHelp bool, linesPrinted int, workingInConflict []string, tblToSummary map[string]diff.DiffSummary) int {
 	inCnfSet := set.NewStrSet(workingInConflict)
 
 	if len(workingInConflict) > 0 {
@@ -159,7 +224,7 @@ func printDiffsNotStaged(wr io.Writer, notStaged *actions.TableDiffs, printHelp
 			tdt := notStaged.TableToType[tblName]
 
 			if tdt != actions.AddedTable && !inCnfSet.Contains(tblName) {
-				lines = append(lines, fmt.Sprintf(statusFmt, tblDiffTypeToLabel[tdt], tblName))
+				lines = append(lines, fmt.Sprintf(statusFmt, tblDiffTypeToLabel[tdt], tblName)+compactDiffSummary(tblToSummary, tblName))
 			}
 		}
 
@@ -183,7 +248,7 @@ func printDiffsNotStaged(wr io.Writer, notStaged *actions.TableDiffs, printHelp
 			tdt := notStaged.TableToType[tblName]
 
 			if tdt == actions.AddedTable {
-				lines = append(lines, fmt.Sprintf(statusFmt, tblDiffTypeToLabel[tdt], tblName))
+				lines = append(lines, fmt.Sprintf(statusFmt, tblDiffTypeToLabel[tdt], tblName)+compactDiffSummary(tblToSummary, tblName))
 			}
 		}
 
@@ -194,9 +259,17 @@ func printDiffsNotStaged(wr io.Writer, notStaged *actions.TableDiffs, printHelp
 	return linesPrinted
 }
 
-func printStatus(dEnv *env.DoltEnv, staged, notStaged *actions.TableDiffs, workingInConflict []string) {
+func printStatus(dEnv *env.DoltEnv, upstream *upstreamInfo, staged, notStaged *actions.TableDiffs, workingInConflict []string, stagedSummaries, notStagedSummaries map[string]diff.DiffSummary) {
 	cli.Printf(branchHeader, dEnv.RepoState.Head.Ref.GetPath())
 
+	if upstream != nil {
+		for _, line := range upstream.statusLines() {
+			cli.Println(line)
+		}
+
+		cli.Println()
+	}
+
 	if dEnv.RepoState.Merge != nil {
 		if len(workingInConflict) > 0 {
 			cli.Println(unmergedTablesHeader)
@@ -205,8 +278,8 @@ func printStatus(dEnv *env.DoltEnv, staged, notStaged *actions.TableDiffs, worki
 		}
 	}
 
-	n := printStagedDiffs(cli.CliOut, staged, true)
-	n = printDiffsNotStaged(cli.CliOut, notStaged, true, n, workingInConflict)
+	n := printStagedDiffs(cli.CliOut, staged, true, stagedSummaries)
+	n = printDiffsNotStaged(cli.CliOut, notStaged, true, n, workingInConflict, notStagedSummaries)
 
 	if dEnv.RepoState.Merge == nil && n == 0 {
 		cli.Println("nothing to commit, working tree clean")
@@ -0,0 +1,192 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/errhand"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env/actions"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+)
+
+// upstreamInfo describes the upstream of a branch, and how far the branch has diverged from it.
+type upstreamInfo struct {
+	// Config is the upstream configuration of the branch
+	Config env.BranchConfig
+
+	// TrackingRef is the remote-tracking ref of the upstream branch, e.g. refs/remotes/origin/master
+	TrackingRef ref.DoltRef
+
+	// Gone is true if the remote-tracking ref doesn't exist, in which case Ahead and Behind are 0
+	Gone bool
+
+	// Ahead is the number of commits on the branch which are not on the upstream
+	Ahead int
+
+	// Behind is the number of commits on the upstream which are not on the branch
+	Behind int
+}
+
+// getUpstreamTrackingRef returns the upstream configuration of the branch given and the remote-tracking ref of its
+// upstream branch. The bool returned is false if the branch has no upstream.
+func getUpstreamTrackingRef(dEnv *env.DoltEnv, branch ref.DoltRef) (env.BranchConfig, ref.DoltRef, bool, errhand.VerboseError) {
+	upstream, ok := dEnv.RepoState.Branches[branch.GetPath()]
+
+	if !ok {
+		return env.BranchConfig{}, nil, false, nil
+	}
+
+	remote, ok := dEnv.RepoState.Remotes[upstream.Remote]
+
+	if !ok {
+		return env.BranchConfig{}, nil, false, errhand.BuildDError("error: the upstream of '%s' is on unknown remote '%s'", branch.GetPath(), upstream.Remote).Build()
+	}
+
+	trackingRef, verr := getTrackingRef(upstream.Merge.Ref, remote)
+
+	if verr != nil {
+		return env.BranchConfig{}, nil, false, verr
+	} else if trackingRef == nil {
+		return env.BranchConfig{}, nil, false, errhand.BuildDError("error: remote '%s' doesn't fetch the upstream of '%s'", remote.Name, branch.GetPath()).Build()
+	}
+
+	return upstream, trackingRef, true, nil
+}
+
+// getUpstreamInfo returns the upstream of the branch given and how far the branch has diverged from it, or nil if the
+// branch has no upstream.
+func getUpstreamInfo(ctx context.Context, dEnv *env.DoltEnv, branch ref.DoltRef) (*upstreamInfo, errhand.VerboseError) {
+	upstream, trackingRef, ok, verr := getUpstreamTrackingRef(dEnv, branch)
+
+	if verr != nil || !ok {
+		return nil, verr
+	}
+
+	info := &upstreamInfo{Config: upstream, TrackingRef: trackingRef}
+	hasRef, err := dEnv.DoltDB.HasRef(ctx, trackingRef)
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: failed to read from db").AddCause(err).Build()
+	} else if !hasRef {
+		info.Gone = true
+		return info, nil
+	}
+
+	cs, _ := doltdb.NewCommitSpec("HEAD", branch.String())
+	local, err := dEnv.DoltDB.Resolve(ctx, cs)
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: unable to resolve '%s'", branch.GetPath()).AddCause(err).Build()
+	}
+
+	cs, _ = doltdb.NewCommitSpec("HEAD", trackingRef.String())
+	remote, err := dEnv.DoltDB.Resolve(ctx, cs)
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: unable to resolve '%s'", trackingRef.GetPath()).AddCause(err).Build()
+	}
+
+	info.Ahead, info.Behind, err = actions.AheadBehind(ctx, dEnv.DoltDB, local, remote)
+
+	if err != nil {
+		return nil, errhand.BuildDError("error: failed to compare '%s' with '%s'", branch.GetPath(), trackingRef.GetPath()).AddCause(err).Build()
+	}
+
+	return info, nil
+}
+
+// statusLines returns the lines describing the divergence of a branch from its upstream which dolt status prints.
+func (info *upstreamInfo) statusLines() []string {
+	name := info.TrackingRef.GetPath()
+
+	switch {
+	case info.Gone:
+		return []string{
+			fmt.Sprintf("Your branch is based on '%s', but the upstream is gone.", name),
+			`  (use "dolt branch --unset-upstream" to fixup)`,
+		}
+	case info.Ahead == 0 && info.Behind == 0:
+		return []string{fmt.Sprintf("Your branch is up to date with '%s'.", name)}
+	case info.Behind == 0:
+		return []string{
+			fmt.Sprintf("Your branch is ahead of '%s' by %s.", name, pluralCommits(info.Ahead)),
+			`  (use "dolt push" to publish your local commits)`,
+		}
+	case info.Ahead == 0:
+		return []string{
+			fmt.Sprintf("Your branch is behind '%s' by %s, and can be fast-forwarded.", name, pluralCommits(info.Behind)),
+			`  (use "dolt pull" to update your local branch)`,
+		}
+	default:
+		return []string{
+			fmt.Sprintf("Your branch and '%s' have diverged,", name),
+			fmt.Sprintf("and have %d and %d different commits each, respectively.", info.Ahead, info.Behind),
+			`  (use "dolt pull" to merge the remote branch into yours)`,
+		}
+	}
+}
+
+// shortDesc returns the description of the upstream shown by dolt branch -vv, e.g. "[origin/master: ahead 1, behind 2]"
+func (info *upstreamInfo) shortDesc() string {
+	var divergence []string
+	if info.Gone {
+		divergence = append(divergence, "gone")
+	}
+
+	if info.Ahead > 0 {
+		divergence = append(divergence, fmt.Sprintf("ahead %d", info.Ahead))
+	}
+
+	if info.Behind > 0 {
+		divergence = append(divergence, fmt.Sprintf("behind %d", info.Behind))
+	}
+
+	if len(divergence) == 0 {
+		return "[" + info.TrackingRef.GetPath() + "]"
+	}
+
+	return "[" + info.TrackingRef.GetPath() + ": " + strings.Join(divergence, ", ") + "]"
+}
+
+func pluralCommits(n int) string {
+	if n == 1 {
+		return "1 commit"
+	}
+
+	return fmt.Sprintf("%d commits", n)
+}
+
+// printNoUpstream prints the error shown when an argument-less push or pull is run on a branch with no upstream.
+func printNoUpstream(dEnv *env.DoltEnv, branch ref.DoltRef) {
+	remoteName := "<remote>"
+	if defRemote, verr := dEnv.GetDefaultRemote(); verr == nil {
+		remoteName = defRemote.Name
+	}
+
+	cli.PrintErrln("There is no tracking information for the current branch " + branch.GetPath() + ".")
+	cli.PrintErrln("To set the upstream of the current branch, use")
+	cli.PrintErrln()
+	cli.PrintErrln("\tdolt branch --set-upstream-to=" + remoteName + "/" + branch.GetPath())
+	cli.PrintErrln()
+	cli.PrintErrln("or push the current branch and set the remote as upstream with")
+	cli.PrintErrln()
+	cli.PrintErrln("\tdolt push --set-upstream " + remoteName + " " + branch.GetPath())
+}
@@ -0,0 +1,46 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+)
+
+func TestUpstreamInfo(t *testing.T) {
+	trackingRef := ref.NewRemoteRef("origin", "master")
+
+	tests := []struct {
+		info       upstreamInfo
+		shortDesc  string
+		statusLine string
+	}{
+		{upstreamInfo{TrackingRef: trackingRef}, "[origin/master]", "Your branch is up to date with 'origin/master'."},
+		{upstreamInfo{TrackingRef: trackingRef, Ahead: 1}, "[origin/master: ahead 1]", "Your branch is ahead of 'origin/master' by 1 commit."},
+		{upstreamInfo{TrackingRef: trackingRef, Behind: 3}, "[origin/master: behind 3]", "Your branch is behind 'origin/master' by 3 commits, and can be fast-forwarded."},
+		{upstreamInfo{TrackingRef: trackingRef, Ahead: 2, Behind: 3}, "[origin/master: ahead 2, behind 3]", "Your branch and 'origin/master' have diverged,"},
+		{upstreamInfo{TrackingRef: trackingRef, Gone: true}, "[origin/master: gone]", "Your branch is based on 'origin/master', but the upstream is gone."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.shortDesc, func(t *testing.T) {
+			assert.Equal(t, test.shortDesc, test.info.shortDesc())
+			assert.Equal(t, test.statusLine, test.info.statusLines()[0])
+		})
+	}
+}
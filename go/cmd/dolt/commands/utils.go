@@ -53,6 +53,8 @@ func UpdateWorkingWithVErr(dEnv *env.DoltEnv, updatedRoot *doltdb.RootValue) err
 		return errhand.BuildDError("fatal: failed to write value").Build()
 	case env.ErrStateUpdate:
 		return errhand.BuildDError("fatal: failed to update the working root state").Build()
+	case env.ErrRepoStateConflict, env.ErrRepoStateLocked:
+		return repoStateUpdateVErr(err)
 	}
 
 	return nil
@@ -66,11 +68,24 @@ func UpdateStagedWithVErr(dEnv *env.DoltEnv, updatedRoot *doltdb.RootValue) errh
 		return errhand.BuildDError("fatal: failed to write value").Build()
 	case env.ErrStateUpdate:
 		return errhand.BuildDError("fatal: failed to update the staged root state").Build()
+	case env.ErrRepoStateConflict, env.ErrRepoStateLocked:
+		return repoStateUpdateVErr(err)
 	}
 
 	return nil
 }
 
+// repoStateUpdateVErr returns the error reported when the repo state could not be saved because another dolt process
+// was updating it
+func repoStateUpdateVErr(err error) errhand.VerboseError {
+	if err == env.ErrRepoStateConflict {
+		return errhand.BuildDError("fatal: the working set was modified by another dolt process").
+			AddDetails("No changes were saved. Run the command again to apply it to the latest working set.").Build()
+	}
+
+	return errhand.BuildDError("fatal: the repository is in use by another dolt process").AddCause(err).Build()
+}
+
 func ValidateTablesWithVErr(tbls []string, roots ...*doltdb.RootValue) errhand.VerboseError {
 	err := actions.ValidateTables(context.TODO(), tbls, roots...)
 
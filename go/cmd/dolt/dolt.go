@@ -25,6 +25,7 @@ import (
 
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/cli"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands"
+	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands/bundlecmds"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands/cnfcmds"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands/credcmds"
 	"github.com/liquidata-inc/dolt/go/cmd/dolt/commands/sqlserver"
@@ -49,6 +50,7 @@ var doltCommand = cli.GenSubCommandHandler([]*cli.Command{
 	{Name: "log", Desc: "Show commit logs.", Func: commands.Log, ReqRepo: true},
 	{Name: "diff", Desc: "Diff a table.", Func: commands.Diff, ReqRepo: true},
 	{Name: "merge", Desc: "Merge a branch.", Func: commands.Merge, ReqRepo: true},
+	{Name: "apply", Desc: "Apply a patch to the working tables.", Func: commands.Apply, ReqRepo: true},
 	{Name: "branch", Desc: "Create, list, edit, delete branches.", Func: commands.Branch, ReqRepo: true},
 	{Name: "checkout", Desc: "Checkout a branch or overwrite a table from HEAD.", Func: commands.Checkout, ReqRepo: true},
 	{Name: "remote", Desc: "Manage set of tracked repositories.", Func: commands.Remote, ReqRepo: true},
@@ -56,11 +58,13 @@ var doltCommand = cli.GenSubCommandHandler([]*cli.Command{
 	{Name: "pull", Desc: "Fetch from a dolt remote data repository and merge.", Func: commands.Pull, ReqRepo: true},
 	{Name: "fetch", Desc: "Update the database from a remote data repository.", Func: commands.Fetch, ReqRepo: true},
 	{Name: "clone", Desc: "Clone from a remote data repository.", Func: commands.Clone, ReqRepo: false},
+	{Name: "bundle", Desc: "Commands for moving repositories with bundle files.", Func: bundlecmds.Commands, ReqRepo: false},
 	{Name: "creds", Desc: "Commands for managing credentials.", Func: credcmds.Commands, ReqRepo: false},
 	{Name: "login", Desc: "Login to a dolt remote host.", Func: commands.Login, ReqRepo: false},
 	{Name: "version", Desc: "Displays the current Dolt cli version.", Func: commands.Version(Version), ReqRepo: false},
 	{Name: "config", Desc: "Dolt configuration.", Func: commands.Config, ReqRepo: false},
 	{Name: "ls", Desc: "List tables in the working set.", Func: commands.Ls, ReqRepo: true},
+	{Name: "compact", Desc: "Rewrite the data files of the repository.", Func: commands.Compact, ReqRepo: true},
 	{Name: "schema", Desc: "Display the schema for table(s)", Func: commands.Schema, ReqRepo: true},
 	{Name: "table", Desc: "Commands for creating, reading, updating, and deleting tables.", Func: tblcmds.Commands, ReqRepo: false},
 	{Name: "conflicts", Desc: "Commands for viewing and resolving merge conflicts.", Func: cnfcmds.Commands, ReqRepo: false},
@@ -254,11 +254,15 @@ func (m *RangeChunk) GetLength() uint32 {
 }
 
 type HttpGetRange struct {
-	Url                  string        `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
-	Ranges               []*RangeChunk `protobuf:"bytes,2,rep,name=ranges,proto3" json:"ranges,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
-	XXX_unrecognized     []byte        `json:"-"`
-	XXX_sizecache        int32         `json:"-"`
+	Url    string        `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	Ranges []*RangeChunk `protobuf:"bytes,2,rep,name=ranges,proto3" json:"ranges,omitempty"`
+	// the range of the zstd dictionaries needed to decompress the chunks, which is empty if they were compressed
+	// without any
+	DictionariesOffset   uint64   `protobuf:"varint,3,opt,name=dictionaries_offset,json=dictionariesOffset,proto3" json:"dictionaries_offset,omitempty"`
+	DictionariesLength   uint32   `protobuf:"varint,4,opt,name=dictionaries_length,json=dictionariesLength,proto3" json:"dictionaries_length,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *HttpGetRange) Reset()         { *m = HttpGetRange{} }
@@ -299,6 +303,20 @@ func (m *HttpGetRange) GetRanges() []*RangeChunk {
 	return nil
 }
 
+func (m *HttpGetRange) GetDictionariesOffset() uint64 {
+	if m != nil {
+		return m.DictionariesOffset
+	}
+	return 0
+}
+
+func (m *HttpGetRange) GetDictionariesLength() uint32 {
+	if m != nil {
+		return m.DictionariesLength
+	}
+	return 0
+}
+
 type DownloadLoc struct {
 	// Types that are valid to be assigned to Location:
 	//	*DownloadLoc_HttpGet
@@ -961,10 +979,13 @@ func (m *ChunkTableInfo) GetChunkCount() uint32 {
 }
 
 type CommitRequest struct {
-	RepoId               *RepoId           `protobuf:"bytes,1,opt,name=repo_id,json=repoId,proto3" json:"repo_id,omitempty"`
-	Current              []byte            `protobuf:"bytes,2,opt,name=current,proto3" json:"current,omitempty"`
-	Last                 []byte            `protobuf:"bytes,3,opt,name=last,proto3" json:"last,omitempty"`
-	ChunkTableInfo       []*ChunkTableInfo `protobuf:"bytes,4,rep,name=chunk_table_info,json=chunkTableInfo,proto3" json:"chunk_table_info,omitempty"`
+	RepoId         *RepoId           `protobuf:"bytes,1,opt,name=repo_id,json=repoId,proto3" json:"repo_id,omitempty"`
+	Current        []byte            `protobuf:"bytes,2,opt,name=current,proto3" json:"current,omitempty"`
+	Last           []byte            `protobuf:"bytes,3,opt,name=last,proto3" json:"last,omitempty"`
+	ChunkTableInfo []*ChunkTableInfo `protobuf:"bytes,4,rep,name=chunk_table_info,json=chunkTableInfo,proto3" json:"chunk_table_info,omitempty"`
+	// Id of the encryption key the table files were written with, empty if
+	// they were not encrypted.
+	KeyId                string            `protobuf:"bytes,5,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
 	ClientRepoFormat     *ClientRepoFormat `protobuf:"bytes,14,opt,name=client_repo_format,json=clientRepoFormat,proto3" json:"client_repo_format,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
 	XXX_unrecognized     []byte            `json:"-"`
@@ -1023,6 +1044,13 @@ func (m *CommitRequest) GetChunkTableInfo() []*ChunkTableInfo {
 	return nil
 }
 
+func (m *CommitRequest) GetKeyId() string {
+	if m != nil {
+		return m.KeyId
+	}
+	return ""
+}
+
 func (m *CommitRequest) GetClientRepoFormat() *ClientRepoFormat {
 	if m != nil {
 		return m.ClientRepoFormat
@@ -1210,6 +1238,434 @@ func (m *ClientRepoFormat) GetNbsVersion() string {
 	return ""
 }
 
+type Ref struct {
+	// Full name of the ref, e.g. refs/heads/master
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Hash of the commit the ref points at
+	Hash                 []byte   `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Ref) Reset()         { *m = Ref{} }
+func (m *Ref) String() string { return proto.CompactTextString(m) }
+func (*Ref) ProtoMessage()    {}
+func (*Ref) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chunkstore_6a769066ddb98dcb, []int{23}
+}
+func (m *Ref) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Ref.Unmarshal(m, b)
+}
+func (m *Ref) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Ref.Marshal(b, m, deterministic)
+}
+func (dst *Ref) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Ref.Merge(dst, src)
+}
+func (m *Ref) XXX_Size() int {
+	return xxx_messageInfo_Ref.Size(m)
+}
+func (m *Ref) XXX_DiscardUnknown() {
+	xxx_messageInfo_Ref.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Ref proto.InternalMessageInfo
+
+func (m *Ref) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Ref) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+type ListRefsRequest struct {
+	RepoId               *RepoId  `protobuf:"bytes,1,opt,name=repo_id,json=repoId,proto3" json:"repo_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListRefsRequest) Reset()         { *m = ListRefsRequest{} }
+func (m *ListRefsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListRefsRequest) ProtoMessage()    {}
+func (*ListRefsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chunkstore_6a769066ddb98dcb, []int{24}
+}
+func (m *ListRefsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListRefsRequest.Unmarshal(m, b)
+}
+func (m *ListRefsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListRefsRequest.Marshal(b, m, deterministic)
+}
+func (dst *ListRefsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListRefsRequest.Merge(dst, src)
+}
+func (m *ListRefsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListRefsRequest.Size(m)
+}
+func (m *ListRefsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListRefsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListRefsRequest proto.InternalMessageInfo
+
+func (m *ListRefsRequest) GetRepoId() *RepoId {
+	if m != nil {
+		return m.RepoId
+	}
+	return nil
+}
+
+type ListRefsResponse struct {
+	Refs                 []*Ref   `protobuf:"bytes,1,rep,name=refs,proto3" json:"refs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListRefsResponse) Reset()         { *m = ListRefsResponse{} }
+func (m *ListRefsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListRefsResponse) ProtoMessage()    {}
+func (*ListRefsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chunkstore_6a769066ddb98dcb, []int{25}
+}
+func (m *ListRefsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListRefsResponse.Unmarshal(m, b)
+}
+func (m *ListRefsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListRefsResponse.Marshal(b, m, deterministic)
+}
+func (dst *ListRefsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListRefsResponse.Merge(dst, src)
+}
+func (m *ListRefsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListRefsResponse.Size(m)
+}
+func (m *ListRefsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListRefsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListRefsResponse proto.InternalMessageInfo
+
+func (m *ListRefsResponse) GetRefs() []*Ref {
+	if m != nil {
+		return m.Refs
+	}
+	return nil
+}
+
+type RefUpdate struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Hash of the commit the ref is expected to point at. Empty if the ref is
+	// expected not to exist.
+	ExpectedHash []byte `protobuf:"bytes,2,opt,name=expected_hash,json=expectedHash,proto3" json:"expected_hash,omitempty"`
+	// Hash of the commit to point the ref at. Empty to delete the ref.
+	NewHash              []byte   `protobuf:"bytes,3,opt,name=new_hash,json=newHash,proto3" json:"new_hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RefUpdate) Reset()         { *m = RefUpdate{} }
+func (m *RefUpdate) String() string { return proto.CompactTextString(m) }
+func (*RefUpdate) ProtoMessage()    {}
+func (*RefUpdate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chunkstore_6a769066ddb98dcb, []int{26}
+}
+func (m *RefUpdate) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RefUpdate.Unmarshal(m, b)
+}
+func (m *RefUpdate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RefUpdate.Marshal(b, m, deterministic)
+}
+func (dst *RefUpdate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RefUpdate.Merge(dst, src)
+}
+func (m *RefUpdate) XXX_Size() int {
+	return xxx_messageInfo_RefUpdate.Size(m)
+}
+func (m *RefUpdate) XXX_DiscardUnknown() {
+	xxx_messageInfo_RefUpdate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RefUpdate proto.InternalMessageInfo
+
+func (m *RefUpdate) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *RefUpdate) GetExpectedHash() []byte {
+	if m != nil {
+		return m.ExpectedHash
+	}
+	return nil
+}
+
+func (m *RefUpdate) GetNewHash() []byte {
+	if m != nil {
+		return m.NewHash
+	}
+	return nil
+}
+
+type UpdateRefsRequest struct {
+	RepoId *RepoId `protobuf:"bytes,1,opt,name=repo_id,json=repoId,proto3" json:"repo_id,omitempty"`
+	// Updates are applied atomically. If any ref does not point at its
+	// expected hash none of the updates are applied. Changes to other refs
+	// never cause the request to fail.
+	Updates        []*RefUpdate      `protobuf:"bytes,2,rep,name=updates,proto3" json:"updates,omitempty"`
+	ChunkTableInfo []*ChunkTableInfo `protobuf:"bytes,3,rep,name=chunk_table_info,json=chunkTableInfo,proto3" json:"chunk_table_info,omitempty"`
+	// Id of the encryption key the table files were written with, empty if
+	// they were not encrypted.
+	KeyId                string            `protobuf:"bytes,4,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	ClientRepoFormat     *ClientRepoFormat `protobuf:"bytes,14,opt,name=client_repo_format,json=clientRepoFormat,proto3" json:"client_repo_format,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *UpdateRefsRequest) Reset()         { *m = UpdateRefsRequest{} }
+func (m *UpdateRefsRequest) String() string { return proto.CompactTextString(m) }
+func (*UpdateRefsRequest) ProtoMessage()    {}
+func (*UpdateRefsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chunkstore_6a769066ddb98dcb, []int{27}
+}
+func (m *UpdateRefsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdateRefsRequest.Unmarshal(m, b)
+}
+func (m *UpdateRefsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdateRefsRequest.Marshal(b, m, deterministic)
+}
+func (dst *UpdateRefsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateRefsRequest.Merge(dst, src)
+}
+func (m *UpdateRefsRequest) XXX_Size() int {
+	return xxx_messageInfo_UpdateRefsRequest.Size(m)
+}
+func (m *UpdateRefsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateRefsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateRefsRequest proto.InternalMessageInfo
+
+func (m *UpdateRefsRequest) GetRepoId() *RepoId {
+	if m != nil {
+		return m.RepoId
+	}
+	return nil
+}
+
+func (m *UpdateRefsRequest) GetUpdates() []*RefUpdate {
+	if m != nil {
+		return m.Updates
+	}
+	return nil
+}
+
+func (m *UpdateRefsRequest) GetChunkTableInfo() []*ChunkTableInfo {
+	if m != nil {
+		return m.ChunkTableInfo
+	}
+	return nil
+}
+
+func (m *UpdateRefsRequest) GetKeyId() string {
+	if m != nil {
+		return m.KeyId
+	}
+	return ""
+}
+
+func (m *UpdateRefsRequest) GetClientRepoFormat() *ClientRepoFormat {
+	if m != nil {
+		return m.ClientRepoFormat
+	}
+	return nil
+}
+
+type UpdateRefsResponse struct {
+	// False if any ref did not point at its expected hash.
+	Success              bool     `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UpdateRefsResponse) Reset()         { *m = UpdateRefsResponse{} }
+func (m *UpdateRefsResponse) String() string { return proto.CompactTextString(m) }
+func (*UpdateRefsResponse) ProtoMessage()    {}
+func (*UpdateRefsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chunkstore_6a769066ddb98dcb, []int{28}
+}
+func (m *UpdateRefsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdateRefsResponse.Unmarshal(m, b)
+}
+func (m *UpdateRefsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdateRefsResponse.Marshal(b, m, deterministic)
+}
+func (dst *UpdateRefsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateRefsResponse.Merge(dst, src)
+}
+func (m *UpdateRefsResponse) XXX_Size() int {
+	return xxx_messageInfo_UpdateRefsResponse.Size(m)
+}
+func (m *UpdateRefsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateRefsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateRefsResponse proto.InternalMessageInfo
+
+func (m *UpdateRefsResponse) GetSuccess() bool {
+	if m != nil {
+		return m.Success
+	}
+	return false
+}
+
+type TableFileInfo struct {
+	// Name of the table file, which is the hash of its contents
+	Hash       []byte `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	ChunkCount uint32 `protobuf:"varint,2,opt,name=chunk_count,json=chunkCount,proto3" json:"chunk_count,omitempty"`
+	// Url from which the whole table file can be read
+	Url                  string   `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TableFileInfo) Reset()         { *m = TableFileInfo{} }
+func (m *TableFileInfo) String() string { return proto.CompactTextString(m) }
+func (*TableFileInfo) ProtoMessage()    {}
+func (*TableFileInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chunkstore_6a769066ddb98dcb, []int{29}
+}
+func (m *TableFileInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TableFileInfo.Unmarshal(m, b)
+}
+func (m *TableFileInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TableFileInfo.Marshal(b, m, deterministic)
+}
+func (dst *TableFileInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TableFileInfo.Merge(dst, src)
+}
+func (m *TableFileInfo) XXX_Size() int {
+	return xxx_messageInfo_TableFileInfo.Size(m)
+}
+func (m *TableFileInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_TableFileInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TableFileInfo proto.InternalMessageInfo
+
+func (m *TableFileInfo) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+func (m *TableFileInfo) GetChunkCount() uint32 {
+	if m != nil {
+		return m.ChunkCount
+	}
+	return 0
+}
+
+func (m *TableFileInfo) GetUrl() string {
+	if m != nil {
+		return m.Url
+	}
+	return ""
+}
+
+type ListTableFilesRequest struct {
+	RepoId               *RepoId  `protobuf:"bytes,1,opt,name=repo_id,json=repoId,proto3" json:"repo_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListTableFilesRequest) Reset()         { *m = ListTableFilesRequest{} }
+func (m *ListTableFilesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListTableFilesRequest) ProtoMessage()    {}
+func (*ListTableFilesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chunkstore_6a769066ddb98dcb, []int{30}
+}
+func (m *ListTableFilesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListTableFilesRequest.Unmarshal(m, b)
+}
+func (m *ListTableFilesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListTableFilesRequest.Marshal(b, m, deterministic)
+}
+func (dst *ListTableFilesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListTableFilesRequest.Merge(dst, src)
+}
+func (m *ListTableFilesRequest) XXX_Size() int {
+	return xxx_messageInfo_ListTableFilesRequest.Size(m)
+}
+func (m *ListTableFilesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListTableFilesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListTableFilesRequest proto.InternalMessageInfo
+
+func (m *ListTableFilesRequest) GetRepoId() *RepoId {
+	if m != nil {
+		return m.RepoId
+	}
+	return nil
+}
+
+type ListTableFilesResponse struct {
+	// The table files in the manifest of the repository
+	TableFileInfo        []*TableFileInfo `protobuf:"bytes,1,rep,name=table_file_info,json=tableFileInfo,proto3" json:"table_file_info,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *ListTableFilesResponse) Reset()         { *m = ListTableFilesResponse{} }
+func (m *ListTableFilesResponse) String() string { return proto.CompactTextString(m) }
+func (*ListTableFilesResponse) ProtoMessage()    {}
+func (*ListTableFilesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_chunkstore_6a769066ddb98dcb, []int{31}
+}
+func (m *ListTableFilesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListTableFilesResponse.Unmarshal(m, b)
+}
+func (m *ListTableFilesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListTableFilesResponse.Marshal(b, m, deterministic)
+}
+func (dst *ListTableFilesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListTableFilesResponse.Merge(dst, src)
+}
+func (m *ListTableFilesResponse) XXX_Size() int {
+	return xxx_messageInfo_ListTableFilesResponse.Size(m)
+}
+func (m *ListTableFilesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListTableFilesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListTableFilesResponse proto.InternalMessageInfo
+
+func (m *ListTableFilesResponse) GetTableFileInfo() []*TableFileInfo {
+	if m != nil {
+		return m.TableFileInfo
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*RepoId)(nil), "dolt.services.remotesapi.v1alpha1.RepoId")
 	proto.RegisterType((*HasChunksRequest)(nil), "dolt.services.remotesapi.v1alpha1.HasChunksRequest")
@@ -1234,6 +1690,15 @@ func init() {
 	proto.RegisterType((*GetRepoMetadataRequest)(nil), "dolt.services.remotesapi.v1alpha1.GetRepoMetadataRequest")
 	proto.RegisterType((*GetRepoMetadataResponse)(nil), "dolt.services.remotesapi.v1alpha1.GetRepoMetadataResponse")
 	proto.RegisterType((*ClientRepoFormat)(nil), "dolt.services.remotesapi.v1alpha1.ClientRepoFormat")
+	proto.RegisterType((*Ref)(nil), "dolt.services.remotesapi.v1alpha1.Ref")
+	proto.RegisterType((*ListRefsRequest)(nil), "dolt.services.remotesapi.v1alpha1.ListRefsRequest")
+	proto.RegisterType((*ListRefsResponse)(nil), "dolt.services.remotesapi.v1alpha1.ListRefsResponse")
+	proto.RegisterType((*RefUpdate)(nil), "dolt.services.remotesapi.v1alpha1.RefUpdate")
+	proto.RegisterType((*UpdateRefsRequest)(nil), "dolt.services.remotesapi.v1alpha1.UpdateRefsRequest")
+	proto.RegisterType((*UpdateRefsResponse)(nil), "dolt.services.remotesapi.v1alpha1.UpdateRefsResponse")
+	proto.RegisterType((*TableFileInfo)(nil), "dolt.services.remotesapi.v1alpha1.TableFileInfo")
+	proto.RegisterType((*ListTableFilesRequest)(nil), "dolt.services.remotesapi.v1alpha1.ListTableFilesRequest")
+	proto.RegisterType((*ListTableFilesResponse)(nil), "dolt.services.remotesapi.v1alpha1.ListTableFilesResponse")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1255,6 +1720,9 @@ type ChunkStoreServiceClient interface {
 	Rebase(ctx context.Context, in *RebaseRequest, opts ...grpc.CallOption) (*RebaseResponse, error)
 	Root(ctx context.Context, in *RootRequest, opts ...grpc.CallOption) (*RootResponse, error)
 	Commit(ctx context.Context, in *CommitRequest, opts ...grpc.CallOption) (*CommitResponse, error)
+	ListRefs(ctx context.Context, in *ListRefsRequest, opts ...grpc.CallOption) (*ListRefsResponse, error)
+	UpdateRefs(ctx context.Context, in *UpdateRefsRequest, opts ...grpc.CallOption) (*UpdateRefsResponse, error)
+	ListTableFiles(ctx context.Context, in *ListTableFilesRequest, opts ...grpc.CallOption) (*ListTableFilesResponse, error)
 }
 
 type chunkStoreServiceClient struct {
@@ -1328,6 +1796,33 @@ func (c *chunkStoreServiceClient) Commit(ctx context.Context, in *CommitRequest,
 	return out, nil
 }
 
+func (c *chunkStoreServiceClient) ListRefs(ctx context.Context, in *ListRefsRequest, opts ...grpc.CallOption) (*ListRefsResponse, error) {
+	out := new(ListRefsResponse)
+	err := c.cc.Invoke(ctx, "/dolt.services.remotesapi.v1alpha1.ChunkStoreService/ListRefs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chunkStoreServiceClient) UpdateRefs(ctx context.Context, in *UpdateRefsRequest, opts ...grpc.CallOption) (*UpdateRefsResponse, error) {
+	out := new(UpdateRefsResponse)
+	err := c.cc.Invoke(ctx, "/dolt.services.remotesapi.v1alpha1.ChunkStoreService/UpdateRefs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chunkStoreServiceClient) ListTableFiles(ctx context.Context, in *ListTableFilesRequest, opts ...grpc.CallOption) (*ListTableFilesResponse, error) {
+	out := new(ListTableFilesResponse)
+	err := c.cc.Invoke(ctx, "/dolt.services.remotesapi.v1alpha1.ChunkStoreService/ListTableFiles", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ChunkStoreServiceServer is the server API for ChunkStoreService service.
 type ChunkStoreServiceServer interface {
 	GetRepoMetadata(context.Context, *GetRepoMetadataRequest) (*GetRepoMetadataResponse, error)
@@ -1337,6 +1832,9 @@ type ChunkStoreServiceServer interface {
 	Rebase(context.Context, *RebaseRequest) (*RebaseResponse, error)
 	Root(context.Context, *RootRequest) (*RootResponse, error)
 	Commit(context.Context, *CommitRequest) (*CommitResponse, error)
+	ListRefs(context.Context, *ListRefsRequest) (*ListRefsResponse, error)
+	UpdateRefs(context.Context, *UpdateRefsRequest) (*UpdateRefsResponse, error)
+	ListTableFiles(context.Context, *ListTableFilesRequest) (*ListTableFilesResponse, error)
 }
 
 func RegisterChunkStoreServiceServer(s *grpc.Server, srv ChunkStoreServiceServer) {
@@ -1469,6 +1967,60 @@ func _ChunkStoreService_Commit_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ChunkStoreService_ListRefs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRefsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChunkStoreServiceServer).ListRefs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dolt.services.remotesapi.v1alpha1.ChunkStoreService/ListRefs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChunkStoreServiceServer).ListRefs(ctx, req.(*ListRefsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChunkStoreService_UpdateRefs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRefsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChunkStoreServiceServer).UpdateRefs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dolt.services.remotesapi.v1alpha1.ChunkStoreService/UpdateRefs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChunkStoreServiceServer).UpdateRefs(ctx, req.(*UpdateRefsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChunkStoreService_ListTableFiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTableFilesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChunkStoreServiceServer).ListTableFiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dolt.services.remotesapi.v1alpha1.ChunkStoreService/ListTableFiles",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChunkStoreServiceServer).ListTableFiles(ctx, req.(*ListTableFilesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _ChunkStoreService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "dolt.services.remotesapi.v1alpha1.ChunkStoreService",
 	HandlerType: (*ChunkStoreServiceServer)(nil),
@@ -1501,6 +2053,18 @@ var _ChunkStoreService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Commit",
 			Handler:    _ChunkStoreService_Commit_Handler,
 		},
+		{
+			MethodName: "ListRefs",
+			Handler:    _ChunkStoreService_ListRefs_Handler,
+		},
+		{
+			MethodName: "UpdateRefs",
+			Handler:    _ChunkStoreService_UpdateRefs_Handler,
+		},
+		{
+			MethodName: "ListTableFiles",
+			Handler:    _ChunkStoreService_ListTableFiles_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "dolt/services/remotesapi/v1alpha1/chunkstore.proto",
@@ -1511,62 +2075,80 @@ func init() {
 }
 
 var fileDescriptor_chunkstore_6a769066ddb98dcb = []byte{
-	// 908 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x57, 0xcf, 0x73, 0xdb, 0x44,
-	0x14, 0x8e, 0x12, 0xe3, 0xd8, 0xcf, 0x3f, 0x70, 0x35, 0x50, 0x34, 0xe6, 0xd0, 0x74, 0x4f, 0x81,
-	0x16, 0xbb, 0x71, 0x99, 0x69, 0x81, 0x0b, 0x93, 0x50, 0xe2, 0xce, 0x14, 0x28, 0x9b, 0x02, 0x03,
-	0x1d, 0x46, 0xb3, 0x96, 0xd7, 0x96, 0x06, 0x79, 0x57, 0x68, 0xd7, 0xe1, 0xc2, 0x1d, 0x2e, 0xfc,
-	0x4d, 0xc0, 0x5f, 0xc5, 0x95, 0xd9, 0xa7, 0x95, 0x23, 0xbb, 0xce, 0xb0, 0xa1, 0x99, 0xdc, 0xb4,
-	0xcf, 0xfb, 0xbd, 0xf7, 0x7d, 0xdf, 0xae, 0xde, 0x93, 0x61, 0x34, 0x95, 0xa9, 0x1e, 0x2a, 0x9e,
-	0x9f, 0x27, 0x11, 0x57, 0xc3, 0x9c, 0x2f, 0xa4, 0xe6, 0x8a, 0x65, 0xc9, 0xf0, 0xfc, 0x88, 0xa5,
-	0x59, 0xcc, 0x8e, 0x86, 0x51, 0xbc, 0x14, 0x3f, 0x29, 0x2d, 0x73, 0x3e, 0xc8, 0x72, 0xa9, 0xa5,
-	0x7f, 0xd7, 0x60, 0x06, 0x25, 0x66, 0x70, 0x81, 0x19, 0x94, 0x18, 0xf2, 0x08, 0xea, 0x94, 0x67,
-	0xf2, 0xe9, 0xd4, 0xef, 0xc1, 0x9e, 0xcc, 0xe7, 0x81, 0x77, 0xe0, 0x1d, 0x36, 0xa9, 0x79, 0xf4,
-	0xdf, 0x85, 0x66, 0xce, 0x33, 0x19, 0x0a, 0xb6, 0xe0, 0xc1, 0x2e, 0xc6, 0x1b, 0x26, 0xf0, 0x25,
-	0x5b, 0x70, 0x22, 0xa0, 0x37, 0x66, 0xea, 0x04, 0x4b, 0x52, 0xfe, 0xf3, 0x92, 0x2b, 0xed, 0x1f,
-	0xc3, 0x3e, 0x02, 0x92, 0x29, 0xa6, 0x69, 0x8d, 0xde, 0x1b, 0xfc, 0x27, 0x83, 0x41, 0x51, 0x9e,
-	0xd6, 0xf3, 0x82, 0xc6, 0x6d, 0xa8, 0xc7, 0x4c, 0xc5, 0x5c, 0x05, 0xbb, 0x07, 0x7b, 0x87, 0x6d,
-	0x6a, 0x57, 0xe4, 0x1e, 0xdc, 0xaa, 0xd4, 0x53, 0x99, 0x14, 0x8a, 0x9b, 0xcd, 0x6c, 0xa2, 0xb8,
-	0xd0, 0x81, 0x77, 0xb0, 0x77, 0xf8, 0x06, 0xb5, 0x2b, 0xf2, 0x18, 0xda, 0x63, 0xad, 0xb3, 0x53,
-	0xae, 0x11, 0x60, 0xb4, 0x2d, 0xf3, 0xb4, 0xd4, 0xb6, 0xcc, 0xd3, 0x4b, 0xcb, 0x3c, 0x07, 0xa0,
-	0x4c, 0xcc, 0x79, 0x81, 0xf3, 0xa1, 0x66, 0xe2, 0x08, 0x6c, 0x53, 0x7c, 0x36, 0x48, 0x39, 0x9b,
-	0x29, 0xae, 0xd1, 0x92, 0x1a, 0xb5, 0x2b, 0x13, 0x4f, 0xb9, 0x98, 0xeb, 0x38, 0xd8, 0x3b, 0xf0,
-	0x0e, 0x3b, 0xd4, 0xae, 0xc8, 0x7c, 0xc5, 0x05, 0x13, 0x6f, 0xe1, 0xf2, 0x04, 0xea, 0xb9, 0xf9,
-	0xa9, 0xe0, 0xd2, 0x1a, 0x7d, 0xe0, 0xe2, 0xda, 0x8a, 0x24, 0xb5, 0x60, 0xf2, 0x97, 0x07, 0xad,
-	0xcf, 0xe4, 0x2f, 0x22, 0x95, 0x6c, 0xfa, 0x4c, 0x46, 0xfe, 0x33, 0x68, 0xc4, 0x5a, 0x67, 0xe1,
-	0x9c, 0x6b, 0x7b, 0x1c, 0x43, 0x87, 0xc4, 0x55, 0xdf, 0xc6, 0x3b, 0x74, 0x3f, 0x2e, 0xd6, 0xfe,
-	0x77, 0xd0, 0x2d, 0xb3, 0x85, 0x58, 0x10, 0xe5, 0x5f, 0x29, 0x27, 0x72, 0x1e, 0xef, 0xd0, 0x76,
-	0x5c, 0x59, 0x1f, 0x03, 0x34, 0x52, 0x19, 0x31, 0x9d, 0x48, 0x41, 0xee, 0x42, 0xc7, 0xec, 0x7d,
-	0x2e, 0xd5, 0x65, 0x07, 0x47, 0x7e, 0x85, 0xe6, 0x37, 0x59, 0x29, 0x71, 0xdb, 0xf9, 0x7c, 0x05,
-	0x4d, 0x24, 0x9a, 0x49, 0xa5, 0x2d, 0xc7, 0x07, 0x8e, 0x1c, 0x57, 0x75, 0xc7, 0x3b, 0x14, 0xbd,
-	0x33, 0x81, 0x35, 0x82, 0x1a, 0x6e, 0x9f, 0x72, 0x5d, 0x71, 0xf9, 0x46, 0xee, 0xfe, 0x8f, 0xf0,
-	0xce, 0x2b, 0x55, 0xed, 0x1b, 0x70, 0x0c, 0xb5, 0x54, 0x46, 0x0a, 0xef, 0x7f, 0x6b, 0x34, 0x70,
-	0xa8, 0x59, 0x49, 0x43, 0x11, 0x4b, 0x72, 0x78, 0xeb, 0x94, 0xeb, 0x95, 0xab, 0x37, 0x22, 0xe9,
-	0x7b, 0x78, 0x7b, 0xa3, 0xa6, 0x15, 0xf4, 0xe9, 0x9a, 0xa0, 0xfb, 0x0e, 0x15, 0x57, 0x49, 0xac,
-	0x9c, 0x33, 0xe8, 0x50, 0x3e, 0x61, 0x8a, 0x5f, 0xa3, 0x0e, 0xd2, 0x83, 0x6e, 0x99, 0xb4, 0x20,
-	0x4a, 0xbe, 0x86, 0x16, 0x95, 0x52, 0x5f, 0x67, 0x91, 0x7b, 0xd0, 0x2e, 0x52, 0x5a, 0x2f, 0x4c,
-	0x03, 0x96, 0x52, 0x87, 0x95, 0x3b, 0xde, 0x30, 0x81, 0x31, 0x53, 0x31, 0x79, 0x02, 0x5d, 0xbc,
-	0xab, 0x2f, 0xd8, 0x24, 0xe5, 0x4f, 0xc5, 0x4c, 0x6e, 0x7d, 0x1b, 0xee, 0x40, 0x0b, 0xc7, 0x42,
-	0x18, 0xc9, 0xa5, 0x28, 0xde, 0x87, 0x0e, 0x05, 0x0c, 0x9d, 0x98, 0x08, 0xf9, 0x7b, 0x17, 0x3a,
-	0x27, 0x72, 0xb1, 0x48, 0xae, 0x53, 0x89, 0x1f, 0xc0, 0x7e, 0xb4, 0xcc, 0x73, 0x6e, 0x4b, 0xb6,
-	0x69, 0xb9, 0x34, 0x24, 0x53, 0xa6, 0x34, 0x36, 0xc9, 0x36, 0xc5, 0x67, 0xff, 0x25, 0xf4, 0x0a,
-	0x92, 0xda, 0x68, 0x09, 0x13, 0x31, 0x93, 0x41, 0x0d, 0xcf, 0xff, 0xc8, 0xa1, 0xf4, 0xba, 0x0b,
-	0xb4, 0x1b, 0xad, 0xbb, 0xc2, 0xc0, 0x8f, 0xd2, 0x84, 0x0b, 0x1d, 0xa2, 0xaa, 0x99, 0xcc, 0x17,
-	0x4c, 0x07, 0x5d, 0x54, 0xf6, 0xd0, 0x25, 0x3d, 0x82, 0x8d, 0xbe, 0xcf, 0x11, 0x4a, 0x7b, 0xd1,
-	0x46, 0x84, 0xbc, 0x0f, 0xdd, 0xd2, 0x42, 0x7b, 0x72, 0x01, 0xec, 0xab, 0x65, 0x14, 0x71, 0xa5,
-	0xd0, 0xc3, 0x06, 0x2d, 0x97, 0xe4, 0x4f, 0x0f, 0x5b, 0x88, 0x41, 0x7f, 0xc1, 0x35, 0x9b, 0x32,
-	0xcd, 0xae, 0xd3, 0xf8, 0x1b, 0x50, 0xfb, 0x12, 0xbb, 0xd1, 0xba, 0x00, 0x2b, 0xfb, 0x0e, 0xb4,
-	0xc4, 0x64, 0x16, 0x9e, 0xf3, 0x5c, 0x25, 0x52, 0xd8, 0xb6, 0x0d, 0x62, 0x32, 0xfb, 0xb6, 0x88,
-	0x14, 0x1b, 0xd4, 0x6a, 0xc3, 0x6e, 0xb9, 0x41, 0xd9, 0x0d, 0xe4, 0x05, 0xf4, 0x36, 0x29, 0xbc,
-	0x7e, 0xd6, 0xd1, 0x3f, 0x75, 0xb8, 0x85, 0xd7, 0xe4, 0xcc, 0x7c, 0x1d, 0x9d, 0x15, 0x06, 0xf8,
-	0xbf, 0x7b, 0xf0, 0xe6, 0x86, 0x12, 0xff, 0x23, 0x07, 0x8f, 0xb6, 0x1f, 0x5f, 0xff, 0xe3, 0xff,
-	0x03, 0xb5, 0xc6, 0x9d, 0x43, 0x73, 0xf5, 0x75, 0xe3, 0xbb, 0x9c, 0xd3, 0xe6, 0xb7, 0x57, 0xff,
-	0xc3, 0xab, 0x81, 0x6c, 0xdd, 0x3f, 0x3c, 0xec, 0xfd, 0x95, 0x99, 0x80, 0x73, 0x4e, 0xb9, 0xfa,
-	0xb0, 0x65, 0x12, 0xba, 0xfa, 0xb0, 0x75, 0x9c, 0xfd, 0xe6, 0x81, 0x5f, 0x9d, 0x0b, 0x96, 0xcd,
-	0x23, 0xb7, 0x94, 0xaf, 0x8c, 0xb0, 0xfe, 0xe3, 0xab, 0x03, 0x2d, 0x93, 0x85, 0xf9, 0x30, 0x36,
-	0x0d, 0xdf, 0x7f, 0xe0, 0xf4, 0x16, 0x56, 0x06, 0x4e, 0xff, 0xe8, 0x0a, 0x08, 0x5b, 0x6e, 0x0e,
-	0x35, 0xd3, 0xfa, 0x7d, 0x97, 0x09, 0x5e, 0x19, 0x3b, 0xfd, 0xa1, 0xf3, 0xfe, 0x0b, 0x5d, 0x45,
-	0xaf, 0x72, 0xd2, 0xb5, 0x36, 0x19, 0x9c, 0x74, 0xad, 0x37, 0xc2, 0xe3, 0xc1, 0x0f, 0xf7, 0x2f,
-	0xfb, 0xe3, 0x12, 0x96, 0x98, 0x4f, 0x2e, 0x62, 0x93, 0x3a, 0xfe, 0x73, 0x79, 0xf8, 0x6f, 0x00,
-	0x00, 0x00, 0xff, 0xff, 0x97, 0xea, 0xa1, 0x84, 0xef, 0x0c, 0x00, 0x00,
+	// 1194 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x03, 0xbd, 0x58, 0x4b, 0x73, 0x1b, 0x45,
+	0x10, 0x46, 0x0f, 0xeb, 0xd1, 0x7a, 0x58, 0x1e, 0xb0, 0x11, 0xe2, 0x00, 0x59, 0xaa, 0x28, 0x43,
+	0x1c, 0x29, 0x96, 0x43, 0xc5, 0x09, 0x17, 0xca, 0x26, 0xc6, 0xa9, 0x72, 0x1e, 0x8c, 0xe3, 0xf0,
+	0x70, 0x51, 0x5b, 0xeb, 0xd5, 0xc8, 0xda, 0x8a, 0xb4, 0x23, 0x76, 0x46, 0x0e, 0xa9, 0xe2, 0x4a,
+	0xc1, 0x85, 0xdf, 0x04, 0x57, 0xfe, 0x01, 0xff, 0x82, 0x1b, 0x67, 0xe6, 0xb5, 0xd2, 0x4a, 0x96,
+	0x2b, 0xa3, 0xa0, 0xf2, 0x6d, 0xbb, 0x35, 0x5f, 0xf7, 0xf7, 0xf5, 0xcc, 0xf4, 0xf6, 0x0a, 0xda,
+	0x1d, 0xda, 0xe7, 0x2d, 0x46, 0xa2, 0x8b, 0xc0, 0x27, 0xac, 0x15, 0x91, 0x01, 0xe5, 0x84, 0x79,
+	0xc3, 0xa0, 0x75, 0xb1, 0xed, 0xf5, 0x87, 0x3d, 0x6f, 0xbb, 0xe5, 0xf7, 0x46, 0xe1, 0x0b, 0xc6,
+	0x69, 0x44, 0x9a, 0xc3, 0x88, 0x72, 0x8a, 0x6e, 0x48, 0x4c, 0x33, 0xc6, 0x34, 0x27, 0x98, 0x66,
+	0x8c, 0x71, 0xee, 0x42, 0x0e, 0x93, 0x21, 0x7d, 0xd8, 0x41, 0x35, 0xc8, 0xd0, 0xe8, 0xbc, 0x9e,
+	0xfa, 0x30, 0xb5, 0x59, 0xc4, 0xf2, 0x11, 0xbd, 0x0f, 0xc5, 0x48, 0xfc, 0xe6, 0x86, 0xde, 0x80,
+	0xd4, 0xd3, 0xca, 0x5f, 0x90, 0x8e, 0xc7, 0xc2, 0x76, 0x42, 0xa8, 0x1d, 0x7a, 0x6c, 0x5f, 0xa5,
+	0xc4, 0xe4, 0xc7, 0x11, 0x61, 0x1c, 0xed, 0x41, 0x5e, 0x01, 0x82, 0x8e, 0x0a, 0x53, 0x6a, 0x7f,
+	0xd2, 0x7c, 0x2d, 0x83, 0xa6, 0x4e, 0x8f, 0x73, 0x91, 0xa6, 0xb1, 0x01, 0xb9, 0x9e, 0xc7, 0x7a,
+	0x84, 0x89, 0x8c, 0x99, 0xcd, 0x32, 0x36, 0x96, 0x73, 0x13, 0xd6, 0x12, 0xf9, 0xd8, 0x90, 0x86,
+	0x8c, 0xc8, 0xc5, 0xde, 0x19, 0x23, 0x21, 0x17, 0xf9, 0x32, 0x9b, 0x2b, 0xd8, 0x58, 0xce, 0x2e,
+	0x94, 0x0f, 0x39, 0x1f, 0x7e, 0x45, 0xb8, 0x02, 0x48, 0x6d, 0xa3, 0xa8, 0x1f, 0x6b, 0x13, 0x8f,
+	0x57, 0xa6, 0x79, 0x0a, 0x80, 0xbd, 0xf0, 0x9c, 0x68, 0x1c, 0x82, 0xac, 0xf4, 0x2b, 0x60, 0x19,
+	0xab, 0x67, 0x89, 0xa4, 0xdd, 0x2e, 0x23, 0x5c, 0x95, 0x24, 0x8b, 0x8d, 0x25, 0xfd, 0x7d, 0x12,
+	0x9e, 0xf3, 0x5e, 0x3d, 0x23, 0xfc, 0x15, 0x6c, 0x2c, 0xe7, 0xaf, 0xd4, 0x98, 0x8c, 0x8a, 0x3c,
+	0x87, 0xcc, 0x03, 0xc8, 0x45, 0xf2, 0x27, 0x4d, 0xa6, 0xd4, 0xbe, 0x65, 0x53, 0xb6, 0x31, 0x4b,
+	0x6c, 0xc0, 0xa8, 0x05, 0x6f, 0x77, 0x02, 0x9f, 0x07, 0x34, 0xf4, 0xa2, 0x80, 0x30, 0xd7, 0xd0,
+	0xcc, 0x28, 0x9a, 0x28, 0xf9, 0xd3, 0x13, 0x4d, 0x79, 0x16, 0x60, 0xf8, 0x67, 0x15, 0xff, 0x29,
+	0xc0, 0x91, 0xd6, 0xf2, 0x67, 0x0a, 0x4a, 0x5f, 0xd2, 0x97, 0x61, 0x9f, 0x7a, 0x9d, 0x23, 0xea,
+	0xa3, 0x23, 0x28, 0xf4, 0x84, 0x34, 0xf7, 0x9c, 0x70, 0xb3, 0xe3, 0x2d, 0x0b, 0xea, 0xc9, 0xad,
+	0x39, 0x7c, 0x0b, 0xe7, 0x7b, 0xda, 0x46, 0xdf, 0x40, 0x35, 0x8e, 0xe6, 0x2a, 0x49, 0xaa, 0xc2,
+	0x0b, 0xc5, 0x54, 0x55, 0x11, 0x31, 0xcb, 0xbd, 0x84, 0xbd, 0x07, 0x50, 0xe8, 0x53, 0xdf, 0x93,
+	0x6a, 0x9c, 0x1b, 0x50, 0x91, 0x6b, 0x9f, 0x52, 0x76, 0xd5, 0xd9, 0x70, 0x7e, 0x86, 0xe2, 0xc9,
+	0x30, 0x96, 0x38, 0xef, 0x08, 0x3c, 0x81, 0xa2, 0x22, 0x3a, 0x14, 0x41, 0x0c, 0xc7, 0xdb, 0x96,
+	0x1c, 0xc7, 0x79, 0x05, 0x49, 0x55, 0x3b, 0xe9, 0x98, 0x22, 0xc8, 0x61, 0x43, 0x10, 0x4f, 0x54,
+	0xf9, 0x5a, 0xae, 0xd7, 0x0f, 0xf0, 0xee, 0xa5, 0xac, 0xe6, 0x92, 0xed, 0x41, 0x56, 0x90, 0x63,
+	0xea, 0x8a, 0x95, 0xda, 0x4d, 0x8b, 0x9c, 0x89, 0x30, 0x58, 0x61, 0x9d, 0x08, 0xde, 0x11, 0xe1,
+	0xc7, 0x55, 0xbd, 0x16, 0x49, 0xdf, 0xc1, 0xfa, 0x4c, 0x4e, 0x23, 0xe8, 0x8b, 0x29, 0x41, 0x5b,
+	0x16, 0x19, 0xc7, 0x41, 0x8c, 0x9c, 0x63, 0xa8, 0x60, 0x72, 0xe6, 0x31, 0xb2, 0x44, 0x1d, 0x4e,
+	0x0d, 0xaa, 0x71, 0x50, 0x4d, 0xd4, 0xf9, 0x1a, 0x4a, 0x98, 0x52, 0xbe, 0xcc, 0x24, 0x37, 0xa1,
+	0xac, 0x43, 0x9a, 0x5a, 0xc8, 0x1e, 0x2f, 0x6c, 0x37, 0x71, 0xc6, 0x0b, 0xd2, 0x21, 0x7a, 0x6d,
+	0xcf, 0x79, 0x00, 0x55, 0x75, 0x56, 0x9f, 0x79, 0x67, 0x7d, 0xf2, 0x30, 0xec, 0xd2, 0xb9, 0xb7,
+	0xe1, 0x03, 0x28, 0xa9, 0x37, 0x8f, 0xeb, 0xd3, 0x51, 0xa8, 0xef, 0x43, 0x05, 0x83, 0x72, 0xed,
+	0x4b, 0x8f, 0xf3, 0x77, 0x1a, 0x2a, 0xfb, 0x74, 0x30, 0x08, 0x96, 0xa9, 0x04, 0xd5, 0x21, 0xef,
+	0x8f, 0xa2, 0x88, 0x98, 0x94, 0x65, 0x1c, 0x9b, 0x92, 0x64, 0xdf, 0x63, 0xba, 0xf1, 0x09, 0x92,
+	0xf2, 0x19, 0x9d, 0x42, 0x4d, 0x93, 0xe4, 0x52, 0x8b, 0x1b, 0x08, 0x31, 0xa2, 0xcf, 0xc9, 0xfd,
+	0xdf, 0xb6, 0x48, 0x3d, 0x5d, 0x05, 0x5c, 0xf5, 0xa7, 0xab, 0xb2, 0x0e, 0xb9, 0x17, 0xe4, 0x95,
+	0x54, 0xb3, 0xa2, 0xba, 0xc8, 0x8a, 0xb0, 0x04, 0x43, 0x0f, 0x90, 0xdf, 0x0f, 0x04, 0x23, 0x57,
+	0x89, 0xed, 0xd2, 0x68, 0xe0, 0xf1, 0x7a, 0x55, 0x09, 0xde, 0xb1, 0xc9, 0xaa, 0xc0, 0x52, 0xf6,
+	0x81, 0x82, 0xe2, 0x9a, 0x3f, 0xe3, 0x71, 0x3e, 0x15, 0x3b, 0x64, 0x2a, 0x6b, 0x36, 0x54, 0x94,
+	0x85, 0x8d, 0x7c, 0x11, 0x92, 0xa9, 0xd2, 0x16, 0x70, 0x6c, 0x3a, 0x7f, 0xa4, 0x54, 0x67, 0x91,
+	0xe8, 0x47, 0x84, 0x7b, 0x1d, 0x8f, 0x7b, 0xcb, 0xdc, 0x8f, 0x6b, 0x50, 0x7b, 0xaa, 0x9a, 0xd4,
+	0xb4, 0x00, 0x23, 0x5b, 0x1c, 0xc2, 0xf0, 0xac, 0xeb, 0x5e, 0x90, 0x88, 0x89, 0x26, 0x6a, 0xba,
+	0x39, 0x08, 0xd7, 0x73, 0xed, 0xd1, 0x0b, 0xd8, 0x78, 0x41, 0x3a, 0x5e, 0xc0, 0xcc, 0x02, 0xe7,
+	0x19, 0xd4, 0x66, 0x29, 0x2c, 0x21, 0xea, 0x2d, 0xc8, 0x60, 0xd2, 0x95, 0x47, 0x52, 0x4d, 0x51,
+	0x3a, 0x82, 0x7a, 0x1e, 0xdf, 0xa5, 0xf4, 0xe4, 0x2e, 0x39, 0x27, 0xb0, 0x7a, 0x14, 0x30, 0x41,
+	0xa1, 0xbb, 0xcc, 0x16, 0xe9, 0x3c, 0x86, 0xda, 0x24, 0xac, 0xa9, 0xd8, 0x7d, 0xc8, 0x46, 0xc2,
+	0x36, 0x5d, 0xf0, 0x63, 0xab, 0xa0, 0x5d, 0xac, 0x30, 0x8e, 0x0b, 0x45, 0x61, 0x9c, 0x0c, 0xc5,
+	0x16, 0x90, 0xb9, 0xda, 0x3e, 0x82, 0x0a, 0xf9, 0x69, 0x48, 0x7c, 0x4e, 0x3a, 0x6e, 0x42, 0x64,
+	0x39, 0x76, 0xca, 0xf6, 0x82, 0xde, 0x83, 0x42, 0x48, 0x5e, 0xea, 0xdf, 0xf5, 0x5d, 0xcd, 0x0b,
+	0x5b, 0x75, 0x9e, 0x7f, 0xd3, 0xb0, 0xa6, 0xc3, 0x2f, 0xb9, 0x14, 0xe8, 0x00, 0xf2, 0x23, 0x15,
+	0x38, 0x1e, 0xb6, 0xb6, 0xec, 0x94, 0x1b, 0x36, 0x31, 0x78, 0x6e, 0x43, 0xc9, 0x2c, 0xbf, 0xa1,
+	0x64, 0xaf, 0xb9, 0xa1, 0x34, 0x01, 0x25, 0xeb, 0xfe, 0xda, 0xa6, 0xf2, 0x1c, 0x2a, 0x8a, 0xf6,
+	0x41, 0xf0, 0x3f, 0xde, 0x10, 0xf1, 0x0c, 0x96, 0x99, 0xcc, 0x60, 0xa7, 0xb0, 0x2e, 0x4f, 0xec,
+	0x38, 0xf6, 0x52, 0xaf, 0x43, 0x04, 0x1b, 0xb3, 0xc1, 0x8d, 0xd0, 0x6f, 0x61, 0x55, 0xef, 0x67,
+	0x37, 0x88, 0x37, 0x55, 0xdf, 0x0f, 0x9b, 0xf9, 0x6e, 0xaa, 0x10, 0xb8, 0xc2, 0x93, 0x66, 0xfb,
+	0x9f, 0x22, 0xac, 0xa9, 0x5d, 0x3f, 0x96, 0x1f, 0x68, 0xc7, 0x3a, 0x0e, 0xfa, 0x2d, 0x05, 0xab,
+	0x33, 0x2d, 0x0d, 0xdd, 0xb3, 0x48, 0x35, 0xbf, 0x8f, 0x37, 0xee, 0xbf, 0x09, 0xd4, 0x48, 0xbf,
+	0x80, 0xe2, 0xf8, 0x03, 0x0b, 0xd9, 0x9c, 0xa6, 0xd9, 0xcf, 0xbf, 0xc6, 0x9d, 0xc5, 0x40, 0x26,
+	0xef, 0xef, 0x29, 0x35, 0x1b, 0x26, 0x66, 0x46, 0x35, 0x07, 0x33, 0xdb, 0x3a, 0xcc, 0x99, 0x94,
+	0x6d, 0xeb, 0x30, 0x77, 0xdc, 0xfd, 0x35, 0x05, 0x28, 0x39, 0x37, 0x1a, 0x36, 0x77, 0xed, 0x42,
+	0x5e, 0x1a, 0x71, 0x1b, 0xbb, 0x8b, 0x03, 0x0d, 0x93, 0x81, 0xfc, 0x36, 0x97, 0x03, 0x21, 0xba,
+	0x6d, 0x75, 0xc6, 0x13, 0x03, 0x69, 0x63, 0x7b, 0x01, 0x84, 0x49, 0x77, 0x0e, 0x59, 0x39, 0x1a,
+	0x22, 0x9b, 0x09, 0x3f, 0x31, 0x96, 0x36, 0x5a, 0xd6, 0xeb, 0x27, 0xba, 0xf4, 0xd0, 0x62, 0xa5,
+	0x6b, 0x6a, 0x72, 0xb4, 0xd2, 0x35, 0x33, 0x11, 0x31, 0x28, 0xc4, 0x2f, 0x3f, 0xd4, 0xb6, 0x80,
+	0xcf, 0xbc, 0x80, 0x1b, 0x3b, 0x0b, 0x61, 0x4c, 0xd2, 0x57, 0x00, 0x93, 0x3e, 0x8a, 0xee, 0x58,
+	0x7d, 0x63, 0xcc, 0xbc, 0xee, 0x1a, 0x9f, 0x2d, 0x88, 0x32, 0xa9, 0x7f, 0x49, 0x41, 0x75, 0xba,
+	0xbd, 0xa1, 0x5d, 0x4b, 0x09, 0x97, 0xda, 0x6d, 0xe3, 0xde, 0x1b, 0x20, 0x35, 0x8f, 0xbd, 0xe6,
+	0xf7, 0x5b, 0x57, 0xfd, 0x67, 0xe5, 0xc6, 0xd8, 0xcf, 0x27, 0xbe, 0xb3, 0x9c, 0xfa, 0xd3, 0x6a,
+	0xe7, 0x3f, 0xce, 0xec, 0x8a, 0xe8, 0xea, 0x12, 0x00, 0x00,
 }
@@ -0,0 +1,408 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bundle reads and writes bundles, single files holding a set of refs along with the chunks reachable from
+// them, which allow a repository to be moved without a reachable remote.
+//
+// A bundle file is laid out as:
+//
+//	Magic          // "DOLTBNDL"
+//	Version        // 4-byte bundle format version
+//	NomsVersion    // 2-byte length followed by the noms bin format version string
+//	HeadCount      // 4-byte count
+//	Heads          // for each head a 2-byte length and ref name, followed by the 20-byte hash of its commit
+//	PrereqCount    // 4-byte count
+//	Prereqs        // 20-byte hash of each commit whose chunks were left out of the bundle
+//	Chunks         // for each chunk its 20-byte hash, 4-byte length, and data
+//	Terminator     // 20 zero bytes followed by the 4-byte count of chunks in the bundle
+//
+// All integers are big endian.
+package bundle
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+const (
+	// Extension is the file extension conventionally used for bundles
+	Extension = ".bundle"
+
+	magic         = "DOLTBNDL"
+	formatVersion = uint32(1)
+
+	// maxChunkSize is the largest chunk read from a bundle, which is far larger than any chunk noms writes
+	maxChunkSize = 256 * 1024 * 1024
+
+	// terminatorSize is the size of the empty hash and chunk count which end a bundle
+	terminatorSize = hash.ByteLen + 4
+)
+
+var ErrNotABundle = errors.New("file is not a dolt bundle")
+var ErrUnsupportedVersion = errors.New("unsupported bundle version")
+var ErrTruncated = errors.New("bundle is truncated")
+
+// Head is a ref stored in a bundle, and the commit it points to.
+type Head struct {
+	Name string
+	Hash hash.Hash
+}
+
+// Header is the description of a bundle's contents which precedes its chunks.
+type Header struct {
+	// NomsVersion is the version of the noms bin format the chunks were written with
+	NomsVersion string
+
+	// Heads are the refs in the bundle
+	Heads []Head
+
+	// Prereqs are the commits whose chunks, and the chunks of their ancestors, are not in the bundle. They must
+	// already be in a repository for the bundle to be read into it.
+	Prereqs []hash.Hash
+}
+
+// Writer writes a bundle. The header is written when the Writer is created, after which each chunk is written with
+// WriteChunk, and Close writes the terminator.
+type Writer struct {
+	wr        *bufio.Writer
+	numChunks uint32
+}
+
+// NewWriter writes the bundle header given and returns a Writer for the bundle's chunks.
+func NewWriter(wr io.Writer, hdr *Header) (*Writer, error) {
+	bw := bufio.NewWriter(wr)
+
+	err := writeHeader(bw, hdr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Writer{wr: bw}, nil
+}
+
+func writeHeader(wr io.Writer, hdr *Header) error {
+	if _, err := io.WriteString(wr, magic); err != nil {
+		return err
+	}
+
+	if err := binary.Write(wr, binary.BigEndian, formatVersion); err != nil {
+		return err
+	}
+
+	if err := writeString(wr, hdr.NomsVersion); err != nil {
+		return err
+	}
+
+	if err := binary.Write(wr, binary.BigEndian, uint32(len(hdr.Heads))); err != nil {
+		return err
+	}
+
+	for _, head := range hdr.Heads {
+		if err := writeString(wr, head.Name); err != nil {
+			return err
+		}
+
+		if _, err := wr.Write(head.Hash[:]); err != nil {
+			return err
+		}
+	}
+
+	if err := binary.Write(wr, binary.BigEndian, uint32(len(hdr.Prereqs))); err != nil {
+		return err
+	}
+
+	for _, h := range hdr.Prereqs {
+		if _, err := wr.Write(h[:]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeString(wr io.Writer, str string) error {
+	if len(str) > math.MaxUint16 {
+		return fmt.Errorf("'%s' is too long to be written to a bundle", str)
+	}
+
+	if err := binary.Write(wr, binary.BigEndian, uint16(len(str))); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(wr, str)
+	return err
+}
+
+// WriteChunk writes a chunk to the bundle.
+func (bw *Writer) WriteChunk(c chunks.Chunk) error {
+	h := c.Hash()
+	if _, err := bw.wr.Write(h[:]); err != nil {
+		return err
+	}
+
+	if err := binary.Write(bw.wr, binary.BigEndian, uint32(len(c.Data()))); err != nil {
+		return err
+	}
+
+	if _, err := bw.wr.Write(c.Data()); err != nil {
+		return err
+	}
+
+	bw.numChunks++
+	return nil
+}
+
+// NumChunks returns the number of chunks written so far.
+func (bw *Writer) NumChunks() uint32 {
+	return bw.numChunks
+}
+
+// Close writes the terminator and flushes the bundle. It does not close the underlying writer.
+func (bw *Writer) Close() error {
+	var empty hash.Hash
+	if _, err := bw.wr.Write(empty[:]); err != nil {
+		return err
+	}
+
+	if err := binary.Write(bw.wr, binary.BigEndian, bw.numChunks); err != nil {
+		return err
+	}
+
+	return bw.wr.Flush()
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	rd io.Reader
+	n  int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.rd.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// Reader reads a bundle. The header is read when the Reader is created, after which the chunks are read with
+// ReadChunks.
+type Reader struct {
+	Header *Header
+	rd     *countingReader
+
+	// the number of bytes in the bundle, or -1 if it is not known
+	size int64
+}
+
+// NewReader reads the header of a bundle and returns a Reader for its chunks. If the reader given is an io.Seeker, the
+// length of each chunk is checked against the length of the bundle before it is read.
+func NewReader(rd io.Reader) (*Reader, error) {
+	size, err := remainingSize(rd)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cr := &countingReader{rd: bufio.NewReader(rd)}
+	hdr, err := ReadHeader(cr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{hdr, cr, size}, nil
+}
+
+// remainingSize returns the number of bytes left to read from a reader which is an io.Seeker, or -1 for any other
+// reader.
+func remainingSize(rd io.Reader) (int64, error) {
+	seeker, ok := rd.(io.Seeker)
+
+	if !ok {
+		return -1, nil
+	}
+
+	pos, err := seeker.Seek(0, io.SeekCurrent)
+
+	if err != nil {
+		return 0, err
+	}
+
+	end, err := seeker.Seek(0, io.SeekEnd)
+
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = seeker.Seek(pos, io.SeekStart)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return end - pos, nil
+}
+
+// ReadChunks calls cb with each of the bundle's chunks in the order they were written. The hash of each chunk is
+// checked against its data, and the count of chunks against the terminator, so an error is returned for a corrupt or
+// truncated bundle.
+func (br *Reader) ReadChunks(cb func(c chunks.Chunk) error) error {
+	return br.readChunks(func(c chunks.Chunk, offset int64) error {
+		return cb(c)
+	})
+}
+
+// readChunks calls cb with each of the bundle's chunks and the offset of its data from the start of the bundle.
+func (br *Reader) readChunks(cb func(c chunks.Chunk, offset int64) error) error {
+	var numChunks uint32
+	for {
+		var h hash.Hash
+		if _, err := io.ReadFull(br.rd, h[:]); err != nil {
+			return truncatedIfEOF(err)
+		}
+
+		if h.IsEmpty() {
+			break
+		}
+
+		var size uint32
+		if err := binary.Read(br.rd, binary.BigEndian, &size); err != nil {
+			return truncatedIfEOF(err)
+		}
+
+		if size > maxChunkSize {
+			return fmt.Errorf("bundle is corrupt: chunk %s has length %d", h.String(), size)
+		} else if br.size >= 0 && int64(size) > br.size-br.rd.n-terminatorSize {
+			return ErrTruncated
+		}
+
+		offset := br.rd.n
+		data := make([]byte, size)
+		if _, err := io.ReadFull(br.rd, data); err != nil {
+			return truncatedIfEOF(err)
+		}
+
+		c := chunks.NewChunk(data)
+
+		if c.Hash() != h {
+			return fmt.Errorf("bundle is corrupt: chunk %s has hash %s", h.String(), c.Hash().String())
+		}
+
+		if err := cb(c, offset); err != nil {
+			return err
+		}
+
+		numChunks++
+	}
+
+	var expected uint32
+	if err := binary.Read(br.rd, binary.BigEndian, &expected); err != nil {
+		return truncatedIfEOF(err)
+	}
+
+	if expected != numChunks {
+		return fmt.Errorf("bundle is corrupt: expected %d chunks but read %d", expected, numChunks)
+	}
+
+	return nil
+}
+
+// ReadHeader reads the header from the start of a bundle.
+func ReadHeader(rd io.Reader) (*Header, error) {
+	var magicBytes [len(magic)]byte
+	if _, err := io.ReadFull(rd, magicBytes[:]); err != nil || string(magicBytes[:]) != magic {
+		return nil, ErrNotABundle
+	}
+
+	var version uint32
+	if err := binary.Read(rd, binary.BigEndian, &version); err != nil {
+		return nil, truncatedIfEOF(err)
+	} else if version != formatVersion {
+		return nil, ErrUnsupportedVersion
+	}
+
+	var hdr Header
+	var err error
+	if hdr.NomsVersion, err = readString(rd); err != nil {
+		return nil, err
+	}
+
+	var numHeads uint32
+	if err := binary.Read(rd, binary.BigEndian, &numHeads); err != nil {
+		return nil, truncatedIfEOF(err)
+	}
+
+	for i := uint32(0); i < numHeads; i++ {
+		var head Head
+		if head.Name, err = readString(rd); err != nil {
+			return nil, err
+		}
+
+		if _, err := io.ReadFull(rd, head.Hash[:]); err != nil {
+			return nil, truncatedIfEOF(err)
+		}
+
+		hdr.Heads = append(hdr.Heads, head)
+	}
+
+	var numPrereqs uint32
+	if err := binary.Read(rd, binary.BigEndian, &numPrereqs); err != nil {
+		return nil, truncatedIfEOF(err)
+	}
+
+	for i := uint32(0); i < numPrereqs; i++ {
+		var h hash.Hash
+		if _, err := io.ReadFull(rd, h[:]); err != nil {
+			return nil, truncatedIfEOF(err)
+		}
+
+		hdr.Prereqs = append(hdr.Prereqs, h)
+	}
+
+	return &hdr, nil
+}
+
+func readString(rd io.Reader) (string, error) {
+	var size uint16
+	if err := binary.Read(rd, binary.BigEndian, &size); err != nil {
+		return "", truncatedIfEOF(err)
+	}
+
+	// the string is read as it is found, rather than allocating the length read up front
+	data, err := ioutil.ReadAll(io.LimitReader(rd, int64(size)))
+
+	if err != nil {
+		return "", err
+	} else if len(data) != int(size) {
+		return "", ErrTruncated
+	}
+
+	return string(data), nil
+}
+
+func truncatedIfEOF(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrTruncated
+	}
+
+	return err
+}
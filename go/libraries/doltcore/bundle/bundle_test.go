@@ -0,0 +1,150 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+const testRef = "refs/heads/master"
+
+// commitStrings commits a list holding numVals strings to the test ref, and returns the hash of the commit.
+func commitStrings(t *testing.T, db datas.Database, numVals int) hash.Hash {
+	ctx := context.Background()
+	vals := make([]types.Value, numVals)
+	for i := range vals {
+		vals[i] = types.String("value " + strconv.Itoa(i))
+	}
+
+	l, err := types.NewList(ctx, db, vals...)
+	require.NoError(t, err)
+
+	ds, err := db.GetDataset(ctx, testRef)
+	require.NoError(t, err)
+
+	ds, err = db.CommitValue(ctx, ds, l)
+	require.NoError(t, err)
+
+	r, ok, err := ds.MaybeHeadRef()
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	return r.TargetHash()
+}
+
+func TestCreateAndLoad(t *testing.T) {
+	ctx := context.Background()
+	storage := &chunks.MemoryStorage{}
+	db := datas.NewDatabase(storage.NewView())
+
+	first := commitStrings(t, db, 2000)
+	second := commitStrings(t, db, 4000)
+	heads := []Head{{testRef, second}}
+
+	full := &bytes.Buffer{}
+	numFull, err := Create(ctx, db, full, heads, nil)
+	require.NoError(t, err)
+
+	incremental := &bytes.Buffer{}
+	numIncremental, err := Create(ctx, db, incremental, heads, []hash.Hash{first})
+	require.NoError(t, err)
+	assert.True(t, numIncremental < numFull)
+
+	hdr, err := Verify(ctx, bytes.NewReader(full.Bytes()), nil)
+	require.NoError(t, err)
+	assert.Equal(t, heads, hdr.Heads)
+	assert.Empty(t, hdr.Prereqs)
+
+	_, err = Verify(ctx, bytes.NewReader(incremental.Bytes()), nil)
+	assert.Error(t, err)
+
+	hdr, err = Verify(ctx, bytes.NewReader(incremental.Bytes()), db)
+	require.NoError(t, err)
+	assert.Equal(t, []hash.Hash{first}, hdr.Prereqs)
+
+	cs, _, err := Load(ctx, bytes.NewReader(full.Bytes()), int64(full.Len()))
+	require.NoError(t, err)
+
+	bundleDB := datas.NewDatabase(cs)
+	ds, err := bundleDB.GetDataset(ctx, testRef)
+	require.NoError(t, err)
+
+	r, ok, err := ds.MaybeHeadRef()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, second, r.TargetHash())
+
+	v, ok, err := ds.MaybeHeadValue()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, uint64(4000), v.(types.List).Len())
+
+	err = datas.Pull(ctx, bundleDB, datas.NewDatabase((&chunks.MemoryStorage{}).NewView()), r, nil)
+	assert.NoError(t, err)
+}
+
+func TestReadErrors(t *testing.T) {
+	ctx := context.Background()
+	storage := &chunks.MemoryStorage{}
+	db := datas.NewDatabase(storage.NewView())
+	h := commitStrings(t, db, 10)
+
+	buf := &bytes.Buffer{}
+	_, err := Create(ctx, db, buf, []Head{{testRef, h}}, nil)
+	require.NoError(t, err)
+
+	_, err = Verify(ctx, strings.NewReader("not a bundle"), nil)
+	assert.Equal(t, ErrNotABundle, err)
+
+	data := buf.Bytes()
+	_, err = Verify(ctx, bytes.NewReader(data[:len(data)-1]), nil)
+	assert.Equal(t, ErrTruncated, err)
+
+	_, _, err = Load(ctx, bytes.NewReader(data[:len(data)-1]), int64(len(data)-1))
+	assert.Equal(t, ErrTruncated, err)
+
+	// a chunk length longer than the rest of the bundle is found to be truncated without reading it
+	h = commitStrings(t, db, 1)
+	hdr := &Header{NomsVersion: db.Format().VersionString(), Heads: []Head{{testRef, h}}}
+	buf = &bytes.Buffer{}
+	require.NoError(t, writeHeader(buf, hdr))
+	buf.Write(h[:])
+	buf.Write([]byte{0x00, 0xff, 0xff, 0xff})
+	_, err = Verify(ctx, bytes.NewReader(buf.Bytes()), nil)
+	assert.Equal(t, ErrTruncated, err)
+
+	buf.Truncate(buf.Len() - 4)
+	buf.Write([]byte{0xff, 0xff, 0xff, 0xff})
+	_, err = Verify(ctx, bytes.NewReader(buf.Bytes()), nil)
+	assert.Error(t, err)
+	assert.NotEqual(t, ErrTruncated, err)
+
+	corrupt := append([]byte{}, data...)
+	corrupt[len(corrupt)-30] ^= 0xff
+	_, err = Verify(ctx, bytes.NewReader(corrupt), nil)
+	assert.Error(t, err)
+}
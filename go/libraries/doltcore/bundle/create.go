@@ -0,0 +1,130 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// the number of chunks read from the ValueReader at a time while walking
+const walkBatchSize = 4096
+
+// Create writes a bundle holding the heads given and every chunk reachable from them which is not also reachable from
+// one of the prerequisite commits. Returns the number of chunks written.
+func Create(ctx context.Context, vr types.ValueReader, wr io.Writer, heads []Head, prereqs []hash.Hash) (uint32, error) {
+	excluded := hash.HashSet{}
+	err := walkChunks(ctx, vr, prereqs, nil, func(h hash.Hash, v types.Value) error {
+		excluded.Insert(h)
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	bw, err := NewWriter(wr, &Header{NomsVersion: vr.Format().VersionString(), Heads: heads, Prereqs: prereqs})
+
+	if err != nil {
+		return 0, err
+	}
+
+	headHashes := make([]hash.Hash, len(heads))
+	for i, head := range heads {
+		headHashes[i] = head.Hash
+	}
+
+	err = walkChunks(ctx, vr, headHashes, excluded, func(h hash.Hash, v types.Value) error {
+		c, err := types.EncodeValue(v, vr.Format())
+
+		if err != nil {
+			return err
+		}
+
+		return bw.WriteChunk(c)
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	err = bw.Close()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return bw.NumChunks(), nil
+}
+
+// walkChunks walks the chunk graph breadth first from the roots given, calling cb once for each chunk reached. Chunks in
+// skip, and those reachable only through them, are not visited.
+func walkChunks(ctx context.Context, vr types.ValueReader, roots []hash.Hash, skip hash.HashSet, cb func(h hash.Hash, v types.Value) error) error {
+	visited := hash.HashSet{}
+	level := roots
+	for len(level) > 0 {
+		var toRead hash.HashSlice
+		for _, h := range level {
+			if !visited.Has(h) && !skip.Has(h) {
+				visited.Insert(h)
+				toRead = append(toRead, h)
+			}
+		}
+
+		var next []hash.Hash
+		for start := 0; start < len(toRead); start += walkBatchSize {
+			end := start + walkBatchSize
+			if end > len(toRead) {
+				end = len(toRead)
+			}
+
+			batch := toRead[start:end]
+			vals, err := vr.ReadManyValues(ctx, batch)
+
+			if err != nil {
+				return err
+			}
+
+			for i, v := range vals {
+				if v == nil {
+					return fmt.Errorf("chunk %s not found", batch[i].String())
+				}
+
+				err = cb(batch[i], v)
+
+				if err != nil {
+					return err
+				}
+
+				err = v.WalkRefs(vr.Format(), func(r types.Ref) error {
+					next = append(next, r.TargetHash())
+					return nil
+				})
+
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		level = next
+	}
+
+	return nil
+}
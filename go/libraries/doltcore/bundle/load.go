@@ -0,0 +1,174 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// Load reads the index of a bundle of the size given and returns a ChunkStore which reads the bundle's chunks from it
+// as they are requested. Each chunk is checked against its hash while the index is read. The root of the ChunkStore is
+// a map from the names of the bundle's heads to their commits, so a database created from it has a dataset for each
+// head. Closing the ChunkStore closes rd if it is an io.Closer.
+func Load(ctx context.Context, rd io.ReaderAt, size int64) (chunks.ChunkStore, *Header, error) {
+	br, err := NewReader(io.NewSectionReader(rd, 0, size))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	storage := &chunks.MemoryStorage{}
+	cs := &chunkStore{rd: rd, locations: make(map[hash.Hash]chunkLocation), novel: storage.NewView()}
+
+	hdr := br.Header
+	err = br.readChunks(func(c chunks.Chunk, offset int64) error {
+		cs.locations[c.Hash()] = chunkLocation{offset, uint32(len(c.Data()))}
+		return nil
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if hdr.NomsVersion != cs.Version() {
+		return nil, nil, fmt.Errorf("bundle has noms version %s but %s is required", hdr.NomsVersion, cs.Version())
+	}
+
+	// The commits of an incremental bundle reference chunks which are not in it, so they can't be checked for
+	// completeness.
+	vs := types.NewValueStore(cs)
+	vs.SetEnforceCompleteness(false)
+
+	var kvs []types.Value
+	for _, head := range hdr.Heads {
+		v, err := vs.ReadValue(ctx, head.Hash)
+
+		if err != nil {
+			return nil, nil, err
+		} else if v == nil {
+			return nil, nil, fmt.Errorf("bundle is missing the commit for %s", head.Name)
+		}
+
+		r, err := types.NewRef(v, vs.Format())
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		r, err = types.ToRefOfValue(r, vs.Format())
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		kvs = append(kvs, types.String(head.Name), r)
+	}
+
+	datasets, err := types.NewMap(ctx, vs, kvs...)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, err := vs.WriteValue(ctx, datasets)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	success, err := vs.Commit(ctx, r.TargetHash(), hash.Hash{})
+
+	if err != nil {
+		return nil, nil, err
+	} else if !success {
+		return nil, nil, errors.New("failed to set the root of the bundle's chunk store")
+	}
+
+	return cs, hdr, nil
+}
+
+// Verify reads a bundle and checks that it is complete. Every chunk referenced by a chunk in the bundle must either be
+// in the bundle or be readable from vr, as must every head and prerequisite. If vr is nil only the chunks in the
+// bundle are considered, so an incremental bundle can't be verified without the repository it will be read into.
+func Verify(ctx context.Context, rd io.Reader, vr types.ValueReader) (*Header, error) {
+	inBundle := hash.HashSet{}
+	referenced := hash.HashSet{}
+	br, err := NewReader(rd)
+
+	if err != nil {
+		return nil, err
+	}
+
+	hdr := br.Header
+	nbf, err := types.GetFormatForVersionString(hdr.NomsVersion)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = br.ReadChunks(func(c chunks.Chunk) error {
+		inBundle.Insert(c.Hash())
+		return types.WalkRefs(c, nbf, func(r types.Ref) error {
+			referenced.Insert(r.TargetHash())
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, head := range hdr.Heads {
+		referenced.Insert(head.Hash)
+	}
+
+	for _, h := range hdr.Prereqs {
+		referenced.Insert(h)
+	}
+
+	var missing hash.HashSlice
+	for h := range referenced {
+		if !inBundle.Has(h) {
+			missing = append(missing, h)
+		}
+	}
+
+	if len(missing) == 0 {
+		return hdr, nil
+	} else if vr == nil {
+		return nil, fmt.Errorf("bundle is missing %d chunks, which must be in the repository it is read into", len(missing))
+	}
+
+	vals, err := vr.ReadManyValues(ctx, missing)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i, v := range vals {
+		if v == nil {
+			return nil, fmt.Errorf("chunk %s is neither in the bundle nor the repository", missing[i].String())
+		}
+	}
+
+	return hdr, nil
+}
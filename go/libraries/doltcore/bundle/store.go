@@ -0,0 +1,134 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"context"
+	"io"
+
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// chunkLocation is the offset and length of the data of a chunk within a bundle
+type chunkLocation struct {
+	offset int64
+	length uint32
+}
+
+// chunkStore is a ChunkStore which reads the chunks of a bundle from the bundle as they are requested, so only their
+// locations are held in memory. Chunks which are put, and the root, are kept in memory.
+type chunkStore struct {
+	rd        io.ReaderAt
+	locations map[hash.Hash]chunkLocation
+	novel     chunks.ChunkStore
+}
+
+var _ chunks.ChunkStore = (*chunkStore)(nil)
+
+func (cs *chunkStore) Get(ctx context.Context, h hash.Hash) (chunks.Chunk, error) {
+	loc, ok := cs.locations[h]
+
+	if !ok {
+		return cs.novel.Get(ctx, h)
+	}
+
+	data := make([]byte, loc.length)
+	_, err := cs.rd.ReadAt(data, loc.offset)
+
+	if err != nil {
+		return chunks.EmptyChunk, truncatedIfEOF(err)
+	}
+
+	return chunks.NewChunkWithHash(h, data), nil
+}
+
+func (cs *chunkStore) GetMany(ctx context.Context, hashes hash.HashSet, foundChunks chan *chunks.Chunk) error {
+	for h := range hashes {
+		c, err := cs.Get(ctx, h)
+
+		if err != nil {
+			return err
+		}
+
+		if !c.IsEmpty() {
+			foundChunks <- &c
+		}
+	}
+
+	return nil
+}
+
+func (cs *chunkStore) Has(ctx context.Context, h hash.Hash) (bool, error) {
+	if _, ok := cs.locations[h]; ok {
+		return true, nil
+	}
+
+	return cs.novel.Has(ctx, h)
+}
+
+func (cs *chunkStore) HasMany(ctx context.Context, hashes hash.HashSet) (hash.HashSet, error) {
+	notInBundle := hash.HashSet{}
+	for h := range hashes {
+		if _, ok := cs.locations[h]; !ok {
+			notInBundle.Insert(h)
+		}
+	}
+
+	return cs.novel.HasMany(ctx, notInBundle)
+}
+
+func (cs *chunkStore) Put(ctx context.Context, c chunks.Chunk) error {
+	return cs.novel.Put(ctx, c)
+}
+
+func (cs *chunkStore) Version() string {
+	return cs.novel.Version()
+}
+
+func (cs *chunkStore) Rebase(ctx context.Context) error {
+	return cs.novel.Rebase(ctx)
+}
+
+func (cs *chunkStore) Root(ctx context.Context) (hash.Hash, error) {
+	return cs.novel.Root(ctx)
+}
+
+func (cs *chunkStore) Commit(ctx context.Context, current, last hash.Hash) (bool, error) {
+	return cs.novel.Commit(ctx, current, last)
+}
+
+func (cs *chunkStore) Stats() interface{} {
+	return nil
+}
+
+func (cs *chunkStore) StatsSummary() string {
+	return "Unsupported"
+}
+
+// Close closes the bundle if it is an io.Closer.
+func (cs *chunkStore) Close() error {
+	err := cs.novel.Close()
+
+	if closer, ok := cs.rd.(io.Closer); ok {
+		closeErr := closer.Close()
+
+		if err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
@@ -44,6 +44,17 @@ const (
 	JWTAlgHeader = "alg"
 )
 
+const (
+	// RemoteAPIAudience is the audience of the bearer tokens sent to remotes
+	RemoteAPIAudience = "dolthub-remote-api.liquidata.co"
+
+	// ClientIssuer is the issuer of the bearer tokens sent to remotes
+	ClientIssuer = "dolt-client.liquidata.co"
+
+	// ClientSubjectPrefix prefixes the base32 key id in the subject of the bearer tokens sent to remotes
+	ClientSubjectPrefix = "doltClientCredentials/"
+)
+
 var B32CredsByteSet = set.NewByteSet([]byte(B32CharEncoding))
 var B32CredsEncoding = base32.NewEncoding(B32CharEncoding).WithPadding(base32.NoPadding)
 var EmptyCreds = DoltCreds{}
@@ -133,9 +144,9 @@ func (dc DoltCreds) toBearerToken() (string, error) {
 	// Shouldn't be hard coded
 	jwtBuilder := jwt.Signed(signer)
 	jwtBuilder = jwtBuilder.Claims(jwt.Claims{
-		Audience: []string{"dolthub-remote-api.liquidata.co"},
-		Issuer:   "dolt-client.liquidata.co",
-		Subject:  "doltClientCredentials/" + b32KIDStr,
+		Audience: []string{RemoteAPIAudience},
+		Issuer:   ClientIssuer,
+		Subject:  ClientSubjectPrefix + b32KIDStr,
 		Expiry:   jwt.NewNumericDate(datetime.Now().Add(30 * time.Second)),
 	})
 
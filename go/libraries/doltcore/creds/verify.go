@@ -0,0 +1,71 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package creds
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/ed25519"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/liquidata-inc/dolt/go/store/util/datetime"
+)
+
+var ErrInvalidBearerToken = errors.New("invalid bearer token")
+var ErrUnknownKeyID = errors.New("bearer token signed with an unknown key")
+var ErrExpiredBearerToken = errors.New("bearer token has expired")
+
+// PubKeyLookup returns the public key with the base32 encoded key id given, and false if the key is unknown.
+type PubKeyLookup func(kid string) ([]byte, bool)
+
+// VerifyBearerToken verifies a bearer token created by DoltCreds.GetRequestMetadata, checking it is signed by the key
+// its kid header names and that it hasn't expired. Returns the base32 encoded key id of the key which signed it.
+func VerifyBearerToken(token string, lookup PubKeyLookup) (string, error) {
+	tok, err := jwt.ParseSigned(token)
+
+	if err != nil || len(tok.Headers) != 1 || tok.Headers[0].Algorithm != string(jose.EdDSA) {
+		return "", ErrInvalidBearerToken
+	}
+
+	kid := tok.Headers[0].KeyID
+	pub, ok := lookup(kid)
+
+	if !ok || len(pub) != pubKeySize {
+		return "", ErrUnknownKeyID
+	}
+
+	var claims jwt.Claims
+	err = tok.Claims(ed25519.PublicKey(pub), &claims)
+
+	if err != nil || claims.Expiry == nil {
+		return "", ErrInvalidBearerToken
+	}
+
+	err = claims.Validate(jwt.Expected{
+		Audience: jwt.Audience{RemoteAPIAudience},
+		Issuer:   ClientIssuer,
+		Subject:  ClientSubjectPrefix + kid,
+		Time:     datetime.Now().Time,
+	})
+
+	if err == jwt.ErrExpired {
+		return "", ErrExpiredBearerToken
+	} else if err != nil {
+		return "", ErrInvalidBearerToken
+	}
+
+	return kid, nil
+}
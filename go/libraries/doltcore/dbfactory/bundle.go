@@ -0,0 +1,65 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbfactory
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/bundle"
+	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// BundleFactory is a DBFactory implementation for reading bundle files.  The chunks of the bundle are read from the
+// file as they are needed, and the database created has a dataset for each ref in the bundle.  Writes to the database
+// are not persisted.
+type BundleFactory struct {
+}
+
+// CreateDB creates a database from the bundle file at the path of the url
+func (fact BundleFactory) CreateDB(ctx context.Context, nbf *types.NomsBinFormat, urlObj *url.URL, params map[string]string) (datas.Database, error) {
+	path := urlObj.Host + urlObj.Path
+
+	f, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	// the chunk store closes the file when the database is closed
+	cs, hdr, err := bundle.Load(ctx, f, info.Size())
+
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if hdr.NomsVersion != nbf.VersionString() {
+		cs.Close()
+		return nil, fmt.Errorf("bundle '%s' has noms version %s but %s is required", path, hdr.NomsVersion, nbf.VersionString())
+	}
+
+	return datas.NewDatabase(cs), nil
+}
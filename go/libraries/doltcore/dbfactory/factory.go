@@ -22,6 +22,7 @@ import (
 
 	"github.com/liquidata-inc/dolt/go/libraries/utils/earl"
 	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
 	"github.com/liquidata-inc/dolt/go/store/types"
 )
 
@@ -44,6 +45,9 @@ const (
 	// HTTPScheme
 	HTTPScheme = "http"
 
+	// BundleScheme
+	BundleScheme = "bundle"
+
 	defaultScheme       = HTTPSScheme
 	defaultMemTableSize = 256 * 1024 * 1024
 )
@@ -56,16 +60,21 @@ type DBFactory interface {
 // DBFactories is a map from url scheme name to DBFactory.  Additional factories can be added to the DBFactories map
 // from external packages.
 var DBFactories = map[string]DBFactory{
-	AWSScheme:  AWSFactory{},
-	GSScheme:   GSFactory{},
-	FileScheme: FileFactory{},
-	MemScheme:  MemFactory{},
+	AWSScheme:    AWSFactory{},
+	GSScheme:     GSFactory{},
+	FileScheme:   FileFactory{},
+	MemScheme:    MemFactory{},
+	BundleScheme: BundleFactory{},
 }
 
-// InitializeFactories initializes any factories that rely on a GRPCConnectionProvider (Namely http and https)
-func InitializeFactories(grpcCP GRPCConnectionProvider) {
-	DBFactories[HTTPScheme] = NewDoltRemoteFactory(grpcCP, true)
-	DBFactories[HTTPSScheme] = NewDoltRemoteFactory(grpcCP, false)
+// InitializeFactories initializes any factories that rely on a GRPCConnectionProvider (Namely http and https). Chunks
+// read from remotes are cached on disk in chunkCacheDir, up to chunkCacheSize bytes per remote host, unless
+// chunkCacheDir is empty or chunkCacheSize is 0. Chunks pushed to remotes are compressed with compression, and
+// encrypted with key unless it is nil. If cfgErr is not nil the configuration of remotes is invalid, and it is
+// returned when a remote database is created.
+func InitializeFactories(grpcCP GRPCConnectionProvider, chunkCacheDir string, chunkCacheSize uint64, compression nbs.ChunkCompression, key *nbs.EncryptionKey, cfgErr error) {
+	DBFactories[HTTPScheme] = NewDoltRemoteFactory(grpcCP, true).WithChunkCache(chunkCacheDir, chunkCacheSize).WithChunkCompression(compression).WithEncryptionKey(key).WithError(cfgErr)
+	DBFactories[HTTPSScheme] = NewDoltRemoteFactory(grpcCP, false).WithChunkCache(chunkCacheDir, chunkCacheSize).WithChunkCompression(compression).WithEncryptionKey(key).WithError(cfgErr)
 }
 
 // CreateDB creates a database based on the supplied urlStr, and creation params.  The DBFactory used for creation is
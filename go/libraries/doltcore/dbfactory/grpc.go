@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"path/filepath"
 
 	"google.golang.org/grpc"
 
@@ -25,6 +26,7 @@ import (
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/remotestorage"
 	"github.com/liquidata-inc/dolt/go/store/chunks"
 	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
 	"github.com/liquidata-inc/dolt/go/store/types"
 )
 
@@ -36,13 +38,46 @@ type GRPCConnectionProvider interface {
 // DoldRemoteFactory is a DBFactory implementation for creating databases backed by a remote server that implements the
 // GRPC rpcs defined by remoteapis.ChunkStoreServiceClient
 type DoltRemoteFactory struct {
-	grpcCP   GRPCConnectionProvider
-	insecure bool
+	grpcCP         GRPCConnectionProvider
+	insecure       bool
+	chunkCacheDir  string
+	chunkCacheSize uint64
+	compression    nbs.ChunkCompression
+	key            *nbs.EncryptionKey
+	err            error
 }
 
 // NewDoltRemoteFactory creates a DoltRemoteFactory instance using the given GRPCConnectionProvider, and insecure setting
 func NewDoltRemoteFactory(grpcCP GRPCConnectionProvider, insecure bool) DoltRemoteFactory {
-	return DoltRemoteFactory{grpcCP, insecure}
+	return DoltRemoteFactory{grpcCP, insecure, "", 0, nbs.SnappyCompression, nil, nil}
+}
+
+// WithChunkCache returns a DoltRemoteFactory whose databases cache the chunks they read on disk, in a directory per
+// remote host within the directory given. The cache of each host is limited to maxSize bytes.
+func (fact DoltRemoteFactory) WithChunkCache(dir string, maxSize uint64) DoltRemoteFactory {
+	return DoltRemoteFactory{fact.grpcCP, fact.insecure, dir, maxSize, fact.compression, fact.key, fact.err}
+}
+
+// WithChunkCompression returns a DoltRemoteFactory whose databases compress the chunks they upload with the compression
+// given.
+func (fact DoltRemoteFactory) WithChunkCompression(compression nbs.ChunkCompression) DoltRemoteFactory {
+	return DoltRemoteFactory{fact.grpcCP, fact.insecure, fact.chunkCacheDir, fact.chunkCacheSize, compression, fact.key, fact.err}
+}
+
+// WithEncryptionKey returns a DoltRemoteFactory whose databases encrypt the chunks they upload with the key given,
+// unless it is nil.
+func (fact DoltRemoteFactory) WithEncryptionKey(key *nbs.EncryptionKey) DoltRemoteFactory {
+	return DoltRemoteFactory{fact.grpcCP, fact.insecure, fact.chunkCacheDir, fact.chunkCacheSize, fact.compression, key, fact.err}
+}
+
+// WithError returns a DoltRemoteFactory which fails to create databases with the error given, which is returned for a
+// configuration of remotes which can't be used. A nil error is ignored.
+func (fact DoltRemoteFactory) WithError(err error) DoltRemoteFactory {
+	if err == nil {
+		return fact
+	}
+
+	return DoltRemoteFactory{fact.grpcCP, fact.insecure, fact.chunkCacheDir, fact.chunkCacheSize, fact.compression, fact.key, err}
 }
 
 // CreateDB creates a database backed by a remote server that implements the GRPC rpcs defined by
@@ -62,6 +97,10 @@ func (fact DoltRemoteFactory) CreateDB(ctx context.Context, nbf *types.NomsBinFo
 }
 
 func (fact DoltRemoteFactory) newChunkStore(ctx context.Context, nbf *types.NomsBinFormat, urlObj *url.URL, params map[string]string) (chunks.ChunkStore, error) {
+	if fact.err != nil {
+		return nil, fact.err
+	}
+
 	conn, err := fact.grpcCP.GrpcConn(urlObj.Host, fact.insecure)
 
 	if err != nil {
@@ -73,7 +112,19 @@ func (fact DoltRemoteFactory) newChunkStore(ctx context.Context, nbf *types.Noms
 
 	if err == remotestorage.ErrInvalidDoltSpecPath {
 		return nil, fmt.Errorf("invalid dolt url '%s'", urlObj.String())
+	} else if err != nil {
+		return nil, err
+	}
+
+	if fact.chunkCacheDir != "" && fact.chunkCacheSize > 0 {
+		diskCache, err := remotestorage.OpenDiskChunkCache(filepath.Join(fact.chunkCacheDir, urlObj.Host), fact.chunkCacheSize)
+
+		if err != nil {
+			return nil, err
+		}
+
+		cs = cs.WithDiskCache(diskCache)
 	}
 
-	return cs, err
+	return cs.WithChunkCompression(fact.compression).WithEncryptionKey(fact.key), nil
 }
@@ -92,7 +92,7 @@ func (ad *AsyncDiffer) GetDiffs(numDiffs int, timeout time.Duration) ([]*diff.Di
 					}
 				} else {
 					ad.isDone = true
-					return diffs, nil
+					return diffs, ad.ae.Get()
 				}
 
 			case <-timeoutChan:
@@ -57,12 +57,12 @@ func (dr *DiffRow) DiffType() DiffChType {
 type RowDiffSource struct {
 	oldConv      *rowconv.RowConverter
 	newConv      *rowconv.RowConverter
-	ad           *AsyncDiffer
+	ad           RowDiffer
 	outSch       schema.Schema
 	bufferedRows []pipeline.RowWithProps
 }
 
-func NewRowDiffSource(ad *AsyncDiffer, oldConv, newConv *rowconv.RowConverter, outSch schema.Schema) *RowDiffSource {
+func NewRowDiffSource(ad RowDiffer, oldConv, newConv *rowconv.RowConverter, outSch schema.Schema) *RowDiffSource {
 	return &RowDiffSource{
 		oldConv,
 		newConv,
@@ -85,7 +85,7 @@ func (rdRd *RowDiffSource) NextDiff() (row.Row, pipeline.ImmutableProperties, er
 		return rowWithProps.Row, rowWithProps.Props, nil
 	}
 
-	if rdRd.ad.isDone {
+	if rdRd.ad.IsDone() {
 		return nil, pipeline.NoProps, io.EOF
 	}
 
@@ -96,7 +96,7 @@ func (rdRd *RowDiffSource) NextDiff() (row.Row, pipeline.ImmutableProperties, er
 	}
 
 	if len(diffs) == 0 {
-		if rdRd.ad.isDone {
+		if rdRd.ad.IsDone() {
 			return nil, pipeline.NoProps, io.EOF
 		}
 
@@ -0,0 +1,711 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sql"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/iohelp"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/valutil"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// Names of the kinds of changes in the sql, json and csv diff formats
+const (
+	AddedDiffTypeName    = "added"
+	RemovedDiffTypeName  = "removed"
+	ModifiedDiffTypeName = "modified"
+)
+
+// CsvDiffTypeColName is the name of the column holding the kind of change made to each row in the csv diff format
+const CsvDiffTypeColName = "diff_type"
+
+// ErrCsvMultipleTables is returned when a csv diff is written for more than one table, as a csv file has a single set
+// of columns.
+var ErrCsvMultipleTables = errors.New("the csv diff format supports a single table, specify the table to diff")
+
+// DiffWriter writes the differences between two versions of a set of tables in some format. For each table which
+// differs, BeginTable is called, then WriteRowDiff for each row of the table which differs, then EndTable. When a table
+// was added oldSch is nil, and when a table was dropped newSch is nil.
+type DiffWriter interface {
+	BeginTable(ctx context.Context, tableName string, oldSch, newSch schema.Schema) error
+	WriteRowDiff(ctx context.Context, rd RowDiff) error
+	EndTable(ctx context.Context) error
+	Close(ctx context.Context) error
+}
+
+func rowDiffTypeName(rd RowDiff) string {
+	switch rd.DiffType() {
+	case DiffAdded:
+		return AddedDiffTypeName
+	case DiffRemoved:
+		return RemovedDiffTypeName
+	default:
+		return ModifiedDiffTypeName
+	}
+}
+
+// SqlDialect is the dialect of SQL a SqlDiffWriter writes
+type SqlDialect int
+
+const (
+	// MySqlDialect quotes identifiers with backticks and escapes backslashes in strings
+	MySqlDialect SqlDialect = iota
+	// PostgresDialect quotes identifiers with double quotes and leaves backslashes in strings as they are
+	PostgresDialect
+)
+
+// ErrUnknownSqlDialect is returned by ParseSqlDialect for a dialect other than mysql or postgres.
+var ErrUnknownSqlDialect = errors.New("unknown sql dialect")
+
+// ParseSqlDialect returns the dialect with the name given, either mysql or postgres.
+func ParseSqlDialect(name string) (SqlDialect, error) {
+	switch strings.ToLower(name) {
+	case "mysql":
+		return MySqlDialect, nil
+	case "postgres", "postgresql":
+		return PostgresDialect, nil
+	default:
+		return MySqlDialect, ErrUnknownSqlDialect
+	}
+}
+
+var postgresTypes = map[types.NomsKind]string{
+	types.StringKind: "text",
+	types.BoolKind:   "boolean",
+	types.FloatKind:  "double precision",
+	types.IntKind:    "bigint",
+	types.UintKind:   "numeric(20)",
+	types.UUIDKind:   "uuid",
+}
+
+// QuoteIdentifier quotes a table or column name.
+func (d SqlDialect) QuoteIdentifier(s string) string {
+	if d == PostgresDialect {
+		return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+	}
+
+	return "`" + strings.Replace(s, "`", "``", -1) + "`"
+}
+
+// quoteString returns the string literal for the string given.
+func (d SqlDialect) quoteString(s string) string {
+	if d == PostgresDialect {
+		return "'" + strings.Replace(s, `'`, `''`, -1) + "'"
+	}
+
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `''`).Replace(s) + "'"
+}
+
+// colType returns the type of a column.
+func (d SqlDialect) colType(col schema.Column) string {
+	if d == PostgresDialect {
+		return postgresTypes[col.Kind]
+	}
+
+	return sql.DoltToSQLType[col.Kind]
+}
+
+// colDefinition returns the definition of a column in a CREATE TABLE or ALTER TABLE ADD COLUMN statement.
+func (d SqlDialect) colDefinition(col schema.Column) string {
+	if d == MySqlDialect {
+		return sql.FmtCol(0, 0, 0, col)
+	}
+
+	def := d.QuoteIdentifier(col.Name) + " " + d.colType(col)
+	if !col.IsNullable() {
+		def += " NOT NULL"
+	}
+
+	return def
+}
+
+// createTableStmt returns the CREATE TABLE statement for a table with the schema given.
+func (d SqlDialect) createTableStmt(tableName string, sch schema.Schema) (string, error) {
+	if d == MySqlDialect {
+		return sql.SchemaAsCreateStmt(tableName, sch), nil
+	}
+
+	var defs []string
+	sch.GetAllCols().IterInSortedOrder(func(tag uint64, col schema.Column) (stop bool) {
+		defs = append(defs, "  "+d.colDefinition(col))
+		return false
+	})
+
+	var pks []string
+	err := sch.GetPKCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+		pks = append(pks, d.QuoteIdentifier(col.Name))
+		return false, nil
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(pks) > 0 {
+		defs = append(defs, "  PRIMARY KEY ("+strings.Join(pks, ",")+")")
+	}
+
+	return "CREATE TABLE " + d.QuoteIdentifier(tableName) + " (\n" + strings.Join(defs, ",\n") + "\n);", nil
+}
+
+// SqlDiffWriter writes a diff as SQL statements which, when applied to a database holding the old version of the
+// tables, produce the new version. Schema changes are written as CREATE, DROP and ALTER TABLE statements, followed by
+// INSERT, UPDATE and DELETE statements for the rows which changed.
+type SqlDiffWriter struct {
+	wr        io.WriteCloser
+	dialect   SqlDialect
+	tableName string
+	sch       schema.Schema
+}
+
+// NewSqlDiffWriter returns a SqlDiffWriter writing statements in the dialect given to the writer given.
+func NewSqlDiffWriter(wr io.WriteCloser, dialect SqlDialect) *SqlDiffWriter {
+	return &SqlDiffWriter{wr: wr, dialect: dialect}
+}
+
+// BeginTable writes the statements needed to change the table's schema.
+func (w *SqlDiffWriter) BeginTable(ctx context.Context, tableName string, oldSch, newSch schema.Schema) error {
+	w.tableName = tableName
+	w.sch = newSch
+
+	var stmts []string
+	if newSch == nil {
+		stmts = []string{"DROP TABLE " + w.dialect.QuoteIdentifier(tableName) + ";"}
+	} else if oldSch == nil {
+		stmt, err := w.dialect.createTableStmt(tableName, newSch)
+
+		if err != nil {
+			return err
+		}
+
+		stmts = []string{stmt}
+	} else {
+		var err error
+		stmts, err = SchemaDiffSqlStatements(tableName, oldSch, newSch, w.dialect)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, stmt := range stmts {
+		if err := iohelp.WriteLine(w.wr, stmt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteRowDiff writes the statement which changes the row. The rows of dropped tables are not written.
+func (w *SqlDiffWriter) WriteRowDiff(ctx context.Context, rd RowDiff) error {
+	if w.sch == nil {
+		return nil
+	}
+
+	stmt, err := RowDiffSqlStatement(ctx, w.tableName, w.sch, rd, w.dialect)
+
+	if err != nil || stmt == "" {
+		return err
+	}
+
+	return iohelp.WriteLine(w.wr, stmt)
+}
+
+// EndTable implements DiffWriter.
+func (w *SqlDiffWriter) EndTable(ctx context.Context) error {
+	return nil
+}
+
+// Close closes the underlying writer.
+func (w *SqlDiffWriter) Close(ctx context.Context) error {
+	return w.wr.Close()
+}
+
+// SchemaDiffSqlStatements returns the ALTER TABLE statements which change a table with the old schema given into a
+// table with the new schema given. Columns are matched by tag.
+func SchemaDiffSqlStatements(tableName string, oldSch, newSch schema.Schema, dialect SqlDialect) ([]string, error) {
+	diffs, err := DiffSchemas(oldSch, newSch)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]uint64, 0, len(diffs))
+	for tag := range diffs {
+		tags = append(tags, tag)
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i] < tags[j]
+	})
+
+	alter := "ALTER TABLE " + dialect.QuoteIdentifier(tableName) + " "
+
+	var stmts []string
+	for _, tag := range tags {
+		dff := diffs[tag]
+		switch dff.DiffType {
+		case SchDiffColAdded:
+			stmts = append(stmts, alter+"ADD COLUMN "+dialect.colDefinition(*dff.New)+";")
+		case SchDiffColRemoved:
+			stmts = append(stmts, alter+"DROP COLUMN "+dialect.QuoteIdentifier(dff.Old.Name)+";")
+		case SchDiffColModified:
+			if dff.Old.Name != dff.New.Name {
+				stmts = append(stmts, alter+"RENAME COLUMN "+dialect.QuoteIdentifier(dff.Old.Name)+" TO "+dialect.QuoteIdentifier(dff.New.Name)+";")
+			}
+
+			renamed := *dff.Old
+			renamed.Name = dff.New.Name
+			if renamed.Equals(*dff.New) {
+				continue
+			}
+
+			if dialect == MySqlDialect {
+				stmts = append(stmts, alter+"MODIFY COLUMN "+dialect.colDefinition(*dff.New)+";")
+				continue
+			}
+
+			col := alter + "ALTER COLUMN " + dialect.QuoteIdentifier(dff.New.Name) + " "
+			if dff.Old.Kind != dff.New.Kind {
+				stmts = append(stmts, col+"TYPE "+dialect.colType(*dff.New)+";")
+			}
+
+			if dff.Old.IsNullable() && !dff.New.IsNullable() {
+				stmts = append(stmts, col+"SET NOT NULL;")
+			} else if !dff.Old.IsNullable() && dff.New.IsNullable() {
+				stmts = append(stmts, col+"DROP NOT NULL;")
+			}
+		}
+	}
+
+	return stmts, nil
+}
+
+// RowDiffSqlStatement returns the INSERT, UPDATE or DELETE statement which applies the row diff given to a table with
+// the schema given. Returns an empty string for a modified row none of whose columns in the schema changed.
+func RowDiffSqlStatement(ctx context.Context, tableName string, sch schema.Schema, rd RowDiff, dialect SqlDialect) (string, error) {
+	var b strings.Builder
+	switch rd.DiffType() {
+	case DiffAdded:
+		var names, vals []string
+		err := sch.GetAllCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+			val, _ := rd.New.GetColVal(tag)
+			lit, err := sqlLiteral(ctx, val, dialect)
+
+			if err != nil {
+				return true, err
+			}
+
+			names = append(names, dialect.QuoteIdentifier(col.Name))
+			vals = append(vals, lit)
+			return false, nil
+		})
+
+		if err != nil {
+			return "", err
+		}
+
+		b.WriteString("INSERT INTO " + dialect.QuoteIdentifier(tableName))
+		b.WriteString(" (" + strings.Join(names, ",") + ")")
+		b.WriteString(" VALUES (" + strings.Join(vals, ",") + ");")
+
+	case DiffRemoved:
+		where, err := pkWhereClause(ctx, sch, rd.Old, dialect)
+
+		if err != nil {
+			return "", err
+		}
+
+		b.WriteString("DELETE FROM " + dialect.QuoteIdentifier(tableName) + " WHERE " + where + ";")
+
+	default:
+		var sets []string
+		err := sch.GetNonPKCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+			oldVal, _ := rd.Old.GetColVal(tag)
+			newVal, _ := rd.New.GetColVal(tag)
+
+			if valutil.NilSafeEqCheck(oldVal, newVal) {
+				return false, nil
+			}
+
+			lit, err := sqlLiteral(ctx, newVal, dialect)
+
+			if err != nil {
+				return true, err
+			}
+
+			sets = append(sets, dialect.QuoteIdentifier(col.Name)+" = "+lit)
+			return false, nil
+		})
+
+		if err != nil {
+			return "", err
+		}
+
+		if len(sets) == 0 {
+			return "", nil
+		}
+
+		where, err := pkWhereClause(ctx, sch, rd.New, dialect)
+
+		if err != nil {
+			return "", err
+		}
+
+		b.WriteString("UPDATE " + dialect.QuoteIdentifier(tableName) + " SET " + strings.Join(sets, ", ") + " WHERE " + where + ";")
+	}
+
+	return b.String(), nil
+}
+
+func pkWhereClause(ctx context.Context, sch schema.Schema, r row.Row, dialect SqlDialect) (string, error) {
+	var conds []string
+	err := sch.GetPKCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+		val, _ := r.GetColVal(tag)
+		lit, err := sqlLiteral(ctx, val, dialect)
+
+		if err != nil {
+			return true, err
+		}
+
+		conds = append(conds, dialect.QuoteIdentifier(col.Name)+" = "+lit)
+		return false, nil
+	})
+
+	return strings.Join(conds, " AND "), err
+}
+
+// sqlLiteral returns the SQL literal for a value in the dialect given.
+func sqlLiteral(ctx context.Context, val types.Value, dialect SqlDialect) (string, error) {
+	if types.IsNull(val) {
+		return "NULL", nil
+	}
+
+	switch val.Kind() {
+	case types.BoolKind:
+		if val.(types.Bool) {
+			return "TRUE", nil
+		}
+
+		return "FALSE", nil
+	case types.IntKind, types.UintKind, types.FloatKind:
+		str, _, err := valueAsString(ctx, val)
+		return str, err
+	default:
+		str, _, err := valueAsString(ctx, val)
+
+		if err != nil {
+			return "", err
+		}
+
+		return dialect.quoteString(str), nil
+	}
+}
+
+// JsonDiffWriter writes a diff as a JSON document with an entry for each table which changed, holding the changes to
+// the table's columns and the old and new values of each row which changed.
+type JsonDiffWriter struct {
+	wr         io.WriteCloser
+	oldSch     schema.Schema
+	newSch     schema.Schema
+	wroteTable bool
+	wroteRow   bool
+}
+
+// NewJsonDiffWriter returns a JsonDiffWriter writing to the writer given.
+func NewJsonDiffWriter(wr io.WriteCloser) *JsonDiffWriter {
+	return &JsonDiffWriter{wr: wr}
+}
+
+// BeginTable writes the name of the table and the changes to its columns.
+func (w *JsonDiffWriter) BeginTable(ctx context.Context, tableName string, oldSch, newSch schema.Schema) error {
+	w.oldSch, w.newSch = oldSch, newSch
+	w.wroteRow = false
+
+	prefix := `{"tables":[`
+	if w.wroteTable {
+		prefix = ",\n"
+	}
+	w.wroteTable = true
+
+	diffType := ModifiedDiffTypeName
+	if oldSch == nil {
+		diffType = AddedDiffTypeName
+	} else if newSch == nil {
+		diffType = RemovedDiffTypeName
+	}
+
+	schChanges, err := jsonSchemaChanges(oldSch, newSch)
+
+	if err != nil {
+		return err
+	}
+
+	name, err := json.Marshal(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	changes, err := json.Marshal(schChanges)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w.wr, prefix+`{"name":`+string(name)+`,"diff_type":"`+diffType+`","schema_changes":`+string(changes)+`,"rows":[`)
+	return err
+}
+
+// jsonSchemaChanges returns the changes to each column between the old and new schemas. Every column of a table which
+// was added or removed is listed, so that the json diff describes the whole schema of the table.
+func jsonSchemaChanges(oldSch, newSch schema.Schema) ([]JsonSchemaChange, error) {
+	var schChanges []JsonSchemaChange
+	if oldSch == nil || newSch == nil {
+		sch, diffType := newSch, AddedDiffTypeName
+		if newSch == nil {
+			sch, diffType = oldSch, RemovedDiffTypeName
+		}
+
+		err := sch.GetAllCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+			if diffType == AddedDiffTypeName {
+				schChanges = append(schChanges, JsonSchemaChange{diffType, nil, NewJsonColumn(col)})
+			} else {
+				schChanges = append(schChanges, JsonSchemaChange{diffType, NewJsonColumn(col), nil})
+			}
+
+			return false, nil
+		})
+
+		return schChanges, err
+	}
+
+	diffs, err := DiffSchemas(oldSch, newSch)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]uint64, 0, len(diffs))
+	for tag := range diffs {
+		tags = append(tags, tag)
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i] < tags[j]
+	})
+
+	for _, tag := range tags {
+		dff := diffs[tag]
+		switch dff.DiffType {
+		case SchDiffColAdded:
+			schChanges = append(schChanges, JsonSchemaChange{AddedDiffTypeName, nil, NewJsonColumn(*dff.New)})
+		case SchDiffColRemoved:
+			schChanges = append(schChanges, JsonSchemaChange{RemovedDiffTypeName, NewJsonColumn(*dff.Old), nil})
+		case SchDiffColModified:
+			schChanges = append(schChanges, JsonSchemaChange{ModifiedDiffTypeName, NewJsonColumn(*dff.Old), NewJsonColumn(*dff.New)})
+		}
+	}
+
+	return schChanges, nil
+}
+
+// WriteRowDiff writes the old and new values of the row.
+func (w *JsonDiffWriter) WriteRowDiff(ctx context.Context, rd RowDiff) error {
+	change := JsonRowChange{DiffType: rowDiffTypeName(rd)}
+
+	var err error
+	if rd.Old != nil {
+		change.From, err = jsonRowValues(ctx, w.oldSch, rd.Old)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if rd.New != nil {
+		change.To, err = jsonRowValues(ctx, w.newSch, rd.New)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(change)
+
+	if err != nil {
+		return err
+	}
+
+	prefix := "\n"
+	if w.wroteRow {
+		prefix = ",\n"
+	}
+	w.wroteRow = true
+
+	_, err = io.WriteString(w.wr, prefix+string(data))
+	return err
+}
+
+func jsonRowValues(ctx context.Context, sch schema.Schema, r row.Row) (map[string]interface{}, error) {
+	vals := make(map[string]interface{})
+	err := sch.GetAllCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+		val, _ := r.GetColVal(tag)
+		vals[col.Name], err = jsonValue(ctx, val)
+		return err != nil, err
+	})
+
+	return vals, err
+}
+
+func jsonValue(ctx context.Context, val types.Value) (interface{}, error) {
+	if types.IsNull(val) {
+		return nil, nil
+	}
+
+	switch v := val.(type) {
+	case types.Bool:
+		return bool(v), nil
+	case types.Int:
+		return int64(v), nil
+	case types.Uint:
+		return uint64(v), nil
+	case types.Float:
+		return float64(v), nil
+	case types.String:
+		return string(v), nil
+	default:
+		str, _, err := valueAsString(ctx, val)
+		return str, err
+	}
+}
+
+// EndTable closes the table's entry.
+func (w *JsonDiffWriter) EndTable(ctx context.Context) error {
+	_, err := io.WriteString(w.wr, "\n]}")
+	return err
+}
+
+// Close finishes the document and closes the underlying writer.
+func (w *JsonDiffWriter) Close(ctx context.Context) error {
+	end := "]}\n"
+	if !w.wroteTable {
+		end = `{"tables":[]}` + "\n"
+	}
+
+	if _, err := io.WriteString(w.wr, end); err != nil {
+		return err
+	}
+
+	return w.wr.Close()
+}
+
+// CsvDiffWriter writes a diff of a single table as csv, with a diff_type column holding added, removed or modified
+// followed by the columns of the table. Added and modified rows are written with their new values, and removed rows
+// with their old values. Columns which were dropped from the table follow the columns of the new schema.
+type CsvDiffWriter struct {
+	wr         io.WriteCloser
+	csvWr      *csv.Writer
+	tags       []uint64
+	wroteTable bool
+}
+
+// NewCsvDiffWriter returns a CsvDiffWriter writing to the writer given.
+func NewCsvDiffWriter(wr io.WriteCloser) *CsvDiffWriter {
+	return &CsvDiffWriter{wr: wr, csvWr: csv.NewWriter(wr)}
+}
+
+// BeginTable writes the header line. Returns ErrCsvMultipleTables if called more than once.
+func (w *CsvDiffWriter) BeginTable(ctx context.Context, tableName string, oldSch, newSch schema.Schema) error {
+	if w.wroteTable {
+		return ErrCsvMultipleTables
+	}
+	w.wroteTable = true
+
+	header := []string{CsvDiffTypeColName}
+	seen := make(map[uint64]bool)
+	for _, sch := range []schema.Schema{newSch, oldSch} {
+		if sch == nil {
+			continue
+		}
+
+		err := sch.GetAllCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+			if !seen[tag] {
+				seen[tag] = true
+				w.tags = append(w.tags, tag)
+				header = append(header, col.Name)
+			}
+
+			return false, nil
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.csvWr.Write(header)
+}
+
+// WriteRowDiff writes a line for the row.
+func (w *CsvDiffWriter) WriteRowDiff(ctx context.Context, rd RowDiff) error {
+	r := rd.New
+	if r == nil {
+		r = rd.Old
+	}
+
+	line := make([]string, 0, len(w.tags)+1)
+	line = append(line, rowDiffTypeName(rd))
+	for _, tag := range w.tags {
+		val, _ := r.GetColVal(tag)
+		str, _, err := valueAsString(ctx, val)
+
+		if err != nil {
+			return err
+		}
+
+		line = append(line, str)
+	}
+
+	return w.csvWr.Write(line)
+}
+
+// EndTable implements DiffWriter.
+func (w *CsvDiffWriter) EndTable(ctx context.Context) error {
+	w.csvWr.Flush()
+	return w.csvWr.Error()
+}
+
+// Close flushes any buffered lines and closes the underlying writer.
+func (w *CsvDiffWriter) Close(ctx context.Context) error {
+	w.csvWr.Flush()
+
+	if err := w.csvWr.Error(); err != nil {
+		return err
+	}
+
+	return w.wr.Close()
+}
@@ -0,0 +1,170 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func TestSchemaDiffSqlStatements(t *testing.T) {
+	oldColColl, _ := schema.NewColCollection(
+		schema.NewColumn("id", 0, types.IntKind, true, schema.NotNullConstraint{}),
+		schema.NewColumn("removed", 1, types.StringKind, false),
+		schema.NewColumn("renamed", 2, types.StringKind, false),
+		schema.NewColumn("type_changed", 3, types.StringKind, false),
+	)
+	newColColl, _ := schema.NewColCollection(
+		schema.NewColumn("id", 0, types.IntKind, true, schema.NotNullConstraint{}),
+		schema.NewColumn("renamed_new", 2, types.StringKind, false),
+		schema.NewColumn("type_changed", 3, types.IntKind, false),
+		schema.NewColumn("added", 4, types.StringKind, false),
+	)
+
+	oldSch, newSch := schema.SchemaFromCols(oldColColl), schema.SchemaFromCols(newColColl)
+
+	stmts, err := SchemaDiffSqlStatements("t", oldSch, newSch, MySqlDialect)
+	require.NoError(t, err)
+	expected := []string{
+		"ALTER TABLE `t` DROP COLUMN `removed`;",
+		"ALTER TABLE `t` RENAME COLUMN `renamed` TO `renamed_new`;",
+		"ALTER TABLE `t` MODIFY COLUMN `type_changed` int comment 'tag:3';",
+		"ALTER TABLE `t` ADD COLUMN `added` varchar comment 'tag:4';",
+	}
+	assert.Equal(t, expected, stmts)
+
+	stmts, err = SchemaDiffSqlStatements("t", oldSch, newSch, PostgresDialect)
+	require.NoError(t, err)
+	expected = []string{
+		`ALTER TABLE "t" DROP COLUMN "removed";`,
+		`ALTER TABLE "t" RENAME COLUMN "renamed" TO "renamed_new";`,
+		`ALTER TABLE "t" ALTER COLUMN "type_changed" TYPE bigint;`,
+		`ALTER TABLE "t" ADD COLUMN "added" text;`,
+	}
+	assert.Equal(t, expected, stmts)
+}
+
+func TestRowDiffSqlStatement(t *testing.T) {
+	colColl, _ := schema.NewColCollection(
+		schema.NewColumn("id", 0, types.IntKind, true, schema.NotNullConstraint{}),
+		schema.NewColumn("name", 1, types.StringKind, false),
+		schema.NewColumn("age", 2, types.UintKind, false),
+	)
+	sch := schema.SchemaFromCols(colColl)
+
+	mustRow := func(vals row.TaggedValues) row.Row {
+		r, err := row.New(types.Format_7_18, sch, vals)
+		require.NoError(t, err)
+		return r
+	}
+
+	oldRow := mustRow(row.TaggedValues{0: types.Int(1), 1: types.String("bill"), 2: types.Uint(32)})
+	newRow := mustRow(row.TaggedValues{0: types.Int(1), 1: types.String("o'brien"), 2: types.Uint(32)})
+	slashRow := mustRow(row.TaggedValues{0: types.Int(1), 1: types.String(`c:\bill`), 2: types.Uint(32)})
+
+	tests := []struct {
+		name     string
+		rd       RowDiff
+		dialect  SqlDialect
+		expected string
+	}{
+		{
+			"added",
+			RowDiff{New: oldRow},
+			MySqlDialect,
+			"INSERT INTO `t` (`id`,`name`,`age`) VALUES (1,'bill',32);",
+		},
+		{
+			"removed",
+			RowDiff{Old: oldRow},
+			MySqlDialect,
+			"DELETE FROM `t` WHERE `id` = 1;",
+		},
+		{
+			"modified",
+			RowDiff{Old: oldRow, New: newRow},
+			MySqlDialect,
+			"UPDATE `t` SET `name` = 'o''brien' WHERE `id` = 1;",
+		},
+		{
+			"unchanged",
+			RowDiff{Old: oldRow, New: oldRow},
+			MySqlDialect,
+			"",
+		},
+		{
+			"backslash",
+			RowDiff{Old: oldRow, New: slashRow},
+			MySqlDialect,
+			"UPDATE `t` SET `name` = 'c:\\\\bill' WHERE `id` = 1;",
+		},
+		{
+			"postgres added",
+			RowDiff{New: oldRow},
+			PostgresDialect,
+			`INSERT INTO "t" ("id","name","age") VALUES (1,'bill',32);`,
+		},
+		{
+			"postgres removed",
+			RowDiff{Old: oldRow},
+			PostgresDialect,
+			`DELETE FROM "t" WHERE "id" = 1;`,
+		},
+		{
+			"postgres modified",
+			RowDiff{Old: oldRow, New: newRow},
+			PostgresDialect,
+			`UPDATE "t" SET "name" = 'o''brien' WHERE "id" = 1;`,
+		},
+		{
+			"postgres backslash",
+			RowDiff{Old: oldRow, New: slashRow},
+			PostgresDialect,
+			`UPDATE "t" SET "name" = 'c:\bill' WHERE "id" = 1;`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stmt, err := RowDiffSqlStatement(context.Background(), "t", sch, test.rd, test.dialect)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, stmt)
+		})
+	}
+}
+
+func TestPostgresCreateTableStmt(t *testing.T) {
+	colColl, _ := schema.NewColCollection(
+		schema.NewColumn("id", 0, types.IntKind, true, schema.NotNullConstraint{}),
+		schema.NewColumn(`say "hi"`, 1, types.StringKind, false),
+	)
+
+	stmt, err := PostgresDialect.createTableStmt("t", schema.SchemaFromCols(colColl))
+	require.NoError(t, err)
+
+	expected := "CREATE TABLE \"t\" (\n" +
+		"  \"id\" bigint NOT NULL,\n" +
+		"  \"say \"\"hi\"\"\" text,\n" +
+		"  PRIMARY KEY (\"id\")\n" +
+		");"
+	assert.Equal(t, expected, stmt)
+}
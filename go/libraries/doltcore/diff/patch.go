@@ -0,0 +1,151 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/sql"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// JsonDiff is a json diff read back in, which can be applied as a patch to another copy of the tables.
+type JsonDiff struct {
+	Tables []JsonTableDiff `json:"tables"`
+}
+
+// JsonTableDiff is the form of the changes to a single table in the json diff format. DiffType is added, removed or
+// modified. For tables which were added or removed every column is listed in SchemaChanges.
+type JsonTableDiff struct {
+	Name          string             `json:"name"`
+	DiffType      string             `json:"diff_type"`
+	SchemaChanges []JsonSchemaChange `json:"schema_changes"`
+	Rows          []JsonRowChange    `json:"rows"`
+}
+
+// JsonColumn is the form of a column in the json diff format
+type JsonColumn struct {
+	Name       string `json:"name"`
+	Tag        uint64 `json:"tag"`
+	Type       string `json:"type"`
+	Kind       string `json:"kind"`
+	PrimaryKey bool   `json:"primary_key"`
+	NotNull    bool   `json:"not_null"`
+}
+
+// JsonSchemaChange is the form of a change to a column in the json diff format
+type JsonSchemaChange struct {
+	DiffType string      `json:"diff_type"`
+	From     *JsonColumn `json:"from,omitempty"`
+	To       *JsonColumn `json:"to,omitempty"`
+}
+
+// JsonRowChange is the form of a change to a row in the json diff format. From and To map column names to values.
+type JsonRowChange struct {
+	DiffType string                 `json:"diff_type"`
+	From     map[string]interface{} `json:"from,omitempty"`
+	To       map[string]interface{} `json:"to,omitempty"`
+}
+
+// NewJsonColumn returns the json diff form of a column.
+func NewJsonColumn(col schema.Column) *JsonColumn {
+	return &JsonColumn{col.Name, col.Tag, sql.DoltToSQLType[col.Kind], col.KindString(), col.IsPartOfPK, !col.IsNullable()}
+}
+
+// Column returns the column described.
+func (jc *JsonColumn) Column() (schema.Column, error) {
+	kind, ok := schema.LwrStrToKind[jc.Kind]
+
+	if !ok {
+		return schema.InvalidCol, fmt.Errorf("column '%s' has unknown kind '%s'", jc.Name, jc.Kind)
+	}
+
+	if jc.NotNull {
+		return schema.NewColumn(jc.Name, jc.Tag, kind, jc.PrimaryKey, schema.NotNullConstraint{}), nil
+	}
+
+	return schema.NewColumn(jc.Name, jc.Tag, kind, jc.PrimaryKey), nil
+}
+
+// ReadJsonDiff reads a document written by a JsonDiffWriter. Numbers are read without loss of precision.
+func ReadJsonDiff(rd io.Reader) (*JsonDiff, error) {
+	dec := json.NewDecoder(rd)
+	dec.UseNumber()
+
+	var jd JsonDiff
+	if err := dec.Decode(&jd); err != nil {
+		return nil, err
+	}
+
+	return &jd, nil
+}
+
+// JsonRowValues converts the column names and json values of a row in the json diff format into tagged values using
+// the schema given. Returns an error for a column not in the schema.
+func JsonRowValues(vals map[string]interface{}, sch schema.Schema) (row.TaggedValues, error) {
+	allCols := sch.GetAllCols()
+	taggedVals := make(row.TaggedValues, len(vals))
+	for name, jsonVal := range vals {
+		col, ok := allCols.GetByName(name)
+
+		if !ok {
+			return nil, fmt.Errorf("unknown column '%s'", name)
+		}
+
+		val, err := valueFromJson(jsonVal, col.Kind)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for column '%s': %v", name, err)
+		}
+
+		if !types.IsNull(val) {
+			taggedVals[col.Tag] = val
+		}
+	}
+
+	return taggedVals, nil
+}
+
+func valueFromJson(jsonVal interface{}, kind types.NomsKind) (types.Value, error) {
+	var str string
+	switch v := jsonVal.(type) {
+	case nil:
+		return types.NullValue, nil
+	case bool:
+		str = strconv.FormatBool(v)
+	case json.Number:
+		str = v.String()
+	case float64:
+		str = strconv.FormatFloat(v, 'g', -1, 64)
+	case string:
+		str = v
+	default:
+		return nil, fmt.Errorf("unexpected json value %v", jsonVal)
+	}
+
+	convFn := doltcore.GetConvFunc(types.StringKind, kind)
+
+	if convFn == nil {
+		return nil, fmt.Errorf("unable to convert '%s' to %s", str, types.KindToString[kind])
+	}
+
+	return convFn(types.String(str))
+}
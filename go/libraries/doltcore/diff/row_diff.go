@@ -0,0 +1,103 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"context"
+	"time"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// RowDiff is the change to a single row between two versions of a table. Old is nil for a row that was added, and New
+// is nil for a row that was removed.
+type RowDiff struct {
+	Old row.Row
+	New row.Row
+}
+
+// DiffType returns DiffAdded, DiffRemoved or DiffModifiedNew depending on which versions of the row exist.
+func (rd RowDiff) DiffType() DiffChType {
+	if rd.Old == nil {
+		return DiffAdded
+	} else if rd.New == nil {
+		return DiffRemoved
+	}
+
+	return DiffModifiedNew
+}
+
+// IterRowDiffs calls the callback given with each row difference returned by the RowDiffer, until the callback returns
+// true or an error. Old rows are read using the old schema and new rows using the new schema. The RowDiffer is closed
+// before returning.
+func IterRowDiffs(differ RowDiffer, oldSch, newSch schema.Schema, cb func(rd RowDiff) (stop bool, err error)) error {
+	defer differ.Close()
+
+	for !differ.IsDone() {
+		diffs, err := differ.GetDiffs(1024, time.Second)
+
+		if err != nil {
+			return err
+		}
+
+		for _, d := range diffs {
+			rd, err := rowDiffFromDifference(d, oldSch, newSch)
+
+			if err != nil {
+				return err
+			}
+
+			stop, err := cb(rd)
+
+			if err != nil || stop {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// valueAsString returns the string form of a value, or false for NULL.
+func valueAsString(ctx context.Context, val types.Value) (string, bool, error) {
+	if types.IsNull(val) {
+		return "", false, nil
+	}
+
+	if val.Kind() == types.StringKind {
+		return string(val.(types.String)), true, nil
+	}
+
+	if convFn := doltcore.GetConvFunc(val.Kind(), types.StringKind); convFn != nil {
+		str, err := convFn(val)
+
+		if err != nil {
+			return "", false, err
+		}
+
+		return string(str.(types.String)), true, nil
+	}
+
+	str, err := types.EncodedValue(ctx, val)
+
+	if err != nil {
+		return "", false, err
+	}
+
+	return str, true, nil
+}
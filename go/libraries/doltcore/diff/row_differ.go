@@ -0,0 +1,312 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"context"
+	"time"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/diff"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// RowDiffer is a source of the differences between two versions of a table's row data. GetDiffs returns up to
+// numDiffs differences, waiting no longer than timeout for them, and IsDone returns true once every difference has been
+// returned.
+type RowDiffer interface {
+	GetDiffs(numDiffs int, timeout time.Duration) ([]*diff.Difference, error)
+	IsDone() bool
+	Close()
+}
+
+var _ RowDiffer = (*AsyncDiffer)(nil)
+var _ RowDiffer = (*RangeDiffer)(nil)
+var _ RowDiffer = (*FilteredDiffer)(nil)
+
+// RangeDiffer is a RowDiffer for the rows whose primary keys are within a range. Rather than diffing the whole maps it
+// seeks to the start of the range in both maps and walks them in step until the end of the range.
+type RangeDiffer struct {
+	ctx     context.Context
+	nbf     *types.NomsBinFormat
+	end     types.Tuple
+	hasEnd  bool
+	oldItr  types.MapIterator
+	newItr  types.MapIterator
+	oldK    types.Value
+	oldV    types.Value
+	newK    types.Value
+	newV    types.Value
+	started bool
+	isDone  bool
+}
+
+// NewRangeDiffer returns a RangeDiffer for the rows of oldRows and newRows whose keys are between start and end,
+// inclusive. The bounds are key tuples which may hold only a prefix of the primary key's columns, in which case every
+// key beginning with the bound is within it. A nil bound leaves that end of the range open.
+func NewRangeDiffer(ctx context.Context, oldRows, newRows types.Map, start, end *types.Tuple) (*RangeDiffer, error) {
+	rd := &RangeDiffer{ctx: ctx, nbf: oldRows.Format()}
+
+	if end != nil {
+		rd.end = *end
+		rd.hasEnd = true
+	}
+
+	var err error
+	if start != nil {
+		rd.oldItr, err = oldRows.IteratorFrom(ctx, *start)
+
+		if err == nil {
+			rd.newItr, err = newRows.IteratorFrom(ctx, *start)
+		}
+	} else {
+		rd.oldItr, err = oldRows.Iterator(ctx)
+
+		if err == nil {
+			rd.newItr, err = newRows.Iterator(ctx)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rd, nil
+}
+
+// GetDiffs returns up to numDiffs differences, or all remaining differences if numDiffs is 0. The timeout is ignored as
+// the maps are read synchronously.
+func (rd *RangeDiffer) GetDiffs(numDiffs int, _ time.Duration) ([]*diff.Difference, error) {
+	var diffs []*diff.Difference
+	var err error
+
+	if !rd.started {
+		rd.started = true
+
+		if rd.oldK, rd.oldV, err = rd.next(rd.oldItr); err != nil {
+			return nil, err
+		}
+
+		if rd.newK, rd.newV, err = rd.next(rd.newItr); err != nil {
+			return nil, err
+		}
+	}
+
+	for !rd.isDone && (numDiffs == 0 || len(diffs) < numDiffs) {
+		var newIsLess, oldIsLess bool
+		if rd.oldK == nil && rd.newK == nil {
+			rd.isDone = true
+			break
+		} else if rd.oldK == nil {
+			newIsLess = true
+		} else if rd.newK == nil {
+			oldIsLess = true
+		} else {
+			if newIsLess, err = rd.newK.Less(rd.nbf, rd.oldK); err != nil {
+				return nil, err
+			}
+
+			if !newIsLess {
+				if oldIsLess, err = rd.oldK.Less(rd.nbf, rd.newK); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		switch {
+		case newIsLess:
+			diffs = append(diffs, &diff.Difference{ChangeType: types.DiffChangeAdded, KeyValue: rd.newK, NewValue: rd.newV})
+			rd.newK, rd.newV, err = rd.next(rd.newItr)
+		case oldIsLess:
+			diffs = append(diffs, &diff.Difference{ChangeType: types.DiffChangeRemoved, KeyValue: rd.oldK, OldValue: rd.oldV})
+			rd.oldK, rd.oldV, err = rd.next(rd.oldItr)
+		default:
+			if !rd.oldV.Equals(rd.newV) {
+				diffs = append(diffs, &diff.Difference{ChangeType: types.DiffChangeModified, KeyValue: rd.newK, OldValue: rd.oldV, NewValue: rd.newV})
+			}
+
+			if rd.oldK, rd.oldV, err = rd.next(rd.oldItr); err == nil {
+				rd.newK, rd.newV, err = rd.next(rd.newItr)
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return diffs, nil
+}
+
+// next returns the next key and value from the iterator, or nil once the iterator is past the end of the range.
+func (rd *RangeDiffer) next(itr types.MapIterator) (types.Value, types.Value, error) {
+	k, v, err := itr.Next(rd.ctx)
+
+	if err != nil || k == nil || !rd.hasEnd {
+		return k, v, err
+	}
+
+	prefix, err := tuplePrefix(rd.nbf, k.(types.Tuple), rd.end.Len())
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if pastEnd, err := rd.end.Less(rd.nbf, prefix); err != nil {
+		return nil, nil, err
+	} else if pastEnd {
+		return nil, nil, nil
+	}
+
+	return k, v, nil
+}
+
+func tuplePrefix(nbf *types.NomsBinFormat, t types.Tuple, n uint64) (types.Tuple, error) {
+	vals := make([]types.Value, 0, n)
+	err := t.IterFields(func(index uint64, value types.Value) (stop bool, err error) {
+		if index >= n {
+			return true, nil
+		}
+
+		vals = append(vals, value)
+		return false, nil
+	})
+
+	if err != nil {
+		return types.Tuple{}, err
+	}
+
+	return types.NewTuple(nbf, vals...)
+}
+
+// IsDone returns true once the end of the range has been reached in both maps.
+func (rd *RangeDiffer) IsDone() bool {
+	return rd.isDone
+}
+
+// Close implements RowDiffer.
+func (rd *RangeDiffer) Close() {
+	rd.isDone = true
+}
+
+// RowDiffFilter reports whether the change to a row should be included in a diff.
+type RowDiffFilter func(rd RowDiff) bool
+
+// FilteredDiffer is a RowDiffer returning the differences from another RowDiffer which match a filter, stopping after a
+// limit is reached.
+type FilteredDiffer struct {
+	differ RowDiffer
+	oldSch schema.Schema
+	newSch schema.Schema
+	filter RowDiffFilter
+	limit  int
+	count  int
+	isDone bool
+}
+
+// NewFilteredDiffer returns a FilteredDiffer over the differences from the RowDiffer given. Old rows are read using
+// the old schema and new rows using the new schema before being passed to the filter. A nil filter matches every row,
+// and a limit of 0 or less returns every matching difference.
+func NewFilteredDiffer(differ RowDiffer, oldSch, newSch schema.Schema, filter RowDiffFilter, limit int) *FilteredDiffer {
+	return &FilteredDiffer{differ: differ, oldSch: oldSch, newSch: newSch, filter: filter, limit: limit}
+}
+
+// GetDiffs returns up to numDiffs matching differences. Unlike the underlying RowDiffer, it only returns no differences
+// when the underlying RowDiffer does, so filtering out a batch is not mistaken for a timeout.
+func (fd *FilteredDiffer) GetDiffs(numDiffs int, timeout time.Duration) ([]*diff.Difference, error) {
+	var diffs []*diff.Difference
+	for !fd.IsDone() {
+		remaining := numDiffs
+		if fd.limit > 0 && (remaining == 0 || fd.limit-fd.count < remaining) {
+			remaining = fd.limit - fd.count
+		}
+
+		batch, err := fd.differ.GetDiffs(remaining, timeout)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, d := range batch {
+			matches, err := fd.matches(d)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if matches {
+				diffs = append(diffs, d)
+				fd.count++
+			}
+		}
+
+		if fd.limit > 0 && fd.count >= fd.limit {
+			fd.isDone = true
+		}
+
+		if len(diffs) > 0 || len(batch) == 0 {
+			break
+		}
+	}
+
+	return diffs, nil
+}
+
+func (fd *FilteredDiffer) matches(d *diff.Difference) (bool, error) {
+	if fd.filter == nil {
+		return true, nil
+	}
+
+	rd, err := rowDiffFromDifference(d, fd.oldSch, fd.newSch)
+
+	if err != nil {
+		return false, err
+	}
+
+	return fd.filter(rd), nil
+}
+
+// IsDone returns true once the limit has been reached or the underlying RowDiffer is done.
+func (fd *FilteredDiffer) IsDone() bool {
+	return fd.isDone || fd.differ.IsDone()
+}
+
+// Close closes the underlying RowDiffer.
+func (fd *FilteredDiffer) Close() {
+	fd.differ.Close()
+}
+
+func rowDiffFromDifference(d *diff.Difference, oldSch, newSch schema.Schema) (RowDiff, error) {
+	var rd RowDiff
+	var err error
+	if d.OldValue != nil {
+		rd.Old, err = row.FromNoms(oldSch, d.KeyValue.(types.Tuple), d.OldValue.(types.Tuple))
+
+		if err != nil {
+			return RowDiff{}, err
+		}
+	}
+
+	if d.NewValue != nil {
+		rd.New, err = row.FromNoms(newSch, d.KeyValue.(types.Tuple), d.NewValue.(types.Tuple))
+
+		if err != nil {
+			return RowDiff{}, err
+		}
+	}
+
+	return rd, nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+var testDiffSch = mustSchema(
+	schema.NewColumn("id", 0, types.IntKind, true, schema.NotNullConstraint{}),
+	schema.NewColumn("name", 1, types.StringKind, false),
+	schema.NewColumn("age", 2, types.UintKind, false),
+)
+
+func mustSchema(cols ...schema.Column) schema.Schema {
+	colColl, err := schema.NewColCollection(cols...)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return schema.SchemaFromCols(colColl)
+}
+
+// testDiffRows returns the row data of a table with testDiffSch holding the rows given.
+func testDiffRows(t *testing.T, rowVals ...row.TaggedValues) types.Map {
+	ctx := context.Background()
+	ddb, err := doltdb.LoadDoltDB(ctx, types.Format_7_18, doltdb.InMemDoltDB)
+	require.NoError(t, err)
+
+	var kvs []types.Value
+	for _, vals := range rowVals {
+		r, err := row.New(types.Format_7_18, testDiffSch, vals)
+		require.NoError(t, err)
+
+		k, err := r.NomsMapKey(testDiffSch).Value(ctx)
+		require.NoError(t, err)
+
+		v, err := r.NomsMapValue(testDiffSch).Value(ctx)
+		require.NoError(t, err)
+
+		kvs = append(kvs, k, v)
+	}
+
+	m, err := types.NewMap(ctx, ddb.ValueReadWriter(), kvs...)
+	require.NoError(t, err)
+	return m
+}
+
+var testOldDiffRows = []row.TaggedValues{
+	{0: types.Int(1), 1: types.String("bill"), 2: types.Uint(32)},
+	{0: types.Int(2), 1: types.String("jane"), 2: types.Uint(25)},
+	{0: types.Int(3), 1: types.String("joe"), 2: types.Uint(40)},
+}
+
+var testNewDiffRows = []row.TaggedValues{
+	{0: types.Int(1), 1: types.String("bill"), 2: types.Uint(33)},
+	{0: types.Int(2), 1: types.String("janet")},
+	{0: types.Int(4), 1: types.String("jim"), 2: types.Uint(51)},
+}
+
+func pkTuple(t *testing.T, id int64) *types.Tuple {
+	tpl, err := types.NewTuple(types.Format_7_18, types.Uint(0), types.Int(id))
+	require.NoError(t, err)
+	return &tpl
+}
+
+func collectDiffIds(t *testing.T, differ RowDiffer) []int64 {
+	var ids []int64
+	err := IterRowDiffs(differ, testDiffSch, testDiffSch, func(rd RowDiff) (stop bool, err error) {
+		r := rd.New
+		if r == nil {
+			r = rd.Old
+		}
+
+		id, _ := r.GetColVal(0)
+		ids = append(ids, int64(id.(types.Int)))
+		return false, nil
+	})
+	require.NoError(t, err)
+
+	return ids
+}
+
+func TestRangeDiffer(t *testing.T) {
+	ctx := context.Background()
+	oldRows := testDiffRows(t, testOldDiffRows...)
+	newRows := testDiffRows(t, testNewDiffRows...)
+
+	tests := []struct {
+		name     string
+		start    *types.Tuple
+		end      *types.Tuple
+		expected []int64
+	}{
+		{"unbounded", nil, nil, []int64{1, 2, 3, 4}},
+		{"start", pkTuple(t, 2), nil, []int64{2, 3, 4}},
+		{"end", nil, pkTuple(t, 3), []int64{1, 2, 3}},
+		{"start and end", pkTuple(t, 2), pkTuple(t, 3), []int64{2, 3}},
+		{"single row", pkTuple(t, 4), pkTuple(t, 4), []int64{4}},
+		{"empty", pkTuple(t, 5), nil, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			differ, err := NewRangeDiffer(ctx, oldRows, newRows, test.start, test.end)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, collectDiffIds(t, differ))
+		})
+	}
+}
+
+func TestFilteredDiffer(t *testing.T) {
+	ctx := context.Background()
+	oldRows := testDiffRows(t, testOldDiffRows...)
+	newRows := testDiffRows(t, testNewDiffRows...)
+
+	namedJ := func(rd RowDiff) bool {
+		r := rd.New
+		if r == nil {
+			r = rd.Old
+		}
+
+		name, _ := r.GetColVal(1)
+		return string(name.(types.String))[0] == 'j'
+	}
+
+	differ, err := NewRangeDiffer(ctx, oldRows, newRows, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2, 3, 4}, collectDiffIds(t, NewFilteredDiffer(differ, testDiffSch, testDiffSch, namedJ, 0)))
+
+	differ, err = NewRangeDiffer(ctx, oldRows, newRows, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2, 3}, collectDiffIds(t, NewFilteredDiffer(differ, testDiffSch, testDiffSch, namedJ, 2)))
+
+	ad := NewAsyncDiffer(1024)
+	ad.Start(ctx, newRows, oldRows)
+	assert.Equal(t, []int64{1}, collectDiffIds(t, NewFilteredDiffer(ad, testDiffSch, testDiffSch, nil, 1)))
+}
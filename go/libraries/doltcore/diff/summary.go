@@ -0,0 +1,189 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"context"
+	"time"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/valutil"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// DiffSummary holds the number of rows and cells which changed between two versions of a table.
+type DiffSummary struct {
+	RowsAdded     uint64
+	RowsDeleted   uint64
+	RowsModified  uint64
+	CellsModified uint64
+	OldRowCount   uint64
+	NewRowCount   uint64
+}
+
+// RowsChanged returns the number of rows which were added, deleted or modified.
+func (ds DiffSummary) RowsChanged() uint64 {
+	return ds.RowsAdded + ds.RowsDeleted + ds.RowsModified
+}
+
+// PercentChanged returns the number of rows which changed as a percentage of the rows in the old version of the table.
+// A table which had no rows and now has some is 100% changed.
+func (ds DiffSummary) PercentChanged() float64 {
+	if ds.OldRowCount == 0 {
+		if ds.RowsChanged() == 0 {
+			return 0
+		}
+
+		return 100
+	}
+
+	return 100 * float64(ds.RowsChanged()) / float64(ds.OldRowCount)
+}
+
+// Add returns the sum of two summaries.
+func (ds DiffSummary) Add(other DiffSummary) DiffSummary {
+	return DiffSummary{
+		RowsAdded:     ds.RowsAdded + other.RowsAdded,
+		RowsDeleted:   ds.RowsDeleted + other.RowsDeleted,
+		RowsModified:  ds.RowsModified + other.RowsModified,
+		CellsModified: ds.CellsModified + other.CellsModified,
+		OldRowCount:   ds.OldRowCount + other.OldRowCount,
+		NewRowCount:   ds.NewRowCount + other.NewRowCount,
+	}
+}
+
+// SummarizeTableDiff summarizes the changes between an old and a new version of a table. Either table may be nil, in
+// which case every row of the other was added or deleted. Tables with the same hash are not diffed.
+func SummarizeTableDiff(ctx context.Context, oldTbl, newTbl *doltdb.Table) (DiffSummary, error) {
+	var oldRows, newRows types.Map
+	var err error
+	if oldTbl != nil {
+		oldRows, err = oldTbl.GetRowData(ctx)
+
+		if err != nil {
+			return DiffSummary{}, err
+		}
+	}
+
+	if newTbl != nil {
+		newRows, err = newTbl.GetRowData(ctx)
+
+		if err != nil {
+			return DiffSummary{}, err
+		}
+	}
+
+	switch {
+	case oldTbl == nil && newTbl == nil:
+		return DiffSummary{}, nil
+	case oldTbl == nil:
+		return DiffSummary{RowsAdded: newRows.Len(), NewRowCount: newRows.Len()}, nil
+	case newTbl == nil:
+		return DiffSummary{RowsDeleted: oldRows.Len(), OldRowCount: oldRows.Len()}, nil
+	}
+
+	oldHash, err := oldTbl.HashOf()
+
+	if err != nil {
+		return DiffSummary{}, err
+	}
+
+	newHash, err := newTbl.HashOf()
+
+	if err != nil {
+		return DiffSummary{}, err
+	}
+
+	if oldHash == newHash {
+		return DiffSummary{OldRowCount: oldRows.Len(), NewRowCount: newRows.Len()}, nil
+	}
+
+	return SummarizeRowDiffs(ctx, oldRows, newRows)
+}
+
+// SummarizeRowDiffs summarizes the changes between the old and new row data of a table. Columns are matched by tag, so
+// a modified row counts a cell for each tag whose value differs, including tags only present in one of the versions.
+func SummarizeRowDiffs(ctx context.Context, oldRows, newRows types.Map) (DiffSummary, error) {
+	summary := DiffSummary{OldRowCount: oldRows.Len(), NewRowCount: newRows.Len()}
+
+	if oldRows.Equals(newRows) {
+		return summary, nil
+	}
+
+	ad := NewAsyncDiffer(1024)
+	ad.Start(ctx, newRows, oldRows)
+	defer ad.Close()
+
+	for {
+		diffs, err := ad.GetDiffs(1024, time.Second)
+
+		if err != nil {
+			return DiffSummary{}, err
+		}
+
+		for _, d := range diffs {
+			switch {
+			case d.OldValue == nil:
+				summary.RowsAdded++
+			case d.NewValue == nil:
+				summary.RowsDeleted++
+			default:
+				summary.RowsModified++
+
+				cells, err := countModifiedCells(d.OldValue.(types.Tuple), d.NewValue.(types.Tuple))
+
+				if err != nil {
+					return DiffSummary{}, err
+				}
+
+				summary.CellsModified += cells
+			}
+		}
+
+		if ad.IsDone() {
+			return summary, ad.ae.Get()
+		}
+	}
+}
+
+func countModifiedCells(oldVal, newVal types.Tuple) (uint64, error) {
+	oldTVs, err := row.ParseTaggedValues(oldVal)
+
+	if err != nil {
+		return 0, err
+	}
+
+	newTVs, err := row.ParseTaggedValues(newVal)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var count uint64
+	for tag, val := range oldTVs {
+		if !valutil.NilSafeEqCheck(val, newTVs[tag]) {
+			count++
+		}
+	}
+
+	for tag, val := range newTVs {
+		if _, ok := oldTVs[tag]; !ok && !types.IsNull(val) {
+			count++
+		}
+	}
+
+	return count, nil
+}
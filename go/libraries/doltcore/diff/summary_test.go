@@ -0,0 +1,91 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func TestSummarizeRowDiffs(t *testing.T) {
+	ctx := context.Background()
+	ddb, err := doltdb.LoadDoltDB(ctx, types.Format_7_18, doltdb.InMemDoltDB)
+	require.NoError(t, err)
+
+	colColl, _ := schema.NewColCollection(
+		schema.NewColumn("id", 0, types.IntKind, true, schema.NotNullConstraint{}),
+		schema.NewColumn("name", 1, types.StringKind, false),
+		schema.NewColumn("age", 2, types.UintKind, false),
+	)
+	sch := schema.SchemaFromCols(colColl)
+
+	mustMap := func(rowVals ...row.TaggedValues) types.Map {
+		var kvs []types.Value
+		for _, vals := range rowVals {
+			r, err := row.New(types.Format_7_18, sch, vals)
+			require.NoError(t, err)
+
+			k, err := r.NomsMapKey(sch).Value(ctx)
+			require.NoError(t, err)
+
+			v, err := r.NomsMapValue(sch).Value(ctx)
+			require.NoError(t, err)
+
+			kvs = append(kvs, k, v)
+		}
+
+		m, err := types.NewMap(ctx, ddb.ValueReadWriter(), kvs...)
+		require.NoError(t, err)
+		return m
+	}
+
+	oldRows := mustMap(
+		row.TaggedValues{0: types.Int(1), 1: types.String("bill"), 2: types.Uint(32)},
+		row.TaggedValues{0: types.Int(2), 1: types.String("jane"), 2: types.Uint(25)},
+		row.TaggedValues{0: types.Int(3), 1: types.String("joe"), 2: types.Uint(40)},
+	)
+	newRows := mustMap(
+		row.TaggedValues{0: types.Int(1), 1: types.String("bill"), 2: types.Uint(33)},
+		row.TaggedValues{0: types.Int(2), 1: types.String("janet")},
+		row.TaggedValues{0: types.Int(4), 1: types.String("jim"), 2: types.Uint(51)},
+	)
+
+	summary, err := SummarizeRowDiffs(ctx, oldRows, newRows)
+	require.NoError(t, err)
+
+	expected := DiffSummary{
+		RowsAdded:     1,
+		RowsDeleted:   1,
+		RowsModified:  2,
+		CellsModified: 3,
+		OldRowCount:   3,
+		NewRowCount:   3,
+	}
+	assert.Equal(t, expected, summary)
+	assert.InDelta(t, 400.0/3.0, summary.PercentChanged(), 0.001)
+
+	summary, err = SummarizeRowDiffs(ctx, oldRows, oldRows)
+	require.NoError(t, err)
+	assert.Equal(t, DiffSummary{OldRowCount: 3, NewRowCount: 3}, summary)
+	assert.Equal(t, 0.0, summary.PercentChanged())
+}
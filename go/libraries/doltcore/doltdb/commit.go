@@ -43,6 +43,17 @@ func (c *Commit) HashOf() (hash.Hash, error) {
 	return c.commitSt.Hash(c.vrw.Format())
 }
 
+// Height returns the height of the commit, which is greater than the height of each of its parents.
+func (c *Commit) Height() (uint64, error) {
+	ref, err := types.NewRef(c.commitSt, c.vrw.Format())
+
+	if err != nil {
+		return 0, err
+	}
+
+	return ref.Height(), nil
+}
+
 // GetCommitMeta gets the metadata associated with the commit
 func (c *Commit) GetCommitMeta() (*CommitMeta, error) {
 	metaVal, found, err := c.commitSt.MaybeGet(metaField)
@@ -190,6 +201,8 @@ func GetCommitAnscestor(ctx context.Context, cm1, cm2 *Commit) (*Commit, error)
 
 	if err != nil {
 		return nil, err
+	} else if targetVal == nil {
+		return nil, ErrBeyondShallowBoundary
 	}
 
 	ancestorSt := targetVal.(types.Struct)
@@ -17,6 +17,8 @@ package doltdb
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -116,17 +118,83 @@ func (cm *CommitMeta) toNomsStruct(nbf *types.NomsBinFormat) (types.Struct, erro
 	return types.NewStruct(nbf, "metadata", metadata)
 }
 
-// FormatTS takes the internal timestamp and turns it into a human readable string in the time.RubyDate format
-// which looks like: "Mon Jan 02 15:04:05 -0700 2006"
-func (cm *CommitMeta) FormatTS() string {
+// Time returns the time at which the commit was made.
+func (cm *CommitMeta) Time() time.Time {
 	seconds := cm.Timestamp / secToMilli
 	nanos := (cm.Timestamp % secToMilli) * milliToNano
-	now := time.Unix(int64(seconds), int64(nanos))
 
-	return now.Format(time.RubyDate)
+	return time.Unix(int64(seconds), int64(nanos))
+}
+
+// FormatTS takes the internal timestamp and turns it into a human readable string in the time.RubyDate format
+// which looks like: "Mon Jan 02 15:04:05 -0700 2006"
+func (cm *CommitMeta) FormatTS() string {
+	return cm.Time().Format(time.RubyDate)
 }
 
 // String returns the human readable string representation of the commit data
 func (cm *CommitMeta) String() string {
 	return fmt.Sprintf("name: %s, email: %s, timestamp: %s, description: %s", cm.Name, cm.Email, cm.FormatTS(), cm.Description)
 }
+
+var relativeDateRegex = regexp.MustCompile(`^(\d+)\s*(second|minute|hour|day|week|month|year)s?\s+ago$`)
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	time.RubyDate,
+}
+
+// ParseDate parses a date given either as an absolute time, such as "2019-07-04" or "2019-07-04 13:00:00", or relative
+// to now, such as "2 weeks ago". Absolute times without a zone are in the local time zone.
+func ParseDate(str string, now time.Time) (time.Time, error) {
+	str = strings.TrimSpace(str)
+	lwr := strings.ToLower(str)
+
+	switch lwr {
+	case "now":
+		return now, nil
+	case "today":
+		y, m, d := now.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, now.Location()), nil
+	case "yesterday":
+		y, m, d := now.Date()
+		return time.Date(y, m, d-1, 0, 0, 0, 0, now.Location()), nil
+	}
+
+	if m := relativeDateRegex.FindStringSubmatch(lwr); m != nil {
+		n, err := strconv.Atoi(m[1])
+
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		switch m[2] {
+		case "second":
+			return now.Add(-time.Duration(n) * time.Second), nil
+		case "minute":
+			return now.Add(-time.Duration(n) * time.Minute), nil
+		case "hour":
+			return now.Add(-time.Duration(n) * time.Hour), nil
+		case "day":
+			return now.AddDate(0, 0, -n), nil
+		case "week":
+			return now.AddDate(0, 0, -7*n), nil
+		case "month":
+			return now.AddDate(0, -n, 0), nil
+		default:
+			return now.AddDate(-n, 0, 0), nil
+		}
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, str, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date: '%s'", str)
+}
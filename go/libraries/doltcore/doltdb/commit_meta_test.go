@@ -17,6 +17,7 @@ package doltdb
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -37,3 +38,37 @@ func TestCommitMetaToAndFromNomsStruct(t *testing.T) {
 
 	t.Log(cm.String())
 }
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2019, 7, 10, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		str      string
+		expected time.Time
+		expErr   bool
+	}{
+		{"now", now, false},
+		{"today", time.Date(2019, 7, 10, 0, 0, 0, 0, time.UTC), false},
+		{"yesterday", time.Date(2019, 7, 9, 0, 0, 0, 0, time.UTC), false},
+		{"2 hours ago", now.Add(-2 * time.Hour), false},
+		{"1 week ago", time.Date(2019, 7, 3, 12, 30, 0, 0, time.UTC), false},
+		{"3 Months Ago", time.Date(2019, 4, 10, 12, 30, 0, 0, time.UTC), false},
+		{"2019-07-04", time.Date(2019, 7, 4, 0, 0, 0, 0, time.UTC), false},
+		{"2019-07-04 13:00:00", time.Date(2019, 7, 4, 13, 0, 0, 0, time.UTC), false},
+		{"2019-07-04T13:00:00-07:00", time.Date(2019, 7, 4, 20, 0, 0, 0, time.UTC), false},
+		{"last tuesday", time.Time{}, true},
+		{"2019-13-01", time.Time{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.str, func(t *testing.T) {
+			actual, err := ParseDate(test.str, now)
+
+			if test.expErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, test.expected.Equal(actual), "expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
@@ -17,7 +17,9 @@ package doltdb
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
 )
@@ -30,6 +32,13 @@ func (s stringer) String() string {
 
 var hashRegex = regexp.MustCompile(`^[0-9a-v]{32}$`)
 
+// partialHashRegex matches strings which may be an abbreviated commit hash
+var partialHashRegex = regexp.MustCompile(`^[0-9a-v]{4,31}$`)
+
+var atSpecRegex = regexp.MustCompile(`^(.*?)@\{([^}]*)\}(.*)$`)
+
+const messageSearchPrefix = ":/"
+
 const head string = "head"
 
 // IsValidUserBranchName returns true if name isn't a valid commit hash, it is not named "head" and
@@ -45,18 +54,79 @@ func IsValidBranchRef(dref ref.DoltRef) bool {
 type CommitSpecType string
 
 const (
-	RefCommitSpec  CommitSpecType = "ref"
-	HashCommitSpec CommitSpecType = "hash"
+	RefCommitSpec     CommitSpecType = "ref"
+	HashCommitSpec    CommitSpecType = "hash"
+	MessageCommitSpec CommitSpecType = "message"
 )
 
-// CommitSpec handles three different types of string representations of commits.  Commits can either be represented
-// by the hash of the commit, a branch name, or using "head" to represent the latest commit of the current branch.
-// An Ancestor spec can be appended to the end of any of these in order to reach commits that are in the ancestor tree
-// of the referenced commit.
+// CommitSpec handles several different types of string representations of commits.  Commits can either be represented
+// by the hash of the commit or an unambiguous prefix of it, a branch name, using "head" to represent the latest commit
+// of the current branch, or using :/<regex> to represent the newest commit on any branch whose message matches the
+// regular expression. An AtSpec may follow a branch or hash, and an Ancestor spec can be appended to the end of any of
+// these in order to reach commits that are in the ancestor tree of the referenced commit.
 type CommitSpec struct {
 	CommitStringer fmt.Stringer
 	CSType         CommitSpecType
 	ASpec          *AncestorSpec
+	AtSpec         *AtSpec
+}
+
+// AtSpec supports using @{<n>} and @{<date>} to select a commit from the history of a ref.
+//   @{<n>} after a commit spec means the commit <n> commits before the referenced commit, following only first
+//     parents. Dolt does not keep a log of the values each ref has held, so <ref>@{<n>} is equivalent to <ref>~<n>.
+//   @{<date>} after a commit spec means the newest commit in the first parent history of the referenced commit which
+//     was made at or before the date, using the timestamps of the commits. The date may be absolute, as in
+//     master@{2019-07-04}, or relative, as in master@{2 weeks ago}.
+// An AtSpec is applied before any ancestor spec, so master@{yesterday}~1 is the parent of the commit master@{yesterday}
+// refers to.
+type AtSpec struct {
+	// SpecStr is the string representation of the AtSpec, without the enclosing @{}
+	SpecStr string
+
+	// N is the number of commits to walk back from the referenced commit, when Date is zero
+	N int
+
+	// Date, if not zero, selects the newest commit made at or before it
+	Date time.Time
+}
+
+// NewAtSpec parses the contents of an @{} spec, resolving relative dates against now.
+func NewAtSpec(s string, now time.Time) (*AtSpec, error) {
+	s = strings.TrimSpace(s)
+
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 0 {
+			return nil, ErrInvalidAtSpec
+		}
+
+		return &AtSpec{SpecStr: s, N: n}, nil
+	}
+
+	date, err := ParseDate(s, now)
+
+	if err != nil {
+		return nil, ErrInvalidAtSpec
+	}
+
+	return &AtSpec{SpecStr: s, Date: date}, nil
+}
+
+// SplitAtSpec takes a string that is a commit spec which may contain an @{} spec, and splits them apart, returning
+// the commit spec with the @{} spec removed. If there is no @{} spec then the returned AtSpec is nil.
+func SplitAtSpec(s string, now time.Time) (string, *AtSpec, error) {
+	m := atSpecRegex.FindStringSubmatch(s)
+
+	if m == nil {
+		return s, nil, nil
+	}
+
+	as, err := NewAtSpec(m[2], now)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return m[1] + m[3], as, nil
 }
 
 // NewCommitSpec takes a spec string and the current working branch.  The current working branch is only relevant when
@@ -64,18 +134,35 @@ type CommitSpec struct {
 func NewCommitSpec(cSpecStr, cwb string) (*CommitSpec, error) {
 	cSpecStrLwr := strings.TrimSpace(cSpecStr)
 
-	name, as, err := SplitAncestorSpec(cSpecStrLwr)
+	if strings.HasPrefix(cSpecStrLwr, messageSearchPrefix) {
+		pattern := cSpecStrLwr[len(messageSearchPrefix):]
+
+		if _, err := regexp.Compile(pattern); err != nil || pattern == "" {
+			return nil, ErrInvalidMessageSearch
+		}
+
+		return &CommitSpec{stringer(pattern), MessageCommitSpec, emptyASpec, nil}, nil
+	}
+
+	withoutAt, at, err := SplitAtSpec(cSpecStrLwr, time.Now())
+
+	if err != nil {
+		return nil, err
+	}
+
+	name, as, err := SplitAncestorSpec(withoutAt)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if strings.ToLower(name) == head {
+	// @{<n>} with no commit refers to the current branch
+	if strings.ToLower(name) == head || (name == "" && at != nil) {
 		name = cwb
 	}
 
 	if hashRegex.MatchString(name) {
-		return &CommitSpec{stringer(name), HashCommitSpec, as}, nil
+		return &CommitSpec{stringer(name), HashCommitSpec, as, at}, nil
 	} else if ref.IsRef(name) {
 		dref, err := ref.Parse(name)
 
@@ -83,9 +170,13 @@ func NewCommitSpec(cSpecStr, cwb string) (*CommitSpec, error) {
 			return nil, err
 		}
 
-		return &CommitSpec{dref, RefCommitSpec, as}, nil
+		return &CommitSpec{dref, RefCommitSpec, as, at}, nil
 	} else if IsValidUserBranchName(name) {
-		return &CommitSpec{ref.NewBranchRef(name), RefCommitSpec, as}, nil
+		// Abbreviated hashes are usually valid branch names as well, so they are resolved as a hash only if there is no
+		// branch with the name.
+		return &CommitSpec{ref.NewBranchRef(name), RefCommitSpec, as, at}, nil
+	} else if partialHashRegex.MatchString(name) {
+		return &CommitSpec{stringer(name), HashCommitSpec, as, at}, nil
 	}
 
 	return nil, ErrInvalidBranchOrHash
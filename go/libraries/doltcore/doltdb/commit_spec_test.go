@@ -16,6 +16,9 @@ package doltdb
 
 import (
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 
 	"github.com/liquidata-inc/dolt/go/libraries/utils/test"
 	"github.com/liquidata-inc/dolt/go/store/hash"
@@ -67,3 +70,66 @@ func TestNewCommitSpec(t *testing.T) {
 		}
 	}
 }
+
+func TestNewCommitSpecWithAtSpec(t *testing.T) {
+	tests := []struct {
+		inputStr       string
+		expectedRefStr string
+		expectedType   CommitSpecType
+		expectedASpec  string
+		expectedN      int
+		expectDate     bool
+		expectErr      bool
+	}{
+		{"master@{2}", "refs/heads/master", RefCommitSpec, "", 2, false, false},
+		{"head@{0}", "refs/heads/master", RefCommitSpec, "", 0, false, false},
+		{"@{3}", "refs/heads/master", RefCommitSpec, "", 3, false, false},
+		{"master@{2019-07-04}", "refs/heads/master", RefCommitSpec, "", 0, true, false},
+		{"master@{2 weeks ago}~1", "refs/heads/master", RefCommitSpec, "~1", 0, true, false},
+		{"master@{last tuesday}", "", "", "", 0, false, true},
+		{"master@{-1}", "", "", "", 0, false, true},
+		{"abcd0123", "refs/heads/abcd0123", RefCommitSpec, "", 0, false, false},
+		{":/fixed .* bug", "fixed .* bug", MessageCommitSpec, "", 0, false, false},
+		{":/[", "", "", "", 0, false, true},
+		{":/", "", "", "", 0, false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.inputStr, func(t *testing.T) {
+			cs, err := NewCommitSpec(test.inputStr, "master")
+
+			if test.expectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedRefStr, cs.CommitStringer.String())
+			assert.Equal(t, test.expectedType, cs.CSType)
+			assert.Equal(t, test.expectedASpec, cs.ASpec.SpecStr)
+
+			if test.expectedType == MessageCommitSpec {
+				assert.Nil(t, cs.AtSpec)
+			} else if assert.NotNil(t, cs.AtSpec) {
+				assert.Equal(t, test.expectedN, cs.AtSpec.N)
+				assert.Equal(t, test.expectDate, !cs.AtSpec.Date.IsZero())
+			}
+		})
+	}
+}
+
+func TestNewAtSpec(t *testing.T) {
+	now := time.Date(2019, 7, 10, 12, 0, 0, 0, time.UTC)
+
+	as, err := NewAtSpec("yesterday", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2019, 7, 9, 0, 0, 0, 0, time.UTC), as.Date)
+
+	as, err = NewAtSpec(" 5 ", now)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, as.N)
+	assert.True(t, as.Date.IsZero())
+
+	_, err = NewAtSpec("", now)
+	assert.Equal(t, ErrInvalidAtSpec, err)
+}
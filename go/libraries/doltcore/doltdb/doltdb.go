@@ -18,10 +18,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/remotestorage"
 	"github.com/liquidata-inc/dolt/go/store/chunks"
 	"github.com/liquidata-inc/dolt/go/store/spec"
 	"github.com/liquidata-inc/dolt/go/store/types/edits"
@@ -30,6 +33,7 @@ import (
 	"github.com/liquidata-inc/dolt/go/libraries/utils/pantoerr"
 	"github.com/liquidata-inc/dolt/go/store/datas"
 	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
 	"github.com/liquidata-inc/dolt/go/store/types"
 )
 
@@ -219,6 +223,187 @@ func getCommitStForHash(ctx context.Context, db datas.Database, c string) (types
 	return valSt, nil
 }
 
+// getCommitStForPartialHash returns the commit whose hash begins with the prefix given, searching every commit
+// reachable from any ref. Returns an AmbiguousHashError if more than one commit matches.
+func (ddb *DoltDB) getCommitStForPartialHash(ctx context.Context, prefix string) (types.Struct, error) {
+	var matches []types.Struct
+	var candidates []string
+	err := ddb.iterAllCommits(ctx, func(h hash.Hash, cm *Commit) (bool, error) {
+		if hashStr := h.String(); strings.HasPrefix(hashStr, prefix) {
+			matches = append(matches, cm.commitSt)
+			candidates = append(candidates, hashStr)
+		}
+
+		return false, nil
+	})
+
+	if err != nil {
+		return types.EmptyStruct(ddb.db.Format()), err
+	}
+
+	switch len(matches) {
+	case 0:
+		return types.EmptyStruct(ddb.db.Format()), ErrHashNotFound
+	case 1:
+		return matches[0], nil
+	default:
+		sort.Strings(candidates)
+		return types.EmptyStruct(ddb.db.Format()), AmbiguousHashError{prefix, candidates}
+	}
+}
+
+// getCommitStForMessage returns the newest commit reachable from any ref whose description matches the regular
+// expression given.
+func (ddb *DoltDB) getCommitStForMessage(ctx context.Context, pattern string) (types.Struct, error) {
+	re, err := regexp.Compile(pattern)
+
+	if err != nil {
+		return types.EmptyStruct(ddb.db.Format()), ErrInvalidMessageSearch
+	}
+
+	var newest *Commit
+	var newestTS uint64
+	err = ddb.iterAllCommits(ctx, func(h hash.Hash, cm *Commit) (bool, error) {
+		meta, err := cm.GetCommitMeta()
+
+		if err != nil {
+			return false, err
+		}
+
+		if re.MatchString(meta.Description) && (newest == nil || meta.Timestamp > newestTS) {
+			newest, newestTS = cm, meta.Timestamp
+		}
+
+		return false, nil
+	})
+
+	if err != nil {
+		return types.EmptyStruct(ddb.db.Format()), err
+	}
+
+	if newest == nil {
+		return types.EmptyStruct(ddb.db.Format()), ErrNoMatchingCommit
+	}
+
+	return newest.commitSt, nil
+}
+
+// iterAllCommits calls the callback given once for each commit reachable from any ref, until it returns true or an
+// error.
+func (ddb *DoltDB) iterAllCommits(ctx context.Context, cb func(h hash.Hash, cm *Commit) (stop bool, err error)) error {
+	refs, err := ddb.GetRefs(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	var toVisit []types.Struct
+	for _, dref := range refs {
+		commitSt, err := getCommitStForRef(ctx, ddb.db, dref)
+
+		if err == ErrBranchNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		toVisit = append(toVisit, commitSt)
+	}
+
+	visited := make(map[hash.Hash]bool)
+	for len(toVisit) > 0 {
+		cm := &Commit{ddb.db, toVisit[len(toVisit)-1]}
+		toVisit = toVisit[:len(toVisit)-1]
+
+		h, err := cm.HashOf()
+
+		if err != nil {
+			return err
+		}
+
+		if visited[h] {
+			continue
+		}
+
+		visited[h] = true
+		stop, err := cb(h, cm)
+
+		if err != nil {
+			return err
+		} else if stop {
+			return nil
+		}
+
+		numParents, err := cm.NumParents()
+
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < numParents; i++ {
+			parentSt, err := cm.getParent(ctx, i)
+
+			if err != nil {
+				return err
+			}
+
+			if parentSt != nil {
+				toVisit = append(toVisit, *parentSt)
+			}
+		}
+	}
+
+	return nil
+}
+
+// walkAtSpec follows the first parents of the commit given until reaching the commit selected by the AtSpec.
+func walkAtSpec(ctx context.Context, db datas.Database, commitSt types.Struct, atSpec *AtSpec) (types.Struct, error) {
+	if atSpec == nil {
+		return commitSt, nil
+	}
+
+	cm := &Commit{db, commitSt}
+	for i := 0; ; i++ {
+		if atSpec.Date.IsZero() {
+			if i == atSpec.N {
+				return cm.commitSt, nil
+			}
+		} else {
+			meta, err := cm.GetCommitMeta()
+
+			if err != nil {
+				return types.EmptyStruct(db.Format()), err
+			}
+
+			if !meta.Time().After(atSpec.Date) {
+				return cm.commitSt, nil
+			}
+		}
+
+		numParents, err := cm.NumParents()
+
+		if err != nil {
+			return types.EmptyStruct(db.Format()), err
+		}
+
+		if numParents == 0 {
+			return types.EmptyStruct(db.Format()), ErrNoMatchingCommit
+		}
+
+		parentSt, err := cm.getParent(ctx, 0)
+
+		if err != nil {
+			return types.EmptyStruct(db.Format()), err
+		}
+
+		if parentSt == nil {
+			return types.EmptyStruct(db.Format()), ErrNoMatchingCommit
+		}
+
+		cm = &Commit{db, *parentSt}
+	}
+}
+
 func walkAncestorSpec(ctx context.Context, db datas.Database, commitSt types.Struct, aSpec *AncestorSpec) (types.Struct, error) {
 	if aSpec == nil || len(aSpec.Instructions) == 0 {
 		return commitSt, nil
@@ -261,16 +446,39 @@ func (ddb *DoltDB) Resolve(ctx context.Context, cs *CommitSpec) (*Commit, error)
 
 	var commitSt types.Struct
 	var err error
-	if cs.CSType == HashCommitSpec {
-		commitSt, err = getCommitStForHash(ctx, ddb.db, cs.CommitStringer.String())
-	} else if cs.CSType == RefCommitSpec {
-		commitSt, err = getCommitStForRef(ctx, ddb.db, cs.CommitStringer.(ref.DoltRef))
+	switch cs.CSType {
+	case HashCommitSpec:
+		hashStr := cs.CommitStringer.String()
+		if hashRegex.MatchString(hashStr) {
+			commitSt, err = getCommitStForHash(ctx, ddb.db, hashStr)
+		} else {
+			commitSt, err = ddb.getCommitStForPartialHash(ctx, hashStr)
+		}
+	case RefCommitSpec:
+		dref := cs.CommitStringer.(ref.DoltRef)
+		commitSt, err = getCommitStForRef(ctx, ddb.db, dref)
+
+		if err == ErrBranchNotFound && dref.GetType() == ref.BranchRefType && partialHashRegex.MatchString(dref.GetPath()) {
+			commitSt, err = ddb.getCommitStForPartialHash(ctx, dref.GetPath())
+
+			if err == ErrHashNotFound {
+				err = ErrBranchNotFound
+			}
+		}
+	case MessageCommitSpec:
+		commitSt, err = ddb.getCommitStForMessage(ctx, cs.CommitStringer.String())
 	}
 
 	if err != nil {
 		return nil, err
 	}
 
+	commitSt, err = walkAtSpec(ctx, ddb.db, commitSt, cs.AtSpec)
+
+	if err != nil {
+		return nil, err
+	}
+
 	commitSt, err = walkAncestorSpec(ctx, ddb.db, commitSt, cs.ASpec)
 
 	if err != nil {
@@ -466,11 +674,22 @@ func (ddb *DoltDB) CommitWithParents(ctx context.Context, valHash hash.Hash, dre
 	return &Commit{ddb.db, commitSt}, nil
 }
 
+// Close closes the underlying noms database, after which the DoltDB can't be used. Databases of remotes write the
+// chunks they read to the chunk cache when they are closed.
+func (ddb *DoltDB) Close() error {
+	return ddb.db.Close()
+}
+
 // ValueReadWriter returns the underlying noms database as a types.ValueReadWriter.
 func (ddb *DoltDB) ValueReadWriter() types.ValueReadWriter {
 	return ddb.db
 }
 
+// Stats returns the statistics reported by the underlying chunk store, such as nbs.Stats for a local repository.
+func (ddb *DoltDB) Stats() interface{} {
+	return ddb.db.Stats()
+}
+
 func writeValAndGetRef(ctx context.Context, vrw types.ValueReadWriter, val types.Value) (types.Ref, error) {
 	valRef, err := types.NewRef(val, vrw.Format())
 
@@ -497,7 +716,8 @@ func writeValAndGetRef(ctx context.Context, vrw types.ValueReadWriter, val types
 
 // ResolveParent returns the n-th ancestor of a given commit (direct parent is index 0). error return value will be
 // non-nil in the case that the commit cannot be resolved, there aren't as many ancestors as requested, or the
-// underlying storage cannot be accessed.
+// underlying storage cannot be accessed. ErrBeyondShallowBoundary is returned if the parent was left out of a shallow
+// history.
 func (ddb *DoltDB) ResolveParent(ctx context.Context, commit *Commit, parentIdx int) (*Commit, error) {
 	var parentCommitSt types.Struct
 	parentSet, err := commit.getParents()
@@ -522,6 +742,8 @@ func (ddb *DoltDB) ResolveParent(ctx context.Context, commit *Commit, parentIdx
 
 	if err != nil {
 		return nil, err
+	} else if parentVal == nil {
+		return nil, ErrBeyondShallowBoundary
 	}
 
 	parentCommitSt = parentVal.(types.Struct)
@@ -552,30 +774,28 @@ func (ddb *DoltDB) GetRefs(ctx context.Context) ([]ref.DoltRef, error) {
 }
 
 func (ddb *DoltDB) GetRefsOfType(ctx context.Context, refTypeFilter map[ref.RefType]struct{}) ([]ref.DoltRef, error) {
-	var branches []ref.DoltRef
-	dss, err := ddb.db.Datasets(ctx)
+	refs, err := ddb.refHashes(ctx)
 
 	if err != nil {
 		return nil, err
 	}
 
-	err = dss.IterAll(ctx, func(key, _ types.Value) error {
-		keyStr := string(key.(types.String))
+	keys := make([]string, 0, len(refs))
+	for keyStr := range refs {
+		keys = append(keys, keyStr)
+	}
+
+	sort.Strings(keys)
 
-		var dref ref.DoltRef
+	var branches []ref.DoltRef
+	for _, keyStr := range keys {
 		if ref.IsRef(keyStr) {
-			dref, _ = ref.Parse(keyStr)
+			dref, _ := ref.Parse(keyStr)
 
 			if _, ok := refTypeFilter[dref.GetType()]; ok {
 				branches = append(branches, dref)
 			}
 		}
-
-		return nil
-	})
-
-	if err != nil {
-		return nil, err
 	}
 
 	return branches, nil
@@ -621,25 +841,586 @@ func (ddb *DoltDB) DeleteBranch(ctx context.Context, dref ref.DoltRef) error {
 }
 
 // PushChunks initiates a push into a database from the source database given, at the commit given. Pull progress is
-// communicated over the provided channel.
-func (ddb *DoltDB) PushChunks(ctx context.Context, srcDB *DoltDB, cm *Commit, progChan chan datas.PullProgress) error {
+// communicated over the provided channel. If cp is not nil the progress of the push is saved with it, and a push which
+// was interrupted resumes where it stopped.
+func (ddb *DoltDB) PushChunks(ctx context.Context, srcDB *DoltDB, cm *Commit, cp datas.PullCheckpointer, progChan chan datas.PullProgress) error {
 	rf, err := types.NewRef(cm.commitSt, ddb.db.Format())
 
 	if err != nil {
 		return err
 	}
 
+	if cp != nil {
+		return datas.ResumablePull(ctx, srcDB.db, ddb.db, rf, cp, progChan)
+	}
+
 	return datas.Pull(ctx, srcDB.db, ddb.db, rf, progChan)
 }
 
 // PullChunks initiates a pull into a database from the source database given, at the commit given. Progress is
-// communicated over the provided channel.
-func (ddb *DoltDB) PullChunks(ctx context.Context, srcDB *DoltDB, cm *Commit, progChan chan datas.PullProgress) error {
+// communicated over the provided channel. If cp is not nil the progress of the pull is saved with it, and a pull which
+// was interrupted resumes where it stopped.
+func (ddb *DoltDB) PullChunks(ctx context.Context, srcDB *DoltDB, cm *Commit, cp datas.PullCheckpointer, progChan chan datas.PullProgress) error {
 	rf, err := types.NewRef(cm.commitSt, ddb.db.Format())
 
 	if err != nil {
 		return err
 	}
 
+	if cp != nil {
+		return datas.ResumablePull(ctx, srcDB.db, ddb.db, rf, cp, progChan)
+	}
+
 	return datas.PullWithoutBatching(ctx, srcDB.db, ddb.db, rf, progChan)
 }
+
+// tableFileBehindRatio is how many times more chunks the source database must hold than the database pulled into for
+// PullTableFiles to copy whole table files.
+const tableFileBehindRatio = 2
+
+// PullTableFiles copies the table files of the source database given into the database, concurrency table files at a
+// time, when the database is empty or far behind the source database. Copying whole table files avoids the per chunk
+// overhead of a pull, but may copy chunks the database already has. Pulling the commits of the source database after
+// its table files have been copied finds their chunks already in the database. Returns false without copying anything
+// if either database can't copy table files, or the database isn't far enough behind. Progress is communicated over
+// the provided channel.
+func (ddb *DoltDB) PullTableFiles(ctx context.Context, srcDB *DoltDB, concurrency int, progChan chan datas.PullProgress) (bool, error) {
+	srcTfs, ok := tableFileStore(srcDB)
+
+	if !ok {
+		return false, nil
+	}
+
+	sinkTfs, ok := tableFileStore(ddb)
+
+	if !ok {
+		return false, nil
+	}
+
+	srcCount, err := tableFileChunkCount(ctx, srcTfs)
+
+	if err == remotestorage.ErrTableFilesUnsupported {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	sinkCount, err := tableFileChunkCount(ctx, sinkTfs)
+
+	if err == remotestorage.ErrTableFilesUnsupported {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if srcCount == 0 || sinkCount*tableFileBehindRatio > srcCount {
+		return false, nil
+	}
+
+	err = remotestorage.CopyTableFiles(ctx, srcTfs, sinkTfs, concurrency, progChan)
+
+	if err == nbs.ErrTableFileWritesUnsupported {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func tableFileStore(ddb *DoltDB) (nbs.TableFileStore, bool) {
+	cs, ok := ddb.chunkStore()
+
+	if !ok {
+		return nil, false
+	}
+
+	tfs, ok := cs.(nbs.TableFileStore)
+	return tfs, ok
+}
+
+func tableFileChunkCount(ctx context.Context, tfs nbs.TableFileStore) (uint64, error) {
+	tableFiles, err := tfs.TableFiles(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var count uint64
+	for _, tf := range tableFiles {
+		count += uint64(tf.ChunkCount())
+	}
+
+	return count, nil
+}
+
+// SetHead sets the head of the ref given to the commit given, whether or not it is a fast forward.
+func (ddb *DoltDB) SetHead(ctx context.Context, dref ref.DoltRef, commit *Commit) error {
+	ds, err := ddb.db.GetDataset(ctx, dref.String())
+
+	if err != nil {
+		return err
+	}
+
+	rf, err := types.NewRef(commit.commitSt, ddb.db.Format())
+
+	if err != nil {
+		return err
+	}
+
+	_, err = ddb.db.SetHead(ctx, ds, rf)
+
+	return err
+}
+
+// RefHash returns the hash of the commit the ref given points at, and false if the ref doesn't exist. The refs of
+// remote databases which support it are listed without reading the database's root map of refs.
+func (ddb *DoltDB) RefHash(ctx context.Context, dref ref.DoltRef) (hash.Hash, bool, error) {
+	refs, err := ddb.refHashes(ctx)
+
+	if err != nil {
+		return hash.Hash{}, false, err
+	}
+
+	h, ok := refs[dref.String()]
+	return h, ok, nil
+}
+
+// CompareAndSetRef points the ref given at the commit given if it currently points at the commit with the expected
+// hash, where the empty hash means the ref is expected not to exist. Returns ErrRefChanged, leaving the ref unchanged,
+// if it doesn't. Remote databases which support it apply the update on the server, so changes made to other refs of the
+// database at the same time don't cause the update to fail.
+func (ddb *DoltDB) CompareAndSetRef(ctx context.Context, dref ref.DoltRef, expected hash.Hash, commit *Commit) error {
+	h, err := commit.HashOf()
+
+	if err != nil {
+		return err
+	}
+
+	updates := []datas.HeadUpdate{{ID: dref.String(), Expected: expected, New: h}}
+
+	if dcs, ok := ddb.remoteChunkStore(); ok {
+		success, err := dcs.UpdateRefs(ctx, updates)
+
+		if err == nil {
+			if !success {
+				return ErrRefChanged
+			}
+
+			return nil
+		} else if err != remotestorage.ErrRefUpdatesUnsupported {
+			return err
+		}
+	}
+
+	err = ddb.db.UpdateHeads(ctx, updates)
+
+	if err == datas.ErrHeadChanged {
+		return ErrRefChanged
+	}
+
+	return err
+}
+
+// refHashes returns the hashes of the commits all the refs of the database point at, keyed by the ref's full name.
+func (ddb *DoltDB) refHashes(ctx context.Context) (map[string]hash.Hash, error) {
+	if dcs, ok := ddb.remoteChunkStore(); ok {
+		refs, err := dcs.ListRefs(ctx)
+
+		if err != remotestorage.ErrRefUpdatesUnsupported {
+			return refs, err
+		}
+	}
+
+	dss, err := ddb.db.Datasets(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make(map[string]hash.Hash)
+	err = dss.IterAll(ctx, func(key, val types.Value) error {
+		refs[string(key.(types.String))] = val.(types.Ref).TargetHash()
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return refs, nil
+}
+
+// remoteChunkStore returns the DoltChunkStore of a remote database.
+func (ddb *DoltDB) remoteChunkStore() (*remotestorage.DoltChunkStore, bool) {
+	cs, ok := ddb.chunkStore()
+
+	if !ok {
+		return nil, false
+	}
+
+	dcs, ok := cs.(*remotestorage.DoltChunkStore)
+	return dcs, ok
+}
+
+// SetChunkCompression sets the compression of the chunks of the table files written by the database, if it is stored
+// in table files. Must be called before the database is wrapped by SetLazyFetch.
+func (ddb *DoltDB) SetChunkCompression(compression nbs.ChunkCompression) {
+	cs, ok := ddb.chunkStore()
+
+	if !ok {
+		return
+	}
+
+	if nbsCS, ok := cs.(interface{ SetChunkCompression(nbs.ChunkCompression) }); ok {
+		nbsCS.SetChunkCompression(compression)
+	}
+}
+
+// SetEncryptionKey sets the key which the chunks of the table files written by the database are encrypted with, if it
+// is stored in table files. Returns an error if the database's table files are encrypted with a different key, are
+// encrypted and key is nil, or were written without a key, as existing table files are not re-encrypted. Must be called
+// before the database is wrapped by SetLazyFetch.
+func (ddb *DoltDB) SetEncryptionKey(key *nbs.EncryptionKey) error {
+	cs, ok := ddb.chunkStore()
+
+	if !ok {
+		return nil
+	}
+
+	if nbsCS, ok := cs.(interface {
+		SetEncryptionKey(*nbs.EncryptionKey) error
+	}); ok {
+		return nbsCS.SetEncryptionKey(key)
+	}
+
+	return nil
+}
+
+// Compact rewrites the table files of the database into new table files of about nbs.DefaultCompactionTargetSize bytes
+// each, storing the chunks of each value near each other in the order they are reached from the root of the database.
+// Returns nbs.ErrStoreInUse if another process is using the database, and ErrCompactionNotSupported unless the database is
+// stored in table files on the local filesystem.
+func (ddb *DoltDB) Compact(ctx context.Context) error {
+	cs, ok := ddb.chunkStore()
+
+	if !ok {
+		return ErrCompactionNotSupported
+	}
+
+	nbsCS, ok := cs.(interface {
+		Compact(context.Context, uint64, nbs.ChunkRefs) error
+	})
+
+	if !ok {
+		return ErrCompactionNotSupported
+	}
+
+	nbf := ddb.db.Format()
+	err := nbsCS.Compact(ctx, nbs.DefaultCompactionTargetSize, func(c chunks.Chunk) (hash.HashSlice, error) {
+		var refs hash.HashSlice
+		err := types.WalkRefs(c, nbf, func(r types.Ref) error {
+			refs = append(refs, r.TargetHash())
+			return nil
+		})
+
+		return refs, err
+	})
+
+	if err == nbs.ErrCompactionUnsupported {
+		return ErrCompactionNotSupported
+	}
+
+	return err
+}
+
+// SetShallow marks the database as holding a shallow history. The parents of the commits at the boundary of a shallow
+// history are missing, so the check that every value written references only values in the database is disabled.
+func (ddb *DoltDB) SetShallow() {
+	ddb.disableCompletenessCheck()
+}
+
+// SetLazyFetch marks the database as holding a partial copy of a remote's data, and reads any chunk missing from the
+// database from the remote database opened by the function given. The remote is opened the first time a chunk is
+// missing. As values written may reference chunks which have not been fetched, the check that every value written
+// references only values in the database is disabled.
+func (ddb *DoltDB) SetLazyFetch(openRemote func(ctx context.Context) (*DoltDB, error)) {
+	cs, ok := ddb.chunkStore()
+
+	if !ok {
+		return
+	}
+
+	lcs := remotestorage.NewLazyChunkStore(cs, func(ctx context.Context) (chunks.ChunkStore, error) {
+		remoteDB, err := openRemote(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		remoteCS, ok := remoteDB.chunkStore()
+
+		if !ok {
+			return nil, errors.New("unable to read chunks from the remote database")
+		}
+
+		return remoteCS, nil
+	})
+
+	ddb.db = datas.NewDatabase(lcs)
+	ddb.disableCompletenessCheck()
+}
+
+func (ddb *DoltDB) disableCompletenessCheck() {
+	if vs, ok := ddb.db.(interface{ SetEnforceCompleteness(bool) }); ok {
+		vs.SetEnforceCompleteness(false)
+	}
+}
+
+// chunkStore returns the ChunkStore underlying the database, if it can be accessed.
+func (ddb *DoltDB) chunkStore() (chunks.ChunkStore, bool) {
+	if vs, ok := ddb.db.(interface{ ChunkStore() chunks.ChunkStore }); ok {
+		return vs.ChunkStore(), true
+	}
+
+	return nil, false
+}
+
+// PullChunksShallow pulls into the database the chunks of the commit given and of its ancestors up to depth commits
+// back, along with everything their root values reference, from the source database given. Returns the hashes of the
+// commits pulled whose parents were left out. Progress is communicated over the provided channel.
+//
+// Commits already in the database are not walked by a pull, so each commit within the depth is pulled in turn. This
+// deepens a shallow history whose boundary commits are within the depth.
+func (ddb *DoltDB) PullChunksShallow(ctx context.Context, srcDB *DoltDB, cm *Commit, depth int, progChan chan datas.PullProgress) ([]hash.Hash, error) {
+	if depth < 1 {
+		return nil, errors.New("depth must be at least 1")
+	}
+
+	h, err := cm.HashOf()
+
+	if err != nil {
+		return nil, err
+	}
+
+	included := hash.NewHashSet(h)
+	level := []*Commit{cm}
+	toPull := []*Commit{cm}
+	var boundary []hash.Hash
+	exclude := hash.HashSet{}
+	for d := 1; len(level) > 0; d++ {
+		var next []*Commit
+		for _, levelCm := range level {
+			parents, err := levelCm.ParentHashes(ctx)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if d == depth {
+				var excluded bool
+				for _, p := range parents {
+					if !included.Has(p) {
+						exclude.Insert(p)
+						excluded = true
+					}
+				}
+
+				if excluded {
+					levelHash, err := levelCm.HashOf()
+
+					if err != nil {
+						return nil, err
+					}
+
+					boundary = append(boundary, levelHash)
+				}
+
+				continue
+			}
+
+			for i, p := range parents {
+				if included.Has(p) {
+					continue
+				}
+
+				parent, err := srcDB.ResolveParent(ctx, levelCm, i)
+
+				if err != nil {
+					return nil, err
+				}
+
+				included.Insert(p)
+				next = append(next, parent)
+				toPull = append(toPull, parent)
+			}
+		}
+
+		level = next
+	}
+
+	for _, pullCm := range toPull {
+		rf, err := types.NewRef(pullCm.commitSt, ddb.db.Format())
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = datas.PullExcluding(ctx, srcDB.db, ddb.db, rf, exclude, progChan)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return boundary, nil
+}
+
+// ShallowCommits returns the commits from those given which are in the database but are missing one or more of
+// their parents.
+func (ddb *DoltDB) ShallowCommits(ctx context.Context, hashes []hash.Hash) ([]hash.Hash, error) {
+	var shallow []hash.Hash
+	for _, h := range hashes {
+		val, err := ddb.db.ReadValue(ctx, h)
+
+		if err != nil {
+			return nil, err
+		} else if val == nil {
+			continue
+		}
+
+		st, ok := val.(types.Struct)
+
+		if !ok {
+			return nil, ErrFoundHashNotACommit
+		}
+
+		cm := &Commit{ddb.ValueReadWriter(), st}
+		parents, err := cm.ParentHashes(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range parents {
+			parentVal, err := ddb.db.ReadValue(ctx, p)
+
+			if err != nil {
+				return nil, err
+			} else if parentVal == nil {
+				shallow = append(shallow, h)
+				break
+			}
+		}
+	}
+
+	return shallow, nil
+}
+
+// PullChunksPartial pulls into the database the chunks of the commit given and of all its ancestors, along with
+// everything their root values reference except the row data of tables not in the list given, from the source database
+// given. The row data left out can be read later by setting up lazy fetching with SetLazyFetch. Progress is
+// communicated over the provided channel.
+func (ddb *DoltDB) PullChunksPartial(ctx context.Context, srcDB *DoltDB, cm *Commit, tables []string, progChan chan datas.PullProgress) error {
+	cs, ok := ddb.chunkStore()
+
+	if !ok {
+		return errors.New("partial pulls are not supported by this database")
+	}
+
+	tableSet := make(map[string]bool, len(tables))
+	for _, tbl := range tables {
+		tableSet[tbl] = true
+	}
+
+	keep := hash.HashSet{}
+	exclude := hash.HashSet{}
+	visited := hash.HashSet{}
+	toVisit := []*Commit{cm}
+	for len(toVisit) > 0 {
+		curr := toVisit[len(toVisit)-1]
+		toVisit = toVisit[:len(toVisit)-1]
+
+		h, err := curr.HashOf()
+
+		if err != nil {
+			return err
+		}
+
+		if visited.Has(h) {
+			continue
+		}
+
+		visited.Insert(h)
+
+		// the commits the database already has, and their ancestors, won't be pulled
+		if exists, err := cs.Has(ctx, h); err != nil {
+			return err
+		} else if exists {
+			continue
+		}
+
+		root, err := curr.GetRootValue()
+
+		if err != nil {
+			return err
+		}
+
+		tblNames, err := root.GetTableNames(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		for _, tblName := range tblNames {
+			tbl, _, err := root.GetTable(ctx, tblName)
+
+			if err != nil {
+				return err
+			}
+
+			rowsHash, err := tbl.getRowDataHash()
+
+			if err != nil {
+				return err
+			}
+
+			if tableSet[tblName] {
+				keep.Insert(rowsHash)
+			} else {
+				exclude.Insert(rowsHash)
+			}
+		}
+
+		numParents, err := curr.NumParents()
+
+		if err != nil {
+			return err
+		}
+
+		for i := 0; i < numParents; i++ {
+			parent, err := srcDB.ResolveParent(ctx, curr, i)
+
+			if err == ErrBeyondShallowBoundary {
+				continue
+			} else if err != nil {
+				return err
+			}
+
+			toVisit = append(toVisit, parent)
+		}
+	}
+
+	// row data shared with one of the tables being pulled is pulled
+	for h := range keep {
+		exclude.Remove(h)
+	}
+
+	rf, err := types.NewRef(cm.commitSt, ddb.db.Format())
+
+	if err != nil {
+		return err
+	}
+
+	return datas.PullExcluding(ctx, srcDB.db, ddb.db, rf, exclude, progChan)
+}
@@ -17,10 +17,13 @@ package doltdb
 import (
 	"context"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
@@ -219,3 +222,292 @@ func TestLDNoms(t *testing.T) {
 		}
 	}
 }
+
+func TestResolveRevisionSpecs(t *testing.T) {
+	ctx := context.Background()
+	ddb, err := LoadDoltDB(ctx, types.Format_7_18, InMemDoltDB)
+	require.NoError(t, err)
+	require.NoError(t, ddb.WriteEmptyRepo(ctx, "Bill Billerson", "bigbillieb@fake.horse"))
+
+	cs, _ := NewCommitSpec("HEAD", "master")
+	initCommit, err := ddb.Resolve(ctx, cs)
+	require.NoError(t, err)
+	root, err := initCommit.GetRootValue()
+	require.NoError(t, err)
+	valHash, err := ddb.WriteRootValue(ctx, root)
+	require.NoError(t, err)
+
+	// three commits on master, a day apart starting on 2019-07-01
+	messages := []string{"first change", "second change", "fixed the prices table"}
+	hashes := make([]hash.Hash, len(messages))
+	for i, msg := range messages {
+		ts := time.Date(2019, 7, 1+i, 12, 0, 0, 0, time.UTC)
+		meta := &CommitMeta{"Bill Billerson", "bigbillieb@fake.horse", uint64(ts.UnixNano()) / milliToNano, msg}
+		cm, err := ddb.Commit(ctx, valHash, ref.NewBranchRef("master"), meta)
+		require.NoError(t, err)
+		hashes[i], err = cm.HashOf()
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		spec     string
+		expected hash.Hash
+		err      error
+	}{
+		{"master@{0}", hashes[2], nil},
+		{"master@{1}", hashes[1], nil},
+		{"HEAD@{2}", hashes[0], nil},
+		{"master@{1}~1", hashes[0], nil},
+		{"master@{2019-07-02T18:00:00Z}", hashes[1], nil},
+		{"master@{2019-07-03T12:00:00Z}", hashes[2], nil},
+		{"master@{2001-01-01}", hash.Hash{}, ErrNoMatchingCommit},
+		{"master@{10}", hash.Hash{}, ErrNoMatchingCommit},
+		{":/prices", hashes[2], nil},
+		{":/change$", hashes[1], nil},
+		{":/^first", hashes[0], nil},
+		{":/no such message", hash.Hash{}, ErrNoMatchingCommit},
+		{hashes[1].String()[:8], hashes[1], nil},
+		{hashes[1].String()[:8] + "~1", hashes[0], nil},
+		{"vvvvvvvv", hash.Hash{}, ErrBranchNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			cs, err := NewCommitSpec(test.spec, "master")
+			require.NoError(t, err)
+
+			cm, err := ddb.Resolve(ctx, cs)
+
+			if test.err != nil {
+				assert.Equal(t, test.err, err)
+				return
+			}
+
+			require.NoError(t, err)
+			h, err := cm.HashOf()
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, h)
+		})
+	}
+}
+
+func TestAmbiguousHashError(t *testing.T) {
+	err := AmbiguousHashError{"abcd", []string{"abcd1", "abcd2"}}
+	assert.Contains(t, err.Error(), "abcd1, abcd2")
+}
+
+func TestPullChunksShallow(t *testing.T) {
+	ctx := context.Background()
+	srcDB, err := LoadDoltDB(ctx, types.Format_7_18, InMemDoltDB)
+	require.NoError(t, err)
+	require.NoError(t, srcDB.WriteEmptyRepo(ctx, "Bill Billerson", "bigbillieb@fake.horse"))
+
+	cs, _ := NewCommitSpec("HEAD", "master")
+	initCommit, err := srcDB.Resolve(ctx, cs)
+	require.NoError(t, err)
+	root, err := initCommit.GetRootValue()
+	require.NoError(t, err)
+	valHash, err := srcDB.WriteRootValue(ctx, root)
+	require.NoError(t, err)
+
+	hashes := make([]hash.Hash, 3)
+	var head *Commit
+	for i := range hashes {
+		meta, err := NewCommitMeta("Bill Billerson", "bigbillieb@fake.horse", "change")
+		require.NoError(t, err)
+		head, err = srcDB.Commit(ctx, valHash, ref.NewBranchRef("master"), meta)
+		require.NoError(t, err)
+		hashes[i], err = head.HashOf()
+		require.NoError(t, err)
+	}
+
+	destDB, err := LoadDoltDB(ctx, types.Format_7_18, InMemDoltDB)
+	require.NoError(t, err)
+	destDB.SetShallow()
+
+	boundary, err := destDB.PullChunksShallow(ctx, srcDB, head, 2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []hash.Hash{hashes[1]}, boundary)
+	require.NoError(t, destDB.SetHead(ctx, ref.NewBranchRef("master"), head))
+
+	destHead, err := destDB.Resolve(ctx, cs)
+	require.NoError(t, err)
+	parent, err := destDB.ResolveParent(ctx, destHead, 0)
+	require.NoError(t, err)
+	_, err = destDB.ResolveParent(ctx, parent, 0)
+	assert.Equal(t, ErrBeyondShallowBoundary, err)
+
+	shallow, err := destDB.ShallowCommits(ctx, hashes)
+	require.NoError(t, err)
+	assert.Equal(t, []hash.Hash{hashes[1]}, shallow)
+
+	// fetching with a greater depth deepens the history past the old boundary
+	boundary, err = destDB.PullChunksShallow(ctx, srcDB, head, 10, nil)
+	require.NoError(t, err)
+	assert.Empty(t, boundary)
+
+	shallow, err = destDB.ShallowCommits(ctx, hashes)
+	require.NoError(t, err)
+	assert.Empty(t, shallow)
+}
+
+func TestPullChunksPartial(t *testing.T) {
+	ctx := context.Background()
+	srcDB, err := LoadDoltDB(ctx, types.Format_7_18, InMemDoltDB)
+	require.NoError(t, err)
+	require.NoError(t, srcDB.WriteEmptyRepo(ctx, "Bill Billerson", "bigbillieb@fake.horse"))
+
+	cs, _ := NewCommitSpec("HEAD", "master")
+	initCommit, err := srcDB.Resolve(ctx, cs)
+	require.NoError(t, err)
+	root, err := initCommit.GetRootValue()
+	require.NoError(t, err)
+
+	tSchema := createTestSchema()
+	rowData, rows := createTestRowData(t, srcDB.db, tSchema)
+	otherRowData, err := rowData.Edit().Remove(rows[0].NomsMapKey(tSchema)).Map(ctx)
+	require.NoError(t, err)
+
+	for name, data := range map[string]types.Map{"people": rowData, "others": otherRowData} {
+		tbl, err := createTestTable(srcDB.db, tSchema, data)
+		require.NoError(t, err)
+		root, err = root.PutTable(ctx, srcDB, name, tbl)
+		require.NoError(t, err)
+	}
+
+	valHash, err := srcDB.WriteRootValue(ctx, root)
+	require.NoError(t, err)
+	meta, err := NewCommitMeta("Bill Billerson", "bigbillieb@fake.horse", "two tables")
+	require.NoError(t, err)
+	head, err := srcDB.Commit(ctx, valHash, ref.NewBranchRef("master"), meta)
+	require.NoError(t, err)
+
+	destDB, err := LoadDoltDB(ctx, types.Format_7_18, InMemDoltDB)
+	require.NoError(t, err)
+	require.NoError(t, destDB.PullChunksPartial(ctx, srcDB, head, []string{"people"}, nil))
+
+	destCS, ok := destDB.chunkStore()
+	require.True(t, ok)
+
+	rowDataHash, err := rowData.Hash(types.Format_7_18)
+	require.NoError(t, err)
+	has, err := destCS.Has(ctx, rowDataHash)
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	otherHash, err := otherRowData.Hash(types.Format_7_18)
+	require.NoError(t, err)
+	has, err = destCS.Has(ctx, otherHash)
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	destDB.SetLazyFetch(func(ctx context.Context) (*DoltDB, error) {
+		return srcDB, nil
+	})
+	require.NoError(t, destDB.SetHead(ctx, ref.NewBranchRef("master"), head))
+
+	destHead, err := destDB.Resolve(ctx, cs)
+	require.NoError(t, err)
+	destRoot, err := destHead.GetRootValue()
+	require.NoError(t, err)
+	tbl, ok, err := destRoot.GetTable(ctx, "others")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	data, err := tbl.GetRowData(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, otherRowData.Len(), data.Len())
+}
+
+func TestCompareAndSetRef(t *testing.T) {
+	ctx := context.Background()
+	ddb, err := LoadDoltDB(ctx, types.Format_7_18, InMemDoltDB)
+	require.NoError(t, err)
+	require.NoError(t, ddb.WriteEmptyRepo(ctx, "Bill Billerson", "bigbillieb@fake.horse"))
+
+	master := ref.NewBranchRef("master")
+	cs, _ := NewCommitSpec("HEAD", "master")
+	initCommit, err := ddb.Resolve(ctx, cs)
+	require.NoError(t, err)
+	initHash, err := initCommit.HashOf()
+	require.NoError(t, err)
+	root, err := initCommit.GetRootValue()
+	require.NoError(t, err)
+	valHash, err := ddb.WriteRootValue(ctx, root)
+	require.NoError(t, err)
+
+	h, ok, err := ddb.RefHash(ctx, master)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, initHash, h)
+
+	meta, err := NewCommitMeta("Bill Billerson", "bigbillieb@fake.horse", "change")
+	require.NoError(t, err)
+	other, err := ddb.Commit(ctx, valHash, ref.NewBranchRef("other"), meta)
+	require.NoError(t, err)
+	otherHash, err := other.HashOf()
+	require.NoError(t, err)
+
+	// the update fails if the ref doesn't point at the expected commit
+	err = ddb.CompareAndSetRef(ctx, master, otherHash, other)
+	assert.Equal(t, ErrRefChanged, err)
+	err = ddb.CompareAndSetRef(ctx, ref.NewBranchRef("new"), initHash, other)
+	assert.Equal(t, ErrRefChanged, err)
+
+	require.NoError(t, ddb.CompareAndSetRef(ctx, master, initHash, other))
+	require.NoError(t, ddb.CompareAndSetRef(ctx, ref.NewBranchRef("new"), hash.Hash{}, other))
+
+	for _, branch := range []string{"master", "new"} {
+		h, ok, err = ddb.RefHash(ctx, ref.NewBranchRef(branch))
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, otherHash, h)
+	}
+
+	_, ok, err = ddb.RefHash(ctx, ref.NewBranchRef("missing"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestPullTableFiles(t *testing.T) {
+	ctx := context.Background()
+	srcDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(srcDir)
+	destDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(destDir)
+
+	srcDB, err := LoadDoltDB(ctx, types.Format_7_18, "file://"+srcDir)
+	require.NoError(t, err)
+	require.NoError(t, srcDB.WriteEmptyRepo(ctx, "Bill Billerson", "bigbillieb@fake.horse"))
+
+	cs, _ := NewCommitSpec("HEAD", "master")
+	head, err := srcDB.Resolve(ctx, cs)
+	require.NoError(t, err)
+
+	destDB, err := LoadDoltDB(ctx, types.Format_7_18, "file://"+destDir)
+	require.NoError(t, err)
+
+	copied, err := destDB.PullTableFiles(ctx, srcDB, 2, nil)
+	require.NoError(t, err)
+	assert.True(t, copied)
+
+	headHash, err := head.HashOf()
+	require.NoError(t, err)
+	destCS, ok := destDB.chunkStore()
+	require.True(t, ok)
+	has, err := destCS.Has(ctx, headHash)
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	require.NoError(t, destDB.PullChunks(ctx, srcDB, head, nil, nil))
+	require.NoError(t, destDB.SetHead(ctx, ref.NewBranchRef("master"), head))
+	_, err = destDB.Resolve(ctx, cs)
+	require.NoError(t, err)
+
+	// the database is no longer behind, so nothing more is copied
+	copied, err = destDB.PullTableFiles(ctx, srcDB, 2, nil)
+	require.NoError(t, err)
+	assert.False(t, copied)
+}
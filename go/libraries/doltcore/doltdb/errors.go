@@ -14,18 +14,26 @@
 
 package doltdb
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var ErrInvBranchName = errors.New("not a valid user branch name")
 var ErrInvTableName = errors.New("not a valid table name")
 var ErrInvHash = errors.New("not a valid hash")
 var ErrInvalidAnscestorSpec = errors.New("invalid anscestor spec")
 var ErrInvalidBranchOrHash = errors.New("string is not a valid branch or hash")
+var ErrInvalidAtSpec = errors.New("invalid @{} spec, expected a number of commits or a date")
+var ErrInvalidMessageSearch = errors.New("invalid commit message search, expected :/<regex>")
 
 var ErrFoundHashNotACommit = errors.New("the value retrieved for this hash is not a commit")
 
 var ErrHashNotFound = errors.New("could not find a value for this hash")
+var ErrNoMatchingCommit = errors.New("no commit matches the spec")
 var ErrBranchNotFound = errors.New("branch not found")
+var ErrRefChanged = errors.New("ref does not point at the expected commit")
 var ErrTableNotFound = errors.New("table not found")
 var ErrTableExists = errors.New("table already exists")
 var ErrAlreadyOnBranch = errors.New("Already on branch")
@@ -37,9 +45,23 @@ var ErrUpToDate = errors.New("up to date")
 var ErrIsAhead = errors.New("current fast forward from a to b. a is ahead of b already")
 var ErrIsBehind = errors.New("cannot reverse from b to a. b is a is behind a already")
 
+var ErrBeyondShallowBoundary = errors.New("commit is beyond the boundary of a shallow history")
+
+var ErrCompactionNotSupported = errors.New("the database is not stored in table files which can be compacted")
+
+// AmbiguousHashError is returned when an abbreviated hash is a prefix of the hashes of more than one commit.
+type AmbiguousHashError struct {
+	Prefix     string
+	Candidates []string
+}
+
+func (e AmbiguousHashError) Error() string {
+	return fmt.Sprintf("short hash '%s' is ambiguous, candidates are: %s", e.Prefix, strings.Join(e.Candidates, ", "))
+}
+
 func IsInvalidFormatErr(err error) bool {
 	switch err {
-	case ErrInvBranchName, ErrInvTableName, ErrInvHash, ErrInvalidAnscestorSpec, ErrInvalidBranchOrHash:
+	case ErrInvBranchName, ErrInvTableName, ErrInvHash, ErrInvalidAnscestorSpec, ErrInvalidBranchOrHash, ErrInvalidAtSpec, ErrInvalidMessageSearch:
 		return true
 	default:
 		return false
@@ -48,7 +70,7 @@ func IsInvalidFormatErr(err error) bool {
 
 func IsNotFoundErr(err error) bool {
 	switch err {
-	case ErrHashNotFound, ErrBranchNotFound, ErrTableNotFound:
+	case ErrHashNotFound, ErrBranchNotFound, ErrTableNotFound, ErrNoMatchingCommit:
 		return true
 	default:
 		return false
@@ -57,7 +79,7 @@ func IsNotFoundErr(err error) bool {
 
 func IsNotACommit(err error) bool {
 	switch err {
-	case ErrHashNotFound, ErrBranchNotFound, ErrFoundHashNotACommit:
+	case ErrHashNotFound, ErrBranchNotFound, ErrFoundHashNotACommit, ErrNoMatchingCommit:
 		return true
 	default:
 		return false
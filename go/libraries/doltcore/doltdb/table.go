@@ -429,6 +429,17 @@ func (t *Table) GetRowData(ctx context.Context) (types.Map, error) {
 	return rowMap, nil
 }
 
+// getRowDataHash returns the hash of the map holding the table's rows, without reading the map.
+func (t *Table) getRowDataHash() (hash.Hash, error) {
+	val, _, err := t.tableStruct.MaybeGet(tableRowsKey)
+
+	if err != nil {
+		return hash.Hash{}, err
+	}
+
+	return val.(types.Ref).TargetHash(), nil
+}
+
 /*func (t *Table) ResolveConflicts(keys []map[uint64]string) (invalid, notFound []types.Value, tbl *Table, err error) {
 	sch := t.GetSchema()
 	pkCols := sch.GetPKCols()
@@ -40,8 +40,19 @@ func MoveBranch(ctx context.Context, dEnv *env.DoltEnv, oldBranch, newBranch str
 		return err
 	}
 
+	// the renamed branch keeps its upstream
+	upstream, changed := dEnv.RepoState.Branches[oldBranch]
+
+	if changed {
+		dEnv.RepoState.Branches[newBranch] = upstream
+	}
+
 	if ref.Equals(dEnv.RepoState.Head.Ref, oldRef) {
 		dEnv.RepoState.Head = ref.MarshalableRef{Ref: newRef}
+		changed = true
+	}
+
+	if changed {
 		err = dEnv.RepoState.Save()
 
 		if err != nil {
@@ -97,7 +108,19 @@ func DeleteBranch(ctx context.Context, dEnv *env.DoltEnv, brName string, force b
 		return ErrCOBranchDelete
 	}
 
-	return DeleteBranchOnDB(ctx, dEnv.DoltDB, dref, force)
+	err := DeleteBranchOnDB(ctx, dEnv.DoltDB, dref, force)
+
+	if err != nil {
+		return err
+	}
+
+	// the upstream of a deleted branch is forgotten with it
+	if _, ok := dEnv.RepoState.Branches[brName]; ok {
+		delete(dEnv.RepoState.Branches, brName)
+		return dEnv.RepoState.Save()
+	}
+
+	return nil
 }
 
 func DeleteBranchOnDB(ctx context.Context, ddb *doltdb.DoltDB, dref ref.DoltRef, force bool) error {
@@ -101,11 +101,11 @@ func CommitStaged(ctx context.Context, dEnv *env.DoltEnv, msg string, allowEmpty
 
 	_, err = dEnv.DoltDB.CommitWithParents(ctx, h, dEnv.RepoState.Head.Ref, mergeCmSpec, meta)
 
-	if err == nil {
-		dEnv.RepoState.ClearMerge()
+	if err != nil {
+		return err
 	}
 
-	return err
+	return dEnv.RepoState.ClearMerge()
 }
 
 func TimeSortedCommits(ctx context.Context, ddb *doltdb.DoltDB, commit *doltdb.Commit, n int) ([]*doltdb.Commit, error) {
@@ -170,7 +170,10 @@ func AddCommits(ctx context.Context, ddb *doltdb.DoltDB, commit *doltdb.Commit,
 	for i := 0; i < numParents && len(hashToCommit) != n; i++ {
 		parentCommit, err := ddb.ResolveParent(ctx, commit, i)
 
-		if err != nil {
+		if err == doltdb.ErrBeyondShallowBoundary {
+			// the history ends at the boundary of a shallow clone
+			continue
+		} else if err != nil {
 			return err
 		}
 
@@ -18,6 +18,7 @@ import (
 	"context"
 	"sort"
 
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
 )
@@ -104,3 +105,30 @@ func GetTableDiffs(ctx context.Context, dEnv *env.DoltEnv) (*TableDiffs, *TableD
 
 	return stagedDiffs, notStagedDiffs, nil
 }
+
+// GetTableDiffSummaries returns a summary of the row changes made to each of the tables given between the older and
+// newer roots.
+func GetTableDiffSummaries(ctx context.Context, newer, older *doltdb.RootValue, tblNames []string) (map[string]diff.DiffSummary, error) {
+	tblToSummary := make(map[string]diff.DiffSummary, len(tblNames))
+	for _, tblName := range tblNames {
+		newTbl, _, err := newer.GetTable(ctx, tblName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		oldTbl, _, err := older.GetTable(ctx, tblName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tblToSummary[tblName], err = diff.SummarizeTableDiff(ctx, oldTbl, newTbl)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tblToSummary, nil
+}
@@ -0,0 +1,408 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// LogFilter restricts the commits returned by Log. The zero value matches every commit.
+type LogFilter struct {
+	// Tables, if not empty, limits the log to commits which changed at least one of the tables.
+	Tables []string
+	// Author, if not nil, limits the log to commits whose "name <email>" matches.
+	Author *regexp.Regexp
+	// Since, if not zero, limits the log to commits made at or after this time.
+	Since time.Time
+	// Until, if not zero, limits the log to commits made at or before this time.
+	Until time.Time
+}
+
+// Matches returns true if the commit given passes the filter.
+func (lf *LogFilter) Matches(ctx context.Context, ddb *doltdb.DoltDB, cm *doltdb.Commit) (bool, error) {
+	meta, err := cm.GetCommitMeta()
+
+	if err != nil {
+		return false, err
+	}
+
+	if lf.Author != nil && !lf.Author.MatchString(fmt.Sprintf("%s <%s>", meta.Name, meta.Email)) {
+		return false, nil
+	}
+
+	cmTime := meta.Time()
+	if !lf.Since.IsZero() && cmTime.Before(lf.Since) {
+		return false, nil
+	}
+
+	if !lf.Until.IsZero() && cmTime.After(lf.Until) {
+		return false, nil
+	}
+
+	if len(lf.Tables) > 0 {
+		return CommitChangedTables(ctx, ddb, cm, lf.Tables)
+	}
+
+	return true, nil
+}
+
+// CommitChangedTables returns true if any of the tables given differs between the commit given and each of its
+// parents, determined by comparing the hashes of the tables. A table added or removed by the commit counts as changed.
+// A merge commit is only considered to change a table if the table differs from every one of its parents.
+func CommitChangedTables(ctx context.Context, ddb *doltdb.DoltDB, cm *doltdb.Commit, tables []string) (bool, error) {
+	root, err := cm.GetRootValue()
+
+	if err != nil {
+		return false, err
+	}
+
+	hashes, err := tableHashes(ctx, root, tables)
+
+	if err != nil {
+		return false, err
+	}
+
+	numParents, err := cm.NumParents()
+
+	if err != nil {
+		return false, err
+	}
+
+	if numParents == 0 {
+		for _, h := range hashes {
+			if !h.IsEmpty() {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+
+	for i := 0; i < numParents; i++ {
+		parent, err := ddb.ResolveParent(ctx, cm, i)
+
+		if err == doltdb.ErrBeyondShallowBoundary {
+			// a parent missing from a shallow history can't show the table to be unchanged
+			continue
+		} else if err != nil {
+			return false, err
+		}
+
+		parentRoot, err := parent.GetRootValue()
+
+		if err != nil {
+			return false, err
+		}
+
+		parentHashes, err := tableHashes(ctx, parentRoot, tables)
+
+		if err != nil {
+			return false, err
+		}
+
+		changed := false
+		for j := range hashes {
+			if hashes[j] != parentHashes[j] {
+				changed = true
+				break
+			}
+		}
+
+		if !changed {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// tableHashes returns the hash of each of the tables given in the root, with an empty hash for a missing table.
+func tableHashes(ctx context.Context, root *doltdb.RootValue, tables []string) ([]hash.Hash, error) {
+	hashes := make([]hash.Hash, len(tables))
+	for i, tbl := range tables {
+		h, _, err := root.GetTableHash(ctx, tbl)
+
+		if err != nil {
+			return nil, err
+		}
+
+		hashes[i] = h
+	}
+
+	return hashes, nil
+}
+
+// Log returns the commits reachable from any of the included commits, but not from any of the excluded commits, which
+// pass the filter given, up to a maximum of n commits if n is not negative. Commits are ordered newest first, but a
+// commit is always listed before its parents. The history is only walked as far as is needed to list n commits.
+func Log(ctx context.Context, ddb *doltdb.DoltDB, include, exclude []*doltdb.Commit, filter *LogFilter, n int) ([]*doltdb.Commit, error) {
+	w := &logWalk{ddb: ddb, nodes: make(map[hash.Hash]*logNode)}
+	for _, cm := range exclude {
+		_, err := w.add(cm, true)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, cm := range include {
+		nd, err := w.add(cm, false)
+
+		if err != nil {
+			return nil, err
+		}
+
+		w.ready = append(w.ready, nd)
+	}
+
+	var commits []*doltdb.Commit
+	for n < 0 || len(commits) < n {
+		cm, err := w.next(ctx)
+
+		if err != nil {
+			return nil, err
+		} else if cm == nil {
+			break
+		}
+
+		if filter != nil {
+			matches, err := filter.Matches(ctx, ddb, cm)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if !matches {
+				continue
+			}
+		}
+
+		commits = append(commits, cm)
+	}
+
+	return commits, nil
+}
+
+// SymmetricDifference returns the commits which should be included and excluded from a log to list the commits
+// reachable from either of the commits given, but not from both.
+func SymmetricDifference(ctx context.Context, cm1, cm2 *doltdb.Commit) (include, exclude []*doltdb.Commit, err error) {
+	ancestor, err := doltdb.GetCommitAnscestor(ctx, cm1, cm2)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return []*doltdb.Commit{cm1, cm2}, []*doltdb.Commit{ancestor}, nil
+}
+
+// logNode is a commit found by a logWalk
+type logNode struct {
+	cm     *doltdb.Commit
+	h      hash.Hash
+	ts     uint64
+	height uint64
+
+	// the parents of the commit, once it has been expanded
+	parents  []*logNode
+	expanded bool
+
+	// whether the commit is reachable from an excluded commit
+	excluded bool
+	listed   bool
+
+	// the number of expanded children which have not been listed
+	children int
+}
+
+// nodesByHeight is a heap of the nodes which have not been expanded, highest first
+type nodesByHeight []*logNode
+
+func (nh nodesByHeight) Len() int            { return len(nh) }
+func (nh nodesByHeight) Less(i, j int) bool  { return nh[i].height > nh[j].height }
+func (nh nodesByHeight) Swap(i, j int)       { nh[i], nh[j] = nh[j], nh[i] }
+func (nh *nodesByHeight) Push(x interface{}) { *nh = append(*nh, x.(*logNode)) }
+func (nh *nodesByHeight) Pop() interface{} {
+	old := *nh
+	nd := old[len(old)-1]
+	*nh = old[:len(old)-1]
+	return nd
+}
+
+// logWalk lists commits newest first, while guaranteeing that a commit comes before any of its parents. A commit is
+// listed when none of its children are left to be listed, and only once every commit which has not been expanded is
+// no higher than it, so that none of its children can remain to be found. Commits are expanded from the highest down,
+// so the history is walked no further than the last commit listed.
+type logWalk struct {
+	ddb        *doltdb.DoltDB
+	nodes      map[hash.Hash]*logNode
+	unexpanded nodesByHeight
+	ready      []*logNode
+}
+
+// add returns the node for the commit given, adding it to the walk if it has not been found before
+func (w *logWalk) add(cm *doltdb.Commit, excluded bool) (*logNode, error) {
+	h, err := cm.HashOf()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if nd, ok := w.nodes[h]; ok {
+		if excluded {
+			nd.markExcluded()
+		}
+
+		return nd, nil
+	}
+
+	meta, err := cm.GetCommitMeta()
+
+	if err != nil {
+		return nil, err
+	}
+
+	height, err := cm.Height()
+
+	if err != nil {
+		return nil, err
+	}
+
+	nd := &logNode{cm: cm, h: h, ts: meta.Timestamp, height: height, excluded: excluded}
+	w.nodes[h] = nd
+	heap.Push(&w.unexpanded, nd)
+
+	return nd, nil
+}
+
+// markExcluded marks the node and all of its expanded ancestors as excluded
+func (nd *logNode) markExcluded() {
+	stack := []*logNode{nd}
+	for len(stack) > 0 {
+		curr := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if curr.excluded {
+			continue
+		}
+
+		curr.excluded = true
+		stack = append(stack, curr.parents...)
+	}
+}
+
+// expand finds the parents of the node given
+func (w *logWalk) expand(ctx context.Context, nd *logNode) error {
+	if nd.expanded {
+		return nil
+	}
+
+	nd.expanded = true
+	numParents, err := nd.cm.NumParents()
+
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < numParents; i++ {
+		parent, err := w.ddb.ResolveParent(ctx, nd.cm, i)
+
+		if err == doltdb.ErrBeyondShallowBoundary {
+			// the history ends at the boundary of a shallow clone
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		p, err := w.add(parent, nd.excluded)
+
+		if err != nil {
+			return err
+		}
+
+		p.children++
+		nd.parents = append(nd.parents, p)
+	}
+
+	return nil
+}
+
+// next returns the next commit to list, or nil once every commit has been listed
+func (w *logWalk) next(ctx context.Context) (*doltdb.Commit, error) {
+	for {
+		// newest last, so the next commit can be popped off the end
+		ready := w.ready[:0]
+		for _, nd := range w.ready {
+			if !nd.excluded && !nd.listed && nd.children == 0 {
+				ready = append(ready, nd)
+			}
+		}
+
+		w.ready = ready
+		if len(w.ready) == 0 {
+			return nil, nil
+		}
+
+		sort.Slice(w.ready, func(i, j int) bool {
+			if w.ready[i].ts != w.ready[j].ts {
+				return w.ready[i].ts < w.ready[j].ts
+			}
+
+			return w.ready[i].h.Less(w.ready[j].h)
+		})
+
+		nd := w.ready[len(w.ready)-1]
+
+		for len(w.unexpanded) > 0 && w.unexpanded[0].expanded {
+			heap.Pop(&w.unexpanded)
+		}
+
+		if len(w.unexpanded) > 0 && w.unexpanded[0].height > nd.height {
+			// a child of the commit may not have been found yet
+			err := w.expand(ctx, heap.Pop(&w.unexpanded).(*logNode))
+
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		err := w.expand(ctx, nd)
+
+		if err != nil {
+			return nil, err
+		}
+
+		nd.listed = true
+		w.ready = w.ready[:len(w.ready)-1]
+
+		for _, p := range nd.parents {
+			p.children--
+			if p.children == 0 {
+				w.ready = append(w.ready, p)
+			}
+		}
+
+		return nd.cm, nil
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// testHistory commits to an in memory database, keeping the message of each commit by its hash
+type testHistory struct {
+	t       *testing.T
+	ddb     *doltdb.DoltDB
+	valHash hash.Hash
+	start   time.Time
+	names   map[hash.Hash]string
+}
+
+func newTestHistory(t *testing.T) *testHistory {
+	ctx := context.Background()
+	ddb, err := doltdb.LoadDoltDB(ctx, types.Format_7_18, doltdb.InMemDoltDB)
+	require.NoError(t, err)
+	require.NoError(t, ddb.WriteEmptyRepo(ctx, "Bill Billerson", "bigbillieb@fake.horse"))
+
+	cs, _ := doltdb.NewCommitSpec("HEAD", "master")
+	initCommit, err := ddb.Resolve(ctx, cs)
+	require.NoError(t, err)
+	root, err := initCommit.GetRootValue()
+	require.NoError(t, err)
+	valHash, err := ddb.WriteRootValue(ctx, root)
+	require.NoError(t, err)
+
+	initHash, err := initCommit.HashOf()
+	require.NoError(t, err)
+
+	return &testHistory{t, ddb, valHash, time.Now().Add(time.Hour), map[hash.Hash]string{initHash: "init"}}
+}
+
+// commit commits to the branch given, dated the number of minutes given after the start of the history. If merge is
+// not nil it is added as a parent.
+func (th *testHistory) commit(dref ref.DoltRef, msg string, minutes int, merge *doltdb.Commit) *doltdb.Commit {
+	ctx := context.Background()
+	ts := uint64(th.start.Add(time.Duration(minutes)*time.Minute).UnixNano()) / uint64(time.Millisecond)
+	meta := &doltdb.CommitMeta{Name: "Bill Billerson", Email: "bigbillieb@fake.horse", Timestamp: ts, Description: msg}
+
+	var parents []*doltdb.CommitSpec
+	if merge != nil {
+		h, err := merge.HashOf()
+		require.NoError(th.t, err)
+		spec, err := doltdb.NewCommitSpec(h.String(), "")
+		require.NoError(th.t, err)
+		parents = append(parents, spec)
+	}
+
+	cm, err := th.ddb.CommitWithParents(ctx, th.valHash, dref, parents, meta)
+	require.NoError(th.t, err)
+	h, err := cm.HashOf()
+	require.NoError(th.t, err)
+	th.names[h] = msg
+
+	return cm
+}
+
+func TestLog(t *testing.T) {
+	ctx := context.Background()
+	th := newTestHistory(t)
+	ddb := th.ddb
+	master := ref.NewBranchRef("master")
+	other := ref.NewBranchRef("other")
+
+	// b1 is dated after the merge which brings it into master, as if its author's clock were ahead
+	a1 := th.commit(master, "a1", 1, nil)
+	require.NoError(t, ddb.NewBranchAtCommit(ctx, other, a1))
+	b1 := th.commit(other, "b1", 10, nil)
+	a2 := th.commit(master, "a2", 2, nil)
+	m := th.commit(master, "m", 5, b1)
+	a3 := th.commit(master, "a3", 6, nil)
+
+	list := func(include, exclude []*doltdb.Commit, n int) []string {
+		commits, err := Log(ctx, ddb, include, exclude, nil, n)
+		require.NoError(t, err)
+
+		var msgs []string
+		for _, cm := range commits {
+			h, err := cm.HashOf()
+			require.NoError(t, err)
+			msgs = append(msgs, th.names[h])
+		}
+
+		return msgs
+	}
+
+	tests := []struct {
+		name     string
+		include  []*doltdb.Commit
+		exclude  []*doltdb.Commit
+		n        int
+		expected []string
+	}{
+		{"all", []*doltdb.Commit{a3}, nil, -1, []string{"a3", "m", "b1", "a2", "a1", "init"}},
+		{"limit", []*doltdb.Commit{a3}, nil, 3, []string{"a3", "m", "b1"}},
+		{"limit to one", []*doltdb.Commit{a3}, nil, 1, []string{"a3"}},
+		{"none", []*doltdb.Commit{a3}, nil, 0, nil},
+		{"exclude", []*doltdb.Commit{a3}, []*doltdb.Commit{a2}, -1, []string{"a3", "m", "b1"}},
+		{"exclude merged branch", []*doltdb.Commit{a3}, []*doltdb.Commit{b1}, -1, []string{"a3", "m", "a2"}},
+		{"exclude descendant", []*doltdb.Commit{a2}, []*doltdb.Commit{m}, -1, nil},
+		{"multiple heads", []*doltdb.Commit{a2, b1}, []*doltdb.Commit{a1}, -1, []string{"b1", "a2"}},
+		{"head and ancestor", []*doltdb.Commit{a2, m}, nil, 2, []string{"m", "b1"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, list(test.include, test.exclude, test.n))
+		})
+	}
+}
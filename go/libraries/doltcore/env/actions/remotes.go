@@ -15,13 +15,17 @@
 package actions
 
 import (
+	"container/heap"
 	"context"
 	"errors"
+	"fmt"
 
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/env"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/hash"
 )
 
 var ErrCantFF = errors.New("can't fast forward merge")
@@ -30,8 +34,11 @@ var ErrCantFF = errors.New("can't fast forward merge")
 // This is accomplished first by verifying that the remote tracking reference for the source database can be updated to
 // the given commit via a fast forward merge.  If this is the case, an attempt will be made to update the branch in the
 // destination db to the given commit via fast forward move.  If that succeeds the tracking branch is updated in the
-// source db.
-func Push(ctx context.Context, destRef ref.BranchRef, remoteRef ref.RemoteRef, srcDB, destDB *doltdb.DoltDB, commit *doltdb.Commit, progChan chan datas.PullProgress) error {
+// source db. If cp is not nil the progress of the push is saved with it so that an interrupted push can be resumed.
+// If tfConcurrency is greater than 0 and the destination db is far behind the source db, the table files of the source
+// db are copied to the destination db whole, tfConcurrency at a time, before any chunks are pushed. This copies every
+// chunk of the source db, not just those reachable from the commit, so callers only pass a tfConcurrency when asked to.
+func Push(ctx context.Context, destRef ref.BranchRef, remoteRef ref.RemoteRef, srcDB, destDB *doltdb.DoltDB, commit *doltdb.Commit, tfConcurrency int, cp datas.PullCheckpointer, progChan chan datas.PullProgress) error {
 	canFF, err := srcDB.CanFastForward(ctx, remoteRef, commit)
 
 	if err != nil {
@@ -40,13 +47,21 @@ func Push(ctx context.Context, destRef ref.BranchRef, remoteRef ref.RemoteRef, s
 		return ErrCantFF
 	}
 
-	err = destDB.PushChunks(ctx, srcDB, commit, progChan)
+	if tfConcurrency > 0 {
+		_, err = destDB.PullTableFiles(ctx, srcDB, tfConcurrency, progChan)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err = destDB.PushChunks(ctx, srcDB, commit, cp, progChan)
 
 	if err != nil {
 		return err
 	}
 
-	err = destDB.FastForward(ctx, destRef, commit)
+	err = fastForwardDest(ctx, destRef, srcDB, destDB, commit)
 
 	if err != nil {
 		return err
@@ -57,6 +72,58 @@ func Push(ctx context.Context, destRef ref.BranchRef, remoteRef ref.RemoteRef, s
 	return err
 }
 
+// fastForwardDest fast forwards the destination branch to the given commit. The branch is updated with a
+// compare-and-swap against the commit it pointed at when it was checked to be an ancestor of the commit given, so that
+// pushes to other branches of the destination database made at the same time don't cause the push to fail.
+func fastForwardDest(ctx context.Context, destRef ref.BranchRef, srcDB, destDB *doltdb.DoltDB, commit *doltdb.Commit) error {
+	h, err := commit.HashOf()
+
+	if err != nil {
+		return err
+	}
+
+	destHash, exists, err := destDB.RefHash(ctx, destRef)
+
+	if err != nil {
+		return err
+	} else if exists && destHash == h {
+		return nil
+	}
+
+	if exists {
+		// the commit the destination branch points at must be in the source database to be an ancestor of the commit
+		cs, err := doltdb.NewCommitSpec(destHash.String(), "")
+
+		if err != nil {
+			return err
+		}
+
+		destCm, err := srcDB.Resolve(ctx, cs)
+
+		if err == doltdb.ErrHashNotFound {
+			return ErrCantFF
+		} else if err != nil {
+			return err
+		}
+
+		canFF, err := destCm.CanFastForwardTo(ctx, commit)
+
+		if err != nil && err != doltdb.ErrUpToDate {
+			return err
+		} else if !canFF {
+			return ErrCantFF
+		}
+	}
+
+	err = destDB.CompareAndSetRef(ctx, destRef, destHash, commit)
+
+	if err == doltdb.ErrRefChanged {
+		return ErrCantFF
+	}
+
+	return err
+}
+
 // DeleteRemoteBranch validates targetRef is a branch on the remote database, and then deletes it, then deletes the
 // remote tracking branch from the local database.
 func DeleteRemoteBranch(ctx context.Context, targetRef ref.BranchRef, remoteRef ref.RemoteRef, localDB, remoteDB *doltdb.DoltDB) error {
@@ -83,8 +150,70 @@ func DeleteRemoteBranch(ctx context.Context, targetRef ref.BranchRef, remoteRef
 	return nil
 }
 
-func Fetch(ctx context.Context, destRef ref.DoltRef, srcDB, destDB *doltdb.DoltDB, commit *doltdb.Commit, progChan chan datas.PullProgress) error {
-	err := destDB.PullChunks(ctx, srcDB, commit, progChan)
+func Fetch(ctx context.Context, destRef ref.DoltRef, srcDB, destDB *doltdb.DoltDB, commit *doltdb.Commit, cp datas.PullCheckpointer, progChan chan datas.PullProgress) error {
+	err := destDB.PullChunks(ctx, srcDB, commit, cp, progChan)
+
+	if err != nil {
+		return err
+	}
+
+	return destDB.FastForward(ctx, destRef, commit)
+}
+
+// FetchShallow pulls the commit given, and its ancestors up to depth commits back, into the destination database and
+// sets the destination ref to the commit. As the history pulled may not reach the commit the ref pointed to before, the
+// ref is set whether or not this is a fast forward. Returns the commits at the boundary of the history pulled.
+func FetchShallow(ctx context.Context, destRef ref.DoltRef, srcDB, destDB *doltdb.DoltDB, commit *doltdb.Commit, depth int, progChan chan datas.PullProgress) ([]hash.Hash, error) {
+	boundary, err := destDB.PullChunksShallow(ctx, srcDB, commit, depth, progChan)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(boundary) > 0 {
+		destDB.SetShallow()
+	}
+
+	err = destDB.SetHead(ctx, destRef, commit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return boundary, nil
+}
+
+// UpdateShallowCommits records the boundary of the repository's shallow history in the repo state, given the boundary
+// commits of the fetches just made. Commits whose missing parents have since been fetched are no longer recorded.
+func UpdateShallowCommits(ctx context.Context, dEnv *env.DoltEnv, boundary []hash.Hash) error {
+	candidates := append(dEnv.RepoState.ShallowCommits(), boundary...)
+	shallow, err := dEnv.DoltDB.ShallowCommits(ctx, candidates)
+
+	if err != nil {
+		return err
+	}
+
+	unique := make([]hash.Hash, 0, len(shallow))
+	seen := hash.HashSet{}
+	for _, h := range shallow {
+		if !seen.Has(h) {
+			seen.Insert(h)
+			unique = append(unique, h)
+		}
+	}
+
+	if len(unique) > 0 {
+		dEnv.DoltDB.SetShallow()
+	}
+
+	dEnv.RepoState.SetShallowCommits(unique)
+	return dEnv.RepoState.Save()
+}
+
+// FetchPartial pulls the commit given into the destination database, leaving out the row data of any table not in the
+// list given, and fast forwards the destination ref to the commit.
+func FetchPartial(ctx context.Context, destRef ref.DoltRef, srcDB, destDB *doltdb.DoltDB, commit *doltdb.Commit, tables []string, progChan chan datas.PullProgress) error {
+	err := destDB.PullChunksPartial(ctx, srcDB, commit, tables, progChan)
 
 	if err != nil {
 		return err
@@ -92,3 +221,142 @@ func Fetch(ctx context.Context, destRef ref.DoltRef, srcDB, destDB *doltdb.DoltD
 
 	return destDB.FastForward(ctx, destRef, commit)
 }
+
+// UpdatePartialTables records in the repo state that only the row data of the tables given was fetched from the remote
+// given, and sets up the repository to read the row data of other tables from the remote when it is needed.
+func UpdatePartialTables(dEnv *env.DoltEnv, remote string, tables []string) error {
+	wasPartial := dEnv.RepoState.Partial != nil
+
+	if wasPartial && dEnv.RepoState.Partial.Remote != remote {
+		return fmt.Errorf("data left out of a fetch from '%s' can't be left out of a fetch from '%s'", dEnv.RepoState.Partial.Remote, remote)
+	}
+
+	dEnv.RepoState.AddPartialTables(remote, tables)
+	err := dEnv.RepoState.Save()
+
+	if err != nil {
+		return err
+	}
+
+	if !wasPartial {
+		dEnv.SetLazyFetch()
+	}
+
+	return nil
+}
+
+const (
+	reachableFromLocal    = 1
+	reachableFromUpstream = 2
+	reachableFromBoth     = reachableFromLocal | reachableFromUpstream
+)
+
+// heightEntry is a commit queued by AheadBehind, along with which of the two heads it is reachable from
+type heightEntry struct {
+	cm        *doltdb.Commit
+	height    uint64
+	reachable int
+}
+
+// entriesByHeight is a heap of commits, highest first
+type entriesByHeight []*heightEntry
+
+func (eh entriesByHeight) Len() int            { return len(eh) }
+func (eh entriesByHeight) Less(i, j int) bool  { return eh[i].height > eh[j].height }
+func (eh entriesByHeight) Swap(i, j int)       { eh[i], eh[j] = eh[j], eh[i] }
+func (eh *entriesByHeight) Push(x interface{}) { *eh = append(*eh, x.(*heightEntry)) }
+func (eh *entriesByHeight) Pop() interface{} {
+	old := *eh
+	e := old[len(old)-1]
+	*eh = old[:len(old)-1]
+	return e
+}
+
+// AheadBehind returns the number of commits reachable from the local commit but not from its upstream commit, and the
+// number reachable from the upstream commit but not from the local one. The histories of both commits are walked
+// together from the highest commit down, so that a commit is only counted once every commit which could reach it has
+// been seen, and the walk stops once every commit left is reachable from both, which is at the common ancestors.
+func AheadBehind(ctx context.Context, ddb *doltdb.DoltDB, local, upstream *doltdb.Commit) (ahead, behind int, err error) {
+	var queue entriesByHeight
+	queued := make(map[hash.Hash]*heightEntry)
+
+	// the number of queued commits which are not reachable from both commits
+	pending := 0
+
+	add := func(cm *doltdb.Commit, reachable int) error {
+		h, err := cm.HashOf()
+
+		if err != nil {
+			return err
+		}
+
+		if e, ok := queued[h]; ok {
+			if e.reachable != reachableFromBoth && e.reachable|reachable == reachableFromBoth {
+				pending--
+			}
+
+			e.reachable |= reachable
+			return nil
+		}
+
+		height, err := cm.Height()
+
+		if err != nil {
+			return err
+		}
+
+		e := &heightEntry{cm, height, reachable}
+		queued[h] = e
+		heap.Push(&queue, e)
+
+		if reachable != reachableFromBoth {
+			pending++
+		}
+
+		return nil
+	}
+
+	if err := add(local, reachableFromLocal); err != nil {
+		return 0, 0, err
+	}
+
+	if err := add(upstream, reachableFromUpstream); err != nil {
+		return 0, 0, err
+	}
+
+	for pending > 0 {
+		e := heap.Pop(&queue).(*heightEntry)
+
+		switch e.reachable {
+		case reachableFromLocal:
+			ahead++
+			pending--
+		case reachableFromUpstream:
+			behind++
+			pending--
+		}
+
+		numParents, err := e.cm.NumParents()
+
+		if err != nil {
+			return 0, 0, err
+		}
+
+		for i := 0; i < numParents; i++ {
+			parent, err := ddb.ResolveParent(ctx, e.cm, i)
+
+			if err == doltdb.ErrBeyondShallowBoundary {
+				// the history ends at the boundary of a shallow clone
+				continue
+			} else if err != nil {
+				return 0, 0, err
+			}
+
+			if err := add(parent, e.reachable); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+
+	return ahead, behind, nil
+}
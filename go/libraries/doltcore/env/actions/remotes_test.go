@@ -0,0 +1,68 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+)
+
+func TestAheadBehind(t *testing.T) {
+	ctx := context.Background()
+	th := newTestHistory(t)
+	master := ref.NewBranchRef("master")
+	other := ref.NewBranchRef("other")
+
+	// each branch merges the other, so m1 and m2 have two merge bases, a2 and b1
+	a1 := th.commit(master, "a1", 1, nil)
+	require.NoError(t, th.ddb.NewBranchAtCommit(ctx, other, a1))
+	b1 := th.commit(other, "b1", 2, nil)
+	a2 := th.commit(master, "a2", 3, nil)
+	m1 := th.commit(master, "m1", 4, b1)
+	m2 := th.commit(other, "m2", 5, a2)
+	a3 := th.commit(master, "a3", 6, nil)
+	b2 := th.commit(other, "b2", 7, nil)
+
+	tests := []struct {
+		name     string
+		local    *doltdb.Commit
+		upstream *doltdb.Commit
+		ahead    int
+		behind   int
+	}{
+		{"same", a1, a1, 0, 0},
+		{"ahead", a3, a1, 4, 0},
+		{"behind", a1, b1, 0, 1},
+		{"diverged", a2, b1, 1, 1},
+		{"merged", m1, b1, 2, 0},
+		{"multiple merge bases", a3, b2, 2, 2},
+		{"merges of each other", m1, m2, 1, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ahead, behind, err := AheadBehind(ctx, th.ddb, test.local, test.upstream)
+			require.NoError(t, err)
+			assert.Equal(t, test.ahead, ahead)
+			assert.Equal(t, test.behind, behind)
+		})
+	}
+}
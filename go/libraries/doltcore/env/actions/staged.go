@@ -121,7 +121,9 @@ func stageTables(ctx context.Context, dEnv *env.DoltEnv, tbls []string, staged *
 			dEnv.RepoState.Staged = sh.String()
 			dEnv.RepoState.Working = wh.String()
 
-			if err = dEnv.RepoState.Save(); err != nil {
+			if err = dEnv.RepoState.Save(); err == env.ErrRepoStateConflict || err == env.ErrRepoStateLocked {
+				return err
+			} else if err != nil {
 				return env.ErrStateUpdate
 			}
 
@@ -0,0 +1,149 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+const checkpointsDir = "checkpoints"
+
+// PushCheckpointer returns a datas.PullCheckpointer saving the progress of pushes to the remote given, so that a push
+// which is interrupted resumes where it stopped.
+func (dEnv *DoltEnv) PushCheckpointer(r Remote) datas.PullCheckpointer {
+	return newFileCheckpointer(dEnv.FS, "push", r.Url)
+}
+
+// FetchCheckpointer returns a datas.PullCheckpointer saving the progress of fetches from the remote given, so that a
+// fetch which is interrupted resumes where it stopped.
+func (dEnv *DoltEnv) FetchCheckpointer(r Remote) datas.PullCheckpointer {
+	return newFileCheckpointer(dEnv.FS, "fetch", r.Url)
+}
+
+type checkpointJSON struct {
+	Target   string            `json:"target"`
+	Tables   map[string]uint32 `json:"tables"`
+	Frontier []string          `json:"frontier"`
+}
+
+// fileCheckpointer saves a checkpoint in a file in the checkpoints directory of the .dolt directory. The table files
+// of a checkpoint only exist in the database they were written to, so the file is named for the direction of the pull
+// and the url of the remote.
+type fileCheckpointer struct {
+	fs   filesys.ReadWriteFS
+	path string
+}
+
+func newFileCheckpointer(fs filesys.ReadWriteFS, kind, url string) *fileCheckpointer {
+	name := hash.Of([]byte(kind + " " + url)).String()
+	return &fileCheckpointer{fs, filepath.Join(dbfactory.DoltDir, checkpointsDir, name)}
+}
+
+// Load returns the last checkpoint saved, or nil if there is none. A checkpoint which can't be read is ignored.
+func (fc *fileCheckpointer) Load(ctx context.Context) (*datas.PullCheckpoint, error) {
+	if exists, _ := fc.fs.Exists(fc.path); !exists {
+		return nil, nil
+	}
+
+	data, err := fc.fs.ReadFile(fc.path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var cpJSON checkpointJSON
+	err = json.Unmarshal(data, &cpJSON)
+
+	if err != nil {
+		return nil, nil
+	}
+
+	target, ok := hash.MaybeParse(cpJSON.Target)
+
+	if !ok {
+		return nil, nil
+	}
+
+	tables := make(map[hash.Hash]uint32, len(cpJSON.Tables))
+	for name, count := range cpJSON.Tables {
+		h, ok := hash.MaybeParse(name)
+
+		if !ok {
+			return nil, nil
+		}
+
+		tables[h] = count
+	}
+
+	frontier := make(hash.HashSlice, len(cpJSON.Frontier))
+	for i, hashStr := range cpJSON.Frontier {
+		h, ok := hash.MaybeParse(hashStr)
+
+		if !ok {
+			return nil, nil
+		}
+
+		frontier[i] = h
+	}
+
+	return &datas.PullCheckpoint{Target: target, Tables: tables, Frontier: frontier}, nil
+}
+
+// Save saves a checkpoint, replacing the last checkpoint saved.
+func (fc *fileCheckpointer) Save(ctx context.Context, cp datas.PullCheckpoint) error {
+	cpJSON := checkpointJSON{
+		Target:   cp.Target.String(),
+		Tables:   make(map[string]uint32, len(cp.Tables)),
+		Frontier: make([]string, len(cp.Frontier)),
+	}
+
+	for h, count := range cp.Tables {
+		cpJSON.Tables[h.String()] = count
+	}
+
+	for i, h := range cp.Frontier {
+		cpJSON.Frontier[i] = h.String()
+	}
+
+	data, err := json.Marshal(cpJSON)
+
+	if err != nil {
+		return err
+	}
+
+	err = fc.fs.MkDirs(filepath.Dir(fc.path))
+
+	if err != nil {
+		return err
+	}
+
+	return fc.fs.WriteFile(fc.path, data)
+}
+
+// Clear deletes the last checkpoint saved.
+func (fc *fileCheckpointer) Clear(ctx context.Context) error {
+	if exists, _ := fc.fs.Exists(fc.path); !exists {
+		return nil
+	}
+
+	return fc.fs.DeleteFile(fc.path)
+}
@@ -0,0 +1,64 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+func TestFileCheckpointer(t *testing.T) {
+	ctx := context.Background()
+	fs := filesys.NewInMemFS([]string{"/repo"}, nil, "/repo")
+	push := newFileCheckpointer(fs, "push", "dolthub.com/org/repo")
+	fetch := newFileCheckpointer(fs, "fetch", "dolthub.com/org/repo")
+
+	cp, err := push.Load(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, cp)
+
+	saved := datas.PullCheckpoint{
+		Target:   hash.Of([]byte("target")),
+		Tables:   map[hash.Hash]uint32{hash.Of([]byte("table")): 12},
+		Frontier: hash.HashSlice{hash.Of([]byte("a")), hash.Of([]byte("b"))},
+	}
+	require.NoError(t, push.Save(ctx, saved))
+
+	cp, err = push.Load(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, cp)
+	assert.Equal(t, saved, *cp)
+
+	cp, err = fetch.Load(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, cp)
+
+	require.NoError(t, push.Clear(ctx))
+	cp, err = push.Load(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, cp)
+
+	require.NoError(t, fs.WriteFile(push.path, []byte("{not json")))
+	cp, err = push.Load(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, cp)
+}
@@ -38,9 +38,21 @@ const (
 
 	DoltEditor = "core.editor"
 
+	// ChunkCompressionKey is the compression of the chunks of new table files, which is one of "snappy", "zstd" or
+	// "zstd-dict". Table files compressed with zstd can't be read by versions of dolt which predate it.
+	ChunkCompressionKey = "core.compression"
+
 	RemotesApiHostKey     = "remotes.default_host"
 	RemotesApiHostPortKey = "remotes.default_port"
 
+	// RemotesCacheSizeKey is the maximum size in megabytes of the on disk cache of chunks read from each remote host.
+	// The cache is disabled unless a size greater than 0 is set.
+	RemotesCacheSizeKey = "remotes.cache_size"
+
+	// RemotesTransferConcurrencyKey is the number of table files copied at the same time when cloning, or pushing to a
+	// remote which is far behind. A concurrency of 0 disables copying whole table files.
+	RemotesTransferConcurrencyKey = "remotes.transfer_concurrency"
+
 	AddCredsUrlKey = "creds.add_url"
 )
 
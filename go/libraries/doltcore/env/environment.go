@@ -18,7 +18,10 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -30,10 +33,12 @@ import (
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/remotestorage"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/encoding"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
 	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
 	"github.com/liquidata-inc/dolt/go/store/types"
 )
 
@@ -41,6 +46,9 @@ const (
 	DefaultLoginUrl       = "https://beta.dolthub.com/settings/credentials"
 	DefaultRemotesApiHost = "doltremoteapi.beta.dolthub.com"
 	DefaultRemotesApiPort = "443"
+
+	encryptionKeyEnvVar     = "DOLT_ENCRYPTION_KEY"
+	encryptionKeyFileEnvVar = "DOLT_ENCRYPTION_KEY_FILE"
 )
 
 var ErrPreexistingDoltDir = errors.New(".dolt dir already exists")
@@ -82,11 +90,184 @@ func Load(ctx context.Context, hdp HomeDirProvider, fs filesys.Filesys, urlStr s
 		hdp,
 	}
 
-	dbfactory.InitializeFactories(dEnv)
+	compression, compressionErr := dEnv.chunkCompression()
+	key, keyErr := loadEncryptionKey()
+
+	if dbLoadErr == nil {
+		ddb.SetChunkCompression(compression)
+
+		if compressionErr != nil {
+			dbLoadErr = compressionErr
+		} else if keyErr == nil {
+			dbLoadErr = ddb.SetEncryptionKey(key)
+		} else {
+			dbLoadErr = keyErr
+		}
+
+		dEnv.DBLoadError = dbLoadErr
+	}
+
+	if rsErr == nil && dbLoadErr == nil && len(repoState.Shallow) > 0 {
+		ddb.SetShallow()
+	}
+
+	if rsErr == nil && dbLoadErr == nil && repoState.Partial != nil {
+		dEnv.SetLazyFetch()
+	}
+
+	cacheDir, cacheSize, cacheErr := dEnv.chunkCacheParams()
+
+	if key != nil {
+		// the chunk cache is shared by every repository of the user and holds chunks unencrypted
+		cacheDir = ""
+	}
+
+	factoryErr := cacheErr
+	if factoryErr == nil {
+		factoryErr = compressionErr
+	}
+
+	dbfactory.InitializeFactories(dEnv, cacheDir, cacheSize, compression, key, factoryErr)
 
 	return dEnv
 }
 
+// TableFileConcurrency returns the number of table files to copy at the same time when copying whole table files to
+// or from a remote. Returns 0 if whole table files should not be copied, which is always the case for shallow and
+// partial repositories as their table files don't hold everything their commits reference. Returns an error if the
+// configured concurrency is not a number.
+func (dEnv *DoltEnv) TableFileConcurrency() (int, error) {
+	if dEnv.RepoState != nil && (len(dEnv.RepoState.Shallow) > 0 || dEnv.RepoState.Partial != nil) {
+		return 0, nil
+	}
+
+	if dEnv.Config != nil {
+		concurrencyStr := *dEnv.Config.GetStringOrDefault(RemotesTransferConcurrencyKey, "")
+
+		if concurrencyStr != "" {
+			concurrency, err := strconv.ParseUint(strings.TrimSpace(concurrencyStr), 10, 32)
+
+			if err != nil {
+				return 0, fmt.Errorf("invalid value '%s' for %s, which must be a number", concurrencyStr, RemotesTransferConcurrencyKey)
+			}
+
+			return int(concurrency), nil
+		}
+	}
+
+	return remotestorage.DefaultTableFileConcurrency, nil
+}
+
+// chunkCompression returns the compression of the chunks of new table files, which is snappy unless configured
+// otherwise. Returns an error if the configured compression is unknown.
+func (dEnv *DoltEnv) chunkCompression() (nbs.ChunkCompression, error) {
+	if dEnv.Config != nil {
+		compressionStr := *dEnv.Config.GetStringOrDefault(ChunkCompressionKey, "")
+
+		if compressionStr != "" {
+			compression, err := nbs.ParseChunkCompression(compressionStr)
+
+			if err != nil {
+				return nbs.SnappyCompression, fmt.Errorf("invalid value '%s' for %s, which must be one of snappy, zstd or zstd-dict", compressionStr, ChunkCompressionKey)
+			}
+
+			return compression, nil
+		}
+	}
+
+	return nbs.SnappyCompression, nil
+}
+
+// chunkCacheParams returns the directory chunks read from remotes are cached in and the maximum size in bytes of the
+// cache of each remote host. The cache is disabled, and the directory returned is empty, unless remotes.cache_size is
+// set to a size in megabytes greater than 0.
+func (dEnv *DoltEnv) chunkCacheParams() (string, uint64, error) {
+	if dEnv.Config == nil {
+		return "", 0, nil
+	}
+
+	sizeStr := strings.TrimSpace(*dEnv.Config.GetStringOrDefault(RemotesCacheSizeKey, ""))
+
+	if sizeStr == "" {
+		return "", 0, nil
+	}
+
+	sizeMB, err := strconv.ParseUint(sizeStr, 10, 64)
+
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid value '%s' for %s, which must be a size in megabytes", sizeStr, RemotesCacheSizeKey)
+	} else if sizeMB == 0 {
+		return "", 0, nil
+	}
+
+	dir, err := getChunkCacheDir(dEnv.hdp)
+
+	if err != nil {
+		return "", 0, err
+	}
+
+	return dir, sizeMB * 1024 * 1024, nil
+}
+
+// loadEncryptionKey returns the key which the table files of the repository are encrypted with, which is read from the
+// DOLT_ENCRYPTION_KEY environment variable, or from the file named by the DOLT_ENCRYPTION_KEY_FILE environment variable.
+// Either holds a base64 encoded AES key. Returns nil if neither is set. A key can only be set for a new repository, or
+// one whose table files were written with it.
+func loadEncryptionKey() (*nbs.EncryptionKey, error) {
+	if keyStr, ok := os.LookupEnv(encryptionKeyEnvVar); ok && keyStr != "" {
+		key, err := nbs.ParseEncryptionKey(keyStr)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid "+encryptionKeyEnvVar)
+		}
+
+		return key, nil
+	}
+
+	if path, ok := os.LookupEnv(encryptionKeyFileEnvVar); ok && path != "" {
+		data, err := ioutil.ReadFile(path)
+
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read the encryption key file")
+		}
+
+		key, err := nbs.ParseEncryptionKey(string(data))
+
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid encryption key file "+path)
+		}
+
+		return key, nil
+	}
+
+	return nil, nil
+}
+
+// setEncryptionKey sets the encryption key of a newly created database to the key configured for the environment
+func setEncryptionKey(ddb *doltdb.DoltDB) error {
+	key, err := loadEncryptionKey()
+
+	if err != nil {
+		return err
+	}
+
+	return ddb.SetEncryptionKey(key)
+}
+
+// SetLazyFetch reads the row data left out of a partial clone or fetch from the remote recorded in the repo state.
+func (dEnv *DoltEnv) SetLazyFetch() {
+	remoteName := dEnv.RepoState.Partial.Remote
+	dEnv.DoltDB.SetLazyFetch(func(ctx context.Context) (*doltdb.DoltDB, error) {
+		r, ok := dEnv.RepoState.Remotes[remoteName]
+
+		if !ok {
+			return nil, fmt.Errorf("unable to read data which was not fetched: unknown remote '%s'", remoteName)
+		}
+
+		return r.GetRemoteDB(ctx, dEnv.DoltDB.ValueReadWriter().Format())
+	})
+}
+
 // HasDoltDir returns true if the .dolt directory exists and is a valid directory
 func (dEnv *DoltEnv) HasDoltDir() bool {
 	return dEnv.hasDoltDir("./")
@@ -175,7 +356,19 @@ func (dEnv *DoltEnv) InitRepoWithNoData(ctx context.Context, nbf *types.NomsBinF
 
 	dEnv.DoltDB, err = doltdb.LoadDoltDB(ctx, nbf, dEnv.urlStr)
 
-	return err
+	if err != nil {
+		return err
+	}
+
+	compression, err := dEnv.chunkCompression()
+
+	if err != nil {
+		return err
+	}
+
+	dEnv.DoltDB.SetChunkCompression(compression)
+
+	return setEncryptionKey(dEnv.DoltDB)
 }
 
 func (dEnv *DoltEnv) createDirectories(dir string) (string, error) {
@@ -212,6 +405,19 @@ func (dEnv *DoltEnv) initDBAndState(ctx context.Context, nbf *types.NomsBinForma
 		return err
 	}
 
+	compression, err := dEnv.chunkCompression()
+
+	if err != nil {
+		return err
+	}
+
+	dEnv.DoltDB.SetChunkCompression(compression)
+	err = setEncryptionKey(dEnv.DoltDB)
+
+	if err != nil {
+		return err
+	}
+
 	err = dEnv.DoltDB.WriteEmptyRepo(ctx, name, email)
 
 	if err != nil {
@@ -260,12 +466,22 @@ func (dEnv *DoltEnv) UpdateWorkingRoot(ctx context.Context, newRoot *doltdb.Root
 	err = dEnv.RepoState.Save()
 
 	if err != nil {
-		return ErrStateUpdate
+		return stateUpdateErr(err)
 	}
 
 	return nil
 }
 
+// stateUpdateErr returns the error to return when saving the repo state failed with the error given. Errors caused by
+// other processes updating the repo state are returned as is so that they can be reported.
+func stateUpdateErr(err error) error {
+	if err == ErrRepoStateConflict || err == ErrRepoStateLocked {
+		return err
+	}
+
+	return ErrStateUpdate
+}
+
 func (dEnv *DoltEnv) HeadRoot(ctx context.Context) (*doltdb.RootValue, error) {
 	cs, _ := doltdb.NewCommitSpec("head", dEnv.RepoState.Head.Ref.String())
 	commit, err := dEnv.DoltDB.Resolve(ctx, cs)
@@ -295,7 +511,7 @@ func (dEnv *DoltEnv) UpdateStagedRoot(ctx context.Context, newRoot *doltdb.RootV
 	err = dEnv.RepoState.Save()
 
 	if err != nil {
-		return hash.Hash{}, ErrStateUpdate
+		return hash.Hash{}, stateUpdateErr(err)
 	}
 
 	return h, nil
@@ -25,11 +25,13 @@ import (
 const (
 	doltRootPathEnvVar = "DOLT_ROOT_PATH"
 	credsDir           = "creds"
+	chunkCacheDir      = "cache"
 
 	configFile   = "config.json"
 	globalConfig = "config_global.json"
 
-	repoStateFile = "repo_state.json"
+	repoStateFile     = "repo_state.json"
+	repoStateLockFile = "repo_state.lock"
 )
 
 // HomeDirProvider is a function that returns the users home directory.  This is where global dolt state is stored for
@@ -60,6 +62,15 @@ func getCredsDir(hdp HomeDirProvider) (string, error) {
 	return filepath.Join(homeDir, dbfactory.DoltDir, credsDir), nil
 }
 
+func getChunkCacheDir(hdp HomeDirProvider) (string, error) {
+	homeDir, err := hdp()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(homeDir, dbfactory.DoltDir, chunkCacheDir), nil
+}
+
 func getGlobalCfgPath(hdp HomeDirProvider) (string, error) {
 	homeDir, err := hdp()
 	if err != nil {
@@ -76,3 +87,7 @@ func getLocalConfigPath() string {
 func getRepoStateFile() string {
 	return filepath.Join(dbfactory.DoltDir, repoStateFile)
 }
+
+func getRepoStateLockFile() string {
+	return filepath.Join(dbfactory.DoltDir, repoStateLockFile)
+}
@@ -16,6 +16,12 @@ package env
 
 import (
 	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/juju/fslock"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
@@ -23,6 +29,22 @@ import (
 	"github.com/liquidata-inc/dolt/go/store/hash"
 )
 
+// repoStateLockTimeout is how long to wait for another process to finish updating the repo state
+var repoStateLockTimeout = 10 * time.Second
+
+// ErrRepoStateConflict is returned when saving a repo state which changes a field that another process changed
+// differently since the repo state was loaded. The repo state is not saved or modified, and must be reloaded for the
+// change to be made again.
+var ErrRepoStateConflict = errors.New("the working set was modified by another dolt process")
+
+// ErrRepoStateLocked is returned when the repo state could not be locked, because another process held the lock for
+// longer than repoStateLockTimeout.
+var ErrRepoStateLocked = errors.New("timed out waiting for another dolt process to update the repo state")
+
+// inMemRepoStateMu serializes updates to repo states which are not stored in the local filesystem, which can't be
+// shared with other processes.
+var inMemRepoStateMu = &sync.Mutex{}
+
 type BranchConfig struct {
 	Merge  ref.MarshalableRef `json:"head"`
 	Remote string             `json:"remote"`
@@ -34,6 +56,13 @@ type MergeState struct {
 	PreMergeWorking string             `json:"working_pre_merge"`
 }
 
+// PartialState records that only some tables' row data was fetched from a remote. The row data of the other tables is
+// read from the remote when it is needed.
+type PartialState struct {
+	Remote string   `json:"remote"`
+	Tables []string `json:"tables"`
+}
+
 type RepoState struct {
 	Head     ref.MarshalableRef      `json:"head"`
 	Staged   string                  `json:"staged"`
@@ -41,11 +70,69 @@ type RepoState struct {
 	Merge    *MergeState             `json:"merge"`
 	Remotes  map[string]Remote       `json:"remotes"`
 	Branches map[string]BranchConfig `json:"branches"`
+	Shallow  []string                `json:"shallow,omitempty"`
+	Partial  *PartialState           `json:"partial,omitempty"`
 
 	fs filesys.ReadWriteFS
+
+	// the repo state as last loaded or saved, which the fields changed since are found by comparing against
+	loaded *RepoState
+}
+
+// lockRepoState locks the repo state against updates by other processes, returning a function which releases the lock.
+// Repo states are only saved and loaded while locked.
+func lockRepoState(fs filesys.ReadWriteFS) (func() error, error) {
+	if fs != filesys.LocalFS {
+		inMemRepoStateMu.Lock()
+		return func() error {
+			inMemRepoStateMu.Unlock()
+			return nil
+		}, nil
+	}
+
+	path, err := fs.Abs(getRepoStateLockFile())
+
+	if err != nil {
+		return nil, err
+	}
+
+	lck := fslock.New(path)
+	err = lck.LockWithTimeout(repoStateLockTimeout)
+
+	if err == fslock.ErrTimeout {
+		return nil, ErrRepoStateLocked
+	} else if err != nil {
+		return nil, err
+	}
+
+	return lck.Unlock, nil
+}
+
+func LoadRepoState(fs filesys.ReadWriteFS) (rs *RepoState, err error) {
+	// outside of a repository there is nowhere to create the lock file, and nothing to read
+	if exists, _ := fs.Exists(getRepoStateFile()); !exists {
+		return readRepoState(fs)
+	}
+
+	unlock, err := lockRepoState(fs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		unlockErr := unlock()
+
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+
+	return readRepoState(fs)
 }
 
-func LoadRepoState(fs filesys.ReadWriteFS) (*RepoState, error) {
+// readRepoState reads the repo state stored in the filesystem given. The repo state must be locked.
+func readRepoState(fs filesys.ReadWriteFS) (*RepoState, error) {
 	path := getRepoStateFile()
 	data, err := fs.ReadFile(path)
 
@@ -61,6 +148,22 @@ func LoadRepoState(fs filesys.ReadWriteFS) (*RepoState, error) {
 	}
 
 	repoState.fs = fs
+	repoState.loaded, err = unmarshalRepoState(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &repoState, nil
+}
+
+func unmarshalRepoState(data []byte) (*RepoState, error) {
+	var repoState RepoState
+	err := json.Unmarshal(data, &repoState)
+
+	if err != nil {
+		return nil, err
+	}
 
 	return &repoState, nil
 }
@@ -68,7 +171,7 @@ func LoadRepoState(fs filesys.ReadWriteFS) (*RepoState, error) {
 func CloneRepoState(fs filesys.ReadWriteFS, r Remote) (*RepoState, error) {
 	h := hash.Hash{}
 	hashStr := h.String()
-	rs := &RepoState{ref.MarshalableRef{Ref: ref.NewBranchRef("master")}, hashStr, hashStr, nil, map[string]Remote{r.Name: r}, nil, fs}
+	rs := &RepoState{ref.MarshalableRef{Ref: ref.NewBranchRef("master")}, hashStr, hashStr, nil, map[string]Remote{r.Name: r}, nil, nil, nil, fs, nil}
 
 	err := rs.Save()
 
@@ -87,7 +190,7 @@ func CreateRepoState(fs filesys.ReadWriteFS, br string, rootHash hash.Hash) (*Re
 		return nil, err
 	}
 
-	rs := &RepoState{ref.MarshalableRef{Ref: headRef}, hashStr, hashStr, nil, nil, nil, fs}
+	rs := &RepoState{ref.MarshalableRef{Ref: headRef}, hashStr, hashStr, nil, nil, nil, nil, nil, fs, nil}
 
 	err = rs.Save()
 
@@ -98,16 +201,159 @@ func CreateRepoState(fs filesys.ReadWriteFS, br string, rootHash hash.Hash) (*Re
 	return rs, nil
 }
 
-func (rs *RepoState) Save() error {
-	data, err := json.MarshalIndent(rs, "", "  ")
+// Save writes the repo state while holding the repo state lock. If another process saved the repo state since it was
+// loaded, only the fields this repo state changed are applied to the stored repo state, with remotes and branch configs
+// applied one at a time, and the other process's changes to the remaining fields are kept. If both changed a field to
+// different values, or either changed the head while the other changed the head, roots or merge state, nothing is saved
+// and ErrRepoStateConflict is returned, leaving the repo state unmodified.
+func (rs *RepoState) Save() (err error) {
+	unlock, err := lockRepoState(rs.fs)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		unlockErr := unlock()
+
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+
+	toSave := rs
+
+	// a repo state which was created rather than loaded replaces whatever is stored
+	if exists, _ := rs.fs.Exists(getRepoStateFile()); exists && rs.loaded != nil {
+		stored, err := readRepoState(rs.fs)
+
+		if err != nil {
+			return err
+		}
+
+		var ok bool
+		toSave, ok = mergeRepoStates(rs.loaded, rs, stored)
+
+		if !ok {
+			return ErrRepoStateConflict
+		}
+	}
+
+	data, err := json.MarshalIndent(toSave, "", "  ")
 
 	if err != nil {
 		return err
 	}
 
 	path := getRepoStateFile()
+	err = rs.fs.WriteFile(path, data)
 
-	return rs.fs.WriteFile(path, data)
+	if err != nil {
+		return err
+	}
+
+	loaded, err := unmarshalRepoState(data)
+
+	if err != nil {
+		return err
+	}
+
+	fs := rs.fs
+	*rs = *toSave
+	rs.fs = fs
+	rs.loaded = loaded
+
+	return nil
+}
+
+// mergeRepoStates returns the repo state to save given the repo state which was loaded, the repo state to save, and the
+// repo state which is stored. Returns false if the changes made by the repo state to save conflict with those made to
+// the stored repo state by another process.
+func mergeRepoStates(loaded, ours, theirs *RepoState) (*RepoState, bool) {
+	// the roots and merge state belong to the branch checked out, so they can't be kept across a change of branch
+	if !sameBranchState(ours, theirs) {
+		if !reflect.DeepEqual(loaded.Head, theirs.Head) && !sameBranchState(loaded, ours) {
+			return nil, false
+		} else if !reflect.DeepEqual(loaded.Head, ours.Head) && !sameBranchState(loaded, theirs) {
+			return nil, false
+		}
+	}
+
+	ok := true
+	merge := func(loaded, ours, theirs interface{}) interface{} {
+		merged, fieldOk := mergeValue(loaded, ours, theirs)
+		ok = ok && fieldOk
+
+		return merged
+	}
+
+	merged := *ours
+	merged.Head = merge(loaded.Head, ours.Head, theirs.Head).(ref.MarshalableRef)
+	merged.Staged = merge(loaded.Staged, ours.Staged, theirs.Staged).(string)
+	merged.Working = merge(loaded.Working, ours.Working, theirs.Working).(string)
+	merged.Merge = merge(loaded.Merge, ours.Merge, theirs.Merge).(*MergeState)
+	merged.Remotes = mergeMap(loaded.Remotes, ours.Remotes, theirs.Remotes, merge).(map[string]Remote)
+	merged.Branches = mergeMap(loaded.Branches, ours.Branches, theirs.Branches, merge).(map[string]BranchConfig)
+	merged.Shallow = merge(loaded.Shallow, ours.Shallow, theirs.Shallow).([]string)
+	merged.Partial = merge(loaded.Partial, ours.Partial, theirs.Partial).(*PartialState)
+
+	if !ok {
+		return nil, false
+	}
+
+	return &merged, true
+}
+
+// sameBranchState returns whether the repo states given have the same head, roots and merge state
+func sameBranchState(rs, other *RepoState) bool {
+	return reflect.DeepEqual(rs.Head, other.Head) &&
+		rs.Staged == other.Staged &&
+		rs.Working == other.Working &&
+		reflect.DeepEqual(rs.Merge, other.Merge)
+}
+
+// mergeValue returns the value to save given the value which was loaded, the value to save, and the value which is
+// stored. Returns false if the stored value was changed by another process and the value to save differs from both.
+func mergeValue(loaded, ours, theirs interface{}) (interface{}, bool) {
+	if reflect.DeepEqual(theirs, loaded) || reflect.DeepEqual(theirs, ours) {
+		return ours, true
+	} else if reflect.DeepEqual(ours, loaded) {
+		return theirs, true
+	}
+
+	return ours, false
+}
+
+// mergeMap merges the maps given one entry at a time using the merge function given, with a missing entry merged as
+// nil. Returns a nil map of the same type if no entries remain.
+func mergeMap(loaded, ours, theirs interface{}, merge func(loaded, ours, theirs interface{}) interface{}) interface{} {
+	loadedVal, oursVal, theirsVal := reflect.ValueOf(loaded), reflect.ValueOf(ours), reflect.ValueOf(theirs)
+	merged := reflect.MakeMap(oursVal.Type())
+
+	keys := append(append(loadedVal.MapKeys(), oursVal.MapKeys()...), theirsVal.MapKeys()...)
+	for _, k := range keys {
+		v := merge(mapEntry(loadedVal, k), mapEntry(oursVal, k), mapEntry(theirsVal, k))
+
+		if v != nil {
+			merged.SetMapIndex(k, reflect.ValueOf(v))
+		}
+	}
+
+	if merged.Len() == 0 {
+		return reflect.Zero(oursVal.Type()).Interface()
+	}
+
+	return merged.Interface()
+}
+
+func mapEntry(m reflect.Value, k reflect.Value) interface{} {
+	v := m.MapIndex(k)
+
+	if !v.IsValid() {
+		return nil
+	}
+
+	return v.Interface()
 }
 
 func (rs *RepoState) CWBHeadSpec() *doltdb.CommitSpec {
@@ -131,6 +377,37 @@ func (rs *RepoState) ClearMerge() error {
 	return rs.Save()
 }
 
+// ShallowCommits returns the commits at the boundary of a shallow history, whose parents are not in the repository.
+func (rs *RepoState) ShallowCommits() []hash.Hash {
+	hashes := make([]hash.Hash, 0, len(rs.Shallow))
+	for _, str := range rs.Shallow {
+		if h, ok := hash.MaybeParse(str); ok {
+			hashes = append(hashes, h)
+		}
+	}
+
+	return hashes
+}
+
+// IsShallowCommit returns whether the commit given is at the boundary of a shallow history.
+func (rs *RepoState) IsShallowCommit(h hash.Hash) bool {
+	for _, str := range rs.Shallow {
+		if str == h.String() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetShallowCommits sets the commits at the boundary of a shallow history. It does not save the repo state.
+func (rs *RepoState) SetShallowCommits(hashes []hash.Hash) {
+	rs.Shallow = nil
+	for _, h := range hashes {
+		rs.Shallow = append(rs.Shallow, h.String())
+	}
+}
+
 func (rs *RepoState) AddRemote(r Remote) {
 	if rs.Remotes == nil {
 		rs.Remotes = make(map[string]Remote)
@@ -138,3 +415,28 @@ func (rs *RepoState) AddRemote(r Remote) {
 
 	rs.Remotes[r.Name] = r
 }
+
+// AddPartialTables records that the row data of only the tables given, along with any recorded previously, was fetched
+// from the remote given. The repo state is not saved.
+func (rs *RepoState) AddPartialTables(remote string, tables []string) {
+	if rs.Partial == nil {
+		rs.Partial = &PartialState{Remote: remote}
+	}
+
+	for _, tbl := range tables {
+		if !rs.Partial.HasTable(tbl) {
+			rs.Partial.Tables = append(rs.Partial.Tables, tbl)
+		}
+	}
+}
+
+// HasTable returns whether the row data of the table given was fetched.
+func (ps *PartialState) HasTable(tbl string) bool {
+	for _, curr := range ps.Tables {
+		if curr == tbl {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,197 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package env
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/juju/fslock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/ref"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+func TestRepoStateCompareAndSwap(t *testing.T) {
+	fs := filesys.NewInMemFS([]string{workingDir, filepath.Join(workingDir, dbfactory.DoltDir)}, nil, workingDir)
+	_, err := CreateRepoState(fs, "master", hash.Of([]byte("root")))
+	require.NoError(t, err)
+
+	first, err := LoadRepoState(fs)
+	require.NoError(t, err)
+	second, err := LoadRepoState(fs)
+	require.NoError(t, err)
+
+	// changes to different roots are both kept
+	working := hash.Of([]byte("working")).String()
+	staged := hash.Of([]byte("staged")).String()
+	first.Working = working
+	require.NoError(t, first.Save())
+	second.Staged = staged
+	require.NoError(t, second.Save())
+	assert.Equal(t, working, second.Working)
+
+	stored, err := LoadRepoState(fs)
+	require.NoError(t, err)
+	assert.Equal(t, working, stored.Working)
+	assert.Equal(t, staged, stored.Staged)
+
+	// a change to a root which was changed by another process is rejected, and the repo state is left as it was
+	first.Working = hash.Of([]byte("first")).String()
+	second.Working = hash.Of([]byte("second")).String()
+	require.NoError(t, second.Save())
+	assert.Equal(t, ErrRepoStateConflict, first.Save())
+	assert.Equal(t, hash.Of([]byte("first")).String(), first.Working)
+
+	stored, err = LoadRepoState(fs)
+	require.NoError(t, err)
+	assert.Equal(t, second.Working, stored.Working)
+
+	// the reloaded repo state can be saved
+	first, err = LoadRepoState(fs)
+	require.NoError(t, err)
+	first.Working = hash.Of([]byte("retried")).String()
+	require.NoError(t, first.Save())
+
+	stored, err = LoadRepoState(fs)
+	require.NoError(t, err)
+	assert.Equal(t, first.Working, stored.Working)
+}
+
+func TestRepoStateMergeFields(t *testing.T) {
+	fs := filesys.NewInMemFS([]string{workingDir, filepath.Join(workingDir, dbfactory.DoltDir)}, nil, workingDir)
+	created, err := CreateRepoState(fs, "master", hash.Of([]byte("root")))
+	require.NoError(t, err)
+	created.AddRemote(NewRemote("origin", "file:///origin", nil))
+	require.NoError(t, created.Save())
+
+	load := func() *RepoState {
+		rs, err := LoadRepoState(fs)
+		require.NoError(t, err)
+		return rs
+	}
+
+	upstream := func(remote string) BranchConfig {
+		return BranchConfig{ref.MarshalableRef{Ref: ref.NewRemoteRef(remote, "master")}, remote}
+	}
+
+	t.Run("remotes and branches are merged by name", func(t *testing.T) {
+		first, second := load(), load()
+
+		first.AddRemote(NewRemote("first", "file:///first", nil))
+		first.Branches = map[string]BranchConfig{"master": upstream("first")}
+		require.NoError(t, first.Save())
+
+		delete(second.Remotes, "origin")
+		second.AddRemote(NewRemote("second", "file:///second", nil))
+		require.NoError(t, second.Save())
+		assert.Equal(t, upstream("first"), second.Branches["master"])
+
+		stored := load()
+		assert.Len(t, stored.Remotes, 2)
+		assert.Contains(t, stored.Remotes, "first")
+		assert.Contains(t, stored.Remotes, "second")
+		assert.Equal(t, map[string]BranchConfig{"master": upstream("first")}, stored.Branches)
+	})
+
+	t.Run("a remote changed by both conflicts", func(t *testing.T) {
+		first, second := load(), load()
+
+		first.AddRemote(NewRemote("first", "file:///changed/by/first", nil))
+		require.NoError(t, first.Save())
+
+		second.AddRemote(NewRemote("first", "file:///changed/by/second", nil))
+		assert.Equal(t, ErrRepoStateConflict, second.Save())
+		assert.Equal(t, "file:///changed/by/second", second.Remotes["first"].Url)
+		assert.Equal(t, "file:///changed/by/first", load().Remotes["first"].Url)
+	})
+
+	t.Run("a merge started by both conflicts", func(t *testing.T) {
+		first, second := load(), load()
+
+		require.NoError(t, first.StartMerge(ref.NewBranchRef("first"), hash.Of([]byte("first")).String()))
+		assert.Equal(t, ErrRepoStateConflict, second.StartMerge(ref.NewBranchRef("second"), hash.Of([]byte("second")).String()))
+
+		require.NoError(t, first.ClearMerge())
+		assert.Nil(t, load().Merge)
+	})
+
+	t.Run("a change of head conflicts with changes to roots", func(t *testing.T) {
+		first, second := load(), load()
+
+		first.Head = ref.MarshalableRef{Ref: ref.NewBranchRef("other")}
+		first.Working = hash.Of([]byte("other")).String()
+		require.NoError(t, first.Save())
+
+		second.Staged = hash.Of([]byte("staged")).String()
+		assert.Equal(t, ErrRepoStateConflict, second.Save())
+
+		// changes to fields which don't belong to the branch are still merged
+		third := load()
+		second = load()
+		third.Head = ref.MarshalableRef{Ref: ref.NewBranchRef("master")}
+		require.NoError(t, third.Save())
+		second.AddRemote(NewRemote("third", "file:///third", nil))
+		require.NoError(t, second.Save())
+
+		stored := load()
+		assert.Equal(t, "master", stored.Head.Ref.GetPath())
+		assert.Contains(t, stored.Remotes, "third")
+	})
+}
+
+func TestRepoStateLocked(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(cwd)
+
+	require.NoError(t, os.Mkdir(dbfactory.DoltDir, os.ModePerm))
+	rs, err := CreateRepoState(filesys.LocalFS, "master", hash.Of([]byte("root")))
+	require.NoError(t, err)
+
+	prevTimeout := repoStateLockTimeout
+	repoStateLockTimeout = 100 * time.Millisecond
+	defer func() {
+		repoStateLockTimeout = prevTimeout
+	}()
+
+	lck := fslock.New(filepath.Join(dir, getRepoStateLockFile()))
+	require.NoError(t, lck.Lock())
+
+	_, err = LoadRepoState(filesys.LocalFS)
+	assert.Equal(t, ErrRepoStateLocked, err)
+
+	rs.Working = hash.Of([]byte("working")).String()
+	assert.Equal(t, ErrRepoStateLocked, rs.Save())
+
+	require.NoError(t, lck.Unlock())
+	require.NoError(t, rs.Save())
+
+	stored, err := LoadRepoState(filesys.LocalFS)
+	require.NoError(t, err)
+	assert.Equal(t, rs.Working, stored.Working)
+}
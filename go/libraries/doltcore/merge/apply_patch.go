@@ -0,0 +1,355 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/encoding"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+// ApplyPatch applies the changes in a json diff to the tables of the root given. Row changes are applied with a three
+// way comparison: a row is changed if its current value matches the value the patch was made against, left alone if it
+// already has the value the patch gives it, and otherwise recorded as a conflict, with the patch's old row as the base,
+// the current row as ours and the patch's new row as theirs. Schema changes must apply cleanly, or an error is returned.
+func ApplyPatch(ctx context.Context, ddb *doltdb.DoltDB, root *doltdb.RootValue, patch *diff.JsonDiff) (*doltdb.RootValue, map[string]*MergeStats, error) {
+	vrw := ddb.ValueReadWriter()
+	tblToStats := make(map[string]*MergeStats)
+
+	for _, td := range patch.Tables {
+		tbl, exists, err := root.GetTable(ctx, td.Name)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var sch schema.Schema
+		var rows types.Map
+		if exists {
+			if sch, err = tbl.GetSchema(ctx); err != nil {
+				return nil, nil, err
+			}
+
+			if rows, err = tbl.GetRowData(ctx); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		var newSch schema.Schema
+		stats := &MergeStats{Operation: TableModified}
+		switch td.DiffType {
+		case diff.AddedDiffTypeName:
+			stats.Operation = TableAdded
+			newSch, err = applySchemaChanges(td.Name, schema.EmptySchema, td.SchemaChanges)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if !exists {
+				sch = newSch
+				rows, err = types.NewMap(ctx, vrw)
+			} else if eq, eqErr := schema.SchemasAreEqual(sch, newSch); eqErr != nil {
+				err = eqErr
+			} else if !eq {
+				err = fmt.Errorf("table '%s' added by the patch already exists with a different schema", td.Name)
+			}
+
+		case diff.RemovedDiffTypeName:
+			if !exists {
+				continue
+			}
+
+			stats.Operation = TableRemoved
+			newSch = sch
+
+		default:
+			if !exists {
+				return nil, nil, fmt.Errorf("table '%s' changed by the patch does not exist", td.Name)
+			}
+
+			newSch, err = applySchemaChanges(td.Name, sch, td.SchemaChanges)
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rows, conflicts, err := applyRowChanges(ctx, vrw, rows, sch, newSch, td.Rows, stats)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		tblToStats[td.Name] = stats
+
+		if stats.Operation == TableRemoved {
+			if conflicts.Len() == 0 && rows.Len() == 0 {
+				if root, err = root.RemoveTables(ctx, td.Name); err != nil {
+					return nil, nil, err
+				}
+
+				continue
+			}
+
+			// rows which the patch did not expect remain, so the table is kept
+			stats.Operation = TableModified
+		}
+
+		schVal, err := encoding.MarshalAsNomsValue(ctx, vrw, newSch)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		newTbl, err := doltdb.NewTable(ctx, vrw, schVal, rows)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if conflicts.Len() > 0 {
+			schRef, err := newTbl.GetSchemaRef()
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			newTbl, err = newTbl.SetConflicts(ctx, doltdb.NewConflict(schRef, schRef, schRef), conflicts)
+
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if root, err = root.PutTable(ctx, ddb, td.Name, newTbl); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return root, tblToStats, nil
+}
+
+// applySchemaChanges returns the schema given with the column changes of a patch applied. Changes which have already
+// been made are skipped.
+func applySchemaChanges(tblName string, sch schema.Schema, changes []diff.JsonSchemaChange) (schema.Schema, error) {
+	var cols []schema.Column
+	err := sch.GetAllCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+		cols = append(cols, col)
+		return false, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	indexOf := func(tag uint64) int {
+		for i, col := range cols {
+			if col.Tag == tag {
+				return i
+			}
+		}
+
+		return -1
+	}
+
+	for _, change := range changes {
+		var from, to schema.Column
+		if change.From != nil {
+			if from, err = change.From.Column(); err != nil {
+				return nil, err
+			}
+		}
+
+		if change.To != nil {
+			if to, err = change.To.Column(); err != nil {
+				return nil, err
+			}
+		}
+
+		switch change.DiffType {
+		case diff.AddedDiffTypeName:
+			if i := indexOf(to.Tag); i == -1 {
+				cols = append(cols, to)
+			} else if !cols[i].Equals(to) {
+				return nil, fmt.Errorf("column '%s' added to table '%s' by the patch already exists with a different definition", to.Name, tblName)
+			}
+
+		case diff.RemovedDiffTypeName:
+			if i := indexOf(from.Tag); i != -1 {
+				if !cols[i].Equals(from) {
+					return nil, fmt.Errorf("column '%s' of table '%s' has changed since the patch was made", from.Name, tblName)
+				}
+
+				cols = append(cols[:i], cols[i+1:]...)
+			}
+
+		case diff.ModifiedDiffTypeName:
+			i := indexOf(from.Tag)
+
+			if i == -1 {
+				return nil, fmt.Errorf("column '%s' of table '%s' changed by the patch does not exist", from.Name, tblName)
+			} else if cols[i].Equals(to) {
+				continue
+			} else if !cols[i].Equals(from) {
+				return nil, fmt.Errorf("column '%s' of table '%s' has changed since the patch was made", from.Name, tblName)
+			} else if from.Kind != to.Kind || from.IsPartOfPK != to.IsPartOfPK {
+				return nil, fmt.Errorf("unable to change the type or primary key of column '%s' of table '%s'", from.Name, tblName)
+			}
+
+			cols[i] = to
+		}
+	}
+
+	colColl, err := schema.NewColCollection(cols...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.SchemaFromCols(colColl), nil
+}
+
+// applyRowChanges applies the row changes of a patch to the row data given, returning the new row data and a map of
+// the conflicts found. The old values of rows in the patch, and the current rows, are read with the old schema, and
+// the new values of rows in the patch with the new schema.
+func applyRowChanges(ctx context.Context, vrw types.ValueReadWriter, rows types.Map, oldSch, newSch schema.Schema, changes []diff.JsonRowChange, stats *MergeStats) (types.Map, types.Map, error) {
+	nbf := vrw.Format()
+	me := rows.Edit()
+	var conflictKVs []types.Value
+
+	for _, change := range changes {
+		var base, theirs row.Row
+		if change.From != nil {
+			vals, err := diff.JsonRowValues(change.From, oldSch)
+
+			if err != nil {
+				return types.EmptyMap, types.EmptyMap, err
+			}
+
+			if base, err = row.New(nbf, oldSch, vals); err != nil {
+				return types.EmptyMap, types.EmptyMap, err
+			}
+		}
+
+		if change.To != nil {
+			vals, err := diff.JsonRowValues(change.To, newSch)
+
+			if err != nil {
+				return types.EmptyMap, types.EmptyMap, err
+			}
+
+			if theirs, err = row.New(nbf, newSch, vals); err != nil {
+				return types.EmptyMap, types.EmptyMap, err
+			}
+		}
+
+		var key types.Value
+		var err error
+		if theirs != nil {
+			key, err = theirs.NomsMapKey(newSch).Value(ctx)
+		} else if base != nil {
+			key, err = base.NomsMapKey(oldSch).Value(ctx)
+		} else {
+			continue
+		}
+
+		if err != nil {
+			return types.EmptyMap, types.EmptyMap, err
+		}
+
+		// read the current row from the original rows, as the patch changes each row at most once
+		curVal, hasCur, err := rows.MaybeGet(ctx, key)
+
+		if err != nil {
+			return types.EmptyMap, types.EmptyMap, err
+		}
+
+		var ours, oursNewSch row.Row
+		if hasCur {
+			if ours, err = row.FromNoms(oldSch, key.(types.Tuple), curVal.(types.Tuple)); err != nil {
+				return types.EmptyMap, types.EmptyMap, err
+			}
+
+			if oursNewSch, err = row.FromNoms(newSch, key.(types.Tuple), curVal.(types.Tuple)); err != nil {
+				return types.EmptyMap, types.EmptyMap, err
+			}
+		}
+
+		switch {
+		case row.AreEqual(oursNewSch, theirs, newSch):
+			// already applied
+		case row.AreEqual(ours, base, oldSch):
+			if theirs == nil {
+				stats.Deletes++
+				me.Remove(key)
+			} else {
+				if hasCur {
+					stats.Modifications++
+				} else {
+					stats.Adds++
+				}
+
+				me.Set(key, theirs.NomsMapValue(newSch))
+			}
+		default:
+			stats.Conflicts++
+			cnfTuple, err := conflictTuple(ctx, vrw, newSch, base, ours, theirs)
+
+			if err != nil {
+				return types.EmptyMap, types.EmptyMap, err
+			}
+
+			conflictKVs = append(conflictKVs, key, cnfTuple)
+		}
+	}
+
+	newRows, err := me.Map(ctx)
+
+	if err != nil {
+		return types.EmptyMap, types.EmptyMap, err
+	}
+
+	conflicts, err := types.NewMap(ctx, vrw, conflictKVs...)
+
+	if err != nil {
+		return types.EmptyMap, types.EmptyMap, err
+	}
+
+	return newRows, conflicts, nil
+}
+
+// conflictTuple returns the conflict for a row, with each version of the row encoded with the schema given.
+func conflictTuple(ctx context.Context, vrw types.ValueReadWriter, sch schema.Schema, base, ours, theirs row.Row) (types.Tuple, error) {
+	vals := make([]types.Value, 3)
+	for i, r := range []row.Row{base, ours, theirs} {
+		if r == nil {
+			continue
+		}
+
+		var err error
+		if vals[i], err = r.NomsMapValue(sch).Value(ctx); err != nil {
+			return types.Tuple{}, err
+		}
+	}
+
+	return doltdb.NewConflict(vals[0], vals[1], vals[2]).ToNomsList(vrw)
+}
@@ -0,0 +1,124 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/diff"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema/encoding"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+var patchTestColColl, _ = schema.NewColCollection(
+	schema.NewColumn("id", 0, types.IntKind, true, schema.NotNullConstraint{}),
+	schema.NewColumn("name", 1, types.StringKind, false),
+)
+var patchTestSch = schema.SchemaFromCols(patchTestColColl)
+
+func patchTestKey(id int64) types.Value {
+	return mustTuple(types.NewTuple(types.Format_7_18, types.Uint(0), types.Int(id)))
+}
+
+func patchTestVal(name string) types.Value {
+	return mustTuple(types.NewTuple(types.Format_7_18, types.Uint(1), types.String(name)))
+}
+
+func TestApplyPatch(t *testing.T) {
+	ctx := context.Background()
+	ddb, err := doltdb.LoadDoltDB(ctx, types.Format_7_18, doltdb.InMemDoltDB)
+	require.NoError(t, err)
+	require.NoError(t, ddb.WriteEmptyRepo(ctx, name, email))
+
+	vrw := ddb.ValueReadWriter()
+	cs, _ := doltdb.NewCommitSpec("head", "master")
+	cm, err := ddb.Resolve(ctx, cs)
+	require.NoError(t, err)
+
+	root, err := cm.GetRootValue()
+	require.NoError(t, err)
+
+	rows, err := types.NewMap(ctx, vrw,
+		patchTestKey(1), patchTestVal("a"),
+		patchTestKey(2), patchTestVal("b"),
+		patchTestKey(3), patchTestVal("c"),
+	)
+	require.NoError(t, err)
+
+	schVal, err := encoding.MarshalAsNomsValue(ctx, vrw, patchTestSch)
+	require.NoError(t, err)
+
+	tbl, err := doltdb.NewTable(ctx, vrw, schVal, rows)
+	require.NoError(t, err)
+
+	root, err = root.PutTable(ctx, ddb, "t", tbl)
+	require.NoError(t, err)
+
+	patch := &diff.JsonDiff{Tables: []diff.JsonTableDiff{{
+		Name:     "t",
+		DiffType: diff.ModifiedDiffTypeName,
+		Rows: []diff.JsonRowChange{
+			{DiffType: diff.ModifiedDiffTypeName, From: map[string]interface{}{"id": json.Number("1"), "name": "a"}, To: map[string]interface{}{"id": json.Number("1"), "name": "A"}},
+			{DiffType: diff.ModifiedDiffTypeName, From: map[string]interface{}{"id": json.Number("2"), "name": "x"}, To: map[string]interface{}{"id": json.Number("2"), "name": "B"}},
+			{DiffType: diff.RemovedDiffTypeName, From: map[string]interface{}{"id": json.Number("3"), "name": "c"}},
+			{DiffType: diff.AddedDiffTypeName, To: map[string]interface{}{"id": json.Number("4"), "name": "d"}},
+		},
+	}}}
+
+	newRoot, stats, err := ApplyPatch(ctx, ddb, root, patch)
+	require.NoError(t, err)
+
+	expectedStats := &MergeStats{Operation: TableModified, Adds: 1, Deletes: 1, Modifications: 1, Conflicts: 1}
+	assert.Equal(t, map[string]*MergeStats{"t": expectedStats}, stats)
+
+	newTbl, ok, err := newRoot.GetTable(ctx, "t")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	hasConflicts, err := newTbl.HasConflicts()
+	require.NoError(t, err)
+	assert.True(t, hasConflicts)
+
+	newRows, err := newTbl.GetRowData(ctx)
+	require.NoError(t, err)
+
+	expected := map[int64]string{1: "A", 2: "b", 4: "d"}
+	assert.Equal(t, uint64(len(expected)), newRows.Len())
+
+	for id, expectedName := range expected {
+		val, ok, err := newRows.MaybeGet(ctx, patchTestKey(id))
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		r, err := row.FromNoms(patchTestSch, patchTestKey(id).(types.Tuple), val.(types.Tuple))
+		require.NoError(t, err)
+
+		nameVal, _ := r.GetColVal(1)
+		assert.Equal(t, types.String(expectedName), nameVal)
+	}
+
+	// applying the same patch again leaves the applied rows alone
+	_, stats, err = ApplyPatch(ctx, ddb, newRoot, patch)
+	require.NoError(t, err)
+	assert.Equal(t, &MergeStats{Operation: TableModified, Conflicts: 1}, stats["t"])
+}
@@ -15,6 +15,8 @@
 package remotestorage
 
 import (
+	"context"
+
 	"github.com/liquidata-inc/dolt/go/store/chunks"
 	"github.com/liquidata-inc/dolt/go/store/hash"
 )
@@ -38,4 +40,8 @@ type chunkCache interface {
 	// GetAndClearChunksToFlush gets a map of hash to chunk which includes all the chunks that were put in the cache
 	// between the last time GetAndClearChunksToFlush was called and now.
 	GetAndClearChunksToFlush() map[hash.Hash]chunks.Chunk
+
+	// Persist writes the chunks put in the cache with PutChunk to the cache's persistent storage, once enough of them
+	// have been put to be worth writing, or all of them if all is true. Caches held only in memory do nothing.
+	Persist(ctx context.Context, all bool) error
 }
@@ -27,11 +27,13 @@ import (
 	"time"
 
 	"github.com/cenkalti/backoff"
-	"github.com/golang/snappy"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	remotesapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/remotesapi_v1alpha1"
 	"github.com/liquidata-inc/dolt/go/libraries/utils/iohelp"
 	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/datas"
 	"github.com/liquidata-inc/dolt/go/store/hash"
 	"github.com/liquidata-inc/dolt/go/store/nbs"
 	"github.com/liquidata-inc/dolt/go/store/types"
@@ -39,6 +41,8 @@ import (
 
 var ErrUploadFailed = errors.New("upload failed")
 var ErrInvalidDoltSpecPath = errors.New("invalid dolt spec path")
+var ErrRefUpdatesUnsupported = errors.New("remote does not support ref updates")
+var ErrTableFilesUnsupported = errors.New("remote does not support listing table files")
 
 var globalHttpFetcher HTTPFetcher = &http.Client{}
 
@@ -76,6 +80,23 @@ type DoltChunkStore struct {
 	metadata    *remotesapi.GetRepoMetadataResponse
 	nbf         *types.NomsBinFormat
 	httpFetcher HTTPFetcher
+	novel       *novelTables
+	compression nbs.ChunkCompression
+	key         *nbs.EncryptionKey
+	decomps     *decompressorCache
+}
+
+// decompressorCache holds the ChunkDecompressors for the table files of the remote which hold zstd dictionaries, by
+// resource path
+type decompressorCache struct {
+	mu      *sync.Mutex
+	decomps map[string]*nbs.ChunkDecompressor
+}
+
+// novelTables are the table files uploaded to the remote which have not yet been added to its manifest
+type novelTables struct {
+	mu     *sync.Mutex
+	tables map[hash.Hash]uint32
 }
 
 func NewDoltChunkStoreFromPath(ctx context.Context, nbf *types.NomsBinFormat, path, host string, csClient remotesapi.ChunkStoreServiceClient) (*DoltChunkStore, error) {
@@ -113,11 +134,40 @@ func NewDoltChunkStore(ctx context.Context, nbf *types.NomsBinFormat, org, repoN
 	if err != nil {
 		return nil, err
 	}
-	return &DoltChunkStore{org, repoName, host, csClient, newMapChunkCache(), metadata, nbf, globalHttpFetcher}, nil
+	novel := &novelTables{&sync.Mutex{}, make(map[hash.Hash]uint32)}
+	decomps := &decompressorCache{&sync.Mutex{}, make(map[string]*nbs.ChunkDecompressor)}
+	return &DoltChunkStore{org, repoName, host, csClient, newMapChunkCache(), metadata, nbf, globalHttpFetcher, novel, nbs.SnappyCompression, nil, decomps}, nil
 }
 
 func (dcs *DoltChunkStore) WithHTTPFetcher(fetcher HTTPFetcher) *DoltChunkStore {
-	return &DoltChunkStore{dcs.org, dcs.repoName, dcs.host, dcs.csClient, dcs.cache, dcs.metadata, dcs.nbf, fetcher}
+	return &DoltChunkStore{dcs.org, dcs.repoName, dcs.host, dcs.csClient, dcs.cache, dcs.metadata, dcs.nbf, fetcher, dcs.novel, dcs.compression, dcs.key, dcs.decomps}
+}
+
+// WithDiskCache returns a DoltChunkStore which keeps the chunks it reads from the remote in the DiskChunkCache given,
+// and reads chunks from it before going to the remote.
+func (dcs *DoltChunkStore) WithDiskCache(disk *DiskChunkCache) *DoltChunkStore {
+	return &DoltChunkStore{dcs.org, dcs.repoName, dcs.host, dcs.csClient, newPersistentChunkCache(disk), dcs.metadata, dcs.nbf, dcs.httpFetcher, dcs.novel, dcs.compression, dcs.key, dcs.decomps}
+}
+
+// WithChunkCompression returns a DoltChunkStore which compresses the chunks of the table files it uploads to the remote
+// with the compression given. The remote must be able to read table files written with it.
+func (dcs *DoltChunkStore) WithChunkCompression(compression nbs.ChunkCompression) *DoltChunkStore {
+	return &DoltChunkStore{dcs.org, dcs.repoName, dcs.host, dcs.csClient, dcs.cache, dcs.metadata, dcs.nbf, dcs.httpFetcher, dcs.novel, compression, dcs.key, dcs.decomps}
+}
+
+// WithEncryptionKey returns a DoltChunkStore which encrypts the chunks of the table files it uploads to the remote with
+// the key given, unless it is nil.
+func (dcs *DoltChunkStore) WithEncryptionKey(key *nbs.EncryptionKey) *DoltChunkStore {
+	return &DoltChunkStore{dcs.org, dcs.repoName, dcs.host, dcs.csClient, dcs.cache, dcs.metadata, dcs.nbf, dcs.httpFetcher, dcs.novel, dcs.compression, key, dcs.decomps}
+}
+
+// keyID returns the id of the key the table files uploaded to the remote are encrypted with, or "" if they are not
+func (dcs *DoltChunkStore) keyID() string {
+	if dcs.key == nil {
+		return ""
+	}
+
+	return dcs.key.ID()
 }
 
 func (dcs *DoltChunkStore) getRepoId() *remotesapi.RepoId {
@@ -178,8 +228,16 @@ const (
 )
 
 type urlAndRanges struct {
-	Url    string
-	Ranges []*remotesapi.RangeChunk
+	Url        string
+	Ranges     []*remotesapi.RangeChunk
+	DictOffset uint64
+	DictLength uint32
+}
+
+// getResourcePath returns the url given without its query, which identifies the table file it refers to
+func getResourcePath(urlStr string) string {
+	urlObj, _ := url.Parse(urlStr)
+	return fmt.Sprintf("%s://%s%s", urlObj.Scheme, urlObj.Host, urlObj.Path)
 }
 
 func (dcs *DoltChunkStore) getDLLocs(ctx context.Context, hashes []hash.Hash) (map[string]urlAndRanges, error) {
@@ -201,15 +259,14 @@ func (dcs *DoltChunkStore) getDLLocs(ctx context.Context, hashes []hash.Hash) (m
 			case *remotesapi.DownloadLoc_HttpGetRange:
 				if len(typedLoc.HttpGetRange.Ranges) > 0 {
 					urlStr := typedLoc.HttpGetRange.Url
-					urlObj, _ := url.Parse(urlStr)
-
-					resourcePath := fmt.Sprintf("%s://%s%s", urlObj.Scheme, urlObj.Host, urlObj.Path)
+					resourcePath := getResourcePath(urlStr)
 
 					if uAndR, ok := resourceToUrlAndRanges[resourcePath]; ok {
 						uAndR.Ranges = append(uAndR.Ranges, typedLoc.HttpGetRange.Ranges...)
 						resourceToUrlAndRanges[resourcePath] = uAndR
 					} else {
-						resourceToUrlAndRanges[resourcePath] = urlAndRanges{urlStr, typedLoc.HttpGetRange.Ranges}
+						getRange := typedLoc.HttpGetRange
+						resourceToUrlAndRanges[resourcePath] = urlAndRanges{urlStr, getRange.Ranges, getRange.DictionariesOffset, getRange.DictionariesLength}
 					}
 				}
 			}
@@ -302,7 +359,7 @@ func (dcs *DoltChunkStore) readChunksAndCache(ctx context.Context, hashes hash.H
 		return err
 	}
 
-	return nil
+	return dcs.cache.Persist(ctx, false)
 }
 
 // Returns true iff the value at the address |h| is contained in the
@@ -437,22 +494,18 @@ func (dcs *DoltChunkStore) Root(ctx context.Context) (hash.Hash, error) {
 // persisted root hash from last to current (or keeps it the same).
 // If last doesn't match the root in persistent storage, returns false.
 func (dcs *DoltChunkStore) Commit(ctx context.Context, current, last hash.Hash) (bool, error) {
-	hashToChunkCount, err := dcs.uploadChunks(ctx)
+	tables, err := dcs.Checkpoint(ctx)
 
 	if err != nil {
 		return false, err
 	}
 
-	chnkTblInfo := make([]*remotesapi.ChunkTableInfo, 0, len(hashToChunkCount))
-	for h, cnt := range hashToChunkCount {
-		chnkTblInfo = append(chnkTblInfo, &remotesapi.ChunkTableInfo{Hash: h[:], ChunkCount: uint32(cnt)})
-	}
-
 	req := &remotesapi.CommitRequest{
 		RepoId:         dcs.getRepoId(),
 		Current:        current[:],
 		Last:           last[:],
-		ChunkTableInfo: chnkTblInfo,
+		ChunkTableInfo: chunkTableInfo(tables),
+		KeyId:          dcs.keyID(),
 		ClientRepoFormat: &remotesapi.ClientRepoFormat{
 			NbfVersion: dcs.nbf.VersionString(),
 			NbsVersion: nbs.StorageVersion,
@@ -465,9 +518,165 @@ func (dcs *DoltChunkStore) Commit(ctx context.Context, current, last hash.Hash)
 
 	}
 
+	// the tables are added to the manifest whether or not the root was updated
+	dcs.novel.remove(tables)
+
+	return resp.Success, nil
+}
+
+// ListRefs returns the hashes of the commits the refs of the remote database point at, keyed by the full name of the
+// ref. Returns ErrRefUpdatesUnsupported if the remote does not implement the ListRefs rpc.
+func (dcs *DoltChunkStore) ListRefs(ctx context.Context) (map[string]hash.Hash, error) {
+	req := &remotesapi.ListRefsRequest{RepoId: dcs.getRepoId()}
+	resp, err := dcs.csClient.ListRefs(ctx, req)
+
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return nil, ErrRefUpdatesUnsupported
+		}
+
+		return nil, NewRpcError(err, "ListRefs", dcs.host, req)
+	}
+
+	refs := make(map[string]hash.Hash, len(resp.Refs))
+	for _, r := range resp.Refs {
+		refs[r.Name] = hash.New(r.Hash)
+	}
+
+	return refs, nil
+}
+
+// UpdateRefs uploads all novel chunks and then applies the updates given to the refs of the remote database. Each
+// update is a compare-and-swap of a single ref, so writes to other refs made at the same time do not cause it to fail.
+// Returns false without changing any ref if a ref does not point at its expected commit. Returns
+// ErrRefUpdatesUnsupported if the remote does not implement the UpdateRefs rpc, in which case the uploaded chunks are
+// added to the remote by the next call to Commit.
+func (dcs *DoltChunkStore) UpdateRefs(ctx context.Context, updates []datas.HeadUpdate) (bool, error) {
+	tables, err := dcs.Checkpoint(ctx)
+
+	if err != nil {
+		return false, err
+	}
+
+	refUpdates := make([]*remotesapi.RefUpdate, len(updates))
+	for i, update := range updates {
+		refUpdates[i] = &remotesapi.RefUpdate{
+			Name:         update.ID,
+			ExpectedHash: refHashBytes(update.Expected),
+			NewHash:      refHashBytes(update.New),
+		}
+	}
+
+	req := &remotesapi.UpdateRefsRequest{
+		RepoId:         dcs.getRepoId(),
+		Updates:        refUpdates,
+		ChunkTableInfo: chunkTableInfo(tables),
+		KeyId:          dcs.keyID(),
+		ClientRepoFormat: &remotesapi.ClientRepoFormat{
+			NbfVersion: dcs.nbf.VersionString(),
+			NbsVersion: nbs.StorageVersion,
+		},
+	}
+	resp, err := dcs.csClient.UpdateRefs(ctx, req)
+
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return false, ErrRefUpdatesUnsupported
+		}
+
+		return false, NewRpcError(err, "UpdateRefs", dcs.host, req)
+	}
+
+	// the tables are added to the manifest whether or not the refs were updated
+	dcs.novel.remove(tables)
+
 	return resp.Success, nil
 }
 
+// Checkpoint uploads the chunks written since the last call to Commit as table files, without adding them to the
+// remote's manifest, and returns the names and chunk counts of all the table files uploaded since the last Commit. The
+// chunks are not visible to readers of the remote until they are committed, but the table files can be added to a later
+// DoltChunkStore with AddNovelTables so that a push interrupted before it was committed can be resumed.
+func (dcs *DoltChunkStore) Checkpoint(ctx context.Context) (map[hash.Hash]uint32, error) {
+	hashToChunkCount, err := dcs.uploadChunks(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dcs.novel.mu.Lock()
+	defer dcs.novel.mu.Unlock()
+
+	for h, cnt := range hashToChunkCount {
+		dcs.novel.tables[h] = uint32(cnt)
+	}
+
+	tables := make(map[hash.Hash]uint32, len(dcs.novel.tables))
+	for h, cnt := range dcs.novel.tables {
+		tables[h] = cnt
+	}
+
+	return tables, nil
+}
+
+// AddNovelTables adds table files uploaded by an earlier call to Checkpoint to the remote's manifest, without changing
+// its root, so that the chunks they hold are known to the remote. Returns an error if the remote no longer has the
+// table files.
+func (dcs *DoltChunkStore) AddNovelTables(ctx context.Context, tables map[hash.Hash]uint32) error {
+	dcs.novel.add(tables)
+
+	root, err := dcs.Root(ctx)
+
+	if err == nil {
+		// the tables are added to the manifest even if the root moves before the commit
+		_, err = dcs.Commit(ctx, root, root)
+	}
+
+	if err != nil {
+		dcs.novel.remove(tables)
+		return err
+	}
+
+	return nil
+}
+
+func (nt *novelTables) add(tables map[hash.Hash]uint32) {
+	nt.mu.Lock()
+	defer nt.mu.Unlock()
+
+	for h, cnt := range tables {
+		nt.tables[h] = cnt
+	}
+}
+
+func (nt *novelTables) remove(tables map[hash.Hash]uint32) {
+	nt.mu.Lock()
+	defer nt.mu.Unlock()
+
+	for h := range tables {
+		delete(nt.tables, h)
+	}
+}
+
+func chunkTableInfo(tables map[hash.Hash]uint32) []*remotesapi.ChunkTableInfo {
+	chnkTblInfo := make([]*remotesapi.ChunkTableInfo, 0, len(tables))
+	for h, cnt := range tables {
+		h := h
+		chnkTblInfo = append(chnkTblInfo, &remotesapi.ChunkTableInfo{Hash: h[:], ChunkCount: cnt})
+	}
+
+	return chnkTblInfo
+}
+
+// refHashBytes returns the bytes of a ref hash as they are sent to the server, where the empty hash is sent as no bytes
+func refHashBytes(h hash.Hash) []byte {
+	if h.IsEmpty() {
+		return nil
+	}
+
+	return h[:]
+}
+
 // Stats may return some kind of struct that reports statistics about the
 // ChunkStore instance. The type is implementation-dependent, and impls
 // may return nil
@@ -487,7 +696,7 @@ func (dcs *DoltChunkStore) StatsSummary() string {
 // Close() concurrently with any other ChunkStore method; behavior is
 // undefined and probably crashy.
 func (dcs *DoltChunkStore) Close() error {
-	return nil
+	return dcs.cache.Persist(context.Background(), true)
 }
 
 // getting this working using the simplest approach first
@@ -507,7 +716,7 @@ func (dcs *DoltChunkStore) uploadChunks(ctx context.Context) (map[hash.Hash]int,
 	hashToData := make(map[hash.Hash][]byte)
 	// structuring so this can be done as multiple files in the future.
 	{
-		name, data, err := nbs.WriteChunks(chnks)
+		name, data, err := nbs.WriteChunks(chnks, dcs.compression, dcs.key)
 
 		if err != nil {
 			return map[hash.Hash]int{}, err
@@ -518,59 +727,63 @@ func (dcs *DoltChunkStore) uploadChunks(ctx context.Context) (map[hash.Hash]int,
 		hashToCount[h] = len(chnks)
 	}
 
-	hashBytes := make([][]byte, 0, len(hashToChunk))
-	for h := range hashToData {
-		tmp := h
-		hashBytes = append(hashBytes, tmp[:])
+	for h, data := range hashToData {
+		err := dcs.uploadTableFile(ctx, h, data)
+
+		if err != nil {
+			return map[hash.Hash]int{}, err
+		}
 	}
 
-	req := &remotesapi.GetUploadLocsRequest{RepoId: dcs.getRepoId(), Hashes: hashBytes}
-	resp, err := dcs.csClient.GetUploadLocations(ctx, req)
+	return hashToCount, nil
+}
 
-	if err != nil {
-		return map[hash.Hash]int{}, err
-	}
+// uploadTableFile uploads a table file to the remote. A failed upload is retried as a whole, getting a new location to
+// upload the table file to before uploading it again.
+func (dcs *DoltChunkStore) uploadTableFile(ctx context.Context, h hash.Hash, data []byte) error {
+	op := func() error {
+		req := &remotesapi.GetUploadLocsRequest{RepoId: dcs.getRepoId(), Hashes: [][]byte{h[:]}}
+		resp, err := dcs.csClient.GetUploadLocations(ctx, req)
 
-	for _, loc := range resp.Locs {
-		var err error
-		h := hash.New(loc.Hash)
-		data := hashToData[h]
-		switch typedLoc := loc.Location.(type) {
-		case *remotesapi.UploadLoc_HttpPost:
-			err = dcs.httpPostUpload(ctx, loc.Hash, typedLoc.HttpPost, data)
-		default:
-			break
+		if err != nil {
+			// the rpc has already been retried by the client
+			return backoff.Permanent(NewRpcError(err, "GetUploadLocations", dcs.host, req))
 		}
 
-		if err != nil {
-			return map[hash.Hash]int{}, err
+		for _, loc := range resp.Locs {
+			switch typedLoc := loc.Location.(type) {
+			case *remotesapi.UploadLoc_HttpPost:
+				err = dcs.httpPostUpload(ctx, typedLoc.HttpPost, data)
+			default:
+				break
+			}
+
+			if err != nil {
+				return err
+			}
 		}
+
+		return nil
 	}
 
-	return hashToCount, nil
+	return backoff.Retry(op, backoff.WithMaxRetries(uploadRetryParams, uploadRetryCount))
 }
 
-func (dcs *DoltChunkStore) httpPostUpload(ctx context.Context, hashBytes []byte, post *remotesapi.HttpPostChunk, data []byte) error {
-	//resp, err := http(post.Url, "application/octet-stream", bytes.NewBuffer(data))
-	req, err := http.NewRequest(http.MethodPut, post.Url, bytes.NewBuffer(data))
+// httpPostUpload makes a single attempt to upload data to the location given. Failed uploads are retried by
+// uploadTableFile.
+func (dcs *DoltChunkStore) httpPostUpload(ctx context.Context, post *remotesapi.HttpPostChunk, data []byte) error {
+	req, err := http.NewRequest(http.MethodPut, post.Url, bytes.NewReader(data))
 	if err != nil {
-		return err
-	}
-
-	var resp *http.Response
-	op := func() error {
-		var err error
-		resp, err = dcs.httpFetcher.Do(req.WithContext(ctx))
-		return processHttpResp(resp, err)
+		return backoff.Permanent(err)
 	}
 
-	err = backoff.Retry(op, backoff.WithMaxRetries(uploadRetryParams, uploadRetryCount))
+	resp, err := dcs.httpFetcher.Do(req.WithContext(ctx))
 
-	if err != nil {
-		return err
+	if err == nil {
+		defer resp.Body.Close()
 	}
 
-	return nil
+	return processHttpResp(resp, err)
 }
 
 // aggregateDownloads looks for byte ranges that need to be downloaded, and tries to aggregate them into a smaller number
@@ -583,7 +796,6 @@ func aggregateDownloads(aggDistance uint64, resourceToUrlAndRanges map[string]ur
 
 	// for each file that we need to download chunks from
 	for _, urlAndRanges := range resourceToUrlAndRanges {
-		urlStr := urlAndRanges.Url
 		ranges := urlAndRanges.Ranges
 
 		// sort the ranges we need to get by the starting offset
@@ -603,7 +815,7 @@ func aggregateDownloads(aggDistance uint64, resourceToUrlAndRanges map[string]ur
 				aggregatedRanges = append(aggregatedRanges, curr)
 			} else {
 				// When not close enough together add a DownloadLoc encompassing all the aggregated chunks
-				getRange := &remotesapi.HttpGetRange{Url: urlStr, Ranges: aggregatedRanges}
+				getRange := newHttpGetRange(urlAndRanges, aggregatedRanges)
 				aggregatedLocs = append(aggregatedLocs, &remotesapi.DownloadLoc{Location: &remotesapi.DownloadLoc_HttpGetRange{HttpGetRange: getRange}})
 
 				// start a new aggregation of ranges
@@ -614,13 +826,24 @@ func aggregateDownloads(aggDistance uint64, resourceToUrlAndRanges map[string]ur
 		}
 
 		// add the last DownloadLoc
-		getRange := &remotesapi.HttpGetRange{Url: urlStr, Ranges: aggregatedRanges}
+		getRange := newHttpGetRange(urlAndRanges, aggregatedRanges)
 		aggregatedLocs = append(aggregatedLocs, &remotesapi.DownloadLoc{Location: &remotesapi.DownloadLoc_HttpGetRange{HttpGetRange: getRange}})
 	}
 
 	return aggregatedLocs
 }
 
+// newHttpGetRange returns an HttpGetRange for ranges of the table file at the url given, along with the range of its
+// dictionaries
+func newHttpGetRange(uAndR urlAndRanges, ranges []*remotesapi.RangeChunk) *remotesapi.HttpGetRange {
+	return &remotesapi.HttpGetRange{
+		Url:                uAndR.Url,
+		Ranges:             ranges,
+		DictionariesOffset: uAndR.DictOffset,
+		DictionariesLength: uAndR.DictLength,
+	}
+}
+
 const (
 	chunkAggDistance       = 8 * 1024
 	maxDownloadConcurrency = 64
@@ -661,12 +884,20 @@ func (dcs *DoltChunkStore) downloadChunks(ctx context.Context, resourceToUrlAndR
 
 // getRangeDownloadFunc returns a work function that does the downloading of one or more chunks and writes those chunks
 // to the chunkChan
-func (dcs *DoltChunkStore) getRangeDownloadFunc(ctx context.Context, urlStr string, ranges []*remotesapi.RangeChunk, chunkChan chan *chunks.Chunk) func() error {
+func (dcs *DoltChunkStore) getRangeDownloadFunc(ctx context.Context, getRange *remotesapi.HttpGetRange, chunkChan chan *chunks.Chunk) func() error {
+	urlStr := getRange.Url
+	ranges := getRange.Ranges
 	numRanges := len(ranges)
 	offset := ranges[0].Offset
 	length := ranges[numRanges-1].Offset - offset + uint64(ranges[numRanges-1].Length)
 
 	return func() error {
+		decomp, err := dcs.getDecompressor(ctx, getRange)
+
+		if err != nil {
+			return err
+		}
+
 		comprData, err := rangeDownloadWithRetries(ctx, dcs.httpFetcher, offset, length, urlStr)
 
 		if err != nil {
@@ -678,7 +909,7 @@ func (dcs *DoltChunkStore) getRangeDownloadFunc(ctx context.Context, urlStr stri
 		for _, r := range ranges {
 			chunkStart := r.Offset - offset
 			chunkEnd := chunkStart + uint64(r.Length) - 4
-			chunkBytes, err := snappy.Decode(nil, comprData[chunkStart:chunkEnd])
+			chunkBytes, err := decomp.Decompress(hash.New(r.Hash), comprData[chunkStart:chunkEnd])
 
 			if err != nil {
 				return err
@@ -692,6 +923,43 @@ func (dcs *DoltChunkStore) getRangeDownloadFunc(ctx context.Context, urlStr stri
 	}
 }
 
+// getDecompressor returns the ChunkDecompressor for the chunks of the table file an HttpGetRange refers to, downloading
+// the dictionaries of the table file the first time they are needed. Returns nil if the table file has no dictionaries.
+func (dcs *DoltChunkStore) getDecompressor(ctx context.Context, getRange *remotesapi.HttpGetRange) (*nbs.ChunkDecompressor, error) {
+	if getRange.DictionariesLength == 0 {
+		return nil, nil
+	}
+
+	resourcePath := getResourcePath(getRange.Url)
+
+	dcs.decomps.mu.Lock()
+	decomp, ok := dcs.decomps.decomps[resourcePath]
+	dcs.decomps.mu.Unlock()
+
+	if ok {
+		return decomp, nil
+	}
+
+	dicts, err := rangeDownloadWithRetries(ctx, dcs.httpFetcher, getRange.DictionariesOffset, uint64(getRange.DictionariesLength), getRange.Url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	decomp, err = nbs.NewChunkDecompressor(dicts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dcs.decomps.mu.Lock()
+	defer dcs.decomps.mu.Unlock()
+
+	dcs.decomps.decomps[resourcePath] = decomp
+
+	return decomp, nil
+}
+
 // rangeDownloadWithRetries executes an http get with the 'Range' header to get a range of bytes from a file.  Request
 // is executed with retries and if progress was made, downloads will be resumed from where they left off on subsequent attempts.
 func rangeDownloadWithRetries(ctx context.Context, fetcher HTTPFetcher, offset, length uint64, urlStr string) ([]byte, error) {
@@ -766,5 +1034,5 @@ func (dcs *DoltChunkStore) getDownloadWorkForLoc(ctx context.Context, getRange *
 		return work
 	}
 
-	return []func() error{dcs.getRangeDownloadFunc(ctx, getRange.Url, getRange.Ranges, chunkChan)}
+	return []func() error{dcs.getRangeDownloadFunc(ctx, getRange, chunkChan)}
 }
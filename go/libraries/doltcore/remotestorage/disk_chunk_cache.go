@@ -0,0 +1,432 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotestorage
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
+)
+
+const (
+	// maxDiskCacheTables is the maximum number of table files a DiskChunkCache keeps open. The least recently used
+	// table files are evicted past this count, regardless of the size of the cache.
+	maxDiskCacheTables = 256
+
+	// diskCacheTouchInterval is the minimum amount of time between updates of the modification time of a table file
+	// when it is read. Modification times order the table files of a cache directory for eviction across processes.
+	diskCacheTouchInterval = time.Minute
+
+	// diskCachePersistSize is the amount of chunk data read from a remote which is held in memory before it is written
+	// to a table file of the DiskChunkCache. The rest is written when the chunk store is closed.
+	diskCachePersistSize = 32 * 1024 * 1024
+
+	// diskCacheTmpFilePrefix prefixes the names of the files table files are written to before they are renamed into
+	// place.
+	diskCacheTmpFilePrefix = "tmp"
+
+	// diskCacheOrphanAge is the age past which a temporary file is taken to have been left behind by a process which
+	// exited while writing it, and is deleted.
+	diskCacheOrphanAge = time.Hour
+)
+
+var openDiskCachesMu = &sync.Mutex{}
+var openDiskCaches = make(map[string]*DiskChunkCache)
+
+// DiskChunkCache is a cache of chunks read from remotes, held in NBS table files in a directory on disk so that
+// it outlives the process. Chunks are content addressed, so a single cache is safely shared by every repository
+// which uses the same remote. When the total size of the table files exceeds the maximum size of the cache the
+// least recently used table files are deleted.
+type DiskChunkCache struct {
+	mu      *sync.Mutex
+	dir     string
+	maxSize uint64
+	tables  map[string]*diskCacheTable
+	index   map[hash.Hash]string
+}
+
+type diskCacheTable struct {
+	tf       *nbs.TableFile
+	size     uint64
+	lastUsed time.Time
+	touched  time.Time
+}
+
+// OpenDiskChunkCache opens the cache held in the directory given, creating the directory if it doesn't exist. A
+// cache directory is opened once per process, and later calls return the already open cache.
+func OpenDiskChunkCache(dir string, maxSize uint64) (*DiskChunkCache, error) {
+	dir, err := filepath.Abs(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	openDiskCachesMu.Lock()
+	defer openDiskCachesMu.Unlock()
+
+	if dc, ok := openDiskCaches[dir]; ok {
+		return dc, nil
+	}
+
+	err = os.MkdirAll(dir, os.ModePerm)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dc := &DiskChunkCache{&sync.Mutex{}, dir, maxSize, make(map[string]*diskCacheTable), make(map[hash.Hash]string)}
+	err = dc.sync()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dc.evict()
+	openDiskCaches[dir] = dc
+
+	return dc, nil
+}
+
+// Get returns the chunks in the cache with the hashes given, keyed by hash. Chunks which aren't in the cache are left
+// out of the map returned.
+func (dc *DiskChunkCache) Get(ctx context.Context, hashes hash.HashSet) (map[hash.Hash]chunks.Chunk, error) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	found := make(map[hash.Hash]chunks.Chunk)
+	for h := range hashes {
+		name, ok := dc.index[h]
+
+		if !ok {
+			continue
+		}
+
+		t := dc.tables[name]
+		c, err := t.tf.Get(ctx, h)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !c.IsEmpty() {
+			found[h] = c
+			dc.touch(name, t)
+		}
+	}
+
+	return found, nil
+}
+
+// Put writes the chunks given to a new table file of the cache, leaving out any chunks already in the cache, and
+// then evicts the least recently used table files if the cache is over its maximum size.
+func (dc *DiskChunkCache) Put(ctx context.Context, chnks []chunks.Chunk) error {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	err := dc.sync()
+
+	if err != nil {
+		return err
+	}
+
+	var novel []chunks.Chunk
+	seen := make(hash.HashSet)
+	for _, c := range chnks {
+		h := c.Hash()
+		if c.IsEmpty() || seen.Has(h) || dc.has(h) {
+			continue
+		}
+
+		seen.Insert(h)
+		novel = append(novel, c)
+	}
+
+	if len(novel) == 0 {
+		return nil
+	}
+
+	name, data, err := nbs.WriteChunks(novel, nbs.SnappyCompression, nil)
+
+	if err != nil {
+		return err
+	}
+
+	err = dc.writeTable(name, data)
+
+	if err != nil {
+		return err
+	}
+
+	dc.evict()
+
+	return nil
+}
+
+func (dc *DiskChunkCache) has(h hash.Hash) bool {
+	_, ok := dc.index[h]
+	return ok
+}
+
+// sync brings the table files of the cache up to date with its directory, which other processes sharing the directory
+// write table files to and evict them from. Table files which were deleted are dropped, table files which were written
+// are added, and temporary files left behind by processes which exited while writing them are deleted.
+func (dc *DiskChunkCache) sync() error {
+	infos, err := ioutil.ReadDir(dc.dir)
+
+	if err != nil {
+		return err
+	}
+
+	inDir := make(map[string]bool, len(infos))
+	for _, info := range infos {
+		name := info.Name()
+		path := filepath.Join(dc.dir, name)
+		if info.IsDir() {
+			continue
+		} else if _, ok := hash.MaybeParse(name); !ok {
+			if strings.HasPrefix(name, diskCacheTmpFilePrefix) && time.Since(info.ModTime()) > diskCacheOrphanAge {
+				_ = os.Remove(path)
+			}
+
+			continue
+		}
+
+		inDir[name] = true
+		if _, ok := dc.tables[name]; ok {
+			continue
+		}
+
+		tf, err := nbs.OpenTableFile(path)
+
+		if os.IsNotExist(err) {
+			// evicted by another process since the directory was read
+			delete(inDir, name)
+			continue
+		} else if err != nil {
+			// a corrupt table file is dropped from the cache
+			_ = os.Remove(path)
+			delete(inDir, name)
+			continue
+		}
+
+		dc.addTable(name, tf, uint64(info.Size()), info.ModTime())
+	}
+
+	for name := range dc.tables {
+		if !inDir[name] {
+			dc.removeTable(name)
+		}
+	}
+
+	return nil
+}
+
+func (dc *DiskChunkCache) addTable(name string, tf *nbs.TableFile, size uint64, lastUsed time.Time) {
+	dc.tables[name] = &diskCacheTable{tf, size, lastUsed, lastUsed}
+
+	for _, h := range tf.Hashes() {
+		if _, ok := dc.index[h]; !ok {
+			dc.index[h] = name
+		}
+	}
+}
+
+// removeTable closes a table file and drops it from the cache, without deleting the file. Chunks which another table
+// file of the cache also holds are dropped from the index as well, and are read from the remote again when needed.
+func (dc *DiskChunkCache) removeTable(name string) {
+	t := dc.tables[name]
+	delete(dc.tables, name)
+
+	for _, h := range t.tf.Hashes() {
+		if dc.index[h] == name {
+			delete(dc.index, h)
+		}
+	}
+
+	_ = t.tf.Close()
+}
+
+func (dc *DiskChunkCache) writeTable(name string, data []byte) error {
+	if _, ok := dc.tables[name]; ok {
+		return nil
+	}
+
+	f, err := ioutil.TempFile(dc.dir, diskCacheTmpFilePrefix)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	path := filepath.Join(dc.dir, name)
+	err = os.Rename(f.Name(), path)
+
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	tf, err := nbs.OpenTableFile(path)
+
+	if err != nil {
+		return err
+	}
+
+	dc.addTable(name, tf, uint64(len(data)), time.Now())
+
+	return nil
+}
+
+// touch marks a table file as used, updating its modification time if it hasn't been updated recently
+func (dc *DiskChunkCache) touch(name string, t *diskCacheTable) {
+	t.lastUsed = time.Now()
+
+	if t.lastUsed.Sub(t.touched) > diskCacheTouchInterval {
+		t.touched = t.lastUsed
+		_ = os.Chtimes(filepath.Join(dc.dir, name), t.lastUsed, t.lastUsed)
+	}
+}
+
+// evict deletes the least recently used table files until the cache is within its maximum size and table count
+func (dc *DiskChunkCache) evict() {
+	var size uint64
+	names := make([]string, 0, len(dc.tables))
+	for name, t := range dc.tables {
+		size += t.size
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		return dc.tables[names[i]].lastUsed.Before(dc.tables[names[j]].lastUsed)
+	})
+
+	for _, name := range names {
+		if size <= dc.maxSize && len(dc.tables) <= maxDiskCacheTables {
+			break
+		}
+
+		size -= dc.tables[name].size
+		dc.removeTable(name)
+
+		// another process sharing the directory may have already deleted the file
+		_ = os.Remove(filepath.Join(dc.dir, name))
+	}
+}
+
+// persistentChunkCache is a chunkCache which keeps the chunks read from the remote in a DiskChunkCache as well as
+// in memory. Chunks written, and the hashes of chunks the remote is known to have, are held only in memory, as they
+// are specific to the remote repository while the DiskChunkCache is shared by all the repositories of a remote.
+type persistentChunkCache struct {
+	*mapChunkCache
+	disk        *DiskChunkCache
+	mu          *sync.Mutex
+	pending     map[hash.Hash]chunks.Chunk
+	pendingSize uint64
+}
+
+func newPersistentChunkCache(disk *DiskChunkCache) *persistentChunkCache {
+	return &persistentChunkCache{
+		newMapChunkCache(),
+		disk,
+		&sync.Mutex{},
+		make(map[hash.Hash]chunks.Chunk),
+		0,
+	}
+}
+
+// Get gets a map of hash to chunk for a set of hashes.  In the event that a chunk is not in the cache, chunks.Empty.
+// is put in it's place
+func (pcc *persistentChunkCache) Get(hashes hash.HashSet) map[hash.Hash]chunks.Chunk {
+	hashToChunk := pcc.mapChunkCache.Get(hashes)
+
+	notInMem := make(hash.HashSet)
+	for h, c := range hashToChunk {
+		if c.IsEmpty() {
+			notInMem.Insert(h)
+		}
+	}
+
+	if len(notInMem) == 0 {
+		return hashToChunk
+	}
+
+	// failing to read the disk cache is treated as a cache miss
+	onDisk, err := pcc.disk.Get(context.Background(), notInMem)
+
+	if err == nil {
+		for h, c := range onDisk {
+			hashToChunk[h] = c
+		}
+	}
+
+	return hashToChunk
+}
+
+// PutChunk puts a single chunk in the cache.  true returns in the event that the chunk was cached successfully
+// and false is returned if that chunk is already is the cache.
+func (pcc *persistentChunkCache) PutChunk(ch *chunks.Chunk) bool {
+	if !pcc.mapChunkCache.PutChunk(ch) {
+		return false
+	}
+
+	pcc.mu.Lock()
+	defer pcc.mu.Unlock()
+
+	pcc.pending[ch.Hash()] = *ch
+	pcc.pendingSize += uint64(len(ch.Data()))
+
+	return true
+}
+
+// Persist writes the chunks read from the remote since they were last written to the DiskChunkCache, once there are
+// diskCachePersistSize bytes of them, or however many there are if all is true.
+func (pcc *persistentChunkCache) Persist(ctx context.Context, all bool) error {
+	pcc.mu.Lock()
+	if len(pcc.pending) == 0 || (!all && pcc.pendingSize < diskCachePersistSize) {
+		pcc.mu.Unlock()
+		return nil
+	}
+
+	pending := pcc.pending
+	pcc.pending = make(map[hash.Hash]chunks.Chunk)
+	pcc.pendingSize = 0
+	pcc.mu.Unlock()
+
+	chnks := make([]chunks.Chunk, 0, len(pending))
+	for _, c := range pending {
+		chnks = append(chnks, c)
+	}
+
+	return pcc.disk.Put(ctx, chnks)
+}
@@ -0,0 +1,130 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotestorage
+
+import (
+	"context"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/nbs"
+)
+
+func TestDiskChunkCache(t *testing.T) {
+	const chunkBatchSize = 10
+
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	seed := time.Now().UnixNano()
+	rng := rand.New(rand.NewSource(seed))
+	hashes, chks := genRandomChunks(rng, chunkBatchSize)
+
+	dc, err := OpenDiskChunkCache(dir, 1<<20)
+	require.NoError(t, err)
+
+	pcc := newPersistentChunkCache(dc)
+	for i := range chks {
+		assert.True(t, pcc.PutChunk(&chks[i]), "new chunk should return true (seed %d)", seed)
+	}
+
+	// chunks are held in memory until there are enough of them to write a table file
+	require.NoError(t, pcc.Persist(ctx, false))
+	assert.Empty(t, dc.tables)
+
+	require.NoError(t, pcc.Persist(ctx, true))
+	assert.Len(t, dc.tables, 1)
+
+	// a new store reading from the same remote reads the chunks from disk, but doesn't know the remote has them
+	other := newPersistentChunkCache(dc)
+	hashToChunk := other.Get(hashes)
+	for _, c := range chks {
+		assert.Equal(t, c.Data(), hashToChunk[c.Hash()].Data(), "chunk not read back from disk (seed %d)", seed)
+	}
+
+	assert.Equal(t, hashes, other.Has(hashes), "disk cache should not report chunks present (seed %d)", seed)
+
+	found, err := dc.Get(ctx, hashes)
+	require.NoError(t, err)
+	assert.Len(t, found, chunkBatchSize)
+
+	// the least recently used table file is evicted when the cache is over its maximum size
+	dc.maxSize = 0
+	moreHashes, moreChks := genRandomChunks(rng, chunkBatchSize)
+	require.NoError(t, dc.Put(ctx, moreChks))
+
+	found, err = dc.Get(ctx, hashes)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	found, err = dc.Get(ctx, moreHashes)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
+
+func TestDiskChunkCacheSync(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	hashes, chks := genRandomChunks(rng, 10)
+	moreHashes, moreChks := genRandomChunks(rng, 10)
+
+	dc, err := OpenDiskChunkCache(dir, 1<<20)
+	require.NoError(t, err)
+	require.NoError(t, dc.Put(ctx, chks))
+
+	// another process sharing the directory evicts the table file, writes a table file of its own, and leaves behind
+	// a temporary file
+	for name := range dc.tables {
+		require.NoError(t, os.Remove(filepath.Join(dir, name)))
+	}
+
+	name, data, err := nbs.WriteChunks(moreChks, nbs.SnappyCompression, nil)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), data, os.ModePerm))
+
+	orphan := filepath.Join(dir, diskCacheTmpFilePrefix+"123")
+	require.NoError(t, ioutil.WriteFile(orphan, data, os.ModePerm))
+	old := time.Now().Add(-2 * diskCacheOrphanAge)
+	require.NoError(t, os.Chtimes(orphan, old, old))
+
+	require.NoError(t, dc.sync())
+	assert.Len(t, dc.tables, 1)
+	assert.Contains(t, dc.tables, name)
+	assert.Len(t, dc.index, len(moreChks))
+
+	found, err := dc.Get(ctx, hashes)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	found, err = dc.Get(ctx, moreHashes)
+	require.NoError(t, err)
+	assert.Len(t, found, len(moreChks))
+
+	_, err = os.Stat(orphan)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,217 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotestorage
+
+import (
+	"context"
+	"sync"
+
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// RemoteOpener opens the ChunkStore of a remote. It is called the first time a chunk is missing from a LazyChunkStore.
+type RemoteOpener func(ctx context.Context) (chunks.ChunkStore, error)
+
+// LazyChunkStore is a ChunkStore which reads any chunk missing from a local ChunkStore from a remote, writing it to the
+// local store as it is read. It allows a repository which was cloned or fetched without some of its chunks to read
+// them on demand.
+//
+// Has and HasMany only consider the local store, so a pull into a LazyChunkStore still copies every chunk it is
+// missing. Chunks read from the remote are written to the local store, but like any other Put they are only persisted
+// by the next Commit.
+type LazyChunkStore struct {
+	local  chunks.ChunkStore
+	open   RemoteOpener
+	mu     *sync.Mutex
+	remote chunks.ChunkStore
+}
+
+// NewLazyChunkStore returns a LazyChunkStore reading from the local ChunkStore given, and from the remote opened by
+// open when a chunk is not found locally.
+func NewLazyChunkStore(local chunks.ChunkStore, open RemoteOpener) *LazyChunkStore {
+	return &LazyChunkStore{local: local, open: open, mu: &sync.Mutex{}}
+}
+
+func (lcs *LazyChunkStore) getRemote(ctx context.Context) (chunks.ChunkStore, error) {
+	lcs.mu.Lock()
+	defer lcs.mu.Unlock()
+
+	if lcs.remote == nil {
+		remote, err := lcs.open(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		lcs.remote = remote
+	}
+
+	return lcs.remote, nil
+}
+
+// Get the Chunk for the value of the hash in the store. If the hash is absent from both the local store and the
+// remote EmptyChunk is returned.
+func (lcs *LazyChunkStore) Get(ctx context.Context, h hash.Hash) (chunks.Chunk, error) {
+	c, err := lcs.local.Get(ctx, h)
+
+	if err != nil || !c.IsEmpty() {
+		return c, err
+	}
+
+	remote, err := lcs.getRemote(ctx)
+
+	if err != nil {
+		return chunks.EmptyChunk, err
+	}
+
+	c, err = remote.Get(ctx, h)
+
+	if err != nil || c.IsEmpty() {
+		return c, err
+	}
+
+	err = lcs.local.Put(ctx, c)
+
+	if err != nil {
+		return chunks.EmptyChunk, err
+	}
+
+	return c, nil
+}
+
+// GetMany gets the Chunks with |hashes| from the store. Chunks missing from the local store are requested from the
+// remote in a single batch. On return, |foundChunks| will have been fully sent all chunks which have been found.
+func (lcs *LazyChunkStore) GetMany(ctx context.Context, hashes hash.HashSet, foundChunks chan *chunks.Chunk) error {
+	missing := make(hash.HashSet, len(hashes))
+	for h := range hashes {
+		missing.Insert(h)
+	}
+
+	err := getManyForwarding(ctx, lcs.local, hashes, foundChunks, func(c *chunks.Chunk) error {
+		missing.Remove(c.Hash())
+		return nil
+	})
+
+	if err != nil || len(missing) == 0 {
+		return err
+	}
+
+	remote, err := lcs.getRemote(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	return getManyForwarding(ctx, remote, missing, foundChunks, func(c *chunks.Chunk) error {
+		return lcs.local.Put(ctx, *c)
+	})
+}
+
+// getManyForwarding calls GetMany on the ChunkStore given, calling cb with each chunk found before sending it on to
+// foundChunks.
+func getManyForwarding(ctx context.Context, cs chunks.ChunkStore, hashes hash.HashSet, foundChunks chan *chunks.Chunk, cb func(c *chunks.Chunk) error) error {
+	found := make(chan *chunks.Chunk, 128)
+	cbErrChan := make(chan error, 1)
+	go func() {
+		defer close(cbErrChan)
+
+		var cbErr error
+		for c := range found {
+			if cbErr == nil {
+				cbErr = cb(c)
+			}
+
+			foundChunks <- c
+		}
+
+		cbErrChan <- cbErr
+	}()
+
+	err := cs.GetMany(ctx, hashes, found)
+	close(found)
+	cbErr := <-cbErrChan
+
+	if err != nil {
+		return err
+	}
+
+	return cbErr
+}
+
+// Has returns true if the chunk is in the local store. The remote is not consulted.
+func (lcs *LazyChunkStore) Has(ctx context.Context, h hash.Hash) (bool, error) {
+	return lcs.local.Has(ctx, h)
+}
+
+// HasMany returns a new HashSet containing any members of |hashes| that are absent from the local store. The remote
+// is not consulted.
+func (lcs *LazyChunkStore) HasMany(ctx context.Context, hashes hash.HashSet) (hash.HashSet, error) {
+	return lcs.local.HasMany(ctx, hashes)
+}
+
+// Put caches c in the local store.
+func (lcs *LazyChunkStore) Put(ctx context.Context, c chunks.Chunk) error {
+	return lcs.local.Put(ctx, c)
+}
+
+// Returns the NomsVersion with which this ChunkSource is compatible.
+func (lcs *LazyChunkStore) Version() string {
+	return lcs.local.Version()
+}
+
+// Rebase brings this ChunkStore into sync with the persistent storage's current root.
+func (lcs *LazyChunkStore) Rebase(ctx context.Context) error {
+	return lcs.local.Rebase(ctx)
+}
+
+// Root returns the root of the local store.
+func (lcs *LazyChunkStore) Root(ctx context.Context) (hash.Hash, error) {
+	return lcs.local.Root(ctx)
+}
+
+// Commit atomically attempts to persist all novel Chunks, including those read from the remote, and update the
+// persisted root hash of the local store from last to current.
+func (lcs *LazyChunkStore) Commit(ctx context.Context, current, last hash.Hash) (bool, error) {
+	return lcs.local.Commit(ctx, current, last)
+}
+
+// Stats may return some kind of struct that reports statistics about the ChunkStore instance. The type is
+// implementation-dependent, and impls may return nil
+func (lcs *LazyChunkStore) Stats() interface{} {
+	return lcs.local.Stats()
+}
+
+// StatsSummary may return a string containing summarized statistics for this ChunkStore. It must return "Unsupported"
+// if this operation is not supported.
+func (lcs *LazyChunkStore) StatsSummary() string {
+	return lcs.local.StatsSummary()
+}
+
+// Close closes the local store, and the remote if it was opened.
+func (lcs *LazyChunkStore) Close() error {
+	err := lcs.local.Close()
+
+	lcs.mu.Lock()
+	defer lcs.mu.Unlock()
+
+	if lcs.remote != nil {
+		if remoteErr := lcs.remote.Close(); err == nil {
+			err = remoteErr
+		}
+	}
+
+	return err
+}
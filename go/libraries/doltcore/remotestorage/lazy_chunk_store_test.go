@@ -0,0 +1,84 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotestorage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+func TestLazyChunkStore(t *testing.T) {
+	ctx := context.Background()
+	local := (&chunks.MemoryStorage{}).NewView()
+	remote := (&chunks.MemoryStorage{}).NewView()
+
+	localChunk := chunks.NewChunk([]byte("local"))
+	remoteChunks := []chunks.Chunk{chunks.NewChunk([]byte("remote 1")), chunks.NewChunk([]byte("remote 2"))}
+	require.NoError(t, local.Put(ctx, localChunk))
+	for _, c := range remoteChunks {
+		require.NoError(t, remote.Put(ctx, c))
+	}
+
+	opens := 0
+	lcs := NewLazyChunkStore(local, func(ctx context.Context) (chunks.ChunkStore, error) {
+		opens++
+		return remote, nil
+	})
+
+	c, err := lcs.Get(ctx, localChunk.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, localChunk.Data(), c.Data())
+	assert.Equal(t, 0, opens)
+
+	has, err := lcs.Has(ctx, remoteChunks[0].Hash())
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	c, err = lcs.Get(ctx, remoteChunks[0].Hash())
+	require.NoError(t, err)
+	assert.Equal(t, remoteChunks[0].Data(), c.Data())
+
+	has, err = lcs.Has(ctx, remoteChunks[0].Hash())
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	absent := chunks.NewChunk([]byte("absent"))
+	hashes := hash.NewHashSet(localChunk.Hash(), remoteChunks[0].Hash(), remoteChunks[1].Hash(), absent.Hash())
+	found := make(chan *chunks.Chunk, len(hashes))
+	require.NoError(t, lcs.GetMany(ctx, hashes, found))
+	close(found)
+
+	foundHashes := hash.HashSet{}
+	for c := range found {
+		foundHashes.Insert(c.Hash())
+	}
+
+	assert.Equal(t, hash.NewHashSet(localChunk.Hash(), remoteChunks[0].Hash(), remoteChunks[1].Hash()), foundHashes)
+	assert.Equal(t, 1, opens)
+
+	has, err = lcs.Has(ctx, remoteChunks[1].Hash())
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	c, err = lcs.Get(ctx, absent.Hash())
+	require.NoError(t, err)
+	assert.True(t, c.IsEmpty())
+}
@@ -15,6 +15,7 @@
 package remotestorage
 
 import (
+	"context"
 	"sync"
 
 	"github.com/liquidata-inc/dolt/go/store/chunks"
@@ -123,3 +124,8 @@ func (mcc *mapChunkCache) GetAndClearChunksToFlush() map[hash.Hash]chunks.Chunk
 
 	return toFlush
 }
+
+// Persist does nothing, as a mapChunkCache is held only in memory.
+func (mcc *mapChunkCache) Persist(ctx context.Context, all bool) error {
+	return nil
+}
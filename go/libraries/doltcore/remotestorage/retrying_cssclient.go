@@ -123,3 +123,42 @@ func (c RetryingChunkStoreServiceClient) Commit(ctx context.Context, in *remotes
 
 	return resp, err
 }
+
+func (c RetryingChunkStoreServiceClient) ListRefs(ctx context.Context, in *remotesapi.ListRefsRequest, opts ...grpc.CallOption) (*remotesapi.ListRefsResponse, error) {
+	var resp *remotesapi.ListRefsResponse
+	op := func() error {
+		var err error
+		resp, err = c.client.ListRefs(ctx, in, opts...)
+		return processGrpcErr(err)
+	}
+
+	err := backoff.Retry(op, backoff.WithMaxRetries(csRetryParams, csClientRetries))
+
+	return resp, err
+}
+
+func (c RetryingChunkStoreServiceClient) UpdateRefs(ctx context.Context, in *remotesapi.UpdateRefsRequest, opts ...grpc.CallOption) (*remotesapi.UpdateRefsResponse, error) {
+	var resp *remotesapi.UpdateRefsResponse
+	op := func() error {
+		var err error
+		resp, err = c.client.UpdateRefs(ctx, in, opts...)
+		return processGrpcErr(err)
+	}
+
+	err := backoff.Retry(op, backoff.WithMaxRetries(csRetryParams, csClientRetries))
+
+	return resp, err
+}
+
+func (c RetryingChunkStoreServiceClient) ListTableFiles(ctx context.Context, in *remotesapi.ListTableFilesRequest, opts ...grpc.CallOption) (*remotesapi.ListTableFilesResponse, error) {
+	var resp *remotesapi.ListTableFilesResponse
+	op := func() error {
+		var err error
+		resp, err = c.client.ListTableFiles(ctx, in, opts...)
+		return processGrpcErr(err)
+	}
+
+	err := backoff.Retry(op, backoff.WithMaxRetries(csRetryParams, csClientRetries))
+
+	return resp, err
+}
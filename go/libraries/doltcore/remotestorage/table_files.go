@@ -0,0 +1,257 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotestorage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	remotesapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/remotesapi_v1alpha1"
+	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
+)
+
+const (
+	// DefaultTableFileConcurrency is the number of table files copied at the same time by default
+	DefaultTableFileConcurrency = 8
+
+	tableFileRetryCount       = 5
+	tableFileRetryMaxInterval = 5 * time.Second
+)
+
+var _ nbs.TableFileStore = (*DoltChunkStore)(nil)
+
+// TableFiles returns the table files in the manifest of the remote. Returns ErrTableFilesUnsupported if the remote does
+// not implement the ListTableFiles rpc.
+func (dcs *DoltChunkStore) TableFiles(ctx context.Context) ([]nbs.TableFileSource, error) {
+	req := &remotesapi.ListTableFilesRequest{RepoId: dcs.getRepoId()}
+	resp, err := dcs.csClient.ListTableFiles(ctx, req)
+
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return nil, ErrTableFilesUnsupported
+		}
+
+		return nil, NewRpcError(err, "ListTableFiles", dcs.host, req)
+	}
+
+	tfs := make([]nbs.TableFileSource, len(resp.TableFileInfo))
+	for i, info := range resp.TableFileInfo {
+		tfs[i] = remoteTableFile{dcs.httpFetcher, hash.New(info.Hash), info.ChunkCount, info.Url}
+	}
+
+	return tfs, nil
+}
+
+// WriteTableFile uploads the table file given to the remote, without adding it to the remote's manifest. The table
+// file is streamed from its source rather than read into memory.
+func (dcs *DoltChunkStore) WriteTableFile(ctx context.Context, src nbs.TableFileSource) error {
+	h := src.Name()
+	req := &remotesapi.GetUploadLocsRequest{RepoId: dcs.getRepoId(), Hashes: [][]byte{h[:]}}
+	resp, err := dcs.csClient.GetUploadLocations(ctx, req)
+
+	if err != nil {
+		return NewRpcError(err, "GetUploadLocations", dcs.host, req)
+	}
+
+	for _, loc := range resp.Locs {
+		switch typedLoc := loc.Location.(type) {
+		case *remotesapi.UploadLoc_HttpPost:
+			err = dcs.httpPutTableFile(ctx, typedLoc.HttpPost, src)
+		default:
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (dcs *DoltChunkStore) httpPutTableFile(ctx context.Context, post *remotesapi.HttpPostChunk, src nbs.TableFileSource) error {
+	rd, size, err := src.Open(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer rd.Close()
+
+	req, err := http.NewRequest(http.MethodPut, post.Url, rd)
+
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+
+	req.ContentLength = int64(size)
+	resp, err := dcs.httpFetcher.Do(req.WithContext(ctx))
+
+	if err == nil {
+		defer resp.Body.Close()
+	}
+
+	return processHttpResp(resp, err)
+}
+
+// AddTableFiles adds table files uploaded with WriteTableFile to the remote's manifest. The root of the remote is not
+// changed.
+func (dcs *DoltChunkStore) AddTableFiles(ctx context.Context, tables map[hash.Hash]uint32) error {
+	return dcs.AddNovelTables(ctx, tables)
+}
+
+// remoteTableFile is a table file of a remote, read from the url the remote listed it with
+type remoteTableFile struct {
+	fetcher HTTPFetcher
+	name    hash.Hash
+	count   uint32
+	url     string
+}
+
+func (tf remoteTableFile) Name() hash.Hash {
+	return tf.name
+}
+
+func (tf remoteTableFile) ChunkCount() uint32 {
+	return tf.count
+}
+
+func (tf remoteTableFile) Open(ctx context.Context) (io.ReadCloser, uint64, error) {
+	req, err := http.NewRequest(http.MethodGet, tf.url, nil)
+
+	if err != nil {
+		return nil, 0, backoff.Permanent(err)
+	}
+
+	resp, err := tf.fetcher.Do(req.WithContext(ctx))
+	err = processHttpResp(resp, err)
+
+	if err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		return nil, 0, err
+	}
+
+	var size uint64
+	if resp.ContentLength > 0 {
+		size = uint64(resp.ContentLength)
+	}
+
+	return resp.Body, size, nil
+}
+
+// CopyTableFiles copies the table files of src which sink doesn't have to sink, concurrency table files at a time, and
+// then adds them to the manifest of sink. Each table file is copied as a whole, rather than chunk by chunk, and a table
+// file which fails to copy is copied again from the start. Progress is reported in chunks over progChan if it isn't
+// nil.
+//
+// As all of the table files in the manifest of src are copied, sink ends up with every chunk src has, even those it
+// already had in other table files.
+func CopyTableFiles(ctx context.Context, src, sink nbs.TableFileStore, concurrency int, progChan chan datas.PullProgress) error {
+	srcTfs, err := src.TableFiles(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	sinkTfs, err := sink.TableFiles(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	present := make(hash.HashSet)
+	for _, tf := range sinkTfs {
+		present.Insert(tf.Name())
+	}
+
+	var knownCount uint64
+	tables := make(map[hash.Hash]uint32)
+	var toCopy []nbs.TableFileSource
+	for _, tf := range srcTfs {
+		if present.Has(tf.Name()) {
+			continue
+		}
+
+		toCopy = append(toCopy, tf)
+		tables[tf.Name()] = tf.ChunkCount()
+		knownCount += uint64(tf.ChunkCount())
+	}
+
+	if len(toCopy) == 0 {
+		return nil
+	}
+
+	var mu sync.Mutex
+	var doneCount uint64
+	work := make([]func() error, len(toCopy))
+	for i, tf := range toCopy {
+		tf := tf
+		work[i] = func() error {
+			err := copyTableFile(ctx, sink, tf)
+
+			if err != nil {
+				return err
+			}
+
+			if progChan != nil {
+				mu.Lock()
+				defer mu.Unlock()
+
+				doneCount += uint64(tf.ChunkCount())
+				progChan <- datas.PullProgress{DoneCount: doneCount, KnownCount: knownCount}
+			}
+
+			return nil
+		}
+	}
+
+	err = concurrentExec(work, concurrency)
+
+	if err != nil {
+		return err
+	}
+
+	return sink.AddTableFiles(ctx, tables)
+}
+
+func copyTableFile(ctx context.Context, sink nbs.TableFileStore, tf nbs.TableFileSource) error {
+	op := func() error {
+		err := sink.WriteTableFile(ctx, tf)
+
+		if err == nbs.ErrTableFileWritesUnsupported {
+			return backoff.Permanent(err)
+		}
+
+		return err
+	}
+
+	// table files are copied concurrently, so each copy has its own backoff
+	retryParams := backoff.NewExponentialBackOff()
+	retryParams.MaxInterval = tableFileRetryMaxInterval
+
+	return backoff.Retry(op, backoff.WithMaxRetries(retryParams, tableFileRetryCount))
+}
@@ -0,0 +1,111 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotestorage
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/constants"
+	"github.com/liquidata-inc/dolt/go/store/datas"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
+)
+
+// flakyTableFileStore fails the first write of each table file
+type flakyTableFileStore struct {
+	*nbs.NomsBlockStore
+	mu     *sync.Mutex
+	failed map[string]bool
+}
+
+func (fs flakyTableFileStore) WriteTableFile(ctx context.Context, src nbs.TableFileSource) error {
+	fs.mu.Lock()
+	name := src.Name().String()
+	failed := fs.failed[name]
+	fs.failed[name] = true
+	fs.mu.Unlock()
+
+	if !failed {
+		return errors.New("connection reset")
+	}
+
+	return fs.NomsBlockStore.WriteTableFile(ctx, src)
+}
+
+func TestCopyTableFiles(t *testing.T) {
+	ctx := context.Background()
+	srcDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(srcDir)
+	sinkDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(sinkDir)
+
+	src, err := nbs.NewLocalStore(ctx, constants.FormatDefaultString, srcDir, 1<<10)
+	require.NoError(t, err)
+	defer src.Close()
+
+	seed := time.Now().UnixNano()
+	rng := rand.New(rand.NewSource(seed))
+	hashes, chks := genRandomChunks(rng, 100)
+	for _, c := range chks {
+		require.NoError(t, src.Put(ctx, c))
+	}
+
+	root, err := src.Root(ctx)
+	require.NoError(t, err)
+	success, err := src.Commit(ctx, chks[0].Hash(), root)
+	require.NoError(t, err)
+	require.True(t, success)
+
+	sinkNBS, err := nbs.NewLocalStore(ctx, constants.FormatDefaultString, sinkDir, 1<<10)
+	require.NoError(t, err)
+	defer sinkNBS.Close()
+
+	progChan := make(chan datas.PullProgress, 1024)
+	sink := flakyTableFileStore{sinkNBS, &sync.Mutex{}, make(map[string]bool)}
+	require.NoError(t, CopyTableFiles(ctx, src, sink, 4, progChan))
+	close(progChan)
+
+	var latest datas.PullProgress
+	for progress := range progChan {
+		latest = progress
+	}
+
+	assert.Equal(t, uint64(len(chks)), latest.DoneCount)
+	assert.Equal(t, latest.KnownCount, latest.DoneCount)
+
+	absent, err := sinkNBS.HasMany(ctx, hashes)
+	require.NoError(t, err)
+	assert.Empty(t, absent, "chunks missing from the sink (seed %d)", seed)
+
+	sinkRoot, err := sinkNBS.Root(ctx)
+	require.NoError(t, err)
+	assert.True(t, sinkRoot.IsEmpty(), "copying table files should not change the root")
+
+	// nothing is left to copy
+	progChan = make(chan datas.PullProgress, 1024)
+	require.NoError(t, CopyTableFiles(ctx, src, sinkNBS, 4, progChan))
+	assert.Len(t, progChan, 0)
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/table/untyped/resultset"
 	"github.com/liquidata-inc/dolt/go/store/types"
 )
 
@@ -130,3 +131,31 @@ func createFilterForWhereExpr(whereExpr sqlparser.Expr, inputSchemas map[string]
 
 	return rowFilter, nil
 }
+
+// CreateFilterForWhereStr parses the where clause expression given, e.g. "id > 10 and name = 'bob'", and returns a
+// filter function for rows of the table with the name and schema given.
+func CreateFilterForWhereStr(whereStr string, tableName string, sch schema.Schema) (RowFilterFn, error) {
+	stmt, err := sqlparser.Parse("select * from " + QuoteIdentifier(tableName) + " where " + whereStr)
+
+	if err != nil {
+		return nil, errFmt("Invalid where clause '%s': %v", whereStr, err.Error())
+	}
+
+	selectStmt, ok := stmt.(*sqlparser.Select)
+
+	if !ok || selectStmt.Where == nil {
+		return nil, errFmt("Invalid where clause '%s'", whereStr)
+	}
+
+	filter, err := createFilterForWhere(selectStmt.Where, map[string]schema.Schema{tableName: sch}, NewAliases())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = filter.Init(resultset.Identity(tableName, sch)); err != nil {
+		return nil, err
+	}
+
+	return filter.filter, nil
+}
@@ -16,38 +16,94 @@ package sqle
 
 import (
 	"context"
+	"errors"
+	"sync"
 
 	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/analyzer"
+	"github.com/src-d/go-mysql-server/sql/plan"
 
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
 )
 
+// ErrSchemaChangedDuringAnalysis is returned by the PinRootRule when the schema of a table a query reads was changed by
+// SetRoot while the query was analyzed. The query can be run again.
+var ErrSchemaChangedDuringAnalysis = errors.New("the schema of a table was changed while the query was analyzed")
+
+// TableAuthorizer is consulted before the rows of a table are read, allowing access to be restricted per client.
+type TableAuthorizer interface {
+	// AuthorizeRead returns an error if the client of the query given is not allowed to read the table.
+	AuthorizeRead(ctx *sql.Context, db, table string) error
+}
+
 // Database implements sql.Database for a dolt DB.
 type Database struct {
 	sql.Database
-	name string
-	root *doltdb.RootValue
+	name       string
+	mu         *sync.RWMutex
+	root       *doltdb.RootValue
+	authorizer TableAuthorizer
+	rowCounter *RowsReadCounter
 }
 
 // NewDatabase returns a new dolt databae to use in queries.
 func NewDatabase(name string, root *doltdb.RootValue) *Database {
 	return &Database{
 		name: name,
+		mu:   &sync.RWMutex{},
 		root: root,
 	}
 }
 
+// NewDatabaseWithAuthorizer returns a new dolt database whose tables may only be read by clients the authorizer
+// allows.
+func NewDatabaseWithAuthorizer(name string, root *doltdb.RootValue, authorizer TableAuthorizer) *Database {
+	return &Database{
+		name:       name,
+		mu:         &sync.RWMutex{},
+		root:       root,
+		authorizer: authorizer,
+	}
+}
+
+// SetRowsReadCounter sets the counter used to record the number of rows each session reads from this database's
+// tables.
+func (db *Database) SetRowsReadCounter(counter *RowsReadCounter) {
+	db.rowCounter = counter
+}
+
 // Name returns the name of this database, set at creation time.
 func (db *Database) Name() string {
 	return db.name
 }
 
+// Root returns the root value whose tables are served by this database.
+func (db *Database) Root() *doltdb.RootValue {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return db.root
+}
+
+// SetRoot atomically replaces the root value whose tables are served by this database. Tables already returned by
+// Tables continue to read from the previous root, so queries in progress are unaffected. Queries analyzed with the
+// PinRootRule read all of their tables from a single root, so a query analyzed while SetRoot is called reads either
+// the previous root or the new one, never both.
+func (db *Database) SetRoot(root *doltdb.RootValue) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.root = root
+}
+
 // Tables returns the tables in this database, currently exactly the same tables as in the current working root.
 func (db *Database) Tables() map[string]sql.Table {
 	ctx := context.Background()
+	root := db.Root()
 
 	tables := make(map[string]sql.Table)
-	tableNames, err := db.root.GetTableNames(ctx)
+	tableNames, err := root.GetTableNames(ctx)
 
 	// TODO: fix panics
 	if err != nil {
@@ -55,7 +111,7 @@ func (db *Database) Tables() map[string]sql.Table {
 	}
 
 	for _, name := range tableNames {
-		table, ok, err := db.root.GetTable(ctx, name)
+		table, ok, err := root.GetTable(ctx, name)
 
 		// TODO: fix panics
 		if err != nil {
@@ -71,8 +127,91 @@ func (db *Database) Tables() map[string]sql.Table {
 		if err != nil {
 			panic(err)
 		}
-		tables[name] = &DoltTable{name: name, table: table, sch: sch}
+		tables[name] = &DoltTable{name: name, table: table, sch: sch, db: db}
 	}
 
 	return tables
 }
+
+// PinRootRule returns an analyzer rule which rebinds every table of the database read by a query to the same root,
+// which is the root of the database when the rule runs. Tables are resolved from the database's root each time the
+// analyzer asks for them, so without it a query analyzed while SetRoot is called could read its tables from different
+// roots. It must run after tables are resolved, as a post analyze rule. Subqueries are analyzed before the query they
+// are part of, so the rule run for the whole query rebinds their tables too.
+func PinRootRule(db *Database) analyzer.RuleFunc {
+	return func(ctx *sql.Context, _ *analyzer.Analyzer, n sql.Node) (sql.Node, error) {
+		root := db.Root()
+
+		return plan.TransformUp(n, func(n sql.Node) (sql.Node, error) {
+			rt, ok := n.(*plan.ResolvedTable)
+
+			if !ok {
+				return n, nil
+			}
+
+			var pinned sql.Table
+			switch t := rt.Table.(type) {
+			case *DoltTable:
+				if t.db != db {
+					return n, nil
+				}
+
+				table, err := t.withRoot(ctx, root)
+
+				if err != nil {
+					return nil, err
+				}
+
+				pinned = table
+			case *IndexedDoltTable:
+				if t.table.db != db {
+					return n, nil
+				}
+
+				table, err := t.table.withRoot(ctx, root)
+
+				if err != nil {
+					return nil, err
+				}
+
+				pinned = &IndexedDoltTable{table, t.indexLookup}
+			default:
+				return n, nil
+			}
+
+			return plan.NewResolvedTable(pinned), nil
+		})
+	}
+}
+
+// withRoot returns this table as it is in the root given. Returns ErrSchemaChangedDuringAnalysis if its schema differs,
+// since the query was analyzed against the schema it has now.
+func (t *DoltTable) withRoot(ctx context.Context, root *doltdb.RootValue) (*DoltTable, error) {
+	table, ok, err := root.GetTable(ctx, t.name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, sql.ErrTableNotFound.New(t.name)
+	}
+
+	sch, err := table.GetSchema(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	same, err := schema.SchemasAreEqual(t.sch, sch)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !same {
+		return nil, ErrSchemaChangedDuringAnalysis
+	}
+
+	return &DoltTable{name: t.name, table: table, sch: sch, db: t.db}, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/plan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	. "github.com/liquidata-inc/dolt/go/libraries/doltcore/sql/sqltestutil"
+	"github.com/liquidata-inc/dolt/go/store/types"
+)
+
+func TestPinRootRule(t *testing.T) {
+	ctx := context.Background()
+	dEnv := dtestutils.CreateTestEnv()
+	CreateTestDatabase(dEnv, t)
+	root, err := dEnv.WorkingRoot(ctx)
+	require.NoError(t, err)
+
+	// the new root has no people
+	people, _, err := root.GetTable(ctx, PeopleTableName)
+	require.NoError(t, err)
+	noRows, err := types.NewMap(ctx, dEnv.DoltDB.ValueReadWriter())
+	require.NoError(t, err)
+	people, err = people.UpdateRows(ctx, noRows)
+	require.NoError(t, err)
+	newRoot, err := root.PutTable(ctx, dEnv.DoltDB, PeopleTableName, people)
+	require.NoError(t, err)
+
+	// the tables of a join are resolved before and after the root is replaced
+	db := NewDatabase("dolt", root)
+	oldPeople := db.Tables()[PeopleTableName]
+	db.SetRoot(newRoot)
+	newEpisodes := db.Tables()[EpisodesTableName]
+	join := plan.NewCrossJoin(plan.NewResolvedTable(oldPeople), plan.NewResolvedTable(newEpisodes))
+
+	readRows := func(n sql.Node) []sql.Row {
+		iter, err := n.RowIter(sql.NewEmptyContext())
+		require.NoError(t, err)
+		rows, err := sql.RowIterToRows(iter)
+		require.NoError(t, err)
+		return rows
+	}
+
+	assert.NotEmpty(t, readRows(join))
+
+	pinned, err := PinRootRule(db)(sql.NewEmptyContext(), nil, join)
+	require.NoError(t, err)
+	assert.Empty(t, readRows(pinned))
+}
@@ -0,0 +1,222 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"regexp"
+	"strings"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/analyzer"
+	"github.com/src-d/go-mysql-server/sql/parse"
+	"github.com/src-d/go-mysql-server/sql/plan"
+)
+
+// Ways in which a table is read, or the kind of join of two tables, in an explained plan.
+const (
+	AccessFullScan       = "full scan"
+	AccessPrimaryKey     = "primary key lookup"
+	AccessSecondaryIndex = "secondary index lookup"
+	AccessInnerJoin      = "inner join"
+	AccessLeftJoin       = "left join"
+	AccessRightJoin      = "right join"
+	AccessCrossJoin      = "cross join"
+)
+
+var explainRegex = regexp.MustCompile(`(?is)^\s*explain\s+(.+?)\s*;?\s*$`)
+
+// ExplainSchema is the schema of the rows returned by Explain.
+var ExplainSchema = sql.Schema{
+	{Name: "plan", Type: sql.Text},
+	{Name: "table", Type: sql.Text, Nullable: true},
+	{Name: "access", Type: sql.Text, Nullable: true},
+	{Name: "detail", Type: sql.Text, Nullable: true},
+}
+
+// ParseExplain returns the query being explained if the query given is an EXPLAIN statement.
+func ParseExplain(query string) (string, bool) {
+	m := explainRegex.FindStringSubmatch(query)
+	if m == nil {
+		return "", false
+	}
+
+	return m[1], true
+}
+
+// PlanStep is a single node of an explained query plan.
+type PlanStep struct {
+	// Depth is the depth of the node in the plan tree, with the root at 0.
+	Depth int
+	// Operation is the description of the node given by the engine.
+	Operation string
+	// Table is the name of the table read by the node, if any.
+	Table string
+	// Access is how the table is read, or the kind of join node which joins tables, if any.
+	Access string
+	// Detail gives the index used for a lookup, or the condition of a join.
+	Detail string
+}
+
+// Explain analyzes the query given using the engine given, and returns the steps of the resulting plan without
+// executing it. Each table read is reported as either a full scan or a lookup via an index, and each join is reported
+// along with the kind of join node in the plan. The engine decides how to execute a join when it is run, so the
+// strategy it uses is not reported.
+func Explain(ctx *sql.Context, engine *sqle.Engine, query string) ([]PlanStep, error) {
+	parsed, err := parse.Parse(ctx, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	analyzed, err := engine.Analyzer.Analyze(ctx, parsed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return explainPlan(analyzed), nil
+}
+
+// ExplainRule is an analyzer rule which replaces the plan of an EXPLAIN statement, which the engine would describe
+// as a tree, with a node returning the rows of ExplainRows for the analyzed query. It must run as a post analyze rule.
+// Engines serving dolt databases add it so that EXPLAIN gives the same results however it is run.
+func ExplainRule(_ *sql.Context, _ *analyzer.Analyzer, n sql.Node) (sql.Node, error) {
+	d, ok := n.(*plan.DescribeQuery)
+
+	if !ok {
+		return n, nil
+	}
+
+	return &explainNode{explainPlan(d.Child)}, nil
+}
+
+func explainPlan(analyzed sql.Node) []PlanStep {
+	var steps []PlanStep
+	explainStep(analyzed, 0, &steps)
+
+	return steps
+}
+
+// ExplainRows returns the steps given as rows matching ExplainSchema, with the plan tree indented by depth.
+func ExplainRows(steps []PlanStep) []sql.Row {
+	rows := make([]sql.Row, len(steps))
+	for i, step := range steps {
+		rows[i] = sql.NewRow(
+			strings.Repeat("  ", step.Depth)+step.Operation,
+			nullIfEmpty(step.Table),
+			nullIfEmpty(step.Access),
+			nullIfEmpty(step.Detail))
+	}
+
+	return rows
+}
+
+func explainStep(n sql.Node, depth int, steps *[]PlanStep) {
+	step := PlanStep{Depth: depth, Operation: nodeDescription(n)}
+
+	switch n := n.(type) {
+	case *plan.ResolvedTable:
+		step.Table, step.Access, step.Detail = tableAccess(n.Table)
+	case *plan.InnerJoin:
+		step.Access, step.Detail = AccessInnerJoin, n.Cond.String()
+	case *plan.LeftJoin:
+		step.Access, step.Detail = AccessLeftJoin, n.Cond.String()
+	case *plan.RightJoin:
+		step.Access, step.Detail = AccessRightJoin, n.Cond.String()
+	case *plan.CrossJoin:
+		step.Access = AccessCrossJoin
+	}
+
+	*steps = append(*steps, step)
+
+	for _, child := range n.Children() {
+		explainStep(child, depth+1, steps)
+	}
+}
+
+// tableAccess returns the name of the table given, how its rows will be read, and the index used to read them if any.
+func tableAccess(t sql.Table) (name, access, detail string) {
+	switch t := t.(type) {
+	case *IndexedDoltTable:
+		return t.Name(), AccessPrimaryKey, strings.Join(t.indexLookup.Indexes(), ", ")
+	case *DoltTable:
+		return t.Name(), AccessFullScan, ""
+	case interface{ IndexLookup() sql.IndexLookup }:
+		// Dolt tables have no secondary indexes yet, but tables from other sources may be read through one
+		if lookup := t.IndexLookup(); lookup != nil {
+			return t.Name(), AccessSecondaryIndex, strings.Join(lookup.Indexes(), ", ")
+		}
+	}
+
+	return t.Name(), AccessFullScan, ""
+}
+
+// nodeDescription returns the first line of the engine's description of the node given, which omits its children.
+func nodeDescription(n sql.Node) string {
+	str := n.String()
+	if idx := strings.Index(str, "\n"); idx != -1 {
+		str = str[:idx]
+	}
+
+	return strings.TrimSpace(str)
+}
+
+func nullIfEmpty(str string) interface{} {
+	if str == "" {
+		return nil
+	}
+
+	return str
+}
+
+// explainNode is the plan of an EXPLAIN statement, returning the steps of the plan of the query explained as rows
+type explainNode struct {
+	steps []PlanStep
+}
+
+// Resolved implements sql.Node.
+func (n *explainNode) Resolved() bool {
+	return true
+}
+
+// String implements sql.Node.
+func (n *explainNode) String() string {
+	return "Explain"
+}
+
+// Schema implements sql.Node.
+func (n *explainNode) Schema() sql.Schema {
+	return ExplainSchema
+}
+
+// Children implements sql.Node.
+func (n *explainNode) Children() []sql.Node {
+	return nil
+}
+
+// RowIter implements sql.Node.
+func (n *explainNode) RowIter(*sql.Context) (sql.RowIter, error) {
+	return sql.RowsToRowIter(ExplainRows(n.steps)...), nil
+}
+
+// WithChildren implements sql.Node.
+func (n *explainNode) WithChildren(children ...sql.Node) (sql.Node, error) {
+	if len(children) != 0 {
+		return nil, sql.ErrInvalidChildrenNumber.New(n, len(children), 0)
+	}
+
+	return n, nil
+}
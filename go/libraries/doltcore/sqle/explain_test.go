@@ -0,0 +1,138 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"testing"
+
+	sqle "github.com/src-d/go-mysql-server"
+	"github.com/src-d/go-mysql-server/sql"
+	"github.com/src-d/go-mysql-server/sql/analyzer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dtestutils"
+	. "github.com/liquidata-inc/dolt/go/libraries/doltcore/sql/sqltestutil"
+)
+
+func TestParseExplain(t *testing.T) {
+	query, ok := ParseExplain("explain select * from people;")
+	assert.True(t, ok)
+	assert.Equal(t, "select * from people", query)
+
+	query, ok = ParseExplain("  EXPLAIN\n select id from people where id = 1")
+	assert.True(t, ok)
+	assert.Equal(t, "select id from people where id = 1", query)
+
+	_, ok = ParseExplain("select * from people")
+	assert.False(t, ok)
+}
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		useIndexes     bool
+		expectedAccess map[string]string
+		expectedJoins  []string
+	}{
+		{
+			name:           "full scan",
+			query:          "select * from people where first = 'Homer'",
+			expectedAccess: map[string]string{PeopleTableName: AccessFullScan},
+		},
+		{
+			name:           "primary key lookup without index driver",
+			query:          "select * from people where id = 1",
+			expectedAccess: map[string]string{PeopleTableName: AccessFullScan},
+		},
+		{
+			name:           "primary key lookup",
+			query:          "select * from people where id = 1",
+			useIndexes:     true,
+			expectedAccess: map[string]string{PeopleTableName: AccessPrimaryKey},
+		},
+		{
+			name:           "inner join",
+			query:          "select * from people p join appearances a on p.id = a.character_id",
+			expectedAccess: map[string]string{PeopleTableName: AccessFullScan, AppearancesTableName: AccessFullScan},
+			expectedJoins:  []string{AccessInnerJoin},
+		},
+		{
+			name:           "cross join",
+			query:          "select * from people, episodes",
+			expectedAccess: map[string]string{PeopleTableName: AccessFullScan, EpisodesTableName: AccessFullScan},
+			expectedJoins:  []string{AccessCrossJoin},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dEnv := dtestutils.CreateTestEnv()
+			CreateTestDatabase(dEnv, t)
+			root, _ := dEnv.WorkingRoot(context.Background())
+
+			db := NewDatabase("dolt", root)
+			engine := sqle.NewDefault()
+			engine.AddDatabase(db)
+			if test.useIndexes {
+				engine.Catalog.RegisterIndexDriver(NewDoltIndexDriver(db))
+				require.NoError(t, engine.Init())
+			}
+
+			steps, err := Explain(sql.NewEmptyContext(), engine, test.query)
+			require.NoError(t, err)
+			require.NotEmpty(t, steps)
+			assert.Equal(t, 0, steps[0].Depth)
+
+			access := make(map[string]string)
+			var joins []string
+			for _, step := range steps {
+				if step.Table != "" {
+					access[step.Table] = step.Access
+				} else if step.Access != "" {
+					joins = append(joins, step.Access)
+				}
+			}
+
+			assert.Equal(t, test.expectedAccess, access)
+			assert.Equal(t, test.expectedJoins, joins)
+			assert.Len(t, ExplainRows(steps), len(steps))
+		})
+	}
+}
+
+func TestExplainRule(t *testing.T) {
+	dEnv := dtestutils.CreateTestEnv()
+	CreateTestDatabase(dEnv, t)
+	root, _ := dEnv.WorkingRoot(context.Background())
+
+	catalog := sql.NewCatalog()
+	engine := sqle.New(catalog, analyzer.NewBuilder(catalog).AddPostAnalyzeRule("explain", ExplainRule).Build(), nil)
+	engine.AddDatabase(NewDatabase("dolt", root))
+
+	query := "select * from people p join appearances a on p.id = a.character_id"
+	steps, err := Explain(sql.NewEmptyContext(), engine, query)
+	require.NoError(t, err)
+
+	// an EXPLAIN statement run by the engine returns the same rows as Explain
+	sch, iter, err := engine.Query(sql.NewEmptyContext(), "explain "+query)
+	require.NoError(t, err)
+	rows, err := sql.RowIterToRows(iter)
+	require.NoError(t, err)
+	assert.Equal(t, ExplainSchema, sch)
+	assert.Equal(t, ExplainRows(steps), rows)
+}
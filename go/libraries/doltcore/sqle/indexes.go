@@ -22,6 +22,7 @@ import (
 
 	"github.com/src-d/go-mysql-server/sql"
 
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/doltdb"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/row"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/schema"
 	"github.com/liquidata-inc/dolt/go/store/types"
@@ -58,7 +59,7 @@ func (i *DoltIndexDriver) LoadAll(db, table string) ([]sql.Index, error) {
 		panic("Unexpected db: " + db)
 	}
 
-	tbl, ok, err := i.db.root.GetTable(context.TODO(), table)
+	tbl, ok, err := i.db.Root().GetTable(context.TODO(), table)
 
 	if err != nil {
 		return nil, err
@@ -220,7 +221,7 @@ func (idt *IndexedDoltTable) Partitions(ctx *sql.Context) (sql.PartitionIter, er
 }
 
 func (idt *IndexedDoltTable) PartitionRows(ctx *sql.Context, _ sql.Partition) (sql.RowIter, error) {
-	return idt.indexLookup.RowIter(ctx)
+	return idt.indexLookup.tableRowIter(ctx, idt.table.table), nil
 }
 
 type doltIndexLookup struct {
@@ -239,13 +240,31 @@ func (il *doltIndexLookup) Values(p sql.Partition) (sql.IndexValueIter, error) {
 	panic("implement me")
 }
 
-// RowIter returns a row iterator for this index lookup. The iterator will return the single matching row for the index.
+// RowIter returns a row iterator for this index lookup. The iterator will return the single matching row for the index
+// in the table as it is in the database's current root.
 func (il *doltIndexLookup) RowIter(ctx *sql.Context) (sql.RowIter, error) {
-	return &indexLookupRowIterAdapter{indexLookup: il, ctx: ctx}, nil
+	table, ok, err := il.idx.db.Root().GetTable(ctx.Context, il.idx.tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, sql.ErrTableNotFound.New(il.idx.tableName)
+	}
+
+	return il.tableRowIter(ctx, table), nil
+}
+
+// tableRowIter returns a row iterator for this index lookup which reads the table given, so that every row of a query
+// is read from the same root even if the database's root is replaced during the query.
+func (il *doltIndexLookup) tableRowIter(ctx *sql.Context, table *doltdb.Table) sql.RowIter {
+	return &indexLookupRowIterAdapter{indexLookup: il, table: table, ctx: ctx}
 }
 
 type indexLookupRowIterAdapter struct {
 	indexLookup *doltIndexLookup
+	table       *doltdb.Table
 	ctx         *sql.Context
 	i           int
 }
@@ -256,13 +275,7 @@ func (i *indexLookupRowIterAdapter) Next() (sql.Row, error) {
 	}
 
 	i.i++
-	table, _, err := i.indexLookup.idx.db.root.GetTable(i.ctx.Context, i.indexLookup.idx.tableName)
-
-	if err != nil {
-		return nil, err
-	}
-
-	r, ok, err := table.GetRowByPKVals(i.ctx.Context, i.indexLookup.key, i.indexLookup.idx.sch)
+	r, ok, err := i.table.GetRowByPKVals(i.ctx.Context, i.indexLookup.key, i.indexLookup.idx.sch)
 
 	if err != nil {
 		return nil, err
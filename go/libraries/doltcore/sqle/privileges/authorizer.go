@@ -0,0 +1,224 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privileges
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/src-d/go-mysql-server/auth"
+	"github.com/src-d/go-mysql-server/sql"
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+var ErrNotAuthorized = errors.New("not authorized")
+
+// Authorizer implements auth.Auth for the go-mysql-server engine using the accounts in a UserStore. A super user,
+// configured outside of the store, always has every privilege. When read only, no user is allowed to write.
+//
+// The store is reloaded before each query is authorized, so users dropped and privileges granted or revoked by other
+// dolt commands apply to the next query. The accounts which can log in, and their passwords, are those in the store
+// when the server was started.
+type Authorizer struct {
+	store         *UserStore
+	database      string
+	superUser     string
+	superPassword string
+	readOnly      bool
+}
+
+// NewAuthorizer returns an Authorizer for the users in the given store. Tables named without a database in queries
+// are in the database given.
+func NewAuthorizer(us *UserStore, database, superUser, superPassword string, readOnly bool) *Authorizer {
+	return &Authorizer{us, database, superUser, superPassword, readOnly}
+}
+
+// Mysql implements auth.Auth. It returns the authentication server used during the MySQL handshake.
+func (a *Authorizer) Mysql() mysql.AuthServer {
+	as := mysql.NewAuthServerStatic()
+	for _, u := range a.store.Users() {
+		as.Entries[u.Name] = []*mysql.AuthServerStaticEntry{{MysqlNativePassword: u.PasswordHash}}
+	}
+
+	as.Entries[a.superUser] = []*mysql.AuthServerStaticEntry{{Password: a.superPassword}}
+
+	return as
+}
+
+// Allowed implements auth.Auth. The engine only reports whether a query reads or writes, so the tables a write modifies
+// are found by parsing the query, and the user must hold the privilege needed on each of them. Reads of specific
+// tables are checked by AuthorizeRead.
+func (a *Authorizer) Allowed(ctx *sql.Context, permission auth.Permission) error {
+	name := ctx.Client().User
+	isWrite := permission&auth.WritePerm != 0
+
+	if err := a.store.Reload(); err != nil {
+		return fmt.Errorf("%v: unable to load sql users: %s", ErrNotAuthorized, err.Error())
+	}
+
+	if a.readOnly && isWrite {
+		return fmt.Errorf("%v: the server is read only", ErrNotAuthorized)
+	}
+
+	if name == a.superUser {
+		return nil
+	}
+
+	u, ok := a.store.GetUser(name)
+	if !ok {
+		return fmt.Errorf("%v: unknown user '%s'", ErrNotAuthorized, name)
+	}
+
+	if !isWrite {
+		return nil
+	}
+
+	if !u.HasAnyPrivilege(WritePrivileges) {
+		return fmt.Errorf("%v: user '%s' does not have write privileges", ErrNotAuthorized, name)
+	}
+
+	return a.AuthorizeWrite(name, ctx.Query())
+}
+
+// AuthorizeWrite returns an error if the named user does not hold the privileges the query needs on every table it
+// modifies: INSERT for inserts, INSERT and DELETE for replaces, UPDATE for updates, DELETE for deletes, and DDL for
+// creating, altering, renaming and dropping tables.
+func (a *Authorizer) AuthorizeWrite(name, query string) error {
+	writes, err := a.tableWrites(query)
+
+	if err != nil {
+		return fmt.Errorf("%v: %s", ErrNotAuthorized, err.Error())
+	}
+
+	for _, w := range writes {
+		if err := a.AuthorizeTable(name, w.db, w.table, w.privs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tableWrite is a table modified by a query, and the privileges needed to modify it
+type tableWrite struct {
+	db    string
+	table string
+	privs Privilege
+}
+
+// tableWrites returns the tables modified by the query given
+func (a *Authorizer) tableWrites(query string) ([]tableWrite, error) {
+	stmt, err := sqlparser.Parse(query)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to determine the tables written by the query: %v", err)
+	}
+
+	var writes []tableWrite
+	addTables := func(privs Privilege, names ...sqlparser.TableName) {
+		for _, tn := range names {
+			if tn.Name.IsEmpty() {
+				continue
+			}
+
+			db := a.database
+			if !tn.Qualifier.IsEmpty() {
+				db = tn.Qualifier.String()
+			}
+
+			writes = append(writes, tableWrite{db, tn.Name.String(), privs})
+		}
+	}
+
+	switch s := stmt.(type) {
+	case *sqlparser.Insert:
+		if s.Action == sqlparser.ReplaceStr {
+			addTables(Insert|Delete, s.Table)
+		} else {
+			addTables(Insert, s.Table)
+		}
+	case *sqlparser.Update:
+		addTables(Update, tableExprNames(s.TableExprs)...)
+	case *sqlparser.Delete:
+		if len(s.Targets) > 0 {
+			addTables(Delete, s.Targets...)
+		} else {
+			addTables(Delete, tableExprNames(s.TableExprs)...)
+		}
+	case *sqlparser.DDL:
+		addTables(DDL, s.Table)
+		addTables(DDL, s.FromTables...)
+		addTables(DDL, s.ToTables...)
+	default:
+		return nil, fmt.Errorf("statement is not supported for users without every privilege")
+	}
+
+	if len(writes) == 0 {
+		return nil, fmt.Errorf("unable to determine the tables written by the query")
+	}
+
+	return writes, nil
+}
+
+// tableExprNames returns the names of the tables in the table expressions of a query
+func tableExprNames(exprs sqlparser.TableExprs) []sqlparser.TableName {
+	var names []sqlparser.TableName
+	for _, expr := range exprs {
+		switch te := expr.(type) {
+		case *sqlparser.AliasedTableExpr:
+			if tn, ok := te.Expr.(sqlparser.TableName); ok {
+				names = append(names, tn)
+			}
+		case *sqlparser.JoinTableExpr:
+			names = append(names, tableExprNames(sqlparser.TableExprs{te.LeftExpr, te.RightExpr})...)
+		case *sqlparser.ParenTableExpr:
+			names = append(names, tableExprNames(te.Exprs)...)
+		}
+	}
+
+	return names
+}
+
+// AuthorizeRead returns an error if the client of the query does not have the SELECT privilege on the table.
+func (a *Authorizer) AuthorizeRead(ctx *sql.Context, db, table string) error {
+	return a.AuthorizeTable(ctx.Client().User, db, table, Select)
+}
+
+// AuthorizeTable returns an error if the named user does not hold all of the privileges on the table.
+func (a *Authorizer) AuthorizeTable(name, db, table string, privs Privilege) error {
+	if name == a.superUser {
+		if a.readOnly && privs&WritePrivileges != 0 {
+			return fmt.Errorf("%v: the server is read only", ErrNotAuthorized)
+		}
+
+		return nil
+	}
+
+	u, ok := a.store.GetUser(name)
+	if !ok {
+		return fmt.Errorf("%v: unknown user '%s'", ErrNotAuthorized, name)
+	}
+
+	if a.readOnly && privs&WritePrivileges != 0 {
+		return fmt.Errorf("%v: the server is read only", ErrNotAuthorized)
+	}
+
+	if !u.PrivilegesOn(db, table).Has(privs) {
+		return fmt.Errorf("%v: user '%s' does not have %s privilege on %s.%s", ErrNotAuthorized, name, privs, db, table)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privileges
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+func TestAuthorizeWrite(t *testing.T) {
+	us := NewUserStore(filesys.NewInMemFS([]string{dbfactory.DoltDir}, nil, "/"))
+	require.NoError(t, us.CreateUser("alice", "pass"))
+	require.NoError(t, us.Grant("alice", "dolt", "a", Insert))
+	require.NoError(t, us.Grant("alice", "dolt", "b", AllPrivileges))
+	require.NoError(t, us.Grant("alice", "other", "c", Update))
+
+	a := NewAuthorizer(us, "dolt", "root", "", false)
+
+	tests := []struct {
+		query   string
+		allowed bool
+	}{
+		{"insert into a (id) values (1)", true},
+		{"insert into b (id) values (1)", true},
+		{"insert into c (id) values (1)", false},
+		{"insert into dolt.a (id) values (1)", true},
+		{"insert into other.a (id) values (1)", false},
+		{"replace into a (id) values (1)", false},
+		{"replace into b (id) values (1)", true},
+		{"update a set id = 2", false},
+		{"update b set id = 2", true},
+		{"update other.c set id = 2", true},
+		{"update a join b on a.id = b.id set b.id = 2", false},
+		{"delete from a where id = 1", false},
+		{"delete from b where id = 1", true},
+		{"create table a (id int primary key)", false},
+		{"create table b (id int primary key)", true},
+		{"alter table a add column x int", false},
+		{"drop table a", false},
+		{"drop table b", true},
+		{"drop table b, a", false},
+		{"rename table b to a", false},
+		{"not a query", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			err := a.AuthorizeWrite("alice", test.query)
+			if test.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+
+	assert.NoError(t, a.AuthorizeTable("root", "dolt", "a", AllPrivileges))
+	assert.Error(t, NewAuthorizer(us, "dolt", "root", "", true).AuthorizeWrite("alice", "insert into a (id) values (1)"))
+}
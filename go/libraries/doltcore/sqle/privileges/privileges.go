@@ -0,0 +1,136 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privileges
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Privilege is a set of operations a user may perform on a table.
+type Privilege uint8
+
+const (
+	Select Privilege = 1 << iota
+	Insert
+	Update
+	Delete
+	DDL
+
+	NoPrivileges  Privilege = 0
+	AllPrivileges           = Select | Insert | Update | Delete | DDL
+
+	// WritePrivileges are the privileges that allow modification of a table.
+	WritePrivileges = Insert | Update | Delete | DDL
+)
+
+// Wildcard matches every database or every table in a grant.
+const Wildcard = "*"
+
+var privilegeNames = map[Privilege]string{
+	Select: "SELECT",
+	Insert: "INSERT",
+	Update: "UPDATE",
+	Delete: "DELETE",
+	DDL:    "DDL",
+}
+
+var namesToPrivilege = map[string]Privilege{
+	"SELECT":         Select,
+	"INSERT":         Insert,
+	"UPDATE":         Update,
+	"DELETE":         Delete,
+	"DDL":            DDL,
+	"CREATE":         DDL,
+	"ALTER":          DDL,
+	"DROP":           DDL,
+	"ALL":            AllPrivileges,
+	"ALL PRIVILEGES": AllPrivileges,
+}
+
+// ParsePrivilege converts a privilege name as it appears in a GRANT or REVOKE statement into a Privilege. The
+// MySQL names CREATE, ALTER and DROP are all treated as DDL.
+func ParsePrivilege(str string) (Privilege, error) {
+	name := strings.ToUpper(strings.Join(strings.Fields(str), " "))
+	if p, ok := namesToPrivilege[name]; ok {
+		return p, nil
+	}
+
+	return NoPrivileges, fmt.Errorf("unknown privilege: '%s'", str)
+}
+
+// Has returns true if every privilege in other is also in p.
+func (p Privilege) Has(other Privilege) bool {
+	return p&other == other
+}
+
+// Names returns the sorted names of the privileges in the set.
+func (p Privilege) Names() []string {
+	var names []string
+	for priv, name := range privilegeNames {
+		if p.Has(priv) {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// String returns a comma separated list of the privileges in the set.
+func (p Privilege) String() string {
+	if p == AllPrivileges {
+		return "ALL"
+	} else if p == NoPrivileges {
+		return "USAGE"
+	}
+
+	return strings.Join(p.Names(), ", ")
+}
+
+// MarshalJSON writes the privilege set as a list of privilege names.
+func (p Privilege) MarshalJSON() ([]byte, error) {
+	names := p.Names()
+	if names == nil {
+		names = []string{}
+	}
+
+	return json.Marshal(names)
+}
+
+// UnmarshalJSON reads a list of privilege names written by MarshalJSON.
+func (p *Privilege) UnmarshalJSON(data []byte) error {
+	var names []string
+	err := json.Unmarshal(data, &names)
+
+	if err != nil {
+		return err
+	}
+
+	*p = NoPrivileges
+	for _, name := range names {
+		priv, err := ParsePrivilege(name)
+
+		if err != nil {
+			return err
+		}
+
+		*p |= priv
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privileges
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// The SQL parser does not support account management statements, so they are recognized here instead. User names may
+// be quoted and may carry a host part ('name'@'host'), which is accepted but ignored.
+var createUserRegex = regexp.MustCompile(`(?is)^\s*create\s+user\s+(if\s+not\s+exists\s+)?(\S+)\s+identified\s+by\s+'((?:[^'\\]|\\.|'')*)'\s*;?\s*$`)
+var alterUserRegex = regexp.MustCompile(`(?is)^\s*alter\s+user\s+(\S+)\s+identified\s+by\s+'((?:[^'\\]|\\.|'')*)'\s*;?\s*$`)
+var dropUserRegex = regexp.MustCompile(`(?is)^\s*drop\s+user\s+(if\s+exists\s+)?(\S+)\s*;?\s*$`)
+var grantRegex = regexp.MustCompile(`(?is)^\s*grant\s+(.+?)\s+on\s+(?:table\s+)?(\S+)\s+to\s+(\S+)\s*;?\s*$`)
+var revokeRegex = regexp.MustCompile(`(?is)^\s*revoke\s+(.+?)\s+on\s+(?:table\s+)?(\S+)\s+from\s+(\S+)\s*;?\s*$`)
+
+var accountStmtPrefixRegex = regexp.MustCompile(`(?is)^\s*(create\s+user|alter\s+user|drop\s+user|grant|revoke)\s`)
+
+// IsAccountStatement returns true if the query is a CREATE USER, ALTER USER, DROP USER, GRANT or REVOKE statement.
+func IsAccountStatement(query string) bool {
+	return accountStmtPrefixRegex.MatchString(query)
+}
+
+// ExecuteAccountStatement applies a CREATE USER, ALTER USER, DROP USER, GRANT or REVOKE statement to the UserStore.
+// The store is not saved.
+func ExecuteAccountStatement(us *UserStore, query string) error {
+	if m := createUserRegex.FindStringSubmatch(query); m != nil {
+		err := us.CreateUser(parseUserName(m[2]), unescape(m[3]))
+
+		if err == ErrUserExists && m[1] != "" {
+			return nil
+		}
+
+		return err
+	} else if m := alterUserRegex.FindStringSubmatch(query); m != nil {
+		return us.SetPassword(parseUserName(m[1]), unescape(m[2]))
+	} else if m := dropUserRegex.FindStringSubmatch(query); m != nil {
+		err := us.DropUser(parseUserName(m[2]))
+
+		if err == ErrUserNotFound && m[1] != "" {
+			return nil
+		}
+
+		return err
+	} else if m := grantRegex.FindStringSubmatch(query); m != nil {
+		privs, db, table, err := parseGrantTarget(m[1], m[2])
+
+		if err != nil {
+			return err
+		}
+
+		return us.Grant(parseUserName(m[3]), db, table, privs)
+	} else if m := revokeRegex.FindStringSubmatch(query); m != nil {
+		privs, db, table, err := parseGrantTarget(m[1], m[2])
+
+		if err != nil {
+			return err
+		}
+
+		return us.Revoke(parseUserName(m[3]), db, table, privs)
+	}
+
+	return fmt.Errorf("Unsupported account management statement: '%v'.", query)
+}
+
+func parseGrantTarget(privList, target string) (Privilege, string, string, error) {
+	privs := NoPrivileges
+	for _, name := range strings.Split(privList, ",") {
+		p, err := ParsePrivilege(name)
+
+		if err != nil {
+			return NoPrivileges, "", "", err
+		}
+
+		privs |= p
+	}
+
+	var db, table string
+	if idx := strings.Index(target, "."); idx != -1 {
+		db, table = unquoteIdentifier(target[:idx]), unquoteIdentifier(target[idx+1:])
+	} else {
+		return NoPrivileges, "", "", fmt.Errorf("grant target must be of the form <database>.<table>: '%s'", target)
+	}
+
+	if db == "" || table == "" || (db == Wildcard && table != Wildcard) {
+		return NoPrivileges, "", "", fmt.Errorf("invalid grant target: '%s'", target)
+	}
+
+	return privs, db, table, nil
+}
+
+func parseUserName(str string) string {
+	if idx := strings.LastIndex(str, "@"); idx > 0 {
+		str = str[:idx]
+	}
+
+	return unquoteIdentifier(str)
+}
+
+func unquoteIdentifier(str string) string {
+	if len(str) >= 2 {
+		first, last := str[0], str[len(str)-1]
+		if first == last && (first == '\'' || first == '"' || first == '`') {
+			return str[1 : len(str)-1]
+		}
+	}
+
+	return str
+}
+
+func unescape(str string) string {
+	return strings.NewReplacer(`\'`, `'`, `''`, `'`, `\\`, `\`).Replace(str)
+}
@@ -0,0 +1,90 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privileges
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+func TestIsAccountStatement(t *testing.T) {
+	tests := map[string]bool{
+		"CREATE USER 'alice' IDENTIFIED BY 'pass'":   true,
+		"create user alice identified by 'pass'":     true,
+		"GRANT SELECT ON dolt.prices TO alice":       true,
+		"revoke select on dolt.prices from alice":    true,
+		"DROP USER alice":                            true,
+		"ALTER USER alice IDENTIFIED BY 'new'":       true,
+		"select * from grants":                       false,
+		"CREATE TABLE users (id int primary key)":    false,
+		"DROP TABLE users":                           false,
+		"insert into revoke_log values ('grant me')": false,
+	}
+
+	for query, expected := range tests {
+		assert.Equal(t, expected, IsAccountStatement(query), query)
+	}
+}
+
+func TestExecuteAccountStatement(t *testing.T) {
+	us := NewUserStore(filesys.NewInMemFS(nil, nil, "/"))
+
+	queries := []string{
+		"CREATE USER 'alice'@'%' IDENTIFIED BY 'it''s'",
+		"create user if not exists alice identified by 'ignored'",
+		"CREATE USER `bob` IDENTIFIED BY 'bobpass';",
+		"GRANT SELECT, INSERT ON dolt.prices TO 'alice'@'localhost'",
+		"GRANT ALL PRIVILEGES ON dolt.* TO bob",
+		"GRANT CREATE ON `dolt`.`orders` TO alice",
+		"REVOKE INSERT ON dolt.prices FROM alice",
+		"ALTER USER bob IDENTIFIED BY 'newpass'",
+	}
+
+	for _, query := range queries {
+		require.NoError(t, ExecuteAccountStatement(us, query), query)
+	}
+
+	alice, ok := us.GetUser("alice")
+	require.True(t, ok)
+	assert.Equal(t, Select, alice.PrivilegesOn("dolt", "prices"))
+	assert.Equal(t, DDL, alice.PrivilegesOn("dolt", "orders"))
+
+	bob, ok := us.GetUser("bob")
+	require.True(t, ok)
+	assert.Equal(t, AllPrivileges, bob.PrivilegesOn("dolt", "anything"))
+	assert.Equal(t, HashPassword("newpass"), bob.PasswordHash)
+
+	badQueries := []string{
+		"CREATE USER alice IDENTIFIED BY 'again'",
+		"GRANT FLY ON dolt.prices TO alice",
+		"GRANT SELECT ON prices TO alice",
+		"GRANT SELECT ON *.prices TO alice",
+		"GRANT SELECT ON dolt.prices TO carol",
+		"DROP USER carol",
+	}
+
+	for _, query := range badQueries {
+		assert.Error(t, ExecuteAccountStatement(us, query), query)
+	}
+
+	require.NoError(t, ExecuteAccountStatement(us, "DROP USER IF EXISTS carol"))
+	require.NoError(t, ExecuteAccountStatement(us, "DROP USER alice"))
+	_, ok = us.GetUser("alice")
+	assert.False(t, ok)
+}
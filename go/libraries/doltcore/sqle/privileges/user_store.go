@@ -0,0 +1,339 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privileges
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+// UsersFile is the name of the file within the .dolt directory where sql users are persisted.
+const UsersFile = "sql_users.json"
+
+var ErrUserExists = errors.New("user already exists")
+var ErrUserNotFound = errors.New("user not found")
+
+// Grant is a set of privileges a user holds on a database and table. Either name may be the Wildcard.
+type Grant struct {
+	Database   string    `json:"database"`
+	Table      string    `json:"table"`
+	Privileges Privilege `json:"privileges"`
+}
+
+func (g Grant) matches(db, table string) bool {
+	return (g.Database == Wildcard || strings.EqualFold(g.Database, db)) &&
+		(g.Table == Wildcard || strings.EqualFold(g.Table, table))
+}
+
+// User is a sql server account. Only the MySQL native password hash of the password is stored.
+type User struct {
+	Name         string  `json:"name"`
+	PasswordHash string  `json:"password_hash"`
+	Grants       []Grant `json:"grants"`
+}
+
+// PrivilegesOn returns all privileges the user holds on a table, combining database and global wildcard grants.
+func (u *User) PrivilegesOn(db, table string) Privilege {
+	privs := NoPrivileges
+	for _, g := range u.Grants {
+		if g.matches(db, table) {
+			privs |= g.Privileges
+		}
+	}
+
+	return privs
+}
+
+// HasAnyPrivilege returns true if the user has been granted any of the given privileges on any table.
+func (u *User) HasAnyPrivilege(privs Privilege) bool {
+	for _, g := range u.Grants {
+		if g.Privileges&privs != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (u *User) grant(db, table string, privs Privilege) {
+	for i := range u.Grants {
+		if u.Grants[i].Database == db && u.Grants[i].Table == table {
+			u.Grants[i].Privileges |= privs
+			return
+		}
+	}
+
+	u.Grants = append(u.Grants, Grant{db, table, privs})
+}
+
+func (u *User) revoke(db, table string, privs Privilege) {
+	grants := u.Grants[:0]
+	for _, g := range u.Grants {
+		if g.Database == db && g.Table == table {
+			g.Privileges &^= privs
+		}
+
+		if g.Privileges != NoPrivileges {
+			grants = append(grants, g)
+		}
+	}
+
+	u.Grants = grants
+}
+
+// HashPassword returns the MySQL native password hash (the hex encoded, double SHA1 of the password prefixed with a
+// '*') which is what MySQL clients authenticate against. An empty password hashes to an empty string.
+func HashPassword(password string) string {
+	if len(password) == 0 {
+		return ""
+	}
+
+	s1 := sha1.Sum([]byte(password))
+	s2 := sha1.Sum(s1[:])
+
+	return "*" + strings.ToUpper(hex.EncodeToString(s2[:]))
+}
+
+// UserStore is the set of sql users and their grants for a repository. It is safe for concurrent use.
+type UserStore struct {
+	mu    *sync.RWMutex
+	users map[string]*User
+
+	// persisted is the content of the users file when it was last loaded or saved
+	persisted []byte
+
+	fs filesys.ReadWriteFS
+}
+
+// NewUserStore returns an empty UserStore which will be persisted to the given filesystem.
+func NewUserStore(fs filesys.ReadWriteFS) *UserStore {
+	return &UserStore{&sync.RWMutex{}, make(map[string]*User), nil, fs}
+}
+
+// LoadUserStore loads the UserStore persisted in the .dolt directory. If no users have been created an empty store is
+// returned.
+func LoadUserStore(fs filesys.ReadWriteFS) (*UserStore, error) {
+	us := NewUserStore(fs)
+	err := us.Reload()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return us, nil
+}
+
+// Reload replaces the users in the store with the users persisted in the .dolt directory if the file has changed
+// since it was last loaded or saved, so that a long running process sees the changes made by other dolt commands. Any
+// changes made to the store which have not been saved are lost when it is reloaded.
+func (us *UserStore) Reload() error {
+	path := getUsersFile()
+
+	var data []byte
+	if exists, _ := us.fs.Exists(path); exists {
+		var err error
+		data, err = us.fs.ReadFile(path)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	if bytes.Equal(data, us.persisted) {
+		return nil
+	}
+
+	var users []*User
+	if len(data) > 0 {
+		err := json.Unmarshal(data, &users)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	us.users = make(map[string]*User, len(users))
+	for _, u := range users {
+		us.users[strings.ToLower(u.Name)] = u
+	}
+
+	us.persisted = data
+
+	return nil
+}
+
+// Save persists the store to the .dolt directory.
+func (us *UserStore) Save() error {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	data, err := json.MarshalIndent(us.sortedUsers(), "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	err = us.fs.WriteFile(getUsersFile(), data)
+
+	if err != nil {
+		return err
+	}
+
+	us.persisted = data
+
+	return nil
+}
+
+// IsEmpty returns true if no users have been created.
+func (us *UserStore) IsEmpty() bool {
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+
+	return len(us.users) == 0
+}
+
+// Users returns copies of all users sorted by name.
+func (us *UserStore) Users() []User {
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+
+	var users []User
+	for _, u := range us.sortedUsers() {
+		users = append(users, copyUser(u))
+	}
+
+	return users
+}
+
+// GetUser returns a copy of the user with the given name.
+func (us *UserStore) GetUser(name string) (User, bool) {
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+
+	u, ok := us.users[strings.ToLower(name)]
+
+	if !ok {
+		return User{}, false
+	}
+
+	return copyUser(u), true
+}
+
+// CreateUser adds a new user with no privileges.
+func (us *UserStore) CreateUser(name, password string) error {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	key := strings.ToLower(name)
+	if _, ok := us.users[key]; ok {
+		return ErrUserExists
+	}
+
+	us.users[key] = &User{Name: name, PasswordHash: HashPassword(password)}
+	return nil
+}
+
+// DropUser removes a user and all of their grants.
+func (us *UserStore) DropUser(name string) error {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	key := strings.ToLower(name)
+	if _, ok := us.users[key]; !ok {
+		return ErrUserNotFound
+	}
+
+	delete(us.users, key)
+	return nil
+}
+
+// SetPassword changes the password of an existing user.
+func (us *UserStore) SetPassword(name, password string) error {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	u, ok := us.users[strings.ToLower(name)]
+	if !ok {
+		return ErrUserNotFound
+	}
+
+	u.PasswordHash = HashPassword(password)
+	return nil
+}
+
+// Grant gives a user privileges on a table. The database or table may be the Wildcard.
+func (us *UserStore) Grant(name, db, table string, privs Privilege) error {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	u, ok := us.users[strings.ToLower(name)]
+	if !ok {
+		return ErrUserNotFound
+	}
+
+	u.grant(db, table, privs)
+	return nil
+}
+
+// Revoke removes privileges previously granted to a user. Only grants on exactly the same database and table are
+// modified, so revoking on a single table does not narrow a wildcard grant.
+func (us *UserStore) Revoke(name, db, table string, privs Privilege) error {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	u, ok := us.users[strings.ToLower(name)]
+	if !ok {
+		return ErrUserNotFound
+	}
+
+	u.revoke(db, table, privs)
+	return nil
+}
+
+func (us *UserStore) sortedUsers() []*User {
+	users := make([]*User, 0, len(us.users))
+	for _, u := range us.users {
+		users = append(users, u)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Name < users[j].Name
+	})
+
+	return users
+}
+
+func copyUser(u *User) User {
+	cp := *u
+	cp.Grants = append([]Grant(nil), u.Grants...)
+	return cp
+}
+
+func getUsersFile() string {
+	return filepath.Join(dbfactory.DoltDir, UsersFile)
+}
@@ -0,0 +1,117 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privileges
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+)
+
+func TestHashPassword(t *testing.T) {
+	assert.Equal(t, "", HashPassword(""))
+	// matches the output of MySQL's PASSWORD('password')
+	assert.Equal(t, "*2470C0C06DEE42FD1618BB99005ADCA2EC9D1E19", HashPassword("password"))
+}
+
+func TestUserStoreGrantRevoke(t *testing.T) {
+	us := NewUserStore(filesys.NewInMemFS([]string{dbfactory.DoltDir}, nil, "/"))
+
+	require.NoError(t, us.CreateUser("alice", "pass"))
+	assert.Equal(t, ErrUserExists, us.CreateUser("ALICE", "pass"))
+	assert.Equal(t, ErrUserNotFound, us.Grant("bob", "dolt", "prices", Select))
+
+	require.NoError(t, us.Grant("alice", "dolt", "prices", Select|Insert))
+	require.NoError(t, us.Grant("alice", "dolt", Wildcard, Select))
+
+	u, ok := us.GetUser("alice")
+	require.True(t, ok)
+	assert.Equal(t, Select|Insert, u.PrivilegesOn("dolt", "prices"))
+	assert.Equal(t, Select, u.PrivilegesOn("dolt", "orders"))
+	assert.Equal(t, NoPrivileges, u.PrivilegesOn("other", "prices"))
+
+	require.NoError(t, us.Revoke("alice", "dolt", "prices", Insert))
+	u, _ = us.GetUser("alice")
+	assert.Equal(t, Select, u.PrivilegesOn("dolt", "prices"))
+
+	require.NoError(t, us.Revoke("alice", "dolt", Wildcard, AllPrivileges))
+	require.NoError(t, us.Revoke("alice", "dolt", "prices", AllPrivileges))
+	u, _ = us.GetUser("alice")
+	assert.Empty(t, u.Grants)
+
+	require.NoError(t, us.DropUser("alice"))
+	assert.True(t, us.IsEmpty())
+}
+
+func TestUserStoreSaveLoad(t *testing.T) {
+	fs := filesys.NewInMemFS([]string{dbfactory.DoltDir}, nil, "/")
+
+	us, err := LoadUserStore(fs)
+	require.NoError(t, err)
+	assert.True(t, us.IsEmpty())
+
+	require.NoError(t, us.CreateUser("alice", "pass"))
+	require.NoError(t, us.Grant("alice", "dolt", "prices", Select|DDL))
+	require.NoError(t, us.Save())
+
+	loaded, err := LoadUserStore(fs)
+	require.NoError(t, err)
+	assert.Equal(t, us.Users(), loaded.Users())
+
+	u, ok := loaded.GetUser("alice")
+	require.True(t, ok)
+	assert.Equal(t, HashPassword("pass"), u.PasswordHash)
+	assert.Equal(t, Select|DDL, u.PrivilegesOn("dolt", "prices"))
+}
+
+func TestUserStoreReload(t *testing.T) {
+	fs := filesys.NewInMemFS([]string{dbfactory.DoltDir}, nil, "/")
+
+	us, err := LoadUserStore(fs)
+	require.NoError(t, err)
+	require.NoError(t, us.CreateUser("alice", "pass"))
+	require.NoError(t, us.Grant("alice", "dolt", "prices", Select|Update))
+	require.NoError(t, us.Save())
+
+	server, err := LoadUserStore(fs)
+	require.NoError(t, err)
+
+	// unchanged file keeps the loaded users
+	require.NoError(t, server.Reload())
+	assert.Equal(t, us.Users(), server.Users())
+
+	require.NoError(t, us.Revoke("alice", "dolt", "prices", Update))
+	require.NoError(t, us.CreateUser("bob", ""))
+	require.NoError(t, us.Save())
+
+	u, _ := server.GetUser("alice")
+	assert.Equal(t, Select|Update, u.PrivilegesOn("dolt", "prices"))
+
+	require.NoError(t, server.Reload())
+	u, _ = server.GetUser("alice")
+	assert.Equal(t, Select, u.PrivilegesOn("dolt", "prices"))
+	assert.Equal(t, us.Users(), server.Users())
+
+	require.NoError(t, us.DropUser("alice"))
+	require.NoError(t, us.Save())
+	require.NoError(t, server.Reload())
+
+	_, ok := server.GetUser("alice")
+	assert.False(t, ok)
+}
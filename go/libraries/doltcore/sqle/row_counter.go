@@ -0,0 +1,52 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"sync"
+
+	"github.com/src-d/go-mysql-server/sql"
+)
+
+// RowsReadCounter accumulates the number of table rows read by each session. A session runs a single query at a time,
+// so taking the count once a query completes gives the rows read by that query.
+type RowsReadCounter struct {
+	mu     *sync.Mutex
+	counts map[uint32]uint64
+}
+
+// NewRowsReadCounter returns a new RowsReadCounter with no rows counted.
+func NewRowsReadCounter() *RowsReadCounter {
+	return &RowsReadCounter{&sync.Mutex{}, make(map[uint32]uint64)}
+}
+
+func (c *RowsReadCounter) add(ctx *sql.Context, n uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counts[ctx.Session.ID()] += n
+}
+
+// Take returns the number of rows read by the session of the context given since the last call, and resets it.
+func (c *RowsReadCounter) Take(ctx *sql.Context) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := ctx.Session.ID()
+	n := c.counts[id]
+	delete(c.counts, id)
+
+	return n
+}
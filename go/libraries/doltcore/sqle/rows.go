@@ -31,6 +31,7 @@ type doltTableRowIter struct {
 	rowData  types.Map
 	ctx      *sql.Context
 	nomsIter types.MapIterator
+	rowsRead uint64
 }
 
 // Returns a new row iterator for the table given
@@ -62,6 +63,7 @@ func (itr *doltTableRowIter) Next() (sql.Row, error) {
 		return nil, io.EOF
 	}
 
+	itr.rowsRead++
 	doltRow, err := row.FromNoms(itr.table.sch, key.(types.Tuple), val.(types.Tuple))
 
 	if err != nil {
@@ -73,6 +75,11 @@ func (itr *doltTableRowIter) Next() (sql.Row, error) {
 
 // Close required by sql.RowIter interface
 func (itr *doltTableRowIter) Close() error {
+	if itr.table.db != nil && itr.table.db.rowCounter != nil {
+		itr.table.db.rowCounter.add(itr.ctx, itr.rowsRead)
+		itr.rowsRead = 0
+	}
+
 	return nil
 }
 
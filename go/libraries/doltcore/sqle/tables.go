@@ -31,6 +31,7 @@ type DoltTable struct {
 	name  string
 	table *doltdb.Table
 	sch   schema.Schema
+	db    *Database
 }
 
 // Implements sql.IndexableTable
@@ -87,7 +88,13 @@ func (t *DoltTable) Schema() sql.Schema {
 
 // Returns the partitions for this table. We return a single partition, but could potentially get more performance by
 // returning multiple.
-func (t *DoltTable) Partitions(*sql.Context) (sql.PartitionIter, error) {
+func (t *DoltTable) Partitions(ctx *sql.Context) (sql.PartitionIter, error) {
+	if t.db != nil && t.db.authorizer != nil {
+		if err := t.db.authorizer.AuthorizeRead(ctx, t.db.name, t.name); err != nil {
+			return nil, err
+		}
+	}
+
 	return &doltTablePartitionIter{}, nil
 }
 
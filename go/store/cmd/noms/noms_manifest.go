@@ -17,6 +17,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"math/big"
 	"os"
 	"path/filepath"
@@ -25,10 +26,13 @@ import (
 	"github.com/dustin/go-humanize"
 	flag "github.com/juju/gnuflag"
 
+	"github.com/liquidata-inc/dolt/go/store/chunks"
 	"github.com/liquidata-inc/dolt/go/store/cmd/noms/util"
 	"github.com/liquidata-inc/dolt/go/store/d"
+	"github.com/liquidata-inc/dolt/go/store/hash"
 	"github.com/liquidata-inc/dolt/go/store/nbs"
 	"github.com/liquidata-inc/dolt/go/store/spec"
+	"github.com/liquidata-inc/dolt/go/store/types"
 )
 
 var nomsManifest = &util.Command{
@@ -64,11 +68,75 @@ func (f NbsFile) modTimeStr() string {
 	return "-"
 }
 
+var compactManifest = false
+var compactCompression = nbs.SnappyCompression.String()
+var compactKeyFile = ""
+
 func setupManifestFlags() *flag.FlagSet {
 	flagSet := flag.NewFlagSet("manifest", flag.ExitOnError)
+	flagSet.BoolVar(&compactManifest, "compact", false, "Rewrites the table files of the database in the order their chunks are reached from the root before printing the manifest")
+	flagSet.StringVar(&compactCompression, "compression", compactCompression, "The compression the chunks of the table files are rewritten with when compacting, one of snappy, zstd or zstd-dict")
+	flagSet.StringVar(&compactKeyFile, "encryption-key-file", "", "The file holding the base64 encoded key the table files of the database are encrypted with, which is required to compact a database whose table files are encrypted")
 	return flagSet
 }
 
+// compactStore rewrites the table files of the database in dir, which is stored in the format given, with the
+// compression and encryption key given by the flags
+func compactStore(ctx context.Context, dir string, vers string) error {
+	nbf, err := types.GetFormatForVersionString(vers)
+
+	if err != nil {
+		return err
+	}
+
+	compression, err := nbs.ParseChunkCompression(compactCompression)
+
+	if err != nil {
+		return err
+	}
+
+	var key *nbs.EncryptionKey
+	if compactKeyFile != "" {
+		data, err := ioutil.ReadFile(compactKeyFile)
+
+		if err != nil {
+			return err
+		}
+
+		key, err = nbs.ParseEncryptionKey(string(data))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	store, err := nbs.NewLocalStore(ctx, vers, dir, 1<<28)
+
+	if err != nil {
+		return err
+	}
+
+	defer store.Close()
+
+	err = store.SetEncryptionKey(key)
+
+	if err != nil {
+		return err
+	}
+
+	store.SetChunkCompression(compression)
+
+	return store.Compact(ctx, nbs.DefaultCompactionTargetSize, func(c chunks.Chunk) (hash.HashSlice, error) {
+		var refs hash.HashSlice
+		err := types.WalkRefs(c, nbf, func(r types.Ref) error {
+			refs = append(refs, r.TargetHash())
+			return nil
+		})
+
+		return refs, err
+	})
+}
+
 func runManifest(ctx context.Context, args []string) int {
 	if len(args) < 1 {
 		fmt.Fprintln(os.Stderr, "Not enough arguments")
@@ -100,6 +168,20 @@ func runManifest(ctx context.Context, args []string) int {
 	manifest, err = nbs.ParseManifest(manifestReader)
 	d.PanicIfError(err)
 
+	if compactManifest {
+		err = compactStore(ctx, spec.DatabaseName, manifest.GetVersion())
+
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Failed to compact", spec.DatabaseName, err)
+			return 1
+		}
+
+		manifestReader, err = os.Open(manifestFile)
+		d.PanicIfError(err)
+		manifest, err = nbs.ParseManifest(manifestReader)
+		d.PanicIfError(err)
+	}
+
 	numSpecs := manifest.NumTableSpecs()
 	nbsFiles := make([]NbsFile, numSpecs)
 	for i := 0; i < numSpecs; i++ {
@@ -128,6 +210,11 @@ func runManifest(ctx context.Context, args []string) int {
 	fmt.Printf("    version: %s\n", manifest.GetVersion())
 	fmt.Printf("    lock:    %s\n", manifest.GetLock())
 	fmt.Printf("    root:    %s\n", manifest.GetRoot())
+
+	if keyID := manifest.GetEncryptionKeyID(); keyID != "" {
+		fmt.Printf("    key:     %s\n", keyID)
+	}
+
 	fmt.Println("    referenced nbs files:")
 
 	for _, nbsFile := range nbsFiles {
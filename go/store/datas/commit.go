@@ -95,12 +95,21 @@ func FindCommonAncestor(ctx context.Context, c1, c2 types.Ref, vr types.ValueRea
 			if common, ok := findCommonRef(c1Parents, c2Parents); ok {
 				return common, true, nil
 			}
-			parentsToQueue(ctx, c1Parents, c1Q, vr)
-			parentsToQueue(ctx, c2Parents, c2Q, vr)
+			if err := parentsToQueue(ctx, c1Parents, c1Q, vr); err != nil {
+				return types.Ref{}, false, err
+			}
+
+			if err := parentsToQueue(ctx, c2Parents, c2Q, vr); err != nil {
+				return types.Ref{}, false, err
+			}
 		} else if c1Ht > c2Ht {
-			parentsToQueue(ctx, c1Q.PopRefsOfHeight(c1Ht), c1Q, vr)
+			if err := parentsToQueue(ctx, c1Q.PopRefsOfHeight(c1Ht), c1Q, vr); err != nil {
+				return types.Ref{}, false, err
+			}
 		} else {
-			parentsToQueue(ctx, c2Q.PopRefsOfHeight(c2Ht), c2Q, vr)
+			if err := parentsToQueue(ctx, c2Q.PopRefsOfHeight(c2Ht), c2Q, vr); err != nil {
+				return types.Ref{}, false, err
+			}
 		}
 	}
 
@@ -115,6 +124,11 @@ func parentsToQueue(ctx context.Context, refs types.RefSlice, q *types.RefByHeig
 			return err
 		}
 
+		if v == nil {
+			// the commit is beyond the boundary of a shallow history
+			continue
+		}
+
 		c := v.(types.Struct)
 		ps, ok, err := c.MaybeGet(ParentsField)
 
@@ -27,9 +27,25 @@ import (
 	"io"
 
 	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/hash"
 	"github.com/liquidata-inc/dolt/go/store/types"
 )
 
+// HeadUpdate is a compare-and-swap of the head of a dataset, applied by
+// Database.UpdateHeads.
+type HeadUpdate struct {
+	// ID is the ID of the dataset
+	ID string
+
+	// Expected is the hash of the commit the dataset is expected to point
+	// at, or the empty hash if the dataset is expected not to exist.
+	Expected hash.Hash
+
+	// New is the hash of the commit to point the dataset at, or the empty
+	// hash to delete the dataset.
+	New hash.Hash
+}
+
 // Database provides versioned storage for noms values. While Values can be
 // directly read and written from a Database, it is generally more appropriate
 // to read data by inspecting the Head of a Dataset and write new data by
@@ -117,6 +133,13 @@ type Database interface {
 	// Regardless, Datasets() is updated to match backing storage upon return.
 	FastForward(ctx context.Context, ds Dataset, newHeadRef types.Ref) (Dataset, error)
 
+	// UpdateHeads atomically sets the heads of several datasets, each only if
+	// it currently points at the commit expected. If any dataset's head is
+	// not the commit expected, none of the heads are changed and
+	// ErrHeadChanged is returned. Concurrent changes to other datasets do
+	// not cause the update to fail.
+	UpdateHeads(ctx context.Context, updates []HeadUpdate) error
+
 	// Stats may return some kind of struct that reports statistics about the
 	// ChunkStore that backs this Database instance. The type is
 	// implementation-dependent, and impls may return nil
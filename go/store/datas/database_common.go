@@ -42,6 +42,7 @@ type database struct {
 var (
 	ErrOptimisticLockFailed = errors.New("optimistic lock failed on database Root update")
 	ErrMergeNeeded          = errors.New("dataset head is not ancestor of commit")
+	ErrHeadChanged          = errors.New("dataset head is not the expected commit")
 )
 
 // TODO: fix panics
@@ -446,6 +447,98 @@ func (db *database) doDelete(ctx context.Context, datasetIDstr string) error {
 	return err
 }
 
+func (db *database) UpdateHeads(ctx context.Context, updates []HeadUpdate) error {
+	newHeadRefs := make([]types.Ref, len(updates))
+	for i, update := range updates {
+		if !DatasetFullRe.MatchString(update.ID) {
+			return fmt.Errorf("invalid dataset ID: %s", update.ID)
+		}
+
+		if update.New.IsEmpty() {
+			continue
+		}
+
+		v, err := db.ReadValue(ctx, update.New)
+
+		if err != nil {
+			return err
+		} else if v == nil {
+			return fmt.Errorf("commit %s not found", update.New.String())
+		}
+
+		if is, err := IsCommit(v); err != nil {
+			return err
+		} else if !is {
+			return fmt.Errorf("%s is not a commit", update.New.String())
+		}
+
+		r, err := types.NewRef(v, db.Format())
+
+		if err != nil {
+			return err
+		}
+
+		newHeadRefs[i], err = types.ToRefOfValue(r, db.Format())
+
+		if err != nil {
+			return err
+		}
+	}
+
+	// Retry until the root is updated, or until one of the datasets no longer points at its expected commit. A change
+	// to any other dataset made since the root was read only causes another attempt.
+	for {
+		currentRootHash, err := db.rt.Root(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		currentDatasets, err := db.Datasets(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		dsEdit := currentDatasets.Edit()
+		for i, update := range updates {
+			datasetID := types.String(update.ID)
+			r, hasHead, err := currentDatasets.MaybeGet(ctx, datasetID)
+
+			if err != nil {
+				return err
+			}
+
+			var currentHead hash.Hash
+			if hasHead {
+				currentHead = r.(types.Ref).TargetHash()
+			}
+
+			if currentHead != update.Expected {
+				return ErrHeadChanged
+			}
+
+			if update.New.IsEmpty() {
+				dsEdit.Remove(datasetID)
+			} else {
+				dsEdit.Set(datasetID, newHeadRefs[i])
+			}
+		}
+
+		currentDatasets, err = dsEdit.Map(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		err = db.tryCommitChunks(ctx, currentDatasets, currentRootHash)
+
+		if err != ErrOptimisticLockFailed {
+			return err
+		}
+	}
+}
+
 func (db *database) tryCommitChunks(ctx context.Context, currentDatasets types.Map, currentRootHash hash.Hash) error {
 	newRoot, err := db.WriteValue(ctx, currentDatasets)
 
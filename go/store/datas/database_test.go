@@ -618,6 +618,74 @@ func (suite *DatabaseSuite) TestFastForward() {
 	suite.True(mustHeadValue(ds).Equals(c))
 }
 
+func (suite *DatabaseSuite) TestUpdateHeads() {
+	ctx := context.Background()
+
+	// ds1: |a| <- |b|
+	// ds2: |c|
+	ds1, err := suite.db.GetDataset(ctx, "ds1")
+	suite.NoError(err)
+	ds1, err = suite.db.CommitValue(ctx, ds1, types.String("a"))
+	suite.NoError(err)
+	a := mustHeadRef(ds1).TargetHash()
+	ds1, err = suite.db.CommitValue(ctx, ds1, types.String("b"))
+	suite.NoError(err)
+	b := mustHeadRef(ds1).TargetHash()
+
+	ds2, err := suite.db.GetDataset(ctx, "ds2")
+	suite.NoError(err)
+	ds2, err = suite.db.CommitValue(ctx, ds2, types.String("c"))
+	suite.NoError(err)
+	c := mustHeadRef(ds2).TargetHash()
+
+	// Move ds1 back, delete ds2 and create ds3 in one update
+	err = suite.db.UpdateHeads(ctx, []HeadUpdate{
+		{ID: "ds1", Expected: b, New: a},
+		{ID: "ds2", Expected: c},
+		{ID: "ds3", New: c},
+	})
+	suite.NoError(err)
+
+	dss, err := suite.db.Datasets(ctx)
+	suite.NoError(err)
+	suite.Equal(uint64(2), dss.Len())
+
+	ds1, err = suite.db.GetDataset(ctx, "ds1")
+	suite.NoError(err)
+	suite.Equal(a, mustHeadRef(ds1).TargetHash())
+
+	ds3, err := suite.db.GetDataset(ctx, "ds3")
+	suite.NoError(err)
+	suite.Equal(c, mustHeadRef(ds3).TargetHash())
+
+	// ds1 no longer points at b, so nothing is updated
+	err = suite.db.UpdateHeads(ctx, []HeadUpdate{
+		{ID: "ds3", Expected: c, New: b},
+		{ID: "ds1", Expected: b, New: c},
+	})
+	suite.Equal(ErrHeadChanged, err)
+
+	ds3, err = suite.db.GetDataset(ctx, "ds3")
+	suite.NoError(err)
+	suite.Equal(c, mustHeadRef(ds3).TargetHash())
+
+	// A change to another dataset behind suite.db's back doesn't stop an update to ds1
+	interloper := suite.makeDb(suite.storage.NewView())
+	defer interloper.Close()
+
+	iDS, err := interloper.GetDataset(ctx, "other")
+	suite.NoError(err)
+	_, err = interloper.CommitValue(ctx, iDS, types.String("stuff"))
+	suite.NoError(err)
+
+	err = suite.db.UpdateHeads(ctx, []HeadUpdate{{ID: "ds1", Expected: a, New: b}})
+	suite.NoError(err)
+
+	ds1, err = suite.db.GetDataset(ctx, "ds1")
+	suite.NoError(err)
+	suite.Equal(b, mustHeadRef(ds1).TargetHash())
+}
+
 func (suite *DatabaseSuite) TestDatabaseHeightOfRefs() {
 	r1, err := suite.db.WriteValue(context.Background(), types.String("hello"))
 	suite.NoError(err)
@@ -43,8 +43,42 @@ type PullProgress struct {
 const (
 	bytesWrittenSampleRate = .10
 	defaultBatchSize       = 1 << 12 // 4096 chunks
+	resumableBatchSize     = 1 << 14 // 16384 chunks
 )
 
+// PullCheckpoint is the progress of a pull which has not yet completed.
+type PullCheckpoint struct {
+	// Target is the hash of the chunk being pulled
+	Target hash.Hash
+
+	// Tables are the names and chunk counts of the table files holding the chunks written to the sink so far
+	Tables map[hash.Hash]uint32
+
+	// Frontier are the hashes of the chunks still to be pulled, along with everything they reference which the sink
+	// does not have
+	Frontier hash.HashSlice
+}
+
+// PullCheckpointer saves and loads the progress of a pull, so that a pull which is interrupted can be resumed.
+type PullCheckpointer interface {
+	// Load returns the last checkpoint saved, or nil if there is none.
+	Load(ctx context.Context) (*PullCheckpoint, error)
+
+	// Save saves a checkpoint, replacing the last checkpoint saved.
+	Save(ctx context.Context, cp PullCheckpoint) error
+
+	// Clear deletes the last checkpoint saved.
+	Clear(ctx context.Context) error
+}
+
+// checkpointingChunkStore is implemented by ChunkStores which can make the chunks put into them durable before they are
+// committed, and add the table files holding them to a later instance of the store. AddNovelTables returns an error if
+// the table files no longer exist.
+type checkpointingChunkStore interface {
+	Checkpoint(ctx context.Context) (map[hash.Hash]uint32, error)
+	AddNovelTables(ctx context.Context, tables map[hash.Hash]uint32) error
+}
+
 func makeProgTrack(progressCh chan PullProgress) func(moreDone, moreKnown, moreApproxBytesWritten uint64) {
 	var doneCount, knownCount, approxBytesWritten uint64
 	return func(moreDone, moreKnown, moreApproxBytesWritten uint64) {
@@ -58,10 +92,18 @@ func makeProgTrack(progressCh chan PullProgress) func(moreDone, moreKnown, moreA
 
 // Pull objects that descend from sourceRef from srcDB to sinkDB.
 func Pull(ctx context.Context, srcDB, sinkDB Database, sourceRef types.Ref, progressCh chan PullProgress) error {
-	return pull(ctx, srcDB, sinkDB, sourceRef, progressCh, defaultBatchSize)
+	return pull(ctx, srcDB, sinkDB, sourceRef, progressCh, defaultBatchSize, nil, nil)
+}
+
+// ResumablePull pulls as Pull does, saving its progress with |checkpointer| after each batch of chunks is written to
+// the sink. A pull of the same ref which finds a checkpoint continues from where the checkpointed pull stopped. The
+// sink's ChunkStore must be able to make the chunks written to it durable before they are committed for progress to be
+// saved, otherwise ResumablePull is the same as Pull.
+func ResumablePull(ctx context.Context, srcDB, sinkDB Database, sourceRef types.Ref, checkpointer PullCheckpointer, progressCh chan PullProgress) error {
+	return pull(ctx, srcDB, sinkDB, sourceRef, progressCh, resumableBatchSize, nil, checkpointer)
 }
 
-func pull(ctx context.Context, srcDB, sinkDB Database, sourceRef types.Ref, progressCh chan PullProgress, batchSize int) error {
+func pull(ctx context.Context, srcDB, sinkDB Database, sourceRef types.Ref, progressCh chan PullProgress, batchSize int, exclude hash.HashSet, checkpointer PullCheckpointer) error {
 	// Sanity Check
 	exists, err := srcDB.chunkStore().Has(ctx, sourceRef.TargetHash())
 
@@ -80,6 +122,10 @@ func pull(ctx context.Context, srcDB, sinkDB Database, sourceRef types.Ref, prog
 	}
 
 	if exists {
+		if checkpointer != nil {
+			return checkpointer.Clear(ctx)
+		}
+
 		return nil // already up to date
 	}
 
@@ -92,6 +138,22 @@ func pull(ctx context.Context, srcDB, sinkDB Database, sourceRef types.Ref, prog
 
 	// TODO: This batches based on limiting the _number_ of chunks processed at the same time. We really want to batch based on the _amount_ of chunk data being processed simultaneously. We also want to consider the chunks in a particular order, however, and the current GetMany() interface doesn't provide any ordering guarantees. Once BUG 3750 is fixed, we should be able to revisit this and do a better job.
 	absent := hash.HashSlice{sourceRef.TargetHash()}
+
+	cpcs, ok := sinkDB.chunkStore().(checkpointingChunkStore)
+	if !ok {
+		checkpointer = nil
+	}
+
+	if checkpointer != nil {
+		frontier, err := resumePull(ctx, sinkDB, cpcs, sourceRef.TargetHash(), checkpointer)
+
+		if err != nil {
+			return err
+		} else if frontier != nil {
+			absent = frontier
+		}
+	}
+
 	for absentCount := len(absent); absentCount != 0; absentCount = len(absent) {
 		updateProgress(0, uint64(absentCount), 0)
 
@@ -117,9 +179,22 @@ func pull(ctx context.Context, srcDB, sinkDB Database, sourceRef types.Ref, prog
 			if err != nil {
 				return err
 			}
+
+			if checkpointer != nil {
+				// chunks still to be pulled are the rest of this level and the children of the chunks written
+				frontier := make(hash.HashSlice, 0, absentCount-end+len(uniqueOrdered))
+				frontier = append(frontier, absent[end:]...)
+				frontier = append(frontier, uniqueOrdered...)
+
+				err = checkpointPull(ctx, cpcs, sourceRef.TargetHash(), frontier, checkpointer)
+
+				if err != nil {
+					return err
+				}
+			}
 		}
 
-		absent, err = nextLevelMissingChunks(ctx, sinkDB, nextLevel, absent, uniqueOrdered)
+		absent, err = nextLevelMissingChunks(ctx, sinkDB, nextLevel, absent, uniqueOrdered, exclude)
 
 		if err != nil {
 			return err
@@ -132,9 +207,62 @@ func pull(ctx context.Context, srcDB, sinkDB Database, sourceRef types.Ref, prog
 		return err
 	}
 
+	if checkpointer != nil {
+		return checkpointer.Clear(ctx)
+	}
+
 	return nil
 }
 
+// resumePull adds the table files of a checkpoint of a pull of |target| to the sink and returns the chunks of the
+// frontier of the checkpoint which the sink does not have, which may be none. Returns nil if there is no checkpoint of
+// a pull of |target|, or if the table files of the checkpoint are gone, in which case the checkpoint is cleared.
+func resumePull(ctx context.Context, sinkDB Database, cpcs checkpointingChunkStore, target hash.Hash, checkpointer PullCheckpointer) (hash.HashSlice, error) {
+	cp, err := checkpointer.Load(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cp == nil || cp.Target != target || len(cp.Frontier) == 0 {
+		return nil, nil
+	}
+
+	err = cpcs.AddNovelTables(ctx, cp.Tables)
+
+	if err != nil {
+		// the table files of the checkpoint are gone, so the pull starts over
+		return nil, checkpointer.Clear(ctx)
+	}
+
+	// chunks of the frontier may have been written to the sink since the checkpoint was saved
+	missing, err := sinkDB.chunkStore().HasMany(ctx, hash.NewHashSet(cp.Frontier...))
+
+	if err != nil {
+		return nil, err
+	}
+
+	frontier := make(hash.HashSlice, 0, len(missing))
+	for _, h := range cp.Frontier {
+		if missing.Has(h) {
+			frontier = append(frontier, h)
+		}
+	}
+
+	return frontier, nil
+}
+
+// checkpointPull makes the chunks written to the sink so far durable and saves them along with |frontier|.
+func checkpointPull(ctx context.Context, cpcs checkpointingChunkStore, target hash.Hash, frontier hash.HashSlice, checkpointer PullCheckpointer) error {
+	tables, err := cpcs.Checkpoint(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	return checkpointer.Save(ctx, PullCheckpoint{target, tables, frontier})
+}
+
 func persistChunks(ctx context.Context, cs chunks.ChunkStore) error {
 	var success bool
 	for !success {
@@ -159,7 +287,14 @@ func persistChunks(ctx context.Context, cs chunks.ChunkStore) error {
 // optimization problem down to the chunk store which can make smarter decisions.
 func PullWithoutBatching(ctx context.Context, srcDB, sinkDB Database, sourceRef types.Ref, progressCh chan PullProgress) error {
 	// by increasing the batch size to MaxInt32 we effectively remove batching here.
-	return pull(ctx, srcDB, sinkDB, sourceRef, progressCh, math.MaxInt32)
+	return pull(ctx, srcDB, sinkDB, sourceRef, progressCh, math.MaxInt32, nil, nil)
+}
+
+// PullExcluding pulls as PullWithoutBatching does, except that the chunks in |exclude|, and any chunks reachable only
+// through them, are not pulled. Excluding the parents of a set of commits pulls a shallow history, leaving the sink
+// with commits whose parents are missing.
+func PullExcluding(ctx context.Context, srcDB, sinkDB Database, sourceRef types.Ref, exclude hash.HashSet, progressCh chan PullProgress) error {
+	return pull(ctx, srcDB, sinkDB, sourceRef, progressCh, math.MaxInt32, exclude, nil)
 }
 
 // concurrently pull all chunks from this batch that the sink is missing out of the source
@@ -226,7 +361,7 @@ func putChunks(ctx context.Context, sinkDB Database, hashes hash.HashSlice, need
 
 // ask sinkDB which of the next level's hashes it doesn't have, and add those chunks to the absent list which will need
 // to be retrieved.
-func nextLevelMissingChunks(ctx context.Context, sinkDB Database, nextLevel hash.HashSet, absent hash.HashSlice, uniqueOrdered hash.HashSlice) (hash.HashSlice, error) {
+func nextLevelMissingChunks(ctx context.Context, sinkDB Database, nextLevel hash.HashSet, absent hash.HashSlice, uniqueOrdered hash.HashSlice, exclude hash.HashSet) (hash.HashSlice, error) {
 	missingFromSink, err := sinkDB.chunkStore().HasMany(ctx, nextLevel)
 
 	if err != nil {
@@ -235,7 +370,7 @@ func nextLevelMissingChunks(ctx context.Context, sinkDB Database, nextLevel hash
 
 	absent = absent[:0]
 	for _, h := range uniqueOrdered {
-		if missingFromSink.Has(h) {
+		if missingFromSink.Has(h) && !exclude.Has(h) {
 			absent = append(absent, h)
 		}
 	}
@@ -23,12 +23,19 @@ package datas
 
 import (
 	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/liquidata-inc/dolt/go/store/chunks"
 	"github.com/liquidata-inc/dolt/go/store/d"
+	"github.com/liquidata-inc/dolt/go/store/nbs"
 	"github.com/liquidata-inc/dolt/go/store/types"
 )
 
@@ -361,3 +368,117 @@ func buildListOfHeight(height int, vrw types.ValueReadWriter) types.List {
 	}
 	return l
 }
+
+var errTestInterrupted = errors.New("interrupted")
+
+// testPullCheckpointer holds a checkpoint in memory, and fails saves once failAfter checkpoints have been saved
+type testPullCheckpointer struct {
+	cp        *PullCheckpoint
+	saves     int
+	failAfter int
+}
+
+func (tpc *testPullCheckpointer) Load(ctx context.Context) (*PullCheckpoint, error) {
+	return tpc.cp, nil
+}
+
+func (tpc *testPullCheckpointer) Save(ctx context.Context, cp PullCheckpoint) error {
+	if tpc.failAfter > 0 && tpc.saves >= tpc.failAfter {
+		return errTestInterrupted
+	}
+
+	tpc.saves++
+	tpc.cp = &cp
+	return nil
+}
+
+func (tpc *testPullCheckpointer) Clear(ctx context.Context) error {
+	tpc.cp = nil
+	return nil
+}
+
+func TestResumablePull(t *testing.T) {
+	ctx := context.Background()
+	source := NewDatabase((&chunks.TestStorage{}).NewView())
+
+	l := buildListOfHeight(3, source)
+	ds, err := source.GetDataset(ctx, datasetID)
+	require.NoError(t, err)
+	ds, err = source.CommitValue(ctx, ds, l)
+	require.NoError(t, err)
+	sourceRef := mustHeadRef(ds)
+
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	version := source.chunkStore().Version()
+	sinkCS, err := nbs.NewLocalStore(ctx, version, dir, 1<<20)
+	require.NoError(t, err)
+
+	// the pull is interrupted after the first checkpoint is saved
+	checkpointer := &testPullCheckpointer{failAfter: 1}
+	err = ResumablePull(ctx, source, NewDatabase(sinkCS), sourceRef, checkpointer, nil)
+	require.Equal(t, errTestInterrupted, err)
+	require.NotNil(t, checkpointer.cp)
+	assert.Equal(t, sourceRef.TargetHash(), checkpointer.cp.Target)
+	assert.NotEmpty(t, checkpointer.cp.Tables)
+	require.NoError(t, sinkCS.Close())
+
+	resumedCS, err := nbs.NewLocalStore(ctx, version, dir, 1<<20)
+	require.NoError(t, err)
+	sink := NewDatabase(resumedCS)
+	defer sink.Close()
+
+	checkpointer.failAfter = 0
+	err = ResumablePull(ctx, source, sink, sourceRef, checkpointer, nil)
+	require.NoError(t, err)
+	assert.Nil(t, checkpointer.cp)
+
+	v := mustValue(sink.ReadValue(ctx, sourceRef.TargetHash())).(types.Struct)
+	assert.True(t, l.Equals(mustGetValue(v.MaybeGet(ValueField))))
+}
+
+func TestResumablePullMissingTables(t *testing.T) {
+	ctx := context.Background()
+	source := NewDatabase((&chunks.TestStorage{}).NewView())
+
+	l := buildListOfHeight(3, source)
+	ds, err := source.GetDataset(ctx, datasetID)
+	require.NoError(t, err)
+	ds, err = source.CommitValue(ctx, ds, l)
+	require.NoError(t, err)
+	sourceRef := mustHeadRef(ds)
+
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	version := source.chunkStore().Version()
+	sinkCS, err := nbs.NewLocalStore(ctx, version, dir, 1<<20)
+	require.NoError(t, err)
+
+	checkpointer := &testPullCheckpointer{failAfter: 1}
+	err = ResumablePull(ctx, source, NewDatabase(sinkCS), sourceRef, checkpointer, nil)
+	require.Equal(t, errTestInterrupted, err)
+	require.NotNil(t, checkpointer.cp)
+	require.NoError(t, sinkCS.Close())
+
+	// the table files of the checkpoint are deleted before the pull is resumed, so it starts over
+	for h := range checkpointer.cp.Tables {
+		require.NoError(t, os.Remove(filepath.Join(dir, h.String())))
+	}
+
+	resumedCS, err := nbs.NewLocalStore(ctx, version, dir, 1<<20)
+	require.NoError(t, err)
+	sink := NewDatabase(resumedCS)
+	defer sink.Close()
+
+	checkpointer.failAfter = 0
+	err = ResumablePull(ctx, source, sink, sourceRef, checkpointer, nil)
+	require.NoError(t, err)
+	assert.Nil(t, checkpointer.cp)
+
+	v := mustValue(sink.ReadValue(ctx, sourceRef.TargetHash())).(types.Struct)
+	assert.True(t, l.Equals(mustGetValue(v.MaybeGet(ValueField))))
+}
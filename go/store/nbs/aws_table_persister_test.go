@@ -292,7 +292,7 @@ func TestAWSTablePersisterDividePlan(t *testing.T) {
 	tooBig := bytesToChunkSource(t, bigUns...)
 
 	sources := chunkSources{justRight, tooBig, tooSmall}
-	plan, err := planConjoin(sources, &Stats{})
+	plan, err := planConjoin(context.Background(), sources, &Stats{})
 	assert.NoError(err)
 	copies, manuals, _, err := dividePlan(context.Background(), plan, minPartSize, maxPartSize)
 	assert.NoError(err)
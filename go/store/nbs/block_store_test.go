@@ -26,6 +26,8 @@ import (
 	"context"
 	"crypto/rand"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -33,6 +35,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/liquidata-inc/dolt/go/libraries/utils/osutil"
@@ -581,3 +584,195 @@ func (suite *BlockStoreSuite) TestChunkStoreGetNonExisting() {
 	suite.NoError(err)
 	suite.True(c.IsEmpty())
 }
+
+func TestBlockStoreCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, testMemTableSize)
+	assert.NoError(t, err)
+
+	c1 := chunks.NewChunk([]byte("abc"))
+	c2 := chunks.NewChunk([]byte("def"))
+	assert.NoError(t, store.Put(ctx, c1))
+	assert.NoError(t, store.Put(ctx, c2))
+
+	tables, err := store.Checkpoint(ctx)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tables)
+	assert.NoError(t, store.Close())
+
+	// the checkpointed chunks are not visible until a store they're added to commits them
+	resumed, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, testMemTableSize)
+	assert.NoError(t, err)
+	defer resumed.Close()
+
+	ok, err := resumed.Has(ctx, c1.Hash())
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, resumed.AddNovelTables(ctx, tables))
+
+	root, err := resumed.Root(ctx)
+	assert.NoError(t, err)
+	success, err := resumed.Commit(ctx, c2.Hash(), root)
+	assert.NoError(t, err)
+	assert.True(t, success)
+
+	reopened, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, testMemTableSize)
+	assert.NoError(t, err)
+	defer reopened.Close()
+
+	for _, c := range []chunks.Chunk{c1, c2} {
+		read, err := reopened.Get(ctx, c.Hash())
+		assert.NoError(t, err)
+		assert.Equal(t, c.Data(), read.Data())
+	}
+}
+
+func TestBlockStoreCheckpointMissingTables(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, testMemTableSize)
+	assert.NoError(t, err)
+	assert.NoError(t, store.Put(ctx, chunks.NewChunk([]byte("abc"))))
+
+	tables, err := store.Checkpoint(ctx)
+	assert.NoError(t, err)
+	assert.NoError(t, store.Close())
+
+	for h := range tables {
+		assert.NoError(t, os.Remove(filepath.Join(dir, h.String())))
+	}
+
+	// the index of the deleted table file is still cached, but the table file must exist to be added
+	resumed, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, testMemTableSize)
+	assert.NoError(t, err)
+	defer resumed.Close()
+
+	assert.Error(t, resumed.AddNovelTables(ctx, tables))
+
+	_, err = resumed.UpdateManifest(ctx, tables)
+	assert.Error(t, err)
+}
+
+func TestBlockStoreCheckpointReplacedTables(t *testing.T) {
+	ctx := context.Background()
+	checkpoint := func(dir string, data string) map[hash.Hash]uint32 {
+		store, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, testMemTableSize)
+		require.NoError(t, err)
+		require.NoError(t, store.Put(ctx, chunks.NewChunk([]byte(data))))
+		tables, err := store.Checkpoint(ctx)
+		require.NoError(t, err)
+		require.NoError(t, store.Close())
+		return tables
+	}
+
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	otherDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(otherDir)
+
+	tables := checkpoint(dir, "abc")
+	otherTables := checkpoint(otherDir, "def")
+
+	// each table file is replaced by a valid table file holding other chunks
+	for h := range tables {
+		for otherH := range otherTables {
+			data, err := ioutil.ReadFile(filepath.Join(otherDir, otherH.String()))
+			require.NoError(t, err)
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, h.String()), data, 0644))
+		}
+	}
+
+	resumed, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, testMemTableSize)
+	require.NoError(t, err)
+	defer resumed.Close()
+
+	_, err = resumed.UpdateManifest(ctx, tables)
+	assert.Equal(t, ErrInvalidTableFile, err)
+}
+
+type truncatedTableFile struct {
+	TableFileSource
+}
+
+func (tf truncatedTableFile) Open(ctx context.Context) (io.ReadCloser, uint64, error) {
+	rd, size, err := tf.TableFileSource.Open(ctx)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	data, err := ioutil.ReadAll(rd)
+	rd.Close()
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(data[:len(data)/2])), size / 2, nil
+}
+
+func TestBlockStoreTableFiles(t *testing.T) {
+	ctx := context.Background()
+	srcDir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(srcDir)
+	sinkDir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(sinkDir)
+
+	src, err := NewLocalStore(ctx, constants.FormatDefaultString, srcDir, testMemTableSize)
+	assert.NoError(t, err)
+	defer src.Close()
+
+	var chnks []chunks.Chunk
+	for i := 0; i < 64; i++ {
+		c := chunks.NewChunk([]byte(fmt.Sprintf("chunk %d", i)))
+		chnks = append(chnks, c)
+		assert.NoError(t, src.Put(ctx, c))
+	}
+
+	root, err := src.Root(ctx)
+	assert.NoError(t, err)
+	success, err := src.Commit(ctx, chnks[0].Hash(), root)
+	assert.NoError(t, err)
+	assert.True(t, success)
+
+	tfs, err := src.TableFiles(ctx)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tfs)
+
+	sink, err := NewLocalStore(ctx, constants.FormatDefaultString, sinkDir, testMemTableSize)
+	assert.NoError(t, err)
+	defer sink.Close()
+
+	// a table file cut short in transit is not written
+	assert.Equal(t, ErrInvalidTableFile, sink.WriteTableFile(ctx, truncatedTableFile{tfs[0]}))
+
+	tables := make(map[hash.Hash]uint32)
+	for _, tf := range tfs {
+		assert.NoError(t, sink.WriteTableFile(ctx, tf))
+		tables[tf.Name()] = tf.ChunkCount()
+	}
+
+	assert.NoError(t, sink.AddTableFiles(ctx, tables))
+
+	for _, c := range chnks {
+		read, err := sink.Get(ctx, c.Hash())
+		assert.NoError(t, err)
+		assert.Equal(t, c.Data(), read.Data())
+	}
+
+	sinkTfs, err := sink.TableFiles(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, sinkTfs, len(tfs))
+}
@@ -58,11 +58,33 @@ func (bsp *blobstorePersister) ConjoinAll(ctx context.Context, sources chunkSour
 	return emptyChunkSource{}, nil
 }
 
+func (bsp *blobstorePersister) exists(ctx context.Context, name addr) (bool, error) {
+	return bsp.bs.Exists(ctx, name.String())
+}
+
+func (bsp *blobstorePersister) readIndex(ctx context.Context, name addr, chunkCount uint32) (tableIndex, error) {
+	size := int64(indexSize(chunkCount) + footerSize)
+	buff, _, err := blobstore.GetBytes(ctx, bsp.bs, name.String(), blobstore.NewBlobRange(-size, 0))
+
+	if err != nil {
+		return tableIndex{}, err
+	} else if int64(len(buff)) != size {
+		return tableIndex{}, ErrInvalidTableFile
+	}
+
+	return parseTableIndex(buff)
+}
+
 // Open a table named |name|, containing |chunkCount| chunks.
 func (bsp *blobstorePersister) Open(ctx context.Context, name addr, chunkCount uint32, stats *Stats) (chunkSource, error) {
 	return newBSChunkSource(ctx, bsp.bs, name, chunkCount, bsp.blockSize, bsp.indexCache, stats)
 }
 
+func (bsp *blobstorePersister) writeTableFile(ctx context.Context, name addr, r io.Reader) error {
+	_, err := bsp.bs.Put(ctx, name.String(), r)
+	return err
+}
+
 type bsTableReaderAt struct {
 	key string
 	bs  blobstore.Blobstore
@@ -0,0 +1,75 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/blobstore"
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/constants"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+func TestBSStore(t *testing.T) {
+	ctx := context.Background()
+	bs := blobstore.NewInMemoryBlobstore()
+
+	store, err := NewBSStore(ctx, constants.FormatDefaultString, bs, testMemTableSize)
+	require.NoError(t, err)
+
+	c := chunks.NewChunk([]byte("abc"))
+	require.NoError(t, store.Put(ctx, c))
+
+	root, err := store.Root(ctx)
+	require.NoError(t, err)
+
+	success, err := store.Commit(ctx, c.Hash(), root)
+	require.NoError(t, err)
+	require.True(t, success)
+	require.NoError(t, store.Close())
+
+	reopened, err := NewBSStore(ctx, constants.FormatDefaultString, bs, testMemTableSize)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	root, err = reopened.Root(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, c.Hash(), root)
+
+	read, err := reopened.Get(ctx, c.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, c.Data(), read.Data())
+
+	locs, err := reopened.GetChunkLocations(hash.NewHashSet(c.Hash()))
+	require.NoError(t, err)
+	require.Len(t, locs, 1)
+
+	for tableHash, ranges := range locs {
+		r, ok := ranges[c.Hash()]
+		require.True(t, ok)
+
+		data, _, err := blobstore.GetBytes(ctx, bs, tableHash.String(), blobstore.NewBlobRange(int64(r.Offset), int64(r.Length)))
+		require.NoError(t, err)
+
+		found, err := tableReader{}.parseChunk(ctx, addr(c.Hash()), data)
+		require.NoError(t, err)
+		assert.Equal(t, c.Data(), found)
+	}
+}
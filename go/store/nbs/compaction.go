@@ -0,0 +1,410 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"context"
+	"errors"
+
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+const (
+	// DefaultCompactionTargetSize is the amount of chunk data Compact writes to each table file, unless told otherwise
+	DefaultCompactionTargetSize = defaultMemTableSize
+
+	// compactionBatchSize is the most chunks read at a time while walking the chunks of a store to compact it
+	compactionBatchSize = 16 * 1024
+)
+
+// ErrCompactionConflict is returned by Compact when the store was modified by another writer while it was compacted.
+// The store is left as the other writer left it, the table files written by the compaction are deleted, and the store
+// can be compacted again.
+var ErrCompactionConflict = errors.New("the store was modified while it was being compacted")
+
+// ErrStoreInUse is returned by Compact when another process, or another store in the same process, has the store open.
+var ErrStoreInUse = errors.New("the store is in use by another process")
+
+// ErrCompactionUnsupported is returned by Compact for stores whose manifest can't be locked against use by other
+// processes, which are those which are not on the local filesystem.
+var ErrCompactionUnsupported = errors.New("only stores on the local filesystem can be compacted")
+
+var errUncommittedChunks = errors.New("the store has chunks which have not been committed")
+
+// ChunkRefs returns the addresses of the chunks referenced by a chunk
+type ChunkRefs func(c chunks.Chunk) (hash.HashSlice, error)
+
+// compactionWriter writes chunks to new table files holding about targetSize bytes of chunk data each
+type compactionWriter struct {
+	nbs        *NomsBlockStore
+	targetSize uint64
+	mt         *memTable
+
+	// written holds the table files written so far, whose indexes are searched for the chunks already written rather
+	// than keeping a set of them
+	written chunkSources
+	specs   []tableSpec
+}
+
+func (cw *compactionWriter) newMemTable(size uint64) {
+	cw.mt = newMemTable(size)
+	cw.mt.compression = cw.nbs.compression
+	cw.mt.key = cw.nbs.key
+}
+
+// has returns true if the chunk with the address given has already been written
+func (cw *compactionWriter) has(h addr) (bool, error) {
+	if cw.mt != nil {
+		has, err := cw.mt.has(h)
+
+		if err != nil || has {
+			return has, err
+		}
+	}
+
+	for _, cs := range cw.written {
+		has, err := cs.has(h)
+
+		if err != nil || has {
+			return has, err
+		}
+	}
+
+	return false, nil
+}
+
+func (cw *compactionWriter) add(ctx context.Context, h addr, data []byte) error {
+	written, err := cw.has(h)
+
+	if err != nil || written {
+		return err
+	}
+
+	if cw.mt == nil {
+		cw.newMemTable(cw.targetSize)
+	}
+
+	if cw.mt.addChunk(h, data) {
+		return nil
+	}
+
+	err = cw.flush(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	// chunks larger than the target size are written to a table file of their own
+	size := cw.targetSize
+	if uint64(len(data)) > size {
+		size = uint64(len(data))
+	}
+
+	cw.newMemTable(size)
+
+	if !cw.mt.addChunk(h, data) {
+		return errors.New("failed to add chunk")
+	}
+
+	return nil
+}
+
+func (cw *compactionWriter) flush(ctx context.Context) error {
+	if cw.mt == nil {
+		return nil
+	}
+
+	cs, err := cw.nbs.p.Persist(ctx, cw.mt, nil, cw.nbs.stats)
+
+	if err != nil {
+		return err
+	}
+
+	cw.mt = nil
+	count, err := cs.count()
+
+	if err != nil {
+		return err
+	} else if count == 0 {
+		return nil
+	}
+
+	name, err := cs.hash()
+
+	if err != nil {
+		return err
+	}
+
+	cw.written = append(cw.written, cs)
+	cw.specs = append(cw.specs, tableSpec{name, count})
+	return nil
+}
+
+// Compact rewrites all of the table files of the store into new table files holding about targetSize bytes of chunk
+// data each. The chunks are written in the order they are reached by a breadth first walk from the root of the store,
+// so that the chunks of a value are stored near each other, followed by any chunks the walk did not reach. refs is
+// called to find the chunks referenced by each chunk walked. Every chunk is written with the store's current compression
+// and encryption key.
+//
+// Compaction is offline. ErrStoreInUse is returned if any other store has the store open, and other processes can't
+// open the store until compaction is done. The table files which were compacted are deleted once the manifest no
+// longer refers to them. Returns ErrCompactionUnsupported for stores which are not on the local filesystem.
+func (nbs *NomsBlockStore) Compact(ctx context.Context, targetSize uint64, refs ChunkRefs) (err error) {
+	nbs.mm.LockForUpdate()
+	defer func() {
+		unlockErr := nbs.mm.UnlockForUpdate()
+
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+
+	mm, unlock, err := nbs.mm.lockExclusive()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		unlockErr := unlock()
+
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+
+	err = nbs.rebase(ctx, mm)
+
+	if err != nil {
+		return err
+	}
+
+	nbs.mu.RLock()
+	upstream := nbs.upstream
+	sources := nbs.tables.upstream
+	hasNovel := nbs.mt != nil || nbs.tables.Novel() > 0
+	nbs.mu.RUnlock()
+
+	if hasNovel {
+		return errUncommittedChunks
+	} else if upstream.keyID != "" && nbs.key == nil {
+		return ErrEncryptionKeyRequired
+	}
+
+	cw := &compactionWriter{nbs: nbs, targetSize: targetSize}
+
+	if !upstream.root.IsEmpty() {
+		err = nbs.compactReachable(ctx, cw, upstream.root, refs)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, src := range sources {
+		err = compactSource(ctx, cw, src)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	err = cw.flush(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	nbs.mu.Lock()
+	defer nbs.mu.Unlock()
+
+	newContents := manifestContents{
+		vers:  upstream.vers,
+		root:  upstream.root,
+		lock:  generateLockHash(upstream.root, cw.specs),
+		specs: cw.specs,
+		keyID: nbs.manifestKeyID(upstream),
+	}
+
+	updated, err := mm.Update(ctx, upstream.lock, newContents, nbs.stats, nil)
+
+	if err != nil {
+		return err
+	}
+
+	nbs.upstream = updated
+	nbs.tables, err = nbs.tables.Rebase(ctx, updated.specs, nbs.stats)
+
+	if err != nil {
+		return err
+	}
+
+	if updated.lock != newContents.lock {
+		err = nbs.deleteReplacedTables(ctx, cw.specs, updated.specs)
+
+		if err != nil {
+			return err
+		}
+
+		return ErrCompactionConflict
+	}
+
+	return nbs.deleteReplacedTables(ctx, upstream.specs, updated.specs)
+}
+
+// deleteReplacedTables deletes the table files of the replaced specs which are not among the current specs of the
+// manifest, if the store's persister can delete table files. The replaced specs are either those the compaction
+// replaced, or those it wrote if its update of the manifest lost.
+func (nbs *NomsBlockStore) deleteReplacedTables(ctx context.Context, replaced, current []tableSpec) error {
+	deleter, ok := nbs.p.(tableFileDeleter)
+
+	if !ok {
+		return nil
+	}
+
+	inManifest := make(map[addr]bool, len(current))
+	for _, spec := range current {
+		inManifest[spec.name] = true
+	}
+
+	var names []addr
+	for _, spec := range replaced {
+		if !inManifest[spec.name] {
+			names = append(names, spec.name)
+		}
+	}
+
+	return deleter.deleteTableFiles(ctx, names)
+}
+
+// compactReachable writes the chunks reachable from the root given, level by level
+func (nbs *NomsBlockStore) compactReachable(ctx context.Context, cw *compactionWriter, root hash.Hash, refs ChunkRefs) error {
+	level := hash.HashSlice{root}
+	for len(level) > 0 {
+		var next hash.HashSlice
+		nextSet := hash.HashSet{}
+
+		for start := 0; start < len(level); start += compactionBatchSize {
+			end := start + compactionBatchSize
+			if end > len(level) {
+				end = len(level)
+			}
+
+			found, err := nbs.getBatch(ctx, level[start:end])
+
+			if err != nil {
+				return err
+			}
+
+			for _, h := range level[start:end] {
+				// chunks left out of shallow or partial stores are missing
+				c, ok := found[h]
+
+				if !ok {
+					continue
+				}
+
+				written, err := cw.has(addr(h))
+
+				if err != nil {
+					return err
+				} else if written {
+					continue
+				}
+
+				err = cw.add(ctx, addr(h), c.Data())
+
+				if err != nil {
+					return err
+				}
+
+				children, err := refs(*c)
+
+				if err != nil {
+					return err
+				}
+
+				for _, child := range children {
+					if nextSet.Has(child) {
+						continue
+					}
+
+					written, err := cw.has(addr(child))
+
+					if err != nil {
+						return err
+					} else if !written {
+						next = append(next, child)
+						nextSet.Insert(child)
+					}
+				}
+			}
+		}
+
+		level = next
+	}
+
+	return nil
+}
+
+// getBatch returns the chunks in the store with the addresses given
+func (nbs *NomsBlockStore) getBatch(ctx context.Context, hashes hash.HashSlice) (map[hash.Hash]*chunks.Chunk, error) {
+	foundChunks := make(chan *chunks.Chunk, len(hashes))
+	err := nbs.GetMany(ctx, hash.NewHashSet(hashes...), foundChunks)
+	close(foundChunks)
+
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[hash.Hash]*chunks.Chunk, len(hashes))
+	for c := range foundChunks {
+		found[c.Hash()] = c
+	}
+
+	return found, nil
+}
+
+// compactSource writes the chunks of a table file which have not already been written
+func compactSource(ctx context.Context, cw *compactionWriter, src chunkSource) error {
+	// the chunks are written as they are extracted, so that only a batch of them is held at a time
+	records := make(chan extractRecord, compactionBatchSize)
+	extractErr := make(chan error, 1)
+	go func() {
+		defer close(records)
+		extractErr <- src.extract(ctx, records)
+	}()
+
+	var err error
+	for rec := range records {
+		// once writing fails the rest of the records are drained, so that extract can finish
+		if err != nil {
+			continue
+		}
+
+		if rec.err != nil {
+			err = rec.err
+		} else {
+			err = cw.add(ctx, rec.a, rec.data)
+		}
+	}
+
+	if exErr := <-extractErr; exErr != nil {
+		return exErr
+	}
+
+	return err
+}
@@ -0,0 +1,189 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/constants"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// compactionTestTree builds chunks which refer to each other through the children map
+type compactionTestTree struct {
+	children map[hash.Hash]hash.HashSlice
+}
+
+func (tt compactionTestTree) parent(name string, kids ...chunks.Chunk) chunks.Chunk {
+	c := chunks.NewChunk([]byte(fmt.Sprintf("%s %d", name, len(kids))))
+	for _, kid := range kids {
+		tt.children[c.Hash()] = append(tt.children[c.Hash()], kid.Hash())
+	}
+
+	return c
+}
+
+func (tt compactionTestTree) refs(c chunks.Chunk) (hash.HashSlice, error) {
+	return tt.children[c.Hash()], nil
+}
+
+func putAndCommit(t *testing.T, store *NomsBlockStore, root hash.Hash, chnks ...chunks.Chunk) {
+	ctx := context.Background()
+	for _, c := range chnks {
+		require.NoError(t, store.Put(ctx, c))
+	}
+
+	last, err := store.Root(ctx)
+	require.NoError(t, err)
+	success, err := store.Commit(ctx, root, last)
+	require.NoError(t, err)
+	require.True(t, success)
+}
+
+func TestCompact(t *testing.T) {
+	ctx := context.Background()
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	store, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, defaultMemTableSize)
+	require.NoError(t, err)
+
+	tt := compactionTestTree{map[hash.Hash]hash.HashSlice{}}
+	leaves := makeCompressibleChunks(0, 64)
+	left := tt.parent("left", leaves[:32]...)
+	right := tt.parent("right", leaves[32:]...)
+	root := tt.parent("root", left, right)
+	orphans := makeCompressibleChunks(64, 16)
+
+	// each commit writes a table file of its own, and the root is written last
+	putAndCommit(t, store, leaves[0].Hash(), leaves[:32]...)
+	putAndCommit(t, store, leaves[32].Hash(), leaves[32:]...)
+	putAndCommit(t, store, orphans[0].Hash(), orphans...)
+	putAndCommit(t, store, root.Hash(), left, right, root)
+	require.Len(t, store.upstream.specs, 4)
+	replaced := store.upstream.specs
+
+	require.NoError(t, store.Compact(ctx, 2048, tt.refs))
+	specs := store.upstream.specs
+	assert.True(t, len(specs) > 1)
+	assert.Equal(t, root.Hash(), store.upstream.root)
+
+	// the table files which were compacted are deleted
+	for _, spec := range replaced {
+		_, err := os.Stat(filepath.Join(dir, spec.name.String()))
+		assert.True(t, os.IsNotExist(err))
+	}
+
+	// the walk from the root writes the root and its children before the leaves and orphans
+	first, err := store.p.Open(ctx, specs[0].name, specs[0].chunkCount, nil)
+	require.NoError(t, err)
+	for _, c := range []chunks.Chunk{root, left, right} {
+		has, err := first.has(addr(c.Hash()))
+		require.NoError(t, err)
+		assert.True(t, has)
+	}
+
+	last, err := store.p.Open(ctx, specs[len(specs)-1].name, specs[len(specs)-1].chunkCount, nil)
+	require.NoError(t, err)
+	has, err := last.has(addr(orphans[len(orphans)-1].Hash()))
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	var count uint32
+	for _, spec := range specs {
+		count += spec.chunkCount
+	}
+
+	all := append(append(append([]chunks.Chunk{}, leaves...), orphans...), left, right, root)
+	assert.Equal(t, uint32(len(all)), count)
+	require.NoError(t, store.Close())
+
+	reopened, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, defaultMemTableSize)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	reopenedRoot, err := reopened.Root(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, root.Hash(), reopenedRoot)
+	assert.Equal(t, specs, reopened.upstream.specs)
+
+	for _, c := range all {
+		found, err := reopened.Get(ctx, c.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, c.Data(), found.Data())
+	}
+}
+
+func TestCompactUncommitted(t *testing.T) {
+	ctx := context.Background()
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	store, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, defaultMemTableSize)
+	require.NoError(t, err)
+	defer store.Close()
+
+	tt := compactionTestTree{map[hash.Hash]hash.HashSlice{}}
+	require.NoError(t, store.Put(ctx, chunks.NewChunk([]byte("uncommitted"))))
+	assert.Equal(t, errUncommittedChunks, store.Compact(ctx, DefaultCompactionTargetSize, tt.refs))
+}
+
+func TestCompactInUse(t *testing.T) {
+	ctx := context.Background()
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	store, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, defaultMemTableSize)
+	require.NoError(t, err)
+	defer store.Close()
+
+	tt := compactionTestTree{map[hash.Hash]hash.HashSlice{}}
+	leaves := makeCompressibleChunks(0, 4)
+	putAndCommit(t, store, leaves[0].Hash(), leaves...)
+	specs := store.upstream.specs
+
+	// another process holds the manifest file lock
+	lck := newLock(dir)
+	require.NoError(t, lck.TryLock())
+	assert.Equal(t, ErrStoreInUse, store.Compact(ctx, DefaultCompactionTargetSize, tt.refs))
+	require.NoError(t, lck.Unlock())
+	assert.Equal(t, specs, store.upstream.specs)
+
+	// another store has the store open, even though it isn't updating the manifest
+	other, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, defaultMemTableSize)
+	require.NoError(t, err)
+	assert.Equal(t, ErrStoreInUse, store.Compact(ctx, DefaultCompactionTargetSize, tt.refs))
+	assert.Equal(t, specs, store.upstream.specs)
+	require.NoError(t, other.Close())
+
+	require.NoError(t, store.Compact(ctx, DefaultCompactionTargetSize, tt.refs))
+
+	// the user locks left by processes which exited without closing the store are removed
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, userLockFilePrefix+"exited"), nil, 0600))
+	require.NoError(t, store.Compact(ctx, DefaultCompactionTargetSize, tt.refs))
+
+	paths, err := filepath.Glob(filepath.Join(dir, userLockFilePrefix+"*"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{store.mm.m.(fileManifest).userLock}, paths)
+}
@@ -0,0 +1,416 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// tableFormat is the version of the format of a table file
+type tableFormat int
+
+const (
+	// tableFormatV1 tables hold chunks compressed with snappy
+	tableFormatV1 tableFormat = iota
+
+	// tableFormatV2 tables hold chunks compressed with snappy or zstd, and the zstd dictionaries the chunks were
+	// compressed with
+	tableFormatV2
+)
+
+// magicNumber returns the magic number in the footer of tables in the format
+func (f tableFormat) magicNumber() string {
+	if f == tableFormatV2 {
+		return magicNumberV2
+	}
+
+	return magicNumber
+}
+
+// ChunkCompression is the compression applied to the chunks of new table files. Table files are read the same way
+// regardless of the compression they were written with.
+type ChunkCompression int
+
+const (
+	// SnappyCompression compresses each chunk with snappy. Table files are written in the original format, which
+	// every version of NBS can read.
+	SnappyCompression ChunkCompression = iota
+
+	// ZstdCompression compresses each chunk with zstd.
+	ZstdCompression
+
+	// ZstdDictCompression compresses each chunk with zstd, using a dictionary trained on the chunks of the table file
+	// being written. The dictionary is stored in the table file.
+	ZstdDictCompression
+)
+
+var chunkCompressionNames = map[ChunkCompression]string{
+	SnappyCompression:   "snappy",
+	ZstdCompression:     "zstd",
+	ZstdDictCompression: "zstd-dict",
+}
+
+// ParseChunkCompression returns the ChunkCompression with the name given, which is one of "snappy", "zstd" or
+// "zstd-dict".
+func ParseChunkCompression(str string) (ChunkCompression, error) {
+	str = strings.ToLower(strings.TrimSpace(str))
+	for c, name := range chunkCompressionNames {
+		if str == name {
+			return c, nil
+		}
+	}
+
+	return SnappyCompression, fmt.Errorf("unknown chunk compression '%s'", str)
+}
+
+func (c ChunkCompression) String() string {
+	if name, ok := chunkCompressionNames[c]; ok {
+		return name
+	}
+
+	return "unknown"
+}
+
+const (
+	// zstdFrameMagic begins every zstd frame. No snappy encoding begins with these bytes, as they would be followed
+	// by a copy of data preceding the start of the encoding, so the compression of chunk data can be told from its
+	// first bytes.
+	zstdFrameMagic = "\x28\xb5\x2f\xfd"
+
+	zstdLevel = zstd.SpeedBetterCompression
+
+	// dictionaries are only trained for table files with at least minDictionaryChunks chunks, on at most
+	// maxDictionarySamples bytes of their chunks
+	minDictionaryChunks  = 64
+	maxDictionarySamples = 4 << 20
+	maxDictionarySize    = 64 << 10
+	dictionaryHashBytes  = 6
+
+	dictionariesHeaderSize = uint32Size + uint32Size
+)
+
+var errDictionaryConflict = errors.New("table files have different dictionaries with the same id")
+
+var (
+	zstdOnce = sync.Once{}
+	zstdEnc  *zstd.Encoder
+	zstdDec  *zstd.Decoder
+	zstdErr  error
+)
+
+// zstdCodecs returns the zstd encoder and decoder used for chunks compressed without a dictionary. Both are safe for
+// concurrent use.
+func zstdCodecs() (*zstd.Encoder, *zstd.Decoder, error) {
+	zstdOnce.Do(func() {
+		zstdEnc, zstdErr = newZstdEncoder(nil)
+
+		if zstdErr == nil {
+			zstdDec, zstdErr = zstd.NewReader(nil)
+		}
+	})
+
+	return zstdEnc, zstdDec, zstdErr
+}
+
+func newZstdEncoder(d []byte) (*zstd.Encoder, error) {
+	// chunk records are already checksummed
+	opts := []zstd.EOption{zstd.WithEncoderLevel(zstdLevel), zstd.WithEncoderCRC(false)}
+
+	if d != nil {
+		opts = append(opts, zstd.WithEncoderDict(d), zstd.WithEncoderConcurrency(1))
+	}
+
+	return zstd.NewWriter(nil, opts...)
+}
+
+func isZstdFrame(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(zstdFrameMagic))
+}
+
+// zstdEncoder is a chunkEncoder which compresses chunks with zstd
+type zstdEncoder struct {
+	enc *zstd.Encoder
+
+	// dicts is the dictionaries section of the table file the chunks are written to
+	dicts []byte
+}
+
+// newZstdChunkEncoder returns a zstdEncoder for a table file holding the chunks given. If train is true and there are
+// enough chunks, the chunks are compressed with a dictionary trained on a sample of them, unless they are too random for
+// a dictionary to be of use.
+func newZstdChunkEncoder(chunks [][]byte, train bool) (zstdEncoder, error) {
+	if !train || len(chunks) < minDictionaryChunks {
+		enc, _, err := zstdCodecs()
+
+		if err != nil {
+			return zstdEncoder{}, err
+		}
+
+		return zstdEncoder{enc, writeDictionaries(nil)}, nil
+	}
+
+	d := buildZstdDictionary(sampleChunks(chunks), maxDictionarySize)
+
+	if d == nil {
+		return newZstdChunkEncoder(chunks, false)
+	}
+
+	enc, err := newZstdEncoder(d)
+
+	if err != nil {
+		return zstdEncoder{}, err
+	}
+
+	return zstdEncoder{enc, writeDictionaries([][]byte{d})}, nil
+}
+
+func (ze zstdEncoder) Encode(dst, src []byte) []byte {
+	return ze.enc.EncodeAll(src, dst[:0])
+}
+
+// sampleChunks returns chunks spread evenly through the chunks given, totalling at most maxDictionarySamples bytes
+func sampleChunks(chunks [][]byte) [][]byte {
+	var total int
+	for _, c := range chunks {
+		total += len(c)
+	}
+
+	step := 1
+	if total > maxDictionarySamples {
+		step = (total + maxDictionarySamples - 1) / maxDictionarySamples
+	}
+
+	var samples [][]byte
+	var size int
+	for i := 0; i < len(chunks) && size < maxDictionarySamples; i += step {
+		samples = append(samples, chunks[i])
+		size += len(chunks[i])
+	}
+
+	return samples
+}
+
+// ChunkDecompressor decompresses the chunk data of chunk records read from table files, which is compressed with
+// either snappy or zstd. Chunk data compressed with a zstd dictionary can only be decompressed by a ChunkDecompressor
+// created with the dictionaries section of the table file it was read from, while a nil *ChunkDecompressor
+// decompresses any chunk data compressed without one. ChunkDecompressors are safe for concurrent use.
+type ChunkDecompressor struct {
+	dec *zstd.Decoder
+}
+
+// NewChunkDecompressor returns a ChunkDecompressor for the chunks of a table file with the dictionaries section
+// given. Returns nil if the section holds no dictionaries.
+func NewChunkDecompressor(dicts []byte) (*ChunkDecompressor, error) {
+	parsed, err := parseDictionaries(dicts)
+
+	if err != nil {
+		return nil, err
+	} else if len(parsed) == 0 {
+		return nil, nil
+	}
+
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderDicts(parsed...))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChunkDecompressor{dec}, nil
+}
+
+// Decompress returns the chunk data held in the data given, which is the chunk record of the chunk with the address h
+// less its checksum. Encrypted chunk records are decrypted with the EncryptionKey they were written with.
+func (cd *ChunkDecompressor) Decompress(h hash.Hash, data []byte) ([]byte, error) {
+	data, err := decryptRecord(addr(h), data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cd.decompress(data)
+}
+
+// decompress returns the chunk data compressed in the data given, which is decrypted
+func (cd *ChunkDecompressor) decompress(data []byte) ([]byte, error) {
+	if !isZstdFrame(data) {
+		return snappy.Decode(nil, data)
+	}
+
+	if cd != nil {
+		return cd.dec.DecodeAll(data, nil)
+	}
+
+	_, dec, err := zstdCodecs()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return dec.DecodeAll(data, nil)
+}
+
+// writeDictionaries returns the dictionaries section of a table file holding the zstd dictionaries given
+func writeDictionaries(dicts [][]byte) []byte {
+	size := uint64(dictionariesHeaderSize) + uint64(len(dicts))*lengthSize
+	for _, d := range dicts {
+		size += uint64(len(d))
+	}
+
+	section := make([]byte, size)
+	binary.BigEndian.PutUint32(section, uint32(size))
+	binary.BigEndian.PutUint32(section[uint32Size:], uint32(len(dicts)))
+
+	pos := uint64(dictionariesHeaderSize)
+	for _, d := range dicts {
+		binary.BigEndian.PutUint32(section[pos:], uint32(len(d)))
+		pos += lengthSize
+	}
+
+	for _, d := range dicts {
+		pos += uint64(copy(section[pos:], d))
+	}
+
+	return section
+}
+
+// parseDictionaries returns the zstd dictionaries held in a dictionaries section. The dictionaries returned reference
+// the section given.
+func parseDictionaries(section []byte) ([][]byte, error) {
+	if len(section) == 0 {
+		return nil, nil
+	}
+
+	if len(section) < dictionariesHeaderSize || uint64(binary.BigEndian.Uint32(section)) != uint64(len(section)) {
+		return nil, ErrInvalidTableFile
+	}
+
+	count := uint64(binary.BigEndian.Uint32(section[uint32Size:]))
+	pos := uint64(dictionariesHeaderSize)
+	dictPos := pos + count*lengthSize
+
+	if dictPos > uint64(len(section)) {
+		return nil, ErrInvalidTableFile
+	}
+
+	dicts := make([][]byte, count)
+	for i := range dicts {
+		length := uint64(binary.BigEndian.Uint32(section[pos:]))
+		pos += lengthSize
+
+		if dictPos+length > uint64(len(section)) {
+			return nil, ErrInvalidTableFile
+		}
+
+		dicts[i] = section[dictPos : dictPos+length]
+		dictPos += length
+	}
+
+	return dicts, nil
+}
+
+// hasDictionaries returns whether a dictionaries section holds any dictionaries
+func hasDictionaries(section []byte) bool {
+	return len(section) >= dictionariesHeaderSize && binary.BigEndian.Uint32(section[uint32Size:]) > 0
+}
+
+// mergeDictionaries returns a dictionaries section holding the dictionaries of all of the sections given. Returns
+// errDictionaryConflict if different dictionaries have the same id, as the chunks compressed with them could not be
+// told apart.
+func mergeDictionaries(sections [][]byte) ([]byte, error) {
+	byID := make(map[uint32][]byte)
+	var merged [][]byte
+	for _, section := range sections {
+		dicts, err := parseDictionaries(section)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, d := range dicts {
+			id := dictionaryID(d)
+
+			if existing, ok := byID[id]; ok {
+				if !bytes.Equal(existing, d) {
+					return nil, errDictionaryConflict
+				}
+
+				continue
+			}
+
+			byID[id] = d
+			merged = append(merged, d)
+		}
+	}
+
+	return writeDictionaries(merged), nil
+}
+
+// dictionaryID returns the id of a zstd dictionary, which frames compressed with it refer to it by
+func dictionaryID(d []byte) uint32 {
+	// the id follows the 4 byte magic number of the dictionary
+	if len(d) < 8 {
+		return 0
+	}
+
+	return binary.LittleEndian.Uint32(d[4:])
+}
+
+// readDictionaries reads the dictionaries section of a table file, which follows its chunk records. Returns nil for
+// table files in the original format, which have no dictionaries section.
+func readDictionaries(ctx context.Context, r tableReaderAt, index tableIndex, stats *Stats) ([]byte, error) {
+	if index.format != tableFormatV2 || index.chunkCount == 0 {
+		return nil, nil
+	}
+
+	off := int64(calcChunkDataLen(index))
+	header := make([]byte, uint32Size)
+	n, err := r.ReadAtWithStats(ctx, header, off, stats)
+
+	if err != nil && err != io.EOF {
+		return nil, err
+	} else if n != len(header) {
+		return nil, errors.New("failed to read all data")
+	}
+
+	size := binary.BigEndian.Uint32(header)
+
+	if size < dictionariesHeaderSize {
+		return nil, ErrInvalidTableFile
+	}
+
+	section := make([]byte, size)
+	copy(section, header)
+	n, err = r.ReadAtWithStats(ctx, section[uint32Size:], off+uint32Size, stats)
+
+	if err != nil && err != io.EOF {
+		return nil, err
+	} else if n != len(section)-uint32Size {
+		return nil, errors.New("failed to read all data")
+	}
+
+	return section, nil
+}
@@ -0,0 +1,316 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/constants"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// makeCompressibleChunks returns chunks which share enough structure for a dictionary to be trained on them
+func makeCompressibleChunks(start, count int) []chunks.Chunk {
+	chnks := make([]chunks.Chunk, count)
+	for i := range chnks {
+		n := start + i
+		data := fmt.Sprintf(`{"id": %d, "name": "user-%d", "email": "user%d@example.com", "score": %d, "tags": ["alpha", "beta"]}`, n, n*7, n*13, n*n)
+		chnks[i] = chunks.NewChunk([]byte(data))
+	}
+
+	return chnks
+}
+
+func writeTestTable(t *testing.T, chnks []chunks.Chunk, compression ChunkCompression) (addr, []byte, tableReader) {
+	name, data, err := WriteChunks(chnks, compression, nil)
+	require.NoError(t, err)
+	ti, err := parseTableIndex(data)
+	require.NoError(t, err)
+	return addr(hash.Parse(name)), data, newTableReader(ti, tableReaderAtFromBytes(data), fileBlockSize)
+}
+
+func TestParseChunkCompression(t *testing.T) {
+	for _, c := range []ChunkCompression{SnappyCompression, ZstdCompression, ZstdDictCompression} {
+		parsed, err := ParseChunkCompression(c.String())
+		assert.NoError(t, err)
+		assert.Equal(t, c, parsed)
+	}
+
+	parsed, err := ParseChunkCompression(" ZSTD ")
+	assert.NoError(t, err)
+	assert.Equal(t, ZstdCompression, parsed)
+
+	_, err = ParseChunkCompression("gzip")
+	assert.Error(t, err)
+}
+
+func TestCompressedTables(t *testing.T) {
+	ctx := context.Background()
+	chnks := makeCompressibleChunks(0, 2*minDictionaryChunks)
+
+	tests := []struct {
+		compression ChunkCompression
+		format      tableFormat
+		hasDicts    bool
+	}{
+		{SnappyCompression, tableFormatV1, false},
+		{ZstdCompression, tableFormatV2, false},
+		{ZstdDictCompression, tableFormatV2, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.compression.String(), func(t *testing.T) {
+			_, data, tr := writeTestTable(t, chnks, test.compression)
+			assert.Equal(t, test.format, tr.format)
+
+			dicts, err := tr.dictionaries(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, test.hasDicts, hasDictionaries(dicts))
+			assert.Equal(t, uint64(len(data)), tr.tableFileSize(dicts))
+
+			for _, c := range chnks {
+				found, err := tr.get(ctx, addr(c.Hash()), &Stats{})
+				require.NoError(t, err)
+				assert.Equal(t, c.Data(), found)
+			}
+
+			extracted := make(chan extractRecord, len(chnks))
+			require.NoError(t, tr.extract(ctx, extracted))
+			close(extracted)
+
+			for rec := range extracted {
+				assert.Equal(t, hash.Hash(rec.a), chunks.NewChunk(rec.data).Hash())
+			}
+		})
+	}
+
+	t.Run("too few chunks to train a dictionary", func(t *testing.T) {
+		_, _, tr := writeTestTable(t, chnks[:minDictionaryChunks-1], ZstdDictCompression)
+		assert.Equal(t, tableFormatV2, tr.format)
+
+		dicts, err := tr.dictionaries(ctx)
+		require.NoError(t, err)
+		assert.False(t, hasDictionaries(dicts))
+	})
+
+	t.Run("failed dictionary reads are retried", func(t *testing.T) {
+		_, data, _ := writeTestTable(t, chnks, ZstdDictCompression)
+		ti, err := parseTableIndex(data)
+		require.NoError(t, err)
+		tr := newTableReader(ti, &failingReaderAt{tableReaderAtFromBytes(data), 1}, fileBlockSize)
+
+		_, err = tr.dictionaries(ctx)
+		assert.Error(t, err)
+
+		dicts, err := tr.dictionaries(ctx)
+		require.NoError(t, err)
+		assert.True(t, hasDictionaries(dicts))
+	})
+}
+
+// failingReaderAt fails the first reads made through it
+type failingReaderAt struct {
+	tableReaderAt
+	failures int
+}
+
+func (fr *failingReaderAt) ReadAtWithStats(ctx context.Context, p []byte, off int64, stats *Stats) (int, error) {
+	if fr.failures > 0 {
+		fr.failures--
+		return 0, errors.New("read failed")
+	}
+
+	return fr.tableReaderAt.ReadAtWithStats(ctx, p, off, stats)
+}
+
+func TestChunkDecompressor(t *testing.T) {
+	data := []byte("the quick brown fox jumps over the lazy dog")
+
+	ze, err := newZstdChunkEncoder([][]byte{data}, false)
+	require.NoError(t, err)
+
+	var decomp *ChunkDecompressor
+	for _, compressed := range [][]byte{snappy.Encode(nil, data), ze.Encode(nil, data)} {
+		found, err := decomp.Decompress(hash.Hash{}, compressed)
+		require.NoError(t, err)
+		assert.Equal(t, data, found)
+	}
+
+	decomp, err = NewChunkDecompressor(writeDictionaries(nil))
+	require.NoError(t, err)
+	assert.Nil(t, decomp)
+}
+
+// fakeDictionary returns bytes which are laid out as a zstd dictionary with the id given
+func fakeDictionary(id uint32, content string) []byte {
+	d := make([]byte, 8, 8+len(content))
+	binary.LittleEndian.PutUint32(d, 0xec30a437)
+	binary.LittleEndian.PutUint32(d[4:], id)
+	return append(d, content...)
+}
+
+func TestDictionariesSection(t *testing.T) {
+	dicts := [][]byte{fakeDictionary(1, "one"), fakeDictionary(2, "two")}
+	section := writeDictionaries(dicts)
+	assert.True(t, hasDictionaries(section))
+
+	parsed, err := parseDictionaries(section)
+	require.NoError(t, err)
+	assert.Equal(t, dicts, parsed)
+
+	_, err = parseDictionaries(section[:len(section)-1])
+	assert.Equal(t, ErrInvalidTableFile, err)
+
+	empty := writeDictionaries(nil)
+	assert.False(t, hasDictionaries(empty))
+	assert.Len(t, empty, dictionariesHeaderSize)
+
+	merged, err := mergeDictionaries([][]byte{section, nil, writeDictionaries(dicts[1:]), writeDictionaries([][]byte{fakeDictionary(3, "three")})})
+	require.NoError(t, err)
+	parsed, err = parseDictionaries(merged)
+	require.NoError(t, err)
+	assert.Equal(t, append(dicts, fakeDictionary(3, "three")), parsed)
+
+	_, err = mergeDictionaries([][]byte{section, writeDictionaries([][]byte{fakeDictionary(2, "other")})})
+	assert.Equal(t, errDictionaryConflict, err)
+}
+
+func TestConjoinCompressedTables(t *testing.T) {
+	ctx := context.Background()
+	var sources chunkSources
+	var contents [][]chunks.Chunk
+	datas := make(map[addr][]byte)
+	for i, compression := range []ChunkCompression{SnappyCompression, ZstdCompression, ZstdDictCompression, ZstdDictCompression} {
+		chnks := makeCompressibleChunks(i*1000, 2*minDictionaryChunks)
+		name, data, tr := writeTestTable(t, chnks, compression)
+		sources = append(sources, chunkSourceAdapter{tr, name})
+		contents = append(contents, chnks)
+		datas[name] = data
+	}
+
+	plan, err := planConjoin(ctx, sources, &Stats{})
+	require.NoError(t, err)
+
+	buff := &bytes.Buffer{}
+	for _, sws := range plan.sources.sws {
+		name, err := sws.source.hash()
+		require.NoError(t, err)
+		buff.Write(datas[name][:sws.dataLen])
+	}
+	buff.Write(plan.mergedIndex)
+	data := buff.Bytes()
+
+	ti, err := parseTableIndex(data)
+	require.NoError(t, err)
+	assert.Equal(t, tableFormatV2, ti.format)
+	assert.Equal(t, ti.suffixes, plan.suffixes())
+
+	tr := newTableReader(ti, tableReaderAtFromBytes(data), fileBlockSize)
+	dicts, err := tr.dictionaries(ctx)
+	require.NoError(t, err)
+	parsed, err := parseDictionaries(dicts)
+	require.NoError(t, err)
+	assert.Len(t, parsed, 2)
+	assert.Equal(t, uint64(len(data)), tr.tableFileSize(dicts))
+
+	for _, chnks := range contents {
+		for _, c := range chnks {
+			found, err := tr.get(ctx, addr(c.Hash()), &Stats{})
+			require.NoError(t, err)
+			assert.Equal(t, c.Data(), found)
+		}
+	}
+}
+
+// dictsChunkSource is a chunkSource with the dictionaries section given
+type dictsChunkSource struct {
+	chunkSource
+	dicts []byte
+}
+
+func (dcs dictsChunkSource) dictionaries(ctx context.Context) ([]byte, error) {
+	return dcs.dicts, nil
+}
+
+func TestKeepDictionaryConflicts(t *testing.T) {
+	ctx := context.Background()
+	first := dictsChunkSource{emptyChunkSource{}, writeDictionaries([][]byte{fakeDictionary(1, "one")})}
+	same := dictsChunkSource{emptyChunkSource{}, writeDictionaries([][]byte{fakeDictionary(1, "one"), fakeDictionary(2, "two")})}
+	conflicting := dictsChunkSource{emptyChunkSource{}, writeDictionaries([][]byte{fakeDictionary(2, "other")})}
+	none := dictsChunkSource{emptyChunkSource{}, nil}
+
+	toConjoin, toKeep, err := keepDictionaryConflicts(ctx, chunkSources{first, same, conflicting, none}, chunkSources{})
+	require.NoError(t, err)
+	assert.Equal(t, chunkSources{first, same, none}, toConjoin)
+	assert.Equal(t, chunkSources{conflicting}, toKeep)
+}
+
+func TestStoreChunkCompression(t *testing.T) {
+	ctx := context.Background()
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	store, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, defaultMemTableSize)
+	require.NoError(t, err)
+	store.SetChunkCompression(ZstdDictCompression)
+
+	chnks := makeCompressibleChunks(0, 2*minDictionaryChunks)
+	for _, c := range chnks {
+		require.NoError(t, store.Put(ctx, c))
+	}
+
+	root, err := store.Root(ctx)
+	require.NoError(t, err)
+	success, err := store.Commit(ctx, chnks[0].Hash(), root)
+	require.NoError(t, err)
+	require.True(t, success)
+	require.NoError(t, store.Close())
+
+	reopened, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, defaultMemTableSize)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	for _, c := range chnks {
+		found, err := reopened.Get(ctx, c.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, c.Data(), found.Data())
+	}
+
+	locs, err := reopened.GetChunkLocations(hash.NewHashSet(chnks[0].Hash()))
+	require.NoError(t, err)
+	require.Len(t, locs, 1)
+
+	for name := range locs {
+		dictsRange, err := reopened.GetDictionariesRange(ctx, name)
+		require.NoError(t, err)
+		assert.NotZero(t, dictsRange.Length)
+
+		info, err := os.Stat(filepath.Join(dir, name.String()))
+		require.NoError(t, err)
+		assert.True(t, dictsRange.Offset+uint64(dictsRange.Length) < uint64(info.Size()))
+	}
+}
@@ -22,6 +22,7 @@
 package nbs
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"sort"
@@ -81,6 +82,7 @@ func conjoin(ctx context.Context, upstream manifestContents, mm manifestUpdater,
 			root:  upstream.root,
 			lock:  generateLockHash(upstream.root, specs),
 			specs: specs,
+			keyID: upstream.keyID,
 		}
 
 		var err error
@@ -148,6 +150,12 @@ func conjoinTables(ctx context.Context, p tablePersister, upstream []tableSpec,
 		return tableSpec{}, nil, nil, err
 	}
 
+	toConjoin, toKeep, err = keepDictionaryConflicts(ctx, toConjoin, toKeep)
+
+	if err != nil {
+		return tableSpec{}, nil, nil, err
+	}
+
 	conjoinedSrc, err := p.ConjoinAll(ctx, toConjoin, stats)
 
 	if err != nil {
@@ -192,6 +200,47 @@ func conjoinTables(ctx context.Context, p tablePersister, upstream []tableSpec,
 	return tableSpec{h, cnt}, conjoinees, keepers, nil
 }
 
+// keepDictionaryConflicts moves any table in |toConjoin| with a zstd dictionary which has the same id as a different
+// dictionary of a table before it to |toKeep|, as a conjoined table can't hold both dictionaries.
+func keepDictionaryConflicts(ctx context.Context, toConjoin, toKeep chunkSources) (chunkSources, chunkSources, error) {
+	byID := make(map[uint32][]byte)
+	compatible := make(chunkSources, 0, len(toConjoin))
+	for _, src := range toConjoin {
+		section, err := src.dictionaries(ctx)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		dicts, err := parseDictionaries(section)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		conflict := false
+		for _, d := range dicts {
+			if existing, ok := byID[dictionaryID(d)]; ok && !bytes.Equal(existing, d) {
+				conflict = true
+				break
+			}
+		}
+
+		if conflict {
+			toKeep = append(toKeep, src)
+			continue
+		}
+
+		for _, d := range dicts {
+			byID[dictionaryID(d)] = d
+		}
+
+		compatible = append(compatible, src)
+	}
+
+	return compatible, toKeep, nil
+}
+
 // Current approach is to choose the smallest N tables which, when removed and replaced with the conjoinment, will leave the conjoinment as the smallest table.
 func chooseConjoinees(upstream chunkSources) (toConjoin, toKeep chunkSources, err error) {
 	sortedUpstream := make(chunkSources, len(upstream))
@@ -44,6 +44,7 @@ const (
 	versAttr       = "vers"
 	nbsVersAttr    = "nbsVers"
 	tableSpecsAttr = "specs"
+	keyIDAttr      = "keyID"
 )
 
 var (
@@ -108,6 +109,9 @@ func (dm dynamoManifest) ParseIfExists(ctx context.Context, stats *Stats, readHo
 				return false, manifestContents{}, ErrCorruptManifest
 			}
 		}
+		if result.Item[keyIDAttr] != nil {
+			contents.keyID = *result.Item[keyIDAttr].S
+		}
 	}
 
 	return exists, contents, nil
@@ -119,10 +123,18 @@ func validateManifest(item map[string]*dynamodb.AttributeValue) (valid, hasSpecs
 		item[versAttr] != nil && item[versAttr].S != nil &&
 		item[lockAttr] != nil && item[lockAttr].B != nil &&
 		item[rootAttr] != nil && item[rootAttr].B != nil {
-		if len(item) == 6 && item[tableSpecsAttr] != nil && item[tableSpecsAttr].S != nil {
+		// the key id is only recorded for stores whose table files are encrypted
+		attrs := len(item)
+		if item[keyIDAttr] != nil {
+			if item[keyIDAttr].S == nil {
+				return false, false
+			}
+			attrs--
+		}
+		if attrs == 6 && item[tableSpecsAttr] != nil && item[tableSpecsAttr].S != nil {
 			return true, true
 		}
-		return len(item) == 5, false
+		return attrs == 5, false
 	}
 	return false, false
 }
@@ -146,6 +158,9 @@ func (dm dynamoManifest) Update(ctx context.Context, lastLock addr, newContents
 		formatSpecs(newContents.specs, tableInfo)
 		putArgs.Item[tableSpecsAttr] = &dynamodb.AttributeValue{S: aws.String(strings.Join(tableInfo, ":"))}
 	}
+	if newContents.keyID != "" {
+		putArgs.Item[keyIDAttr] = &dynamodb.AttributeValue{S: aws.String(newContents.keyID)}
+	}
 
 	expr := valueEqualsExpression
 	if lastLock == (addr{}) {
@@ -0,0 +1,181 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+const (
+	// encryptedRecordMagic begins the data of every encrypted chunk record. Its first byte is the snappy encoding of a
+	// decoded length of 0, which no chunk has, so encrypted chunk data can be told from snappy or zstd compressed chunk
+	// data by its first bytes.
+	encryptedRecordMagic = "\x00\x9f\x4e\xc2"
+
+	encryptionKeyIDSize = 8
+	gcmNonceSize        = 12
+	gcmTagSize          = 16
+
+	// The data of an encrypted chunk record is laid out as:
+	// +-------+--------+-------+-----------------------------+-----+
+	// | Magic | Key ID | Nonce | Encrypted Compressed Chunk  | Tag |
+	// +-------+--------+-------+-----------------------------+-----+
+	// The compressed chunk data is encrypted with AES-GCM, using the address of the chunk as additional data so that
+	// records can't be moved between chunks.
+	encryptedRecordHeaderSize = uint64(len(encryptedRecordMagic)) + encryptionKeyIDSize + gcmNonceSize
+	encryptedRecordOverhead   = encryptedRecordHeaderSize + gcmTagSize
+)
+
+// ErrEncryptionKeyNotLoaded is returned when reading a chunk which is encrypted with a key which has not been created
+// in the process.
+var ErrEncryptionKeyNotLoaded = errors.New("chunk is encrypted with a key which has not been loaded")
+
+// ErrEncryptionKeyMismatch is returned when setting the encryption key of a store whose table files are encrypted with
+// a different key.
+var ErrEncryptionKeyMismatch = errors.New("table files are encrypted with a different key")
+
+// ErrEncryptionKeyRequired is returned when opening a store whose table files are encrypted without a key.
+var ErrEncryptionKeyRequired = errors.New("table files are encrypted and no encryption key was given")
+
+// ErrTableFilesUnencrypted is returned when setting an encryption key for a store whose table files were written
+// without one. A key can only be set for a store with no table files.
+var ErrTableFilesUnencrypted = errors.New("table files are not encrypted, and an encryption key can only be set for a new repository")
+
+var errDecryptionFailed = errors.New("failed to decrypt chunk - likely corrupt data or the wrong key")
+
+type encryptionKeyID [encryptionKeyIDSize]byte
+
+// encryptionKeys holds every EncryptionKey created in the process, by id, so that an encrypted chunk record can be
+// decrypted wherever it is read, whether from a local table file, a remote or a table file store.
+var encryptionKeys = struct {
+	mu   *sync.RWMutex
+	keys map[encryptionKeyID]*EncryptionKey
+}{&sync.RWMutex{}, make(map[encryptionKeyID]*EncryptionKey)}
+
+// EncryptionKey is an AES key which the chunk records of table files are encrypted with. Table files written with a key
+// are readable in any process which has created an EncryptionKey with the same key.
+type EncryptionKey struct {
+	id   encryptionKeyID
+	aead cipher.AEAD
+}
+
+// NewEncryptionKey returns an EncryptionKey for the AES key given, which is 16, 24 or 32 bytes long.
+func NewEncryptionKey(key []byte) (*EncryptionKey, error) {
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the id is recorded in every encrypted chunk record and in the manifest, so it must not reveal the key
+	h := sha256.New()
+	h.Write([]byte("nbs encryption key id"))
+	h.Write(key)
+
+	k := &EncryptionKey{aead: aead}
+	copy(k.id[:], h.Sum(nil))
+
+	encryptionKeys.mu.Lock()
+	defer encryptionKeys.mu.Unlock()
+
+	encryptionKeys.keys[k.id] = k
+
+	return k, nil
+}
+
+// ParseEncryptionKey returns an EncryptionKey for the base64 encoded AES key given.
+func ParseEncryptionKey(str string) (*EncryptionKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(str))
+
+	if err != nil {
+		return nil, errors.New("encryption key is not valid base64")
+	}
+
+	return NewEncryptionKey(key)
+}
+
+// ID returns the id of the key, which is recorded in the manifest of stores whose table files are encrypted with it.
+func (k *EncryptionKey) ID() string {
+	return hex.EncodeToString(k.id[:])
+}
+
+func isEncryptedRecord(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedRecordMagic))
+}
+
+// encrypt encrypts the compressed data of the chunk with the address h, which must begin encryptedRecordHeaderSize
+// bytes into dst, in place. Returns the data of the encrypted chunk record, which begins at the start of dst.
+func (k *EncryptionKey) encrypt(dst []byte, h addr, compressed []byte) ([]byte, error) {
+	n := uint64(copy(dst, encryptedRecordMagic))
+	n += uint64(copy(dst[n:], k.id[:]))
+	nonce := dst[n:encryptedRecordHeaderSize]
+
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := k.aead.Seal(compressed[:0], nonce, compressed, h[:])
+	n = encryptedRecordHeaderSize + uint64(copy(dst[encryptedRecordHeaderSize:], sealed))
+
+	return dst[:n], nil
+}
+
+// decryptRecord returns the compressed data of the chunk with the address h, held in the chunk record data given. Data
+// which is not encrypted is returned as is.
+func decryptRecord(h addr, data []byte) ([]byte, error) {
+	if !isEncryptedRecord(data) {
+		return data, nil
+	}
+
+	if uint64(len(data)) < encryptedRecordOverhead {
+		return nil, ErrInvalidTableFile
+	}
+
+	var id encryptionKeyID
+	copy(id[:], data[len(encryptedRecordMagic):])
+
+	encryptionKeys.mu.RLock()
+	k, ok := encryptionKeys.keys[id]
+	encryptionKeys.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrEncryptionKeyNotLoaded
+	}
+
+	nonce := data[encryptedRecordHeaderSize-gcmNonceSize : encryptedRecordHeaderSize]
+	compressed, err := k.aead.Open(nil, nonce, data[encryptedRecordHeaderSize:], h[:])
+
+	if err != nil {
+		return nil, errDecryptionFailed
+	}
+
+	return compressed, nil
+}
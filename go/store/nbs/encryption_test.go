@@ -0,0 +1,309 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/constants"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+func makeTestEncryptionKey(t *testing.T) *EncryptionKey {
+	raw := make([]byte, 32)
+	_, err := rand.Read(raw)
+	require.NoError(t, err)
+
+	key, err := NewEncryptionKey(raw)
+	require.NoError(t, err)
+
+	return key
+}
+
+func TestParseEncryptionKey(t *testing.T) {
+	raw := make([]byte, 32)
+	_, err := rand.Read(raw)
+	require.NoError(t, err)
+
+	key, err := ParseEncryptionKey(base64.StdEncoding.EncodeToString(raw) + "\n")
+	require.NoError(t, err)
+	again, err := NewEncryptionKey(raw)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID(), again.ID())
+	assert.NotEqual(t, key.ID(), makeTestEncryptionKey(t).ID())
+
+	_, err = ParseEncryptionKey("not base64!")
+	assert.Error(t, err)
+
+	_, err = NewEncryptionKey(raw[:20])
+	assert.Error(t, err)
+}
+
+func TestEncryptedTables(t *testing.T) {
+	ctx := context.Background()
+	key := makeTestEncryptionKey(t)
+	chnks := makeCompressibleChunks(0, 2*minDictionaryChunks)
+
+	for _, compression := range []ChunkCompression{SnappyCompression, ZstdCompression, ZstdDictCompression} {
+		t.Run(compression.String(), func(t *testing.T) {
+			name, data, err := WriteChunks(chnks, compression, key)
+			require.NoError(t, err)
+			assert.False(t, bytes.Contains(data, []byte("example.com")))
+
+			_, plain, err := WriteChunks(chnks, compression, nil)
+			require.NoError(t, err)
+			plainTI, err := parseTableIndex(plain)
+			require.NoError(t, err)
+
+			ti, err := parseTableIndex(data)
+			require.NoError(t, err)
+			assert.Equal(t, tableFormatV2, ti.format)
+			assert.Equal(t, plainTI.suffixes, ti.suffixes)
+			assert.Equal(t, name, addr(hash.Parse(name)).String())
+
+			tr := newTableReader(ti, tableReaderAtFromBytes(data), fileBlockSize)
+			dicts, err := tr.dictionaries(ctx)
+			require.NoError(t, err)
+			assert.False(t, hasDictionaries(dicts))
+
+			for _, c := range chnks {
+				found, err := tr.get(ctx, addr(c.Hash()), &Stats{})
+				require.NoError(t, err)
+				assert.Equal(t, c.Data(), found)
+			}
+
+			extracted := make(chan extractRecord, len(chnks))
+			require.NoError(t, tr.extract(ctx, extracted))
+			close(extracted)
+
+			for rec := range extracted {
+				assert.Equal(t, hash.Hash(rec.a), chunks.NewChunk(rec.data).Hash())
+			}
+		})
+	}
+}
+
+func TestDecryptRecord(t *testing.T) {
+	key := makeTestEncryptionKey(t)
+	c := chunks.NewChunk([]byte("the quick brown fox jumps over the lazy dog"))
+	h := addr(c.Hash())
+
+	buff := make([]byte, 256)
+	compressed := realSnappyEncoder{}.Encode(buff[encryptedRecordHeaderSize:], c.Data())
+	record, err := key.encrypt(buff, h, compressed)
+	require.NoError(t, err)
+	require.True(t, isEncryptedRecord(record))
+
+	decrypted, err := decryptRecord(h, record)
+	require.NoError(t, err)
+	var decomp *ChunkDecompressor
+	found, err := decomp.Decompress(c.Hash(), record)
+	require.NoError(t, err)
+	assert.Equal(t, c.Data(), found)
+
+	plain, err := decryptRecord(h, decrypted)
+	require.NoError(t, err)
+	assert.Equal(t, decrypted, plain)
+
+	_, err = decryptRecord(addr(hash.Of([]byte("another chunk"))), record)
+	assert.Equal(t, errDecryptionFailed, err)
+
+	_, err = decryptRecord(h, record[:encryptedRecordOverhead-1])
+	assert.Equal(t, ErrInvalidTableFile, err)
+
+	unknown := append([]byte{}, record...)
+	unknown[len(encryptedRecordMagic)] ^= 0xff
+	_, err = decryptRecord(h, unknown)
+	assert.Equal(t, ErrEncryptionKeyNotLoaded, err)
+}
+
+func TestStoreEncryption(t *testing.T) {
+	ctx := context.Background()
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	key := makeTestEncryptionKey(t)
+	store, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, defaultMemTableSize)
+	require.NoError(t, err)
+	require.NoError(t, store.SetEncryptionKey(key))
+
+	chnks := makeCompressibleChunks(0, 16)
+	for _, c := range chnks {
+		require.NoError(t, store.Put(ctx, c))
+	}
+
+	root, err := store.Root(ctx)
+	require.NoError(t, err)
+	success, err := store.Commit(ctx, chnks[0].Hash(), root)
+	require.NoError(t, err)
+	require.True(t, success)
+	require.NoError(t, store.Close())
+
+	f, err := os.Open(filepath.Join(dir, manifestFileName))
+	require.NoError(t, err)
+	contents, err := parseManifest(f)
+	require.NoError(t, f.Close())
+	require.NoError(t, err)
+	assert.Equal(t, key.ID(), contents.keyID)
+
+	reopened, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, defaultMemTableSize)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	assert.Equal(t, ErrEncryptionKeyRequired, reopened.SetEncryptionKey(nil))
+	assert.Equal(t, ErrEncryptionKeyMismatch, reopened.SetEncryptionKey(makeTestEncryptionKey(t)))
+	require.NoError(t, reopened.SetEncryptionKey(key))
+
+	for _, c := range chnks {
+		found, err := reopened.Get(ctx, c.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, c.Data(), found.Data())
+	}
+}
+
+// writeTestTables commits chunks to a new store in dir, encrypted with the key given unless it is nil, and returns the
+// store with its table files. The chunks must differ from those written by other tests in other formats, as table files
+// are named for their chunks and their indexes are cached by name.
+func writeTestTables(t *testing.T, dir string, key *EncryptionKey, start int) (*NomsBlockStore, []TableFileSource) {
+	ctx := context.Background()
+	store, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, defaultMemTableSize)
+	require.NoError(t, err)
+	require.NoError(t, store.SetEncryptionKey(key))
+
+	chnks := makeCompressibleChunks(start, 16)
+	for _, c := range chnks {
+		require.NoError(t, store.Put(ctx, c))
+	}
+
+	root, err := store.Root(ctx)
+	require.NoError(t, err)
+	success, err := store.Commit(ctx, chnks[0].Hash(), root)
+	require.NoError(t, err)
+	require.True(t, success)
+
+	tfs, err := store.TableFiles(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, tfs)
+
+	return store, tfs
+}
+
+func TestEncryptionKeyOfExistingTables(t *testing.T) {
+	ctx := context.Background()
+	key := makeTestEncryptionKey(t)
+
+	plainDir := makeTempDir(t)
+	defer os.RemoveAll(plainDir)
+	plain, plainTfs := writeTestTables(t, plainDir, nil, 500)
+	defer plain.Close()
+
+	encDir := makeTempDir(t)
+	defer os.RemoveAll(encDir)
+	encrypted, encTfs := writeTestTables(t, encDir, key, 600)
+	defer encrypted.Close()
+
+	otherDir := makeTempDir(t)
+	defer os.RemoveAll(otherDir)
+	other, otherTfs := writeTestTables(t, otherDir, makeTestEncryptionKey(t), 700)
+	defer other.Close()
+
+	t.Run("plaintext tables", func(t *testing.T) {
+		assert.Equal(t, ErrTableFilesUnencrypted, plain.SetEncryptionKey(key))
+		assert.Empty(t, plain.upstream.keyID)
+		assert.Nil(t, plain.key)
+	})
+
+	t.Run("table file writes", func(t *testing.T) {
+		assert.Equal(t, ErrTableFileWritesUnsupported, encrypted.WriteTableFile(ctx, plainTfs[0]))
+	})
+
+	t.Run("key ids of added tables", func(t *testing.T) {
+		dir := makeTempDir(t)
+		defer os.RemoveAll(dir)
+
+		store, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, defaultMemTableSize)
+		require.NoError(t, err)
+		defer store.Close()
+
+		add := func(tf TableFileSource, keyID string) error {
+			require.NoError(t, store.WriteTableFile(ctx, tf))
+			_, err := store.UpdateManifestWithKeyID(ctx, map[hash.Hash]uint32{tf.Name(): tf.ChunkCount()}, keyID)
+			return err
+		}
+
+		require.NoError(t, add(encTfs[0], key.ID()))
+		assert.Equal(t, key.ID(), store.upstream.keyID)
+
+		assert.Equal(t, ErrEncryptionKeyMismatch, add(otherTfs[0], other.key.ID()))
+		assert.Equal(t, ErrEncryptionKeyRequired, add(plainTfs[0], ""))
+		assert.Equal(t, key.ID(), store.upstream.keyID)
+		assert.Len(t, store.upstream.specs, 1)
+
+		// table files already in the manifest are not checked
+		_, err = store.UpdateManifestWithKeyID(ctx, map[hash.Hash]uint32{encTfs[0].Name(): encTfs[0].ChunkCount()}, "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("encrypted tables added to plaintext tables", func(t *testing.T) {
+		dir := makeTempDir(t)
+		defer os.RemoveAll(dir)
+
+		store, err := NewLocalStore(ctx, constants.FormatDefaultString, dir, defaultMemTableSize)
+		require.NoError(t, err)
+		defer store.Close()
+
+		require.NoError(t, store.WriteTableFile(ctx, plainTfs[0]))
+		_, err = store.UpdateManifestWithKeyID(ctx, map[hash.Hash]uint32{plainTfs[0].Name(): plainTfs[0].ChunkCount()}, "")
+		require.NoError(t, err)
+
+		require.NoError(t, store.WriteTableFile(ctx, encTfs[0]))
+		_, err = store.UpdateManifestWithKeyID(ctx, map[hash.Hash]uint32{encTfs[0].Name(): encTfs[0].ChunkCount()}, key.ID())
+		assert.Equal(t, ErrTableFilesUnencrypted, err)
+		assert.Empty(t, store.upstream.keyID)
+	})
+}
+
+func TestManifestKeyID(t *testing.T) {
+	contents := makeContents("locker", "nuroot", []tableSpec{{computeAddr([]byte("a")), 3}})
+	contents.keyID = makeTestEncryptionKey(t).ID()
+
+	buff := &bytes.Buffer{}
+	require.NoError(t, writeManifest(buff, contents))
+	assert.True(t, strings.HasPrefix(buff.String(), encryptedStorageVersion+":"))
+
+	parsed, err := parseManifest(buff)
+	require.NoError(t, err)
+	assert.Equal(t, contents, parsed)
+
+	contents.keyID = ""
+	buff.Reset()
+	require.NoError(t, writeManifest(buff, contents))
+	assert.True(t, strings.HasPrefix(buff.String(), StorageVersion+":"))
+
+	parsed, err = parseManifest(buff)
+	require.NoError(t, err)
+	assert.Equal(t, contents, parsed)
+}
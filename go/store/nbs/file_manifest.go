@@ -39,6 +39,14 @@ import (
 const (
 	manifestFileName = "manifest"
 	lockFileName     = "LOCK"
+
+	// userLockFilePrefix is the prefix of the names of the lock files held by the stores which have the store open
+	userLockFilePrefix = "LOCK."
+
+	// encryptedStorageVersion is the nbs version of the manifests of stores whose table files are encrypted, which
+	// record the id of the encryption key after the root hash. Versions of NBS which can't decrypt table files don't
+	// recognize it, so they won't open the store.
+	encryptedStorageVersion = "5"
 )
 
 // fileManifest provides access to a NomsBlockStore manifest stored on disk in |dir|. The format
@@ -46,8 +54,16 @@ const (
 //
 // |-- String --|-- String --|-------- String --------|-------- String --------|-- String --|- String --|...|-- String --|- String --|
 // | nbs version:Noms version:Base32-encoded lock hash:Base32-encoded root hash:table 1 hash:table 1 cnt:...:table N hash:table N cnt|
+//
+// or, for stores whose table files are encrypted:
+//
+// |-- String --|-- String --|-------- String --------|-------- String --------|-- String --|-- String --|- String --|...|
+// | nbs version:Noms version:Base32-encoded lock hash:Base32-encoded root hash:  key id   :table 1 hash:table 1 cnt:...|
 type fileManifest struct {
 	dir string
+
+	// userLock is the path of the user lock file held by the store the manifest belongs to, if it holds one
+	userLock string
 }
 
 func newLock(dir string) *fslock.Lock {
@@ -55,6 +71,128 @@ func newLock(dir string) *fslock.Lock {
 	return fslock.New(lockPath)
 }
 
+// manifestFileLocker takes the manifest file lock of the store in dir, returning the function which releases it
+type manifestFileLocker func(dir string) (unlock func() error, err error)
+
+// lockManifestFile takes the manifest file lock of the store in dir, waiting for it if another process holds it
+func lockManifestFile(dir string) (func() error, error) {
+	lck := newLock(dir)
+	err := lck.Lock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return lck.Unlock, nil
+}
+
+// heldManifestFileLock is the manifestFileLocker of a manifest whose lock is already held
+func heldManifestFileLock(dir string) (func() error, error) {
+	return func() error { return nil }, nil
+}
+
+// lockStoreUser records that the store in dir is open, by creating a user lock file and holding its lock until unlock is
+// called. Compaction is refused while another store holds a user lock. Waits for the manifest file lock, so a store
+// isn't opened while it is being compacted. The user lock files of stores which were not closed are deleted.
+func lockStoreUser(dir string) (path string, unlock func() error, err error) {
+	unlockManifest, err := lockManifestFile(dir)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	defer func() {
+		unlockErr := unlockManifest()
+
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+
+	_, err = removeStaleUserLocks(dir, "")
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	f, err := ioutil.TempFile(dir, userLockFilePrefix)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	path = f.Name()
+	err = f.Close()
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	lck := fslock.New(path)
+	err = lck.TryLock()
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return path, func() error {
+		err := lck.Unlock()
+
+		if err != nil {
+			return err
+		}
+
+		// the file may have been deleted as stale between unlocking and removing it
+		err = os.Remove(path)
+
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}, nil
+}
+
+// removeStaleUserLocks deletes the user lock files in dir which are not held by any store, other than the one at the
+// path given. Returns true if any of them are held. The manifest file lock must be held.
+func removeStaleUserLocks(dir string, own string) (inUse bool, err error) {
+	paths, err := filepath.Glob(filepath.Join(dir, userLockFilePrefix+"*"))
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, path := range paths {
+		if path == own {
+			continue
+		}
+
+		lck := fslock.New(path)
+		err = lck.TryLock()
+
+		if err == fslock.ErrLocked {
+			inUse = true
+			continue
+		} else if err != nil {
+			return false, err
+		}
+
+		err = lck.Unlock()
+
+		if err != nil {
+			return false, err
+		}
+
+		err = os.Remove(path)
+
+		if err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+	}
+
+	return inUse, nil
+}
+
 func lockFileExists(dir string) (bool, error) {
 	lockPath := filepath.Join(dir, lockFileName)
 	info, err := os.Stat(lockPath)
@@ -76,6 +214,52 @@ func (fm fileManifest) Name() string {
 	return fm.dir
 }
 
+// lockExclusive takes the manifest file lock without waiting for it, and holds it until unlock is called. Other
+// processes take the lock to open the store and to read or update the manifest, so they can't use the store while it
+// is held. Returns ErrStoreInUse if another process holds the lock, or if any other store has the store open, which is
+// known by the user lock files they hold. The manifest returned reads and updates the manifest while the lock is held.
+func (fm fileManifest) lockExclusive() (m manifest, unlock func() error, err error) {
+	lck := newLock(fm.dir)
+	err = lck.TryLock()
+
+	if err == fslock.ErrLocked {
+		return nil, nil, ErrStoreInUse
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	inUse, err := removeStaleUserLocks(fm.dir, fm.userLock)
+
+	if err == nil && inUse {
+		err = ErrStoreInUse
+	}
+
+	if err != nil {
+		// the error of taking the lock is returned rather than that of releasing it
+		lck.Unlock()
+		return nil, nil, err
+	}
+
+	return lockedFileManifest{fm}, lck.Unlock, nil
+}
+
+// lockedFileManifest is a fileManifest whose manifest file lock is held by lockExclusive
+type lockedFileManifest struct {
+	fm fileManifest
+}
+
+func (lfm lockedFileManifest) Name() string {
+	return lfm.fm.Name()
+}
+
+func (lfm lockedFileManifest) ParseIfExists(ctx context.Context, stats *Stats, readHook func() error) (exists bool, contents manifestContents, err error) {
+	return lfm.fm.parseIfExists(ctx, stats, readHook, heldManifestFileLock)
+}
+
+func (lfm lockedFileManifest) Update(ctx context.Context, lastLock addr, newContents manifestContents, stats *Stats, writeHook func() error) (mc manifestContents, err error) {
+	return lfm.fm.update(ctx, lastLock, newContents, stats, writeHook, heldManifestFileLock)
+}
+
 // ParseIfExists looks for a LOCK and manifest file in fm.dir. If it finds
 // them, it takes the lock, parses the manifest and returns its contents,
 // setting |exists| to true. If not, it sets |exists| to false and returns. In
@@ -83,6 +267,10 @@ func (fm fileManifest) Name() string {
 // it will be executed while ParseIfExists() holds the manifest file lock.
 // This is to allow for race condition testing.
 func (fm fileManifest) ParseIfExists(ctx context.Context, stats *Stats, readHook func() error) (exists bool, contents manifestContents, err error) {
+	return fm.parseIfExists(ctx, stats, readHook, lockManifestFile)
+}
+
+func (fm fileManifest) parseIfExists(ctx context.Context, stats *Stats, readHook func() error, lock manifestFileLocker) (exists bool, contents manifestContents, err error) {
 	t1 := time.Now()
 	defer func() {
 		stats.ReadManifestLatency.SampleTimeSince(t1)
@@ -97,17 +285,18 @@ func (fm fileManifest) ParseIfExists(ctx context.Context, stats *Stats, readHook
 
 	// !exists(lockFileName) => unitialized store
 	if locked {
-		var f io.ReadCloser
+		// f is nil if the manifest doesn't exist, such as when the lock file was created by lockExclusive
+		var f *os.File
 		err = func() (ferr error) {
-			lck := newLock(fm.dir)
-			ferr = lck.Lock()
+			var unlock func() error
+			unlock, ferr = lock(fm.dir)
 
 			if ferr != nil {
 				return ferr
 			}
 
 			defer func() {
-				unlockErr := lck.Unlock()
+				unlockErr := unlock()
 
 				if ferr == nil {
 					ferr = unlockErr
@@ -182,15 +371,21 @@ func parseManifest(r io.Reader) (manifestContents, error) {
 	}
 
 	slices := strings.Split(string(manifest), ":")
-	if len(slices) < 4 || len(slices)%2 == 1 {
+
+	headerLen := 4
+	if slices[0] == encryptedStorageVersion {
+		headerLen = 5
+	}
+
+	if len(slices) < headerLen || (len(slices)-headerLen)%2 == 1 {
 		return manifestContents{}, ErrCorruptManifest
 	}
 
-	if StorageVersion != string(slices[0]) {
+	if StorageVersion != string(slices[0]) && encryptedStorageVersion != string(slices[0]) {
 		return manifestContents{}, errors.New("invalid storage version")
 	}
 
-	specs, err := parseSpecs(slices[4:])
+	specs, err := parseSpecs(slices[headerLen:])
 
 	if err != nil {
 		return manifestContents{}, err
@@ -202,15 +397,25 @@ func parseManifest(r io.Reader) (manifestContents, error) {
 		return manifestContents{}, err
 	}
 
+	var keyID string
+	if headerLen == 5 {
+		keyID = slices[4]
+	}
+
 	return manifestContents{
 		vers:  slices[1],
 		lock:  ad,
 		root:  hash.Parse(slices[3]),
 		specs: specs,
+		keyID: keyID,
 	}, nil
 }
 
 func (fm fileManifest) Update(ctx context.Context, lastLock addr, newContents manifestContents, stats *Stats, writeHook func() error) (mc manifestContents, err error) {
+	return fm.update(ctx, lastLock, newContents, stats, writeHook, lockManifestFile)
+}
+
+func (fm fileManifest) update(ctx context.Context, lastLock addr, newContents manifestContents, stats *Stats, writeHook func() error, lock manifestFileLocker) (mc manifestContents, err error) {
 	t1 := time.Now()
 	defer func() { stats.WriteManifestLatency.SampleTimeSince(t1) }()
 
@@ -250,15 +455,15 @@ func (fm fileManifest) Update(ctx context.Context, lastLock addr, newContents ma
 	defer os.Remove(tempManifestPath) // If we rename below, this will be a no-op
 
 	// Take manifest file lock
-	lck := newLock(fm.dir)
-	err = lck.Lock()
+	var unlock func() error
+	unlock, err = lock(fm.dir)
 
 	if err != nil {
 		return manifestContents{}, err
 	}
 
 	defer func() {
-		unlockErr := lck.Unlock()
+		unlockErr := unlock()
 
 		if err == nil {
 			err = unlockErr
@@ -327,10 +532,16 @@ func (fm fileManifest) Update(ctx context.Context, lastLock addr, newContents ma
 }
 
 func writeManifest(temp io.Writer, contents manifestContents) error {
-	strs := make([]string, 2*len(contents.specs)+4)
-	strs[0], strs[1], strs[2], strs[3] = StorageVersion, contents.vers, contents.lock.String(), contents.root.String()
-	tableInfo := strs[4:]
+	strs := []string{StorageVersion, contents.vers, contents.lock.String(), contents.root.String()}
+
+	if contents.keyID != "" {
+		strs[0] = encryptedStorageVersion
+		strs = append(strs, contents.keyID)
+	}
+
+	tableInfo := make([]string, 2*len(contents.specs))
 	formatSpecs(contents.specs, tableInfo)
+	strs = append(strs, tableInfo...)
 	_, err := io.WriteString(temp, strings.Join(strs, ":"))
 
 	return err
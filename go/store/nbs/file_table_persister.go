@@ -46,6 +46,57 @@ type fsTablePersister struct {
 	indexCache *indexCache
 }
 
+func (ftp *fsTablePersister) exists(ctx context.Context, name addr) (bool, error) {
+	_, err := os.Stat(filepath.Join(ftp.dir, name.String()))
+
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (ftp *fsTablePersister) readIndex(ctx context.Context, name addr, chunkCount uint32) (index tableIndex, err error) {
+	f, err := os.Open(filepath.Join(ftp.dir, name.String()))
+
+	if err != nil {
+		return tableIndex{}, err
+	}
+
+	defer func() {
+		closeErr := f.Close()
+
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	return readTableFileIndex(f)
+}
+
+// deleteTableFiles removes the table files with the names given. The store must no longer read them, and they must no
+// longer be in the manifest.
+func (ftp *fsTablePersister) deleteTableFiles(ctx context.Context, names []addr) error {
+	// the cached file handles of the table files are closed first, as open files can't be removed on every platform
+	err := ftp.fc.ShrinkCache()
+
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		err = os.Remove(filepath.Join(ftp.dir, name.String()))
+
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (ftp *fsTablePersister) Open(ctx context.Context, name addr, chunkCount uint32, stats *Stats) (chunkSource, error) {
 	return newMmapTableReader(ftp.dir, name, chunkCount, ftp.indexCache, ftp.fc)
 }
@@ -129,7 +180,7 @@ func (ftp *fsTablePersister) persistTable(ctx context.Context, name addr, data [
 }
 
 func (ftp *fsTablePersister) ConjoinAll(ctx context.Context, sources chunkSources, stats *Stats) (chunkSource, error) {
-	plan, err := planConjoin(sources, stats)
+	plan, err := planConjoin(ctx, sources, stats)
 
 	if err != nil {
 		return emptyChunkSource{}, err
@@ -207,3 +258,59 @@ func (ftp *fsTablePersister) ConjoinAll(ctx context.Context, sources chunkSource
 
 	return ftp.Open(ctx, name, plan.chunkCount, stats)
 }
+
+func (ftp *fsTablePersister) writeTableFile(ctx context.Context, name addr, r io.Reader) error {
+	tempName, err := func() (tempName string, ferr error) {
+		var temp *os.File
+		temp, ferr = ioutil.TempFile(ftp.dir, tempTablePrefix)
+
+		if ferr != nil {
+			return "", ferr
+		}
+
+		defer func() {
+			closeErr := temp.Close()
+
+			if ferr == nil {
+				ferr = closeErr
+			}
+
+			if ferr != nil {
+				os.Remove(temp.Name())
+			}
+		}()
+
+		_, ferr = io.Copy(temp, r)
+
+		if ferr != nil {
+			return "", ferr
+		}
+
+		// a table file which was cut short or corrupted in transit won't have a valid index with the expected name
+		var index tableIndex
+		index, ferr = readTableFileIndex(temp)
+
+		if ferr != nil {
+			return "", ferr
+		}
+
+		if nameFromSuffixes(index.suffixes) != name {
+			return "", ErrInvalidTableFile
+		}
+
+		return temp.Name(), nil
+	}()
+
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tempName, filepath.Join(ftp.dir, name.String()))
+
+	if err != nil {
+		os.Remove(tempName)
+		return err
+	}
+
+	return nil
+}
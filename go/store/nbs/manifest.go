@@ -76,11 +76,19 @@ type manifestUpdater interface {
 	Update(ctx context.Context, lastLock addr, newContents manifestContents, stats *Stats, writeHook func() error) (manifestContents, error)
 }
 
+// exclusiveManifest is implemented by manifests which can be locked against use by other processes
+type exclusiveManifest interface {
+	// lockExclusive locks the manifest against use by other processes until unlock is called, returning ErrStoreInUse
+	// if another process is using it. The manifest returned is used in its place while it is locked.
+	lockExclusive() (m manifest, unlock func() error, err error)
+}
+
 // ManifestInfo is an interface for retrieving data from a manifest outside of this package
 type ManifestInfo interface {
 	GetVersion() string
 	GetLock() string
 	GetRoot() hash.Hash
+	GetEncryptionKeyID() string
 	NumTableSpecs() int
 	GetTableSpecInfo(i int) TableSpecInfo
 }
@@ -90,6 +98,9 @@ type manifestContents struct {
 	lock  addr
 	root  hash.Hash
 	specs []tableSpec
+
+	// keyID is the id of the EncryptionKey the store's table files are encrypted with, or empty if they are not
+	keyID string
 }
 
 func (mc manifestContents) GetVersion() string {
@@ -104,6 +115,10 @@ func (mc manifestContents) GetRoot() hash.Hash {
 	return mc.root
 }
 
+func (mc manifestContents) GetEncryptionKeyID() string {
+	return mc.keyID
+}
+
 func (mc manifestContents) NumTableSpecs() int {
 	return len(mc.specs)
 }
@@ -113,7 +128,7 @@ func (mc manifestContents) GetTableSpecInfo(i int) TableSpecInfo {
 }
 
 func (mc manifestContents) size() (size uint64) {
-	size += uint64(len(mc.vers)) + addrSize + hash.ByteLen
+	size += uint64(len(mc.vers)) + addrSize + hash.ByteLen + uint64(len(mc.keyID))
 	for _, sp := range mc.specs {
 		size += uint64(len(sp.name)) + uint32Size // for sp.chunkCount
 	}
@@ -286,6 +301,25 @@ func (mm manifestManager) Update(ctx context.Context, lastLock addr, newContents
 	return
 }
 
+// lockExclusive locks the manifest against use by other processes until unlock is called. Returns ErrStoreInUse if
+// another process is using it, or ErrCompactionUnsupported if the manifest can't be locked. The manifestManager returned
+// must be used in place of mm while the manifest is locked.
+func (mm manifestManager) lockExclusive() (locked manifestManager, unlock func() error, err error) {
+	em, ok := mm.m.(exclusiveManifest)
+
+	if !ok {
+		return manifestManager{}, nil, ErrCompactionUnsupported
+	}
+
+	m, unlock, err := em.lockExclusive()
+
+	if err != nil {
+		return manifestManager{}, nil, err
+	}
+
+	return manifestManager{m, mm.cache, mm.locks}, unlock, nil
+}
+
 func (mm manifestManager) Name() string {
 	return mm.m.Name()
 }
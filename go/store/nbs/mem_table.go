@@ -33,13 +33,17 @@ import (
 	"github.com/liquidata-inc/dolt/go/store/hash"
 )
 
-func WriteChunks(chunks []chunks.Chunk) (string, []byte, error) {
+// WriteChunks writes the chunks given to a table file compressed with the compression given, and encrypted with the key
+// given unless it is nil, and returns its name and contents.
+func WriteChunks(chunks []chunks.Chunk, compression ChunkCompression, key *EncryptionKey) (string, []byte, error) {
 	var size uint64
 	for _, chunk := range chunks {
 		size += uint64(len(chunk.Data()))
 	}
 
 	mt := newMemTable(size)
+	mt.compression = compression
+	mt.key = key
 
 	for _, chunk := range chunks {
 		if !mt.addChunk(addr(chunk.Hash()), chunk.Data()) {
@@ -66,7 +70,9 @@ type memTable struct {
 	order              []hasRecord // Must maintain the invariant that these are sorted by rec.order
 	maxData, totalData uint64
 
-	snapper snappyEncoder
+	encoder     chunkEncoder
+	compression ChunkCompression
+	key         *EncryptionKey
 }
 
 func newMemTable(memTableSize uint64) *memTable {
@@ -158,10 +164,6 @@ func (mt *memTable) extract(ctx context.Context, chunks chan<- extractRecord) er
 }
 
 func (mt *memTable) write(haver chunkReader, stats *Stats) (name addr, data []byte, count uint32, err error) {
-	maxSize := maxTableSize(uint64(len(mt.order)), mt.totalData)
-	buff := make([]byte, maxSize)
-	tw := newTableWriter(buff, mt.snapper)
-
 	if haver != nil {
 		sort.Sort(hasRecordByPrefix(mt.order)) // hasMany() requires addresses to be sorted.
 		_, err := haver.hasMany(mt.order)
@@ -173,10 +175,60 @@ func (mt *memTable) write(haver chunkReader, stats *Stats) (name addr, data []by
 		sort.Sort(hasRecordByOrder(mt.order)) // restore "insertion" order for write
 	}
 
+	maxSize := maxTableSize(uint64(len(mt.order)), mt.totalData)
+
+	if mt.key != nil {
+		maxSize += uint64(len(mt.order)) * encryptedRecordOverhead
+	}
+
+	var tw *tableWriter
+	var buff []byte
+	if mt.encoder == nil && (mt.compression != SnappyCompression || mt.key != nil) {
+		var novel [][]byte
+		for _, addr := range mt.order {
+			if !addr.has {
+				novel = append(novel, mt.chunks[*addr.a])
+			}
+		}
+
+		if len(novel) > 0 {
+			var encoder chunkEncoder = realSnappyEncoder{}
+			dicts := writeDictionaries(nil)
+
+			if mt.compression != SnappyCompression {
+				// a dictionary is trained on the chunks it compresses, so it isn't used for encrypted tables
+				ze, err := newZstdChunkEncoder(novel, mt.compression == ZstdDictCompression && mt.key == nil)
+
+				if err != nil {
+					return addr{}, nil, 0, err
+				}
+
+				encoder, dicts = ze, ze.dicts
+			}
+
+			// encrypted tables are written in the second version of the format so that versions of NBS which can't
+			// decrypt them don't try to read them
+			buff = make([]byte, maxSize+uint64(len(dicts)))
+			tw = newTableWriterV2(buff, encoder, dicts)
+		}
+	}
+
+	if tw == nil {
+		buff = make([]byte, maxSize)
+		tw = newTableWriter(buff, mt.encoder)
+	}
+
+	tw.key = mt.key
+
 	for _, addr := range mt.order {
 		if !addr.has {
 			h := addr.a
-			tw.addChunk(*h, mt.chunks[*h])
+			err := tw.addChunk(*h, mt.chunks[*h])
+
+			if err != nil {
+				return name, nil, 0, err
+			}
+
 			count++
 		}
 	}
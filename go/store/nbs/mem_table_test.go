@@ -55,7 +55,7 @@ func TestWriteChunks(t *testing.T) {
 		mustChunk(types.EncodeValue(types.String("knocking people’s hats off—then, I account it high time to get to sea as soon as I can."), types.Format_7_18)),
 	}
 
-	name, data, err := WriteChunks(chunks)
+	name, data, err := WriteChunks(chunks, SnappyCompression, nil)
 	if err != nil {
 		t.Error(err)
 	}
@@ -192,7 +192,7 @@ func TestMemTableSnappyWriteOutOfLine(t *testing.T) {
 	for _, c := range chunks {
 		assert.True(mt.addChunk(computeAddr(c), c))
 	}
-	mt.snapper = &outOfLineSnappy{[]bool{false, true, false}} // chunks[1] should trigger a panic
+	mt.encoder = &outOfLineSnappy{[]bool{false, true, false}} // chunks[1] should trigger a panic
 
 	assert.Panics(func() { mt.write(nil, &Stats{}) })
 }
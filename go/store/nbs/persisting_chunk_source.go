@@ -209,6 +209,20 @@ func (ccs *persistingChunkSource) reader(ctx context.Context) (io.Reader, error)
 	return ccs.cs.reader(ctx)
 }
 
+func (ccs *persistingChunkSource) dictionaries(ctx context.Context) ([]byte, error) {
+	err := ccs.wait()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ccs.cs == nil {
+		return nil, ErrNoChunkSource
+	}
+
+	return ccs.cs.dictionaries(ctx)
+}
+
 func (ccs *persistingChunkSource) calcReads(reqs []getRecord, blockSize uint64) (reads int, remaining bool, err error) {
 	err = ccs.wait()
 
@@ -275,6 +289,10 @@ func (ecs emptyChunkSource) reader(context.Context) (io.Reader, error) {
 	return &bytes.Buffer{}, nil
 }
 
+func (ecs emptyChunkSource) dictionaries(ctx context.Context) ([]byte, error) {
+	return nil, nil
+}
+
 func (ecs emptyChunkSource) calcReads(reqs []getRecord, blockSize uint64) (reads int, remaining bool, err error) {
 	return 0, true, nil
 }
@@ -84,8 +84,13 @@ type NomsBlockStore struct {
 	tables   tableSet
 	upstream manifestContents
 
-	mtSize   uint64
-	putCount uint64
+	mtSize      uint64
+	putCount    uint64
+	compression ChunkCompression
+	key         *EncryptionKey
+
+	// unlockUser releases the user lock held by stores on the local filesystem while they are open
+	unlockUser func() error
 
 	stats *Stats
 }
@@ -179,7 +184,129 @@ func (nbs *NomsBlockStore) GetChunkLocations(hashes hash.HashSet) (map[hash.Hash
 	return ranges, nil
 }
 
+// GetDictionariesRange returns the range of the dictionaries section of the table file with the name given, which
+// holds the zstd dictionaries needed to decompress chunks read from the ranges returned by GetChunkLocations. Returns
+// a Range with a Length of 0 if the table file has no dictionaries.
+func (nbs *NomsBlockStore) GetDictionariesRange(ctx context.Context, name hash.Hash) (Range, error) {
+	nbs.mu.RLock()
+	defer nbs.mu.RUnlock()
+
+	for _, css := range []chunkSources{nbs.tables.upstream, nbs.tables.novel} {
+		for _, cs := range css {
+			h, err := cs.hash()
+
+			if err != nil {
+				return Range{}, err
+			}
+
+			if h != addr(name) {
+				continue
+			}
+
+			dicts, err := cs.dictionaries(ctx)
+
+			if err != nil {
+				return Range{}, err
+			}
+
+			if !hasDictionaries(dicts) {
+				return Range{}, nil
+			}
+
+			index, err := cs.index()
+
+			if err != nil {
+				return Range{}, err
+			}
+
+			return Range{Offset: calcChunkDataLen(index), Length: uint32(len(dicts))}, nil
+		}
+	}
+
+	return Range{}, nil
+}
+
+// SetChunkCompression sets the compression of the chunks of table files written by the store. Table files which have
+// already been written are read as they are.
+func (nbs *NomsBlockStore) SetChunkCompression(compression ChunkCompression) {
+	nbs.mu.Lock()
+	defer nbs.mu.Unlock()
+
+	nbs.compression = compression
+	if nbs.mt != nil {
+		nbs.mt.compression = compression
+	}
+}
+
+// SetEncryptionKey sets the key which the chunks of table files written by the store are encrypted with, which is
+// recorded in the manifest the next time it is updated. Chunks are not compressed with trained dictionaries while a key
+// is set, as the dictionaries would hold chunk data unencrypted. Table files which have already been written are not
+// re-encrypted, so a key can only be set for a store which has no table files or whose table files are encrypted with
+// it. Returns ErrEncryptionKeyMismatch if the manifest records a different key, ErrEncryptionKeyRequired if key is nil
+// and the manifest records a key, or ErrTableFilesUnencrypted if the store has table files written without a key.
+func (nbs *NomsBlockStore) SetEncryptionKey(key *EncryptionKey) error {
+	nbs.mu.Lock()
+	defer nbs.mu.Unlock()
+
+	var keyID string
+	if key != nil {
+		keyID = key.ID()
+	}
+
+	err := checkManifestKeyID(nbs.upstream, keyID)
+
+	if err != nil {
+		return err
+	}
+
+	nbs.key = key
+	if nbs.mt != nil {
+		nbs.mt.key = key
+	}
+
+	return nil
+}
+
+// checkManifestKeyID returns an error if table files written with the key with the id given, or without a key if
+// keyID is empty, can't be added to the manifest given.
+func checkManifestKeyID(contents manifestContents, keyID string) error {
+	if contents.keyID == keyID {
+		return nil
+	} else if keyID == "" {
+		return ErrEncryptionKeyRequired
+	} else if contents.keyID != "" {
+		return ErrEncryptionKeyMismatch
+	} else if len(contents.specs) > 0 {
+		return ErrTableFilesUnencrypted
+	}
+
+	return nil
+}
+
+// manifestKeyID returns the id of the encryption key to record in a manifest replacing the one given
+func (nbs *NomsBlockStore) manifestKeyID(upstream manifestContents) string {
+	if nbs.key != nil {
+		return nbs.key.ID()
+	}
+
+	return upstream.keyID
+}
+
 func (nbs *NomsBlockStore) UpdateManifest(ctx context.Context, updates map[hash.Hash]uint32) (mi ManifestInfo, err error) {
+	return nbs.addTablesToManifest(ctx, updates, nil)
+}
+
+// UpdateManifestWithKeyID adds the table files given to the manifest, as UpdateManifest does, for table files which were
+// written elsewhere with the encryption key with the id given, or without a key if keyID is empty. The key id is
+// recorded in the manifest. Returns ErrEncryptionKeyMismatch, ErrEncryptionKeyRequired or ErrTableFilesUnencrypted if
+// the table files in the manifest were written with a different key.
+func (nbs *NomsBlockStore) UpdateManifestWithKeyID(ctx context.Context, updates map[hash.Hash]uint32, keyID string) (mi ManifestInfo, err error) {
+	return nbs.addTablesToManifest(ctx, updates, &keyID)
+}
+
+// addTablesToManifest adds the table files given to the manifest. If keyID is nil the tables were written by the store,
+// with its encryption key.
+func (nbs *NomsBlockStore) addTablesToManifest(ctx context.Context, updates map[hash.Hash]uint32, keyID *string) (mi ManifestInfo, err error) {
 	nbs.mm.LockForUpdate()
 	defer func() {
 		unlockErr := nbs.mm.UnlockForUpdate()
@@ -203,6 +330,9 @@ func (nbs *NomsBlockStore) UpdateManifest(ctx context.Context, updates map[hash.
 		contents = manifestContents{vers: nbs.upstream.vers}
 	}
 
+	// the key id is checked against the table files which were in the manifest before the update
+	prevContents := contents
+
 	currSpecs := make(map[addr]bool)
 	for _, spec := range contents.specs {
 		currSpecs[spec.name] = true
@@ -213,6 +343,14 @@ func (nbs *NomsBlockStore) UpdateManifest(ctx context.Context, updates map[hash.
 		a := addr(h)
 
 		if _, ok := currSpecs[a]; !ok {
+			// the manifest must not refer to a table file which is missing, such as one uploaded by an interrupted
+			// push and since deleted
+			err = nbs.checkTableFile(ctx, a, count)
+
+			if err != nil {
+				return manifestContents{}, err
+			}
+
 			addCount++
 			contents.specs = append(contents.specs, tableSpec{a, count})
 		}
@@ -222,19 +360,225 @@ func (nbs *NomsBlockStore) UpdateManifest(ctx context.Context, updates map[hash.
 		return contents, nil
 	}
 
+	if keyID != nil {
+		err = checkManifestKeyID(prevContents, *keyID)
+
+		if err != nil {
+			return manifestContents{}, err
+		}
+	}
+
+	lastLock := contents.lock
+	contents.lock = generateLockHash(contents.root, contents.specs)
+
+	if keyID != nil {
+		contents.keyID = *keyID
+	} else {
+		contents.keyID = nbs.manifestKeyID(contents)
+	}
+
 	var updatedContents manifestContents
-	updatedContents, err = nbs.mm.Update(ctx, contents.lock, contents, &stats, nil)
+	updatedContents, err = nbs.mm.Update(ctx, lastLock, contents, &stats, nil)
 
 	if err != nil {
 		return manifestContents{}, err
+	} else if updatedContents.lock != contents.lock {
+		return manifestContents{}, errOptimisticLockFailedTables
 	}
 
 	nbs.upstream = updatedContents
 	nbs.tables, err = nbs.tables.Rebase(ctx, contents.specs, nbs.stats)
 
+	if err != nil {
+		return manifestContents{}, err
+	}
+
 	return updatedContents, nil
 }
 
+// Checkpoint writes the chunks put in the store since it was last committed to table files, without adding them to
+// the manifest, and returns the names and chunk counts of the table files holding them. The chunks are not visible to
+// other instances of the store until they are committed, but the table files can be added to a later instance with
+// AddNovelTables so that a write interrupted before it was committed can be resumed.
+func (nbs *NomsBlockStore) Checkpoint(ctx context.Context) (map[hash.Hash]uint32, error) {
+	nbs.mu.Lock()
+	defer nbs.mu.Unlock()
+
+	if nbs.mt != nil {
+		cnt, err := nbs.mt.count()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if cnt > 0 {
+			nbs.tables = nbs.tables.Prepend(ctx, nbs.mt, nbs.stats)
+		}
+
+		nbs.mt = nil
+	}
+
+	tables := make(map[hash.Hash]uint32)
+	for _, src := range nbs.tables.novel {
+		cnt, err := src.count()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if cnt == 0 {
+			continue
+		}
+
+		h, err := src.hash()
+
+		if err != nil {
+			return nil, err
+		}
+
+		tables[hash.Hash(h)] = cnt
+	}
+
+	return tables, nil
+}
+
+// AddNovelTables opens table files written by an earlier call to Checkpoint and adds them to the tables the next
+// Commit adds to the manifest.
+func (nbs *NomsBlockStore) AddNovelTables(ctx context.Context, tables map[hash.Hash]uint32) error {
+	nbs.mu.Lock()
+	defer nbs.mu.Unlock()
+
+	specs, err := nbs.tables.ToSpecs()
+
+	if err != nil {
+		return err
+	}
+
+	present := make(map[addr]bool)
+	for _, spec := range specs {
+		present[spec.name] = true
+	}
+
+	var novel chunkSources
+	for h, cnt := range tables {
+		if present[addr(h)] {
+			continue
+		}
+
+		err = nbs.checkTableFile(ctx, addr(h), cnt)
+
+		if err != nil {
+			return err
+		}
+
+		src, err := nbs.p.Open(ctx, addr(h), cnt, nbs.stats)
+
+		if err != nil {
+			return err
+		}
+
+		novel = append(novel, src)
+	}
+
+	nbs.tables = nbs.tables.AppendNovel(novel)
+
+	return nil
+}
+
+// checkTableFile returns an error if the persister of the store does not have the table file named, or if the table
+// file's index does not parse, hold chunkCount chunks, or match its name. Table files uploaded by clients are checked
+// before they are added to the manifest.
+func (nbs *NomsBlockStore) checkTableFile(ctx context.Context, name addr, chunkCount uint32) error {
+	checker, ok := nbs.p.(tableFileChecker)
+
+	if !ok {
+		return nil
+	}
+
+	exists, err := checker.exists(ctx, name)
+
+	if err != nil {
+		return err
+	} else if !exists {
+		return fmt.Errorf("table file %s not found", name.String())
+	}
+
+	index, err := checker.readIndex(ctx, name, chunkCount)
+
+	if err != nil {
+		return err
+	} else if index.chunkCount != chunkCount || nameFromSuffixes(index.suffixes) != name {
+		return ErrInvalidTableFile
+	}
+
+	return nil
+}
+
+// TableFiles returns the table files in the manifest of the store.
+func (nbs *NomsBlockStore) TableFiles(ctx context.Context) ([]TableFileSource, error) {
+	nbs.mu.Lock()
+	defer nbs.mu.Unlock()
+
+	tfs := make([]TableFileSource, 0, len(nbs.tables.upstream))
+	for _, cs := range nbs.tables.upstream {
+		cnt, err := cs.count()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if cnt == 0 {
+			continue
+		}
+
+		h, err := cs.hash()
+
+		if err != nil {
+			return nil, err
+		}
+
+		tfs = append(tfs, chunkSourceTableFile{cs, hash.Hash(h), cnt})
+	}
+
+	return tfs, nil
+}
+
+// WriteTableFile writes the table file given to the store, without adding it to the manifest. Table files which are
+// cut short or corrupted are not written. Returns ErrTableFileWritesUnsupported if the store's table files can't be
+// written directly, or if the store has an encryption key, as table files from elsewhere may not be encrypted with it.
+func (nbs *NomsBlockStore) WriteTableFile(ctx context.Context, src TableFileSource) error {
+	tfw, ok := nbs.p.(tableFileWriter)
+
+	if !ok {
+		return ErrTableFileWritesUnsupported
+	}
+
+	nbs.mu.RLock()
+	encrypted := nbs.key != nil
+	nbs.mu.RUnlock()
+
+	if encrypted {
+		return ErrTableFileWritesUnsupported
+	}
+
+	rd, _, err := src.Open(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer rd.Close()
+
+	return tfw.writeTableFile(ctx, addr(src.Name()), rd)
+}
+
+// AddTableFiles adds table files written with WriteTableFile to the manifest, keyed by name with their chunk counts.
+// The root of the store is not changed.
+func (nbs *NomsBlockStore) AddTableFiles(ctx context.Context, tables map[hash.Hash]uint32) error {
+	_, err := nbs.UpdateManifest(ctx, tables)
+	return err
+}
+
 func NewAWSStore(ctx context.Context, nbfVerStr string, table, ns, bucket string, s3 s3svc, ddb ddbsvc, memTableSize uint64) (*NomsBlockStore, error) {
 	cacheOnce.Do(makeGlobalCaches)
 	readRateLimiter := make(chan struct{}, 32)
@@ -258,6 +602,13 @@ func NewGCSStore(ctx context.Context, nbfVerStr string, bucketName, path string,
 
 	bucket := gcs.Bucket(bucketName)
 	bs := blobstore.NewGCSBlobstore(bucket, path)
+	return NewBSStore(ctx, nbfVerStr, bs, memTableSize)
+}
+
+// NewBSStore returns an nbs implementation whose manifest and table files are stored in the Blobstore given
+func NewBSStore(ctx context.Context, nbfVerStr string, bs blobstore.Blobstore, memTableSize uint64) (*NomsBlockStore, error) {
+	cacheOnce.Do(makeGlobalCaches)
+
 	mm := makeManifestManager(blobstoreManifest{"manifest", bs})
 
 	p := &blobstorePersister{bs, s3BlockSize, globalIndexCache}
@@ -272,9 +623,24 @@ func NewLocalStore(ctx context.Context, nbfVerStr string, dir string, memTableSi
 		return nil, err
 	}
 
-	mm := makeManifestManager(fileManifest{dir})
+	userLock, unlockUser, err := lockStoreUser(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mm := makeManifestManager(fileManifest{dir, userLock})
 	p := newFSTablePersister(dir, globalFDCache, globalIndexCache)
-	return newNomsBlockStore(ctx, nbfVerStr, mm, p, inlineConjoiner{defaultMaxTables}, memTableSize)
+	nbs, err := newNomsBlockStore(ctx, nbfVerStr, mm, p, inlineConjoiner{defaultMaxTables}, memTableSize)
+
+	if err != nil {
+		// the error of opening the store is returned rather than that of releasing the user lock
+		unlockUser()
+		return nil, err
+	}
+
+	nbs.unlockUser = unlockUser
+	return nbs, nil
 }
 
 func checkDir(dir string) error {
@@ -345,10 +711,14 @@ func (nbs *NomsBlockStore) addChunk(ctx context.Context, h addr, data []byte) bo
 	defer nbs.mu.Unlock()
 	if nbs.mt == nil {
 		nbs.mt = newMemTable(nbs.mtSize)
+		nbs.mt.compression = nbs.compression
+		nbs.mt.key = nbs.key
 	}
 	if !nbs.mt.addChunk(h, data) {
 		nbs.tables = nbs.tables.Prepend(ctx, nbs.mt, nbs.stats)
 		nbs.mt = newMemTable(nbs.mtSize)
+		nbs.mt.compression = nbs.compression
+		nbs.mt.key = nbs.key
 		return nbs.mt.addChunk(h, data)
 	}
 	return true
@@ -609,9 +979,14 @@ func toHasRecords(hashes hash.HashSet) []hasRecord {
 }
 
 func (nbs *NomsBlockStore) Rebase(ctx context.Context) error {
+	return nbs.rebase(ctx, nbs.mm)
+}
+
+// rebase reads the manifest with the manifestManager given, which is nbs.mm unless the manifest is locked
+func (nbs *NomsBlockStore) rebase(ctx context.Context, mm manifestManager) error {
 	nbs.mu.Lock()
 	defer nbs.mu.Unlock()
-	exists, contents, err := nbs.mm.Fetch(ctx, nbs.stats)
+	exists, contents, err := mm.Fetch(ctx, nbs.stats)
 
 	if err != nil {
 		return err
@@ -782,6 +1157,7 @@ func (nbs *NomsBlockStore) updateManifest(ctx context.Context, current, last has
 		root:  current,
 		lock:  generateLockHash(current, specs),
 		specs: specs,
+		keyID: nbs.manifestKeyID(nbs.upstream),
 	}
 
 	upstream, err := nbs.mm.Update(ctx, nbs.upstream.lock, newContents, nbs.stats, nil)
@@ -809,6 +1185,11 @@ func (nbs *NomsBlockStore) Version() string {
 }
 
 func (nbs *NomsBlockStore) Close() (err error) {
+	if nbs.unlockUser != nil {
+		err = nbs.unlockUser()
+		nbs.unlockUser = nil
+	}
+
 	return
 }
 
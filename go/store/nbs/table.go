@@ -100,6 +100,26 @@ import (
 
     NOTE: Unsigned integer quanities, hashes and hash suffix are all encoded big-endian
 
+  Table Format Versions
+  Tables in the format above hold only chunk data compressed with snappy. Tables in the second version of the format
+  may also hold chunk data compressed with zstd, and are followed by the zstd dictionaries used to compress it:
+
+   +----------------+-----+----------------+--------------+-------+--------+
+   | Chunk Record 0 | ... | Chunk Record N | Dictionaries | Index | Footer |
+   +----------------+-----+----------------+--------------+-------+--------+
+
+   Dictionaries:
+   +----------------------------+---------------------------+-------------------+-----+-------------------+--------------+-----+--------------+
+   | (Uint32) Dictionaries Size | (Uint32) Dictionary Count | (Uint32) Length 0 | ... | (Uint32) Length M | Dictionary 0 | ... | Dictionary M |
+   +----------------------------+---------------------------+-------------------+-----+-------------------+--------------+-----+--------------+
+
+     -Dictionaries Size is the size in bytes of the whole Dictionaries section, which may hold no dictionaries.
+     -Each Length is the length of the Dictionary at the same position.
+     -Chunk data compressed with zstd is a single zstd frame, which refers to the dictionary it was compressed with by
+      the dictionary's id. Chunk data is compressed with zstd iff it begins with the zstd frame magic number.
+     -The Magic Number of the second version is the first 8 bytes of the SHA256 hash of
+      "https://github.com/liquidata-inc/dolt/nbs/v2".
+
 
   Looking up Chunks in an NBS Table
   There are two phases to loading chunk data for a given Hash from an NBS Table: Checking for the chunk's presence, and fetching the chunk's bytes. When performing a has-check, only the first phase is necessary.
@@ -131,6 +151,7 @@ const (
 	ordinalSize     = uint32Size
 	lengthSize      = uint32Size
 	magicNumber     = "\xff\xb5\xd8\xc2\x24\x63\xee\x50"
+	magicNumberV2   = "\x75\xe8\xdc\xcd\x72\xe6\x16\x23"
 	magicNumberSize = 8 //len(magicNumber)
 	footerSize      = uint32Size + uint64Size + magicNumberSize
 	prefixTupleSize = addrPrefixSize + ordinalSize
@@ -254,6 +275,10 @@ type chunkSource interface {
 	// opens a Reader to the first byte of the chunkData segment of this table.
 	reader(context.Context) (io.Reader, error)
 	index() (tableIndex, error)
+
+	// returns the dictionaries section of this table, which follows its chunkData segment. Returns nil if the table
+	// has no dictionaries section.
+	dictionaries(ctx context.Context) ([]byte, error)
 }
 
 type chunkSources []chunkSource
@@ -0,0 +1,136 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// TableFile reads the chunks of a single table file, such as one written with the data returned by WriteChunks,
+// outside of any NomsBlockStore.
+type TableFile struct {
+	tr    tableReader
+	f     *os.File
+	stats *Stats
+}
+
+// OpenTableFile opens the table file at the path given and reads its index. The file is held open until Close is
+// called.
+func OpenTableFile(path string) (*TableFile, error) {
+	f, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := readTableFileIndex(f)
+
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &TableFile{newTableReader(index, &fileReaderAt{f}, fileBlockSize), f, NewStats()}, nil
+}
+
+func readTableFileIndex(f *os.File) (tableIndex, error) {
+	fi, err := f.Stat()
+
+	if err != nil {
+		return tableIndex{}, err
+	}
+
+	size := fi.Size()
+	if size < footerSize {
+		return tableIndex{}, ErrInvalidTableFile
+	}
+
+	footer := make([]byte, footerSize)
+	_, err = f.ReadAt(footer, size-footerSize)
+
+	if err != nil {
+		return tableIndex{}, err
+	}
+
+	chunkCount := binary.BigEndian.Uint32(footer)
+	indexLen := int64(indexSize(chunkCount)) + footerSize
+
+	if indexLen > size {
+		return tableIndex{}, ErrInvalidTableFile
+	}
+
+	buff := make([]byte, indexLen)
+	_, err = f.ReadAt(buff, size-indexLen)
+
+	if err != nil {
+		return tableIndex{}, err
+	}
+
+	return parseTableIndex(buff)
+}
+
+// Count returns the number of chunks in the table file.
+func (tf *TableFile) Count() uint32 {
+	return tf.tr.chunkCount
+}
+
+// Hashes returns the hashes of the chunks in the table file.
+func (tf *TableFile) Hashes() hash.HashSlice {
+	hashes := make(hash.HashSlice, tf.tr.chunkCount)
+	for idx, prefix := range tf.tr.prefixes {
+		ordinal := tf.tr.prefixIdxToOrdinal(uint32(idx))
+		binary.BigEndian.PutUint64(hashes[ordinal][:], prefix)
+		li := uint64(ordinal) * addrSuffixSize
+		copy(hashes[ordinal][addrPrefixSize:], tf.tr.suffixes[li:li+addrSuffixSize])
+	}
+
+	return hashes
+}
+
+// Has returns whether the table file contains the chunk with the hash given.
+func (tf *TableFile) Has(h hash.Hash) (bool, error) {
+	return tf.tr.has(addr(h))
+}
+
+// Get returns the chunk with the hash given, or the empty chunk if the table file doesn't contain it.
+func (tf *TableFile) Get(ctx context.Context, h hash.Hash) (chunks.Chunk, error) {
+	data, err := tf.tr.get(ctx, addr(h), tf.stats)
+
+	if err != nil {
+		return chunks.EmptyChunk, err
+	} else if data == nil {
+		return chunks.EmptyChunk, nil
+	}
+
+	return chunks.NewChunkWithHash(h, data), nil
+}
+
+// Close closes the table file.
+func (tf *TableFile) Close() error {
+	return tf.f.Close()
+}
+
+type fileReaderAt struct {
+	f *os.File
+}
+
+func (fra *fileReaderAt) ReadAtWithStats(ctx context.Context, p []byte, off int64, stats *Stats) (int, error) {
+	return fra.f.ReadAt(p, off)
+}
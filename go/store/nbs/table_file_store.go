@@ -0,0 +1,96 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+// ErrTableFileWritesUnsupported is returned when writing a table file to a store whose table files can't be written
+// directly.
+var ErrTableFileWritesUnsupported = errors.New("the store does not support writing table files")
+
+// TableFileSource is a table file of a TableFileStore which can be read in full, so that it can be copied to another
+// store without reading its chunks.
+type TableFileSource interface {
+	// Name returns the name of the table file, which is the hash of its contents.
+	Name() hash.Hash
+
+	// ChunkCount returns the number of chunks in the table file.
+	ChunkCount() uint32
+
+	// Open returns a reader of the whole table file, along with its size in bytes.
+	Open(ctx context.Context) (io.ReadCloser, uint64, error)
+}
+
+// TableFileStore is a store whose table files can be listed and copied to and from other stores.
+type TableFileStore interface {
+	// TableFiles returns the table files in the manifest of the store.
+	TableFiles(ctx context.Context) ([]TableFileSource, error)
+
+	// WriteTableFile writes the table file given to the store, without adding it to the manifest.
+	WriteTableFile(ctx context.Context, src TableFileSource) error
+
+	// AddTableFiles adds table files written with WriteTableFile to the manifest, keyed by name with their chunk
+	// counts. The root of the store is not changed.
+	AddTableFiles(ctx context.Context, tables map[hash.Hash]uint32) error
+}
+
+// tableFileWriter is implemented by tablePersisters which can write whole table files read from another store
+type tableFileWriter interface {
+	writeTableFile(ctx context.Context, name addr, r io.Reader) error
+}
+
+type chunkSourceTableFile struct {
+	cs    chunkSource
+	name  hash.Hash
+	count uint32
+}
+
+func (tf chunkSourceTableFile) Name() hash.Hash {
+	return tf.name
+}
+
+func (tf chunkSourceTableFile) ChunkCount() uint32 {
+	return tf.count
+}
+
+func (tf chunkSourceTableFile) Open(ctx context.Context) (io.ReadCloser, uint64, error) {
+	index, err := tf.cs.index()
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	dicts, err := tf.cs.dictionaries(ctx)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rd, err := tf.cs.reader(ctx)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	size := index.tableFileSize(dicts)
+	return ioutil.NopCloser(io.LimitReader(rd, int64(size))), size, nil
+}
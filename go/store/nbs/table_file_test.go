@@ -0,0 +1,80 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/liquidata-inc/dolt/go/store/chunks"
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+func TestOpenTableFile(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	chnks := []chunks.Chunk{
+		chunks.NewChunk([]byte("abc")),
+		chunks.NewChunk([]byte("def")),
+		chunks.NewChunk([]byte("ghi")),
+	}
+
+	name, data, err := WriteChunks(chnks, SnappyCompression, nil)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, data, os.ModePerm))
+
+	tf, err := OpenTableFile(path)
+	require.NoError(t, err)
+	defer tf.Close()
+
+	assert.Equal(t, uint32(len(chnks)), tf.Count())
+	assert.ElementsMatch(t, hash.HashSlice{chnks[0].Hash(), chnks[1].Hash(), chnks[2].Hash()}, tf.Hashes())
+
+	for _, c := range chnks {
+		ok, err := tf.Has(c.Hash())
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		read, err := tf.Get(ctx, c.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, c.Data(), read.Data())
+	}
+
+	missing := chunks.NewChunk([]byte("jkl"))
+	ok, err := tf.Has(missing.Hash())
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	read, err := tf.Get(ctx, missing.Hash())
+	require.NoError(t, err)
+	assert.True(t, read.IsEmpty())
+
+	invalid := filepath.Join(dir, "invalid")
+	require.NoError(t, ioutil.WriteFile(invalid, []byte("not a table file"), os.ModePerm))
+
+	_, err = OpenTableFile(invalid)
+	assert.Equal(t, ErrInvalidTableFile, err)
+}
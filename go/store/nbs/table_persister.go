@@ -52,6 +52,19 @@ type tablePersister interface {
 	Open(ctx context.Context, name addr, chunkCount uint32, stats *Stats) (chunkSource, error)
 }
 
+// tableFileChecker is implemented by tablePersisters which can check that a table file exists, and read its index,
+// without relying on the index cache, which may hold the index of a table file which has since been deleted or
+// replaced.
+type tableFileChecker interface {
+	exists(ctx context.Context, name addr) (bool, error)
+	readIndex(ctx context.Context, name addr, chunkCount uint32) (tableIndex, error)
+}
+
+// tableFileDeleter is implemented by tablePersisters which can delete table files which are no longer in the manifest
+type tableFileDeleter interface {
+	deleteTableFiles(ctx context.Context, names []addr) error
+}
+
 // indexCache provides sized storage for table indices. While getting and/or
 // setting the cache entry for a given table name, the caller MUST hold the
 // lock that for that entry.
@@ -198,19 +211,24 @@ type sourceWithSize struct {
 }
 
 type compactionPlan struct {
-	sources             chunkSourcesByDescendingDataSize
+	sources chunkSourcesByDescendingDataSize
+
+	// mergedIndex holds everything written after the chunk data of the sources: the dictionaries section, if the
+	// conjoined table is in the second version of the format, and then the index and footer
 	mergedIndex         []byte
+	dictsLen            uint64
 	chunkCount          uint32
 	totalCompressedData uint64
 }
 
 func (cp compactionPlan) suffixes() []byte {
-	suffixesStart := uint64(cp.chunkCount) * (prefixTupleSize + lengthSize)
+	suffixesStart := cp.dictsLen + uint64(cp.chunkCount)*(prefixTupleSize+lengthSize)
 	return cp.mergedIndex[suffixesStart : suffixesStart+uint64(cp.chunkCount)*addrSuffixSize]
 }
 
-func planConjoin(sources chunkSources, stats *Stats) (plan compactionPlan, err error) {
+func planConjoin(ctx context.Context, sources chunkSources, stats *Stats) (plan compactionPlan, err error) {
 	var totalUncompressedData uint64
+	format := tableFormatV1
 	for _, src := range sources {
 		var uncmp uint64
 		uncmp, err = src.uncompressedLen()
@@ -228,6 +246,10 @@ func planConjoin(sources chunkSources, stats *Stats) (plan compactionPlan, err e
 
 		plan.chunkCount += index.chunkCount
 
+		if index.format == tableFormatV2 {
+			format = tableFormatV2
+		}
+
 		// Calculate the amount of chunk data in |src|
 		chunkDataLen := calcChunkDataLen(index)
 		plan.sources.sws = append(plan.sources.sws, sourceWithSize{src, chunkDataLen})
@@ -239,9 +261,31 @@ func planConjoin(sources chunkSources, stats *Stats) (plan compactionPlan, err e
 		return compactionPlan{}, plan.sources.err
 	}
 
-	lengthsPos := lengthsOffset(plan.chunkCount)
-	suffixesPos := suffixesOffset(plan.chunkCount)
-	plan.mergedIndex = make([]byte, indexSize(plan.chunkCount)+footerSize)
+	// the conjoined table holds the chunks of any source in the second version of the format as they are, so it must
+	// also hold the dictionaries they were compressed with
+	var dicts []byte
+	if format == tableFormatV2 {
+		sections := make([][]byte, len(plan.sources.sws))
+		for i, sws := range plan.sources.sws {
+			sections[i], err = sws.source.dictionaries(ctx)
+
+			if err != nil {
+				return compactionPlan{}, err
+			}
+		}
+
+		dicts, err = mergeDictionaries(sections)
+
+		if err != nil {
+			return compactionPlan{}, err
+		}
+	}
+
+	plan.dictsLen = uint64(len(dicts))
+	lengthsPos := plan.dictsLen + lengthsOffset(plan.chunkCount)
+	suffixesPos := plan.dictsLen + suffixesOffset(plan.chunkCount)
+	plan.mergedIndex = make([]byte, plan.dictsLen+indexSize(plan.chunkCount)+footerSize)
+	copy(plan.mergedIndex, dicts)
 
 	prefixIndexRecs := make(prefixIndexSlice, 0, plan.chunkCount)
 	var ordinalOffset uint32
@@ -287,7 +331,7 @@ func planConjoin(sources chunkSources, stats *Stats) (plan compactionPlan, err e
 
 	// Sort all prefixTuples by hash and then insert them starting at the beginning of plan.mergedIndex
 	sort.Sort(prefixIndexRecs)
-	var pfxPos uint64
+	pfxPos := plan.dictsLen
 	for _, pi := range prefixIndexRecs {
 		binary.BigEndian.PutUint64(plan.mergedIndex[pfxPos:], pi.prefix)
 		pfxPos += addrPrefixSize
@@ -295,7 +339,7 @@ func planConjoin(sources chunkSources, stats *Stats) (plan compactionPlan, err e
 		pfxPos += ordinalSize
 	}
 
-	writeFooter(plan.mergedIndex[uint64(len(plan.mergedIndex))-footerSize:], plan.chunkCount, totalUncompressedData)
+	writeFooter(plan.mergedIndex[uint64(len(plan.mergedIndex))-footerSize:], format, plan.chunkCount, totalUncompressedData)
 
 	stats.BytesPerConjoin.Sample(uint64(plan.totalCompressedData) + uint64(len(plan.mergedIndex)))
 	return plan, nil
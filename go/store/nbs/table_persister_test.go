@@ -22,6 +22,7 @@
 package nbs
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -51,7 +52,7 @@ func TestPlanCompaction(t *testing.T) {
 		sources = append(sources, src)
 	}
 
-	plan, err := planConjoin(sources, &Stats{})
+	plan, err := planConjoin(context.Background(), sources, &Stats{})
 	assert.NoError(err)
 
 	var totalChunks uint32
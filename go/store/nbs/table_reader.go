@@ -30,8 +30,6 @@ import (
 	"sort"
 	"sync"
 
-	"github.com/golang/snappy"
-
 	"github.com/liquidata-inc/dolt/go/store/atomicerr"
 	"github.com/liquidata-inc/dolt/go/store/chunks"
 	"github.com/liquidata-inc/dolt/go/store/hash"
@@ -40,6 +38,7 @@ import (
 var ErrInvalidTableFile = errors.New("invalid or corrupt table file")
 
 type tableIndex struct {
+	format                tableFormat
 	chunkCount            uint32
 	totalUncompressedData uint64
 	prefixes, offsets     []uint64
@@ -60,6 +59,16 @@ type tableReader struct {
 	tableIndex
 	r         tableReaderAt
 	blockSize uint64
+	dicts     *tableDictionaries
+}
+
+// tableDictionaries holds the zstd dictionaries of a table, which are read the first time a chunk compressed with
+// zstd is read from the table. They are read again by the next read if reading them fails.
+type tableDictionaries struct {
+	mu      sync.Mutex
+	loaded  bool
+	section []byte
+	decomp  *ChunkDecompressor
 }
 
 // parses a valid nbs tableIndex from a byte stream. |buff| must end with an NBS index
@@ -71,7 +80,17 @@ func parseTableIndex(buff []byte) (tableIndex, error) {
 	// footer
 	pos -= magicNumberSize
 
-	if string(buff[pos:]) != magicNumber {
+	if pos < 0 {
+		return tableIndex{}, ErrInvalidTableFile
+	}
+
+	var format tableFormat
+	switch string(buff[pos:]) {
+	case magicNumber:
+		format = tableFormatV1
+	case magicNumberV2:
+		format = tableFormatV2
+	default:
 		return tableIndex{}, ErrInvalidTableFile
 	}
 
@@ -122,6 +141,7 @@ func parseTableIndex(buff []byte) (tableIndex, error) {
 	prefixes, ordinals := computePrefixes(chunkCount, buff[pos:pos+tuplesSize])
 
 	return tableIndex{
+		format,
 		chunkCount, totalUncompressedData,
 		prefixes, offsets,
 		lengths, ordinals,
@@ -129,6 +149,16 @@ func parseTableIndex(buff []byte) (tableIndex, error) {
 	}, nil
 }
 
+// tableFileSize returns the size of the table file the index was read from, given its dictionaries section
+func (ti tableIndex) tableFileSize(dicts []byte) uint64 {
+	var dataLen uint64
+	for _, l := range ti.lengths {
+		dataLen += uint64(l)
+	}
+
+	return dataLen + uint64(len(dicts)) + indexSize(ti.chunkCount) + footerSize
+}
+
 func computeOffsets(count uint32, buff []byte) (lengths []uint32, offsets []uint64) {
 	lengths = make([]uint32, count)
 	offsets = make([]uint64, count)
@@ -201,7 +231,53 @@ func (ti tableIndex) lookupOrdinal(h addr) uint32 {
 // and footer, though it may contain an unspecified number of bytes before that data. r should allow
 // retrieving any desired range of bytes from the table.
 func newTableReader(index tableIndex, r tableReaderAt, blockSize uint64) tableReader {
-	return tableReader{index, r, blockSize}
+	return tableReader{index, r, blockSize, &tableDictionaries{}}
+}
+
+func (tr tableReader) loadDictionaries(ctx context.Context) (*tableDictionaries, error) {
+	if tr.dicts == nil {
+		return nil, errors.New("table reader has no dictionaries")
+	}
+
+	tr.dicts.mu.Lock()
+	defer tr.dicts.mu.Unlock()
+
+	if tr.dicts.loaded {
+		return tr.dicts, nil
+	}
+
+	section, err := readDictionaries(ctx, tr.r, tr.tableIndex, &Stats{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	decomp, err := NewChunkDecompressor(section)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tr.dicts.section = section
+	tr.dicts.decomp = decomp
+	tr.dicts.loaded = true
+
+	return tr.dicts, nil
+}
+
+// dictionaries returns the dictionaries section of the table, which is nil for tables in the original format
+func (tr tableReader) dictionaries(ctx context.Context) ([]byte, error) {
+	if tr.format != tableFormatV2 {
+		return nil, nil
+	}
+
+	dicts, err := tr.loadDictionaries(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return dicts.section, nil
 }
 
 // Scan across (logically) two ordered slices of address prefixes.
@@ -298,7 +374,7 @@ func (tr tableReader) get(ctx context.Context, h addr, stats *Stats) ([]byte, er
 		return nil, errors.New("failed to read all data")
 	}
 
-	data, err := tr.parseChunk(buff)
+	data, err := tr.parseChunk(ctx, h, buff)
 
 	if err != nil {
 		return nil, err
@@ -356,7 +432,7 @@ func (tr tableReader) readAtOffsets(
 			return errors.New("length goes past the end")
 		}
 
-		data, err := tr.parseChunk(buff[localStart:localEnd])
+		data, err := tr.parseChunk(ctx, *rec.a, buff[localStart:localEnd])
 
 		if err != nil {
 			return err
@@ -516,7 +592,7 @@ func canReadAhead(fRec offsetRec, fLength uint32, readStart, readEnd, blockSize
 }
 
 // Fetches the byte stream of data logically encoded within the table starting at |pos|.
-func (tr tableReader) parseChunk(buff []byte) ([]byte, error) {
+func (tr tableReader) parseChunk(ctx context.Context, h addr, buff []byte) ([]byte, error) {
 	dataLen := uint64(len(buff)) - checksumSize
 
 	chksum := binary.BigEndian.Uint32(buff[dataLen:])
@@ -525,7 +601,24 @@ func (tr tableReader) parseChunk(buff []byte) ([]byte, error) {
 		return nil, errors.New("checksum error")
 	}
 
-	data, err := snappy.Decode(nil, buff[:dataLen])
+	compressed, err := decryptRecord(h, buff[:dataLen])
+
+	if err != nil {
+		return nil, err
+	}
+
+	var decomp *ChunkDecompressor
+	if isZstdFrame(compressed) && tr.format == tableFormatV2 {
+		dicts, err := tr.loadDictionaries(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		decomp = dicts.decomp
+	}
+
+	data, err := decomp.decompress(compressed)
 
 	if err != nil {
 		return nil, errors.New("decode error - likely corrupt data")
@@ -596,7 +689,7 @@ func (tr tableReader) extract(ctx context.Context, chunks chan<- extractRecord)
 
 	sendChunk := func(i uint32) error {
 		localOffset := tr.offsets[i] - tr.offsets[0]
-		data, err := tr.parseChunk(buff[localOffset : localOffset+uint64(tr.lengths[i])])
+		data, err := tr.parseChunk(ctx, hashes[i], buff[localOffset:localOffset+uint64(tr.lengths[i])])
 
 		if err != nil {
 			return err
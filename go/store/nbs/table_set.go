@@ -324,6 +324,21 @@ func (ts tableSet) Prepend(ctx context.Context, mt *memTable, stats *Stats) tabl
 	return newTs
 }
 
+// AppendNovel returns a new tableSet holding the novel and upstream tables of |ts| along with |novel| as additional
+// novel tables.
+func (ts tableSet) AppendNovel(novel chunkSources) tableSet {
+	newTs := tableSet{
+		novel:    make(chunkSources, 0, len(ts.novel)+len(novel)),
+		upstream: make(chunkSources, len(ts.upstream)),
+		p:        ts.p,
+		rl:       ts.rl,
+	}
+	newTs.novel = append(newTs.novel, ts.novel...)
+	newTs.novel = append(newTs.novel, novel...)
+	copy(newTs.upstream, ts.upstream)
+	return newTs
+}
+
 func (ts tableSet) extract(ctx context.Context, chunks chan<- extractRecord) error {
 	// Since new tables are _prepended_ to a tableSet, extracting chunks in insertOrder requires iterating ts.upstream back to front, followed by ts.novel.
 	for i := len(ts.upstream) - 1; i >= 0; i-- {
@@ -43,10 +43,18 @@ type tableWriter struct {
 	prefixes              prefixIndexSlice // TODO: This is in danger of exploding memory
 	blockHash             hash.Hash
 
-	snapper snappyEncoder
+	encoder chunkEncoder
+	format  tableFormat
+
+	// dicts is the dictionaries section written between the chunk records and the index of tables in the second
+	// version of the format
+	dicts []byte
+
+	// key encrypts the chunk records if it is not nil
+	key *EncryptionKey
 }
 
-type snappyEncoder interface {
+type chunkEncoder interface {
 	Encode(dst, src []byte) []byte
 }
 
@@ -59,6 +67,8 @@ func (r realSnappyEncoder) Encode(dst, src []byte) []byte {
 func maxTableSize(numChunks, totalData uint64) uint64 {
 	avgChunkSize := totalData / numChunks
 	d.Chk.True(avgChunkSize < maxChunkSize)
+	// the bound snappy gives on the length of compressed chunk data also bounds the length of chunk data compressed
+	// with zstd
 	maxSnappySize := snappy.MaxEncodedLen(int(avgChunkSize))
 	d.Chk.True(maxSnappySize > 0)
 	return numChunks*(prefixTupleSize+lengthSize+addrSuffixSize+checksumSize+uint64(maxSnappySize)) + footerSize
@@ -77,35 +87,63 @@ func suffixesOffset(numChunks uint32) uint64 {
 }
 
 // len(buff) must be >= maxTableSize(numChunks, totalData)
-func newTableWriter(buff []byte, snapper snappyEncoder) *tableWriter {
-	if snapper == nil {
-		snapper = realSnappyEncoder{}
+func newTableWriter(buff []byte, encoder chunkEncoder) *tableWriter {
+	if encoder == nil {
+		encoder = realSnappyEncoder{}
 	}
 	return &tableWriter{
 		buff:      buff,
 		blockHash: sha512.New(),
-		snapper:   snapper,
+		encoder:   encoder,
+		format:    tableFormatV1,
 	}
 }
 
-func (tw *tableWriter) addChunk(h addr, data []byte) bool {
+// newTableWriterV2 returns a tableWriter which writes tables in the second version of the format, with the
+// dictionaries section given. len(buff) must be >= maxTableSize(numChunks, totalData) + len(dicts)
+func newTableWriterV2(buff []byte, encoder chunkEncoder, dicts []byte) *tableWriter {
+	return &tableWriter{
+		buff:      buff,
+		blockHash: sha512.New(),
+		encoder:   encoder,
+		format:    tableFormatV2,
+		dicts:     dicts,
+	}
+}
+
+func (tw *tableWriter) addChunk(h addr, data []byte) error {
 	if len(data) == 0 {
 		panic("NBS blocks cannont be zero length")
 	}
 
-	// Compress data straight into tw.buff
-	compressed := tw.snapper.Encode(tw.buff[tw.pos:], data)
-	dataLength := uint64(len(compressed))
-	tw.totalCompressedData += dataLength
+	// Compress data straight into tw.buff, leaving room for the header of encrypted records
+	start := tw.pos
+	if tw.key != nil {
+		start += encryptedRecordHeaderSize
+	}
+
+	compressed := tw.encoder.Encode(tw.buff[start:], data)
 
 	// BUG 3156 indicated that, sometimes, snappy decided that there's not enough space in tw.buff[tw.pos:] to encode into.
 	// This _should never happen anymore be_, because we iterate over all chunks to be added and sum the max amount of space that snappy says it might need.
 	// Since we know that |data| can't be 0-length, we also know that the compressed version of |data| has length greater than zero. The first element in a snappy-encoded blob is a Uvarint indicating how much data is present. Therefore, if there's a Uvarint-encoded 0 at tw.buff[tw.pos:], we know that snappy did not write anything there and we have a problem.
-	if v, n := binary.Uvarint(tw.buff[tw.pos:]); v == 0 {
+	if v, n := binary.Uvarint(tw.buff[start:]); v == 0 {
 		d.Chk.True(n != 0)
-		panic(fmt.Errorf("bug 3156: unbuffered chunk %s: uncompressed %d, compressed %d, snappy max %d, tw.buff %d", h.String(), len(data), dataLength, snappy.MaxEncodedLen(len(data)), len(tw.buff[tw.pos:])))
+		panic(fmt.Errorf("bug 3156: unbuffered chunk %s: uncompressed %d, compressed %d, snappy max %d, tw.buff %d", h.String(), len(data), len(compressed), snappy.MaxEncodedLen(len(data)), len(tw.buff[start:])))
+	}
+
+	if tw.key != nil {
+		var err error
+		compressed, err = tw.key.encrypt(tw.buff[tw.pos:], h, compressed)
+
+		if err != nil {
+			return err
+		}
 	}
 
+	dataLength := uint64(len(compressed))
+	tw.totalCompressedData += dataLength
+
 	tw.pos += dataLength
 	tw.totalUncompressedData += uint64(len(data))
 
@@ -121,10 +159,14 @@ func (tw *tableWriter) addChunk(h addr, data []byte) bool {
 		uint32(checksumSize + dataLength),
 	})
 
-	return true
+	return nil
 }
 
 func (tw *tableWriter) finish() (uncompressedLength uint64, blockAddr addr, err error) {
+	if tw.format == tableFormatV2 {
+		tw.pos += uint64(copy(tw.buff[tw.pos:], tw.dicts))
+	}
+
 	err = tw.writeIndex()
 
 	if err != nil {
@@ -195,10 +237,10 @@ func (tw *tableWriter) writeIndex() error {
 }
 
 func (tw *tableWriter) writeFooter() {
-	tw.pos += writeFooter(tw.buff[tw.pos:], uint32(len(tw.prefixes)), tw.totalUncompressedData)
+	tw.pos += writeFooter(tw.buff[tw.pos:], tw.format, uint32(len(tw.prefixes)), tw.totalUncompressedData)
 }
 
-func writeFooter(dst []byte, chunkCount uint32, uncData uint64) (consumed uint64) {
+func writeFooter(dst []byte, format tableFormat, chunkCount uint32, uncData uint64) (consumed uint64) {
 	// chunk count
 	binary.BigEndian.PutUint32(dst[consumed:], chunkCount)
 	consumed += uint32Size
@@ -208,7 +250,7 @@ func writeFooter(dst []byte, chunkCount uint32, uncData uint64) (consumed uint64
 	consumed += uint64Size
 
 	// magic number
-	copy(dst[consumed:], magicNumber)
+	copy(dst[consumed:], format.magicNumber())
 	consumed += magicNumberSize
 	return
 }
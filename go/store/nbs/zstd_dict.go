@@ -0,0 +1,291 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"container/heap"
+	"encoding/binary"
+
+	"github.com/klauspost/compress/huff0"
+
+	"github.com/liquidata-inc/dolt/go/store/hash"
+)
+
+const (
+	// dictionarySegmentSize is the size of the pieces of the samples which are chosen from to make up the content of a
+	// dictionary
+	dictionarySegmentSize = 256
+
+	// dictionaryHashLog is the log2 of the number of buckets substrings of the samples are counted in
+	dictionaryHashLog = 20
+
+	// dictionaries are given ids above those reserved for registered dictionaries
+	minDictionaryID = 1 << 15
+	maxDictionaryID = 1<<31 - 1
+)
+
+var zstdDictMagic = []byte{0x37, 0xa4, 0x30, 0xec}
+
+// The entropy tables of trained dictionaries use the default distributions of the zstd format for literal lengths,
+// match lengths and offsets, which the encoder replaces with tables of its own when they compress better.
+var (
+	defaultLiteralLengthsTable = writeNormalizedCounts(6, []int16{4, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1, 1, 1,
+		2, 2, 2, 2, 2, 2, 2, 2, 2, 3, 2, 1, 1, 1, 1, 1, -1, -1, -1, -1})
+	defaultMatchLengthsTable = writeNormalizedCounts(6, []int16{1, 4, 3, 2, 2, 2, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1, -1, -1, -1,
+		-1, -1})
+	defaultOffsetsTable = writeNormalizedCounts(5, []int16{1, 1, 1, 1, 1, 1, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, -1, -1, -1, -1, -1})
+)
+
+// buildZstdDictionary returns a zstd dictionary of at most maxSize bytes of content for compressing chunks like the
+// samples given. The content is made of the pieces of the samples whose substrings appear in the most other samples.
+// Returns nil if the samples are too few or too random for a dictionary to be of use.
+func buildZstdDictionary(samples [][]byte, maxSize int) []byte {
+	content := trainDictionaryContent(samples, maxSize)
+
+	// the initial repeat offsets of 1, 4 and 8 must be within the content
+	if len(content) < 8 {
+		return nil
+	}
+
+	// the literals table is built from the samples, and every byte value is given a code so that the literals of any
+	// chunk can be encoded with it
+	literals := make([]byte, 0, huff0.BlockSizeMax)
+	for _, sample := range samples {
+		if len(literals)+len(sample) > huff0.BlockSizeMax-256 {
+			break
+		}
+
+		literals = append(literals, sample...)
+	}
+
+	for i := 0; i < 256; i++ {
+		literals = append(literals, byte(i))
+	}
+
+	var s huff0.Scratch
+	_, _, err := huff0.Compress1X(literals, &s)
+
+	if err != nil {
+		return nil
+	}
+
+	h := hash.Of(content)
+	id := minDictionaryID + binary.BigEndian.Uint32(h[:])%(maxDictionaryID-minDictionaryID)
+
+	d := make([]byte, 0, len(zstdDictMagic)+uint32Size+len(s.OutTable)+64+3*uint32Size+len(content))
+	d = append(d, zstdDictMagic...)
+	d = appendUint32LE(d, id)
+	d = append(d, s.OutTable...)
+	d = append(d, defaultOffsetsTable...)
+	d = append(d, defaultMatchLengthsTable...)
+	d = append(d, defaultLiteralLengthsTable...)
+	d = appendUint32LE(d, 1)
+	d = appendUint32LE(d, 4)
+	d = appendUint32LE(d, 8)
+	d = append(d, content...)
+
+	return d
+}
+
+func appendUint32LE(b []byte, v uint32) []byte {
+	var buff [uint32Size]byte
+	binary.LittleEndian.PutUint32(buff[:], v)
+	return append(b, buff[:]...)
+}
+
+// writeNormalizedCounts returns the description of an FSE table with the normalized counts given, as it is stored in
+// zstd dictionaries. The counts must not be 0.
+func writeNormalizedCounts(tableLog uint, counts []int16) []byte {
+	var out []byte
+	bits := uint32(tableLog - 5)
+	nBitsUsed := uint(4)
+	remaining := int32(1<<tableLog) + 1
+	threshold := int32(1 << tableLog)
+	nBits := tableLog + 1
+
+	for _, norm := range counts {
+		if remaining <= 1 {
+			break
+		}
+
+		// counts of less than 1 are written as -1, and every count is written plus 1
+		count := int32(norm)
+		max := 2*threshold - 1 - remaining
+
+		if count < 0 {
+			remaining += count
+		} else {
+			remaining -= count
+		}
+
+		count++
+		if count >= threshold {
+			count += max
+		}
+
+		bits |= uint32(count) << nBitsUsed
+		nBitsUsed += nBits
+		if count < max {
+			nBitsUsed--
+		}
+
+		for remaining < threshold {
+			nBits--
+			threshold >>= 1
+		}
+
+		for nBitsUsed >= 8 {
+			out = append(out, byte(bits))
+			bits >>= 8
+			nBitsUsed -= 8
+		}
+	}
+
+	if nBitsUsed > 0 {
+		out = append(out, byte(bits))
+	}
+
+	return out
+}
+
+// dictionarySegment is a piece of a sample which may be added to the content of a dictionary
+type dictionarySegment struct {
+	sample     int
+	start, end int
+	score      uint64
+}
+
+type segmentHeap []dictionarySegment
+
+func (sh segmentHeap) Len() int           { return len(sh) }
+func (sh segmentHeap) Less(i, j int) bool { return sh[i].score > sh[j].score }
+func (sh segmentHeap) Swap(i, j int)      { sh[i], sh[j] = sh[j], sh[i] }
+
+func (sh *segmentHeap) Push(x interface{}) {
+	*sh = append(*sh, x.(dictionarySegment))
+}
+
+func (sh *segmentHeap) Pop() interface{} {
+	old := *sh
+	seg := old[len(old)-1]
+	*sh = old[:len(old)-1]
+	return seg
+}
+
+// trainDictionaryContent returns at most maxSize bytes of segments of the samples given. Each segment is scored by the
+// number of samples which each of its substrings of dictionaryHashBytes bytes appears in, and segments are chosen
+// greedily, with the substrings of the segments already chosen no longer counting towards the score of others. The
+// best segments are placed last, where they are closest to the data compressed with the dictionary.
+func trainDictionaryContent(samples [][]byte, maxSize int) []byte {
+	counts := make([]uint32, 1<<dictionaryHashLog)
+	lastSample := make([]int32, 1<<dictionaryHashLog)
+	for i, sample := range samples {
+		for pos := 0; pos+dictionaryHashBytes <= len(sample); pos++ {
+			b := dictionaryBucket(sample[pos:])
+
+			if lastSample[b] != int32(i+1) {
+				lastSample[b] = int32(i + 1)
+				counts[b]++
+			}
+		}
+	}
+
+	// lastSample is reused to count each bucket once per segment
+	epoch := int32(0)
+	for i := range lastSample {
+		lastSample[i] = 0
+	}
+
+	score := func(seg dictionarySegment) uint64 {
+		epoch++
+		var total uint64
+		data := samples[seg.sample][seg.start:seg.end]
+		for pos := 0; pos+dictionaryHashBytes <= len(data); pos++ {
+			b := dictionaryBucket(data[pos:])
+
+			if lastSample[b] != epoch && counts[b] > 1 {
+				lastSample[b] = epoch
+				total += uint64(counts[b])
+			}
+		}
+
+		return total
+	}
+
+	var segments segmentHeap
+	for i, sample := range samples {
+		for start := 0; start+dictionaryHashBytes <= len(sample); start += dictionarySegmentSize {
+			end := start + dictionarySegmentSize
+			if end > len(sample) {
+				end = len(sample)
+			}
+
+			seg := dictionarySegment{i, start, end, 0}
+			seg.score = score(seg)
+
+			if seg.score > 0 {
+				segments = append(segments, seg)
+			}
+		}
+	}
+
+	heap.Init(&segments)
+
+	var chosen [][]byte
+	var size int
+	for segments.Len() > 0 && size < maxSize {
+		seg := heap.Pop(&segments).(dictionarySegment)
+		seg.score = score(seg)
+
+		if seg.score == 0 {
+			continue
+		} else if segments.Len() > 0 && seg.score < segments[0].score {
+			// the segment shares substrings with those already chosen, and is no longer the best
+			heap.Push(&segments, seg)
+			continue
+		}
+
+		data := samples[seg.sample][seg.start:seg.end]
+		if size+len(data) > maxSize {
+			data = data[:maxSize-size]
+		}
+
+		chosen = append(chosen, data)
+		size += len(data)
+
+		for pos := 0; pos+dictionaryHashBytes <= len(data); pos++ {
+			counts[dictionaryBucket(data[pos:])] = 0
+		}
+	}
+
+	content := make([]byte, 0, size)
+	for i := len(chosen) - 1; i >= 0; i-- {
+		content = append(content, chosen[i]...)
+	}
+
+	return content
+}
+
+// dictionaryBucket returns the bucket the dictionaryHashBytes bytes at the start of the data given are counted in
+func dictionaryBucket(data []byte) uint32 {
+	var v uint64
+	for i := 0; i < dictionaryHashBytes; i++ {
+		v |= uint64(data[i]) << (8 * uint(i))
+	}
+
+	return uint32((v * 0xcf1bbcdcb7a56463) >> (64 - dictionaryHashLog))
+}
@@ -0,0 +1,74 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildZstdDictionary(t *testing.T) {
+	var samples [][]byte
+	for _, c := range makeCompressibleChunks(0, 2*minDictionaryChunks) {
+		samples = append(samples, c.Data())
+	}
+
+	d := buildZstdDictionary(samples, maxDictionarySize)
+	require.NotNil(t, d)
+	assert.True(t, dictionaryID(d) >= minDictionaryID)
+	assert.Equal(t, d, buildZstdDictionary(samples, maxDictionarySize))
+
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderDict(d), zstd.WithEncoderConcurrency(1))
+	require.NoError(t, err)
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderDicts(d))
+	require.NoError(t, err)
+	plain, _, err := zstdCodecs()
+	require.NoError(t, err)
+
+	var withDict, withoutDict int
+	for _, c := range makeCompressibleChunks(1000, 16) {
+		compressed := enc.EncodeAll(c.Data(), nil)
+		withDict += len(compressed)
+		withoutDict += len(plain.EncodeAll(c.Data(), nil))
+
+		decompressed, err := dec.DecodeAll(compressed, nil)
+		require.NoError(t, err)
+		assert.Equal(t, c.Data(), decompressed)
+	}
+
+	assert.True(t, withDict < withoutDict)
+
+	t.Run("small content", func(t *testing.T) {
+		d := buildZstdDictionary(samples, 16)
+		require.NotNil(t, d)
+		_, err := zstd.NewReader(nil, zstd.WithDecoderDicts(d))
+		assert.NoError(t, err)
+	})
+
+	t.Run("random samples", func(t *testing.T) {
+		rnd := rand.New(rand.NewSource(0))
+		random := make([][]byte, minDictionaryChunks)
+		for i := range random {
+			random[i] = make([]byte, 1024)
+			rnd.Read(random[i])
+		}
+
+		assert.Nil(t, buildZstdDictionary(random, maxDictionarySize))
+	})
+}
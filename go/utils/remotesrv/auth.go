@@ -0,0 +1,282 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	remotesapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/remotesapi_v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/creds"
+)
+
+// Permission is the access a key has to a repository
+type Permission int
+
+const (
+	NoPermission Permission = iota
+	ReadPermission
+	WritePermission
+)
+
+var permissionNames = map[string]Permission{
+	"read":  ReadPermission,
+	"write": WritePermission,
+}
+
+const (
+	authorizationHeader = "authorization"
+	bearerPrefix        = "Bearer "
+
+	anyRepo = "*"
+)
+
+// KeyConfig is the configuration of a client key in the auth config file.
+type KeyConfig struct {
+	// Name identifies the key in the server logs
+	Name string `json:"name"`
+
+	// PubKey is the base32 encoded public key of the client credentials, as printed by dolt creds ls
+	PubKey string `json:"pub_key"`
+
+	// Repos maps a repository "org/repo", every repository of an org "org/*", or every repository "*" to "read" or
+	// "write". Write permission implies read permission.
+	Repos map[string]string `json:"repos"`
+}
+
+// AuthConfig is the auth config file read by remotesrv
+type AuthConfig struct {
+	Keys []KeyConfig `json:"keys"`
+}
+
+type keyPerms struct {
+	name   string
+	pubKey []byte
+	repos  map[string]Permission
+}
+
+// Authorizer verifies the bearer tokens sent by dolt clients, and checks the permissions the keys which signed them
+// have on each repository.
+type Authorizer struct {
+	keys map[string]*keyPerms
+}
+
+// LoadAuthorizer reads an AuthConfig from the json file at the path given
+func LoadAuthorizer(path string) (*Authorizer, error) {
+	data, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg AuthConfig
+	err = json.Unmarshal(data, &cfg)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	return NewAuthorizer(cfg)
+}
+
+// NewAuthorizer returns an Authorizer granting the permissions in the config given
+func NewAuthorizer(cfg AuthConfig) (*Authorizer, error) {
+	keys := make(map[string]*keyPerms, len(cfg.Keys))
+	for _, keyCfg := range cfg.Keys {
+		pub, err := creds.B32CredsEncoding.DecodeString(keyCfg.PubKey)
+
+		if err != nil {
+			return nil, fmt.Errorf("key '%s' has an invalid public key: %v", keyCfg.Name, err)
+		}
+
+		kid := creds.PubKeyToKIDStr(pub)
+
+		if _, ok := keys[kid]; ok {
+			return nil, fmt.Errorf("key '%s' is configured more than once", keyCfg.Name)
+		}
+
+		repos := make(map[string]Permission, len(keyCfg.Repos))
+		for repo, permName := range keyCfg.Repos {
+			perm, ok := permissionNames[strings.ToLower(permName)]
+
+			if !ok {
+				return nil, fmt.Errorf("key '%s' has invalid permission '%s' for '%s'. Permissions are 'read' or 'write'", keyCfg.Name, permName, repo)
+			}
+
+			repos[repo] = perm
+		}
+
+		keys[kid] = &keyPerms{keyCfg.Name, pub, repos}
+	}
+
+	return &Authorizer{keys}, nil
+}
+
+func (auth *Authorizer) lookup(kid string) ([]byte, bool) {
+	kp, ok := auth.keys[kid]
+
+	if !ok {
+		return nil, false
+	}
+
+	return kp.pubKey, true
+}
+
+// permission returns the permission the key has on the repository, which is the greatest permission granted by any
+// entry matching the repository. A repository with an invalid name has no permissions.
+func (kp *keyPerms) permission(org, repo string) Permission {
+	if !validRepoName(org) || !validRepoName(repo) {
+		return NoPermission
+	}
+
+	perm := NoPermission
+	for _, pattern := range []string{org + "/" + repo, org + "/" + anyRepo, anyRepo} {
+		if p, ok := kp.repos[pattern]; ok && p > perm {
+			perm = p
+		}
+	}
+
+	return perm
+}
+
+// validRepoName returns whether an org or repository name can be used as a path element. Names which are empty, hold
+// a path separator, or hold ".." are not valid, as they could refer to a path outside of the repository.
+func validRepoName(name string) bool {
+	return name != "" && name != "." && !strings.ContainsAny(name, `/\`) && !strings.Contains(name, "..")
+}
+
+// ValidateRepoId returns an InvalidArgument grpc status error if the repository id is missing or its org or repository
+// name is not valid.
+func ValidateRepoId(repoId *remotesapi.RepoId) error {
+	if repoId == nil {
+		return status.Error(codes.InvalidArgument, "missing repo id")
+	}
+
+	if !validRepoName(repoId.Org) || !validRepoName(repoId.RepoName) {
+		return status.Errorf(codes.InvalidArgument, "invalid repository '%s/%s'", repoId.Org, repoId.RepoName)
+	}
+
+	return nil
+}
+
+// Authorize checks the bearer token in the grpc request metadata, and that the key which signed it has the permission
+// given on the repository. The error returned is a grpc status error.
+func (auth *Authorizer) Authorize(ctx context.Context, repoId *remotesapi.RepoId, perm Permission) (string, error) {
+	if err := ValidateRepoId(repoId); err != nil {
+		return "", err
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(authorizationHeader)
+
+	if len(values) != 1 || !strings.HasPrefix(values[0], bearerPrefix) {
+		return "", status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+
+	kid, err := creds.VerifyBearerToken(strings.TrimPrefix(values[0], bearerPrefix), auth.lookup)
+
+	if err != nil {
+		return "", status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	kp := auth.keys[kid]
+
+	if kp.permission(repoId.Org, repoId.RepoName) < perm {
+		return kp.name, status.Errorf(codes.PermissionDenied, "permission denied to %s/%s", repoId.Org, repoId.RepoName)
+	}
+
+	return kp.name, nil
+}
+
+const (
+	expiresParam   = "expires"
+	signatureParam = "signature"
+)
+
+var errBadSignature = errors.New("invalid signature")
+var errExpiredURL = errors.New("url has expired")
+
+// URLSigner signs the urls of the http file server which the grpc server hands out, so that only clients authorized by
+// the grpc server can read and write the files, and only until the urls expire.
+type URLSigner struct {
+	key []byte
+	ttl time.Duration
+}
+
+// NewURLSigner returns a URLSigner with a random key, which signs urls valid for the duration given.
+func NewURLSigner(ttl time.Duration) (*URLSigner, error) {
+	key := make([]byte, sha256.Size)
+	_, err := rand.Read(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &URLSigner{key, ttl}, nil
+}
+
+func (us *URLSigner) signature(perm Permission, path string, expires int64) string {
+	mac := hmac.New(sha256.New, us.key)
+	fmt.Fprintf(mac, "%d\n%s\n%d", perm, path, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign returns the query string for the path given, allowing access with the permission given until the url expires.
+func (us *URLSigner) Sign(perm Permission, path string) string {
+	expires := time.Now().Add(us.ttl).Unix()
+
+	q := url.Values{}
+	q.Set(expiresParam, strconv.FormatInt(expires, 10))
+	q.Set(signatureParam, us.signature(perm, path, expires))
+
+	return q.Encode()
+}
+
+// Verify checks the query of a request for the path given carries an unexpired signature granting the permission
+// given.
+func (us *URLSigner) Verify(perm Permission, path string, query url.Values) error {
+	expires, err := strconv.ParseInt(query.Get(expiresParam), 10, 64)
+
+	if err != nil {
+		return errBadSignature
+	}
+
+	expected := us.signature(perm, path, expires)
+
+	if !hmac.Equal([]byte(expected), []byte(query.Get(signatureParam))) {
+		return errBadSignature
+	}
+
+	if time.Now().Unix() > expires {
+		return errExpiredURL
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	remotesapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/remotesapi_v1alpha1"
+	"github.com/liquidata-inc/dolt/go/libraries/doltcore/creds"
+)
+
+func TestAuthorizer(t *testing.T) {
+	reader, err := creds.GenerateCredentials()
+	require.NoError(t, err)
+	writer, err := creds.GenerateCredentials()
+	require.NoError(t, err)
+	unknown, err := creds.GenerateCredentials()
+	require.NoError(t, err)
+
+	auth, err := NewAuthorizer(AuthConfig{Keys: []KeyConfig{
+		{"reader", reader.PubKeyBase32Str(), map[string]string{"org/*": "read"}},
+		{"writer", writer.PubKeyBase32Str(), map[string]string{"org/repo": "write", "*": "read"}},
+	}})
+	require.NoError(t, err)
+
+	ctxFor := func(dc creds.DoltCreds) context.Context {
+		md, err := dc.GetRequestMetadata(context.Background())
+		require.NoError(t, err)
+		return metadata.NewIncomingContext(context.Background(), metadata.New(md))
+	}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		repoId *remotesapi.RepoId
+		perm   Permission
+		code   codes.Code
+	}{
+		{"read org", ctxFor(reader), &remotesapi.RepoId{Org: "org", RepoName: "repo"}, ReadPermission, codes.OK},
+		{"write org", ctxFor(reader), &remotesapi.RepoId{Org: "org", RepoName: "repo"}, WritePermission, codes.PermissionDenied},
+		{"read other org", ctxFor(reader), &remotesapi.RepoId{Org: "other", RepoName: "repo"}, ReadPermission, codes.PermissionDenied},
+		{"write repo", ctxFor(writer), &remotesapi.RepoId{Org: "org", RepoName: "repo"}, WritePermission, codes.OK},
+		{"write other repo", ctxFor(writer), &remotesapi.RepoId{Org: "org", RepoName: "other"}, WritePermission, codes.PermissionDenied},
+		{"read any repo", ctxFor(writer), &remotesapi.RepoId{Org: "other", RepoName: "other"}, ReadPermission, codes.OK},
+		{"unknown key", ctxFor(unknown), &remotesapi.RepoId{Org: "org", RepoName: "repo"}, ReadPermission, codes.Unauthenticated},
+		{"no token", context.Background(), &remotesapi.RepoId{Org: "org", RepoName: "repo"}, ReadPermission, codes.Unauthenticated},
+		{"no repo id", ctxFor(writer), nil, ReadPermission, codes.InvalidArgument},
+		{"empty org", ctxFor(writer), &remotesapi.RepoId{Org: "", RepoName: "repo"}, ReadPermission, codes.InvalidArgument},
+		{"slash", ctxFor(reader), &remotesapi.RepoId{Org: "org", RepoName: "repo/../../other"}, ReadPermission, codes.InvalidArgument},
+		{"backslash", ctxFor(reader), &remotesapi.RepoId{Org: "org", RepoName: `..\other`}, ReadPermission, codes.InvalidArgument},
+		{"parent", ctxFor(writer), &remotesapi.RepoId{Org: "..", RepoName: "repo"}, ReadPermission, codes.InvalidArgument},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := auth.Authorize(test.ctx, test.repoId, test.perm)
+			assert.Equal(t, test.code, status.Code(err))
+		})
+	}
+}
+
+func TestURLSigner(t *testing.T) {
+	signer, err := NewURLSigner(time.Minute)
+	require.NoError(t, err)
+
+	path := "/org/repo/" + "0123456789abcdefghijklmnopqrstuv"
+	query, err := url.ParseQuery(signer.Sign(ReadPermission, path))
+	require.NoError(t, err)
+
+	assert.NoError(t, signer.Verify(ReadPermission, path, query))
+	assert.Equal(t, errBadSignature, signer.Verify(WritePermission, path, query))
+	assert.Equal(t, errBadSignature, signer.Verify(ReadPermission, "/org/other/0123456789abcdefghijklmnopqrstuv", query))
+	assert.Equal(t, errBadSignature, signer.Verify(ReadPermission, path, url.Values{}))
+
+	other, err := NewURLSigner(time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, errBadSignature, other.Verify(ReadPermission, path, query))
+
+	tampered, err := url.ParseQuery(query.Encode())
+	require.NoError(t, err)
+	tampered.Set(expiresParam, "99999999999")
+	assert.Equal(t, errBadSignature, signer.Verify(ReadPermission, path, tampered))
+
+	expired, err := NewURLSigner(-time.Minute)
+	require.NoError(t, err)
+	query, err = url.ParseQuery(expired.Sign(WritePermission, path))
+	require.NoError(t, err)
+	assert.Equal(t, errExpiredURL, expired.Verify(WritePermission, path, query))
+}
@@ -0,0 +1,166 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/storage"
+
+	"github.com/liquidata-inc/dolt/go/store/blobstore"
+)
+
+const (
+	memBlobstore = "mem"
+
+	fileScheme = "file"
+	gsScheme   = "gs"
+)
+
+// BlobstoreProvider returns the blobstore holding the manifest and table files of a repository
+type BlobstoreProvider func(ctx context.Context, org, repo string) (blobstore.Blobstore, error)
+
+// NewBlobstoreProvider returns the BlobstoreProvider for the blobstore url given, which is one of
+//
+//	mem                      - repositories are held in memory and lost when the server exits
+//	file:///path/to/dir      - repositories are stored in <dir>/<org>/<repo> on the local filesystem
+//	gs://bucket/path/prefix  - repositories are stored under <prefix>/<org>/<repo>/ in a GCS bucket
+func NewBlobstoreProvider(ctx context.Context, bsURL string) (BlobstoreProvider, error) {
+	if bsURL == memBlobstore {
+		return memBlobstoreProvider(), nil
+	}
+
+	urlObj, err := url.Parse(bsURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(urlObj.Scheme) {
+	case fileScheme:
+		return localBlobstoreProvider(filepath.FromSlash(urlObj.Path)), nil
+
+	case gsScheme:
+		gcs, err := storage.NewClient(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return gcsBlobstoreProvider(gcs.Bucket(urlObj.Host), strings.Trim(urlObj.Path, "/")), nil
+	}
+
+	return nil, fmt.Errorf("unsupported blobstore url '%s'", bsURL)
+}
+
+// errInvalidRepo is returned by the BlobstoreProviders for an org or repository name which is not valid, or which
+// would refer to a path outside of the root of the blobstores.
+func errInvalidRepo(org, repo string) error {
+	return fmt.Errorf("invalid repository '%s/%s'", org, repo)
+}
+
+func memBlobstoreProvider() BlobstoreProvider {
+	mu := &sync.Mutex{}
+	blobstores := make(map[string]*blobstore.InMemoryBlobstore)
+	return func(ctx context.Context, org, repo string) (blobstore.Blobstore, error) {
+		if !validRepoName(org) || !validRepoName(repo) {
+			return nil, errInvalidRepo(org, repo)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		id := path.Join(org, repo)
+		bs, ok := blobstores[id]
+
+		if !ok {
+			bs = blobstore.NewInMemoryBlobstore()
+			blobstores[id] = bs
+		}
+
+		return bs, nil
+	}
+}
+
+// localRepoDir returns the directory of a repository stored under dir, checking that it is within dir.
+func localRepoDir(dir, org, repo string) (string, error) {
+	if !validRepoName(org) || !validRepoName(repo) {
+		return "", errInvalidRepo(org, repo)
+	}
+
+	repoDir := filepath.Join(dir, org, repo)
+	rel, err := filepath.Rel(filepath.Clean(dir), repoDir)
+
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errInvalidRepo(org, repo)
+	}
+
+	return repoDir, nil
+}
+
+func localBlobstoreProvider(dir string) BlobstoreProvider {
+	return func(ctx context.Context, org, repo string) (blobstore.Blobstore, error) {
+		repoDir, err := localRepoDir(dir, org, repo)
+
+		if err != nil {
+			return nil, err
+		}
+
+		err = os.MkdirAll(repoDir, os.ModePerm)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return blobstore.NewLocalBlobstore(repoDir), nil
+	}
+}
+
+// gcsRepoPrefix returns the prefix of the objects of a repository stored under prefix, checking that it is within
+// prefix.
+func gcsRepoPrefix(prefix, org, repo string) (string, error) {
+	if !validRepoName(org) || !validRepoName(repo) {
+		return "", errInvalidRepo(org, repo)
+	}
+
+	repoPrefix := path.Join(prefix, org, repo)
+
+	if prefix != "" && !strings.HasPrefix(repoPrefix, path.Clean(prefix)+"/") {
+		return "", errInvalidRepo(org, repo)
+	} else if repoPrefix == ".." || strings.HasPrefix(repoPrefix, "../") || strings.HasPrefix(repoPrefix, "/") {
+		return "", errInvalidRepo(org, repo)
+	}
+
+	return repoPrefix + "/", nil
+}
+
+func gcsBlobstoreProvider(bucket *storage.BucketHandle, prefix string) BlobstoreProvider {
+	return func(ctx context.Context, org, repo string) (blobstore.Blobstore, error) {
+		repoPrefix, err := gcsRepoPrefix(prefix, org, repo)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return blobstore.NewGCSBlobstore(bucket, repoPrefix), nil
+	}
+}
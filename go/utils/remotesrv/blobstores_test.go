@@ -0,0 +1,77 @@
+// Copyright 2019 Liquidata, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var invalidRepos = [][2]string{
+	{"", "repo"},
+	{"org", ""},
+	{"..", "repo"},
+	{"org", ".."},
+	{".", "repo"},
+	{"org", "a/b"},
+	{"org", `a\b`},
+	{"org", "a..b"},
+}
+
+func TestLocalRepoDir(t *testing.T) {
+	root := filepath.Join("srv", "repos")
+	dir, err := localRepoDir(root, "org", "repo")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "org", "repo"), dir)
+
+	for _, invalid := range invalidRepos {
+		_, err := localRepoDir(root, invalid[0], invalid[1])
+		assert.Error(t, err, "%s/%s", invalid[0], invalid[1])
+	}
+}
+
+func TestGCSRepoPrefix(t *testing.T) {
+	prefix, err := gcsRepoPrefix("dolt/repos", "org", "repo")
+	require.NoError(t, err)
+	assert.Equal(t, "dolt/repos/org/repo/", prefix)
+
+	prefix, err = gcsRepoPrefix("", "org", "repo")
+	require.NoError(t, err)
+	assert.Equal(t, "org/repo/", prefix)
+
+	for _, invalid := range invalidRepos {
+		_, err := gcsRepoPrefix("dolt/repos", invalid[0], invalid[1])
+		assert.Error(t, err, "%s/%s", invalid[0], invalid[1])
+	}
+}
+
+func TestMemBlobstoreProvider(t *testing.T) {
+	provider := memBlobstoreProvider()
+	bs, err := provider(context.Background(), "org", "repo")
+	require.NoError(t, err)
+
+	same, err := provider(context.Background(), "org", "repo")
+	require.NoError(t, err)
+	assert.True(t, bs == same)
+
+	for _, invalid := range invalidRepos {
+		_, err := provider(context.Background(), invalid[0], invalid[1])
+		assert.Error(t, err, "%s/%s", invalid[0], invalid[1])
+	}
+}
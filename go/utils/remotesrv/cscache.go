@@ -16,10 +16,12 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
 	"sync"
 
 	"github.com/liquidata-inc/dolt/go/libraries/utils/filesys"
+	"github.com/liquidata-inc/dolt/go/store/blobstore"
 	"github.com/liquidata-inc/dolt/go/store/nbs"
 	"github.com/liquidata-inc/dolt/go/store/types"
 )
@@ -32,7 +34,8 @@ type DBCache struct {
 	mu  *sync.Mutex
 	dbs map[string]*nbs.NomsBlockStore
 
-	fs filesys.Filesys
+	fs         filesys.Filesys
+	blobstores BlobstoreProvider
 }
 
 func NewLocalCSCache(filesys filesys.Filesys) *DBCache {
@@ -40,10 +43,39 @@ func NewLocalCSCache(filesys filesys.Filesys) *DBCache {
 		&sync.Mutex{},
 		make(map[string]*nbs.NomsBlockStore),
 		filesys,
+		nil,
 	}
 }
 
+// NewBlobstoreCSCache returns a DBCache whose repositories are stored in the blobstores returned by the provider given
+func NewBlobstoreCSCache(blobstores BlobstoreProvider) *DBCache {
+	return &DBCache{
+		&sync.Mutex{},
+		make(map[string]*nbs.NomsBlockStore),
+		nil,
+		blobstores,
+	}
+}
+
+// Blobstore returns the blobstore the repository is stored in, or nil if the repositories are stored on the local
+// filesystem.
+func (cache *DBCache) Blobstore(ctx context.Context, org, repo string) (blobstore.Blobstore, error) {
+	if !validRepoName(org) || !validRepoName(repo) {
+		return nil, fmt.Errorf("invalid repository '%s/%s'", org, repo)
+	}
+
+	if cache.blobstores == nil {
+		return nil, nil
+	}
+
+	return cache.blobstores(ctx, org, repo)
+}
+
 func (cache *DBCache) Get(org, repo string) (*nbs.NomsBlockStore, error) {
+	if !validRepoName(org) || !validRepoName(repo) {
+		return nil, fmt.Errorf("invalid repository '%s/%s'", org, repo)
+	}
+
 	cache.mu.Lock()
 	defer cache.mu.Unlock()
 
@@ -54,7 +86,19 @@ func (cache *DBCache) Get(org, repo string) (*nbs.NomsBlockStore, error) {
 	}
 
 	var newCS *nbs.NomsBlockStore
-	if cache.fs != nil {
+	if cache.blobstores != nil {
+		bs, err := cache.blobstores(context.TODO(), org, repo)
+
+		if err != nil {
+			return nil, err
+		}
+
+		newCS, err = nbs.NewBSStore(context.TODO(), types.Format_Default.VersionString(), bs, defaultMemTableSize)
+
+		if err != nil {
+			return nil, err
+		}
+	} else if cache.fs != nil {
 		err := cache.fs.MkDirs(id)
 
 		if err != nil {
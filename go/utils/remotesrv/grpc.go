@@ -25,28 +25,65 @@ import (
 
 	remotesapi "github.com/liquidata-inc/dolt/go/gen/proto/dolt/services/remotesapi_v1alpha1"
 	"github.com/liquidata-inc/dolt/go/libraries/doltcore/remotestorage"
+	"github.com/liquidata-inc/dolt/go/store/datas"
 	"github.com/liquidata-inc/dolt/go/store/hash"
 	"github.com/liquidata-inc/dolt/go/store/nbs"
+	"github.com/liquidata-inc/dolt/go/store/types"
 )
 
 type RemoteChunkStore struct {
 	HttpHost string
 	csCache  *DBCache
 	bucket   string
+	auth     *Authorizer
+	signer   *URLSigner
 }
 
-func NewHttpFSBackedChunkStore(httpHost string, csCache *DBCache) *RemoteChunkStore {
+// NewHttpFSBackedChunkStore returns a RemoteChunkStore serving the files of its repositories from the http file server
+// at httpHost. If auth is nil every request is allowed, and if signer is nil the urls handed out are not signed.
+func NewHttpFSBackedChunkStore(httpHost string, csCache *DBCache, auth *Authorizer, signer *URLSigner) *RemoteChunkStore {
 	return &RemoteChunkStore{
 		httpHost,
 		csCache,
 		"",
+		auth,
+		signer,
 	}
 }
 
+// authorize checks the repository id is valid, and that the request is allowed the permission given on the
+// repository. Every request for a valid repository is allowed if the server has no Authorizer.
+func (rs *RemoteChunkStore) authorize(ctx context.Context, logger func(string), repoId *remotesapi.RepoId, perm Permission) error {
+	if err := ValidateRepoId(repoId); err != nil {
+		logger(fmt.Sprintf("invalid request: %v", err))
+		return err
+	}
+
+	if rs.auth == nil {
+		return nil
+	}
+
+	name, err := rs.auth.Authorize(ctx, repoId, perm)
+
+	if err != nil {
+		logger(fmt.Sprintf("unauthorized request for %s/%s from '%s': %v", repoId.Org, repoId.RepoName, name, err))
+		return err
+	}
+
+	logger(fmt.Sprintf("authorized request for %s/%s from '%s'", repoId.Org, repoId.RepoName, name))
+	return nil
+}
+
 func (rs *RemoteChunkStore) HasChunks(ctx context.Context, req *remotesapi.HasChunksRequest) (*remotesapi.HasChunksResponse, error) {
 	logger := getReqLogger("GRPC", "HasChunks")
 	defer func() { logger("finished") }()
 
+	err := rs.authorize(ctx, logger, req.RepoId, ReadPermission)
+
+	if err != nil {
+		return nil, err
+	}
+
 	cs := rs.getStore(req.RepoId, "HasChunks")
 
 	if cs == nil {
@@ -83,6 +120,12 @@ func (rs *RemoteChunkStore) GetDownloadLocations(ctx context.Context, req *remot
 	logger := getReqLogger("GRPC", "GetDownloadLocations")
 	defer func() { logger("finished") }()
 
+	err := rs.authorize(ctx, logger, req.RepoId, ReadPermission)
+
+	if err != nil {
+		return nil, err
+	}
+
 	cs := rs.getStore(req.RepoId, "GetDownloadLoctions")
 
 	if cs == nil {
@@ -115,7 +158,18 @@ func (rs *RemoteChunkStore) GetDownloadLocations(ctx context.Context, req *remot
 
 		logger("The URL is " + url)
 
-		getRange := &remotesapi.HttpGetRange{Url: url, Ranges: ranges}
+		dictsRange, err := cs.GetDictionariesRange(ctx, loc)
+
+		if err != nil {
+			return nil, err
+		}
+
+		getRange := &remotesapi.HttpGetRange{
+			Url:                url,
+			Ranges:             ranges,
+			DictionariesOffset: dictsRange.Offset,
+			DictionariesLength: dictsRange.Length,
+		}
 		locs = append(locs, &remotesapi.DownloadLoc{Location: &remotesapi.DownloadLoc_HttpGetRange{HttpGetRange: getRange}})
 	}
 
@@ -123,13 +177,19 @@ func (rs *RemoteChunkStore) GetDownloadLocations(ctx context.Context, req *remot
 }
 
 func (rs *RemoteChunkStore) getDownloadUrl(logger func(string), org, repoName, fileId string) (string, error) {
-	return fmt.Sprintf("http://%s/%s/%s/%s", rs.HttpHost, org, repoName, fileId), nil
+	return rs.getUrl(ReadPermission, org, repoName, fileId), nil
 }
 
 func (rs *RemoteChunkStore) GetUploadLocations(ctx context.Context, req *remotesapi.GetUploadLocsRequest) (*remotesapi.GetUploadLocsResponse, error) {
 	logger := getReqLogger("GRPC", "GetUploadLocations")
 	defer func() { logger("finished") }()
 
+	err := rs.authorize(ctx, logger, req.RepoId, WritePermission)
+
+	if err != nil {
+		return nil, err
+	}
+
 	cs := rs.getStore(req.RepoId, "GetWriteChunkUrls")
 
 	if cs == nil {
@@ -169,13 +229,29 @@ func (rs *RemoteChunkStore) GetUploadLocations(ctx context.Context, req *remotes
 }
 
 func (rs *RemoteChunkStore) getUploadUrl(logger func(string), org, repoName, fileId string) (string, error) {
-	return fmt.Sprintf("http://%s/%s/%s/%s", rs.HttpHost, org, repoName, fileId), nil
+	return rs.getUrl(WritePermission, org, repoName, fileId), nil
+}
+
+func (rs *RemoteChunkStore) getUrl(perm Permission, org, repoName, fileId string) string {
+	path := fmt.Sprintf("/%s/%s/%s", org, repoName, fileId)
+
+	if rs.signer == nil {
+		return "http://" + rs.HttpHost + path
+	}
+
+	return "http://" + rs.HttpHost + path + "?" + rs.signer.Sign(perm, path)
 }
 
 func (rs *RemoteChunkStore) Rebase(ctx context.Context, req *remotesapi.RebaseRequest) (*remotesapi.RebaseResponse, error) {
 	logger := getReqLogger("GRPC", "Rebase")
 	defer func() { logger("finished") }()
 
+	err := rs.authorize(ctx, logger, req.RepoId, ReadPermission)
+
+	if err != nil {
+		return nil, err
+	}
+
 	cs := rs.getStore(req.RepoId, "Rebase")
 
 	if cs == nil {
@@ -184,7 +260,7 @@ func (rs *RemoteChunkStore) Rebase(ctx context.Context, req *remotesapi.RebaseRe
 
 	logger(fmt.Sprintf("found %s/%s", req.RepoId.Org, req.RepoId.RepoName))
 
-	err := cs.Rebase(ctx)
+	err = cs.Rebase(ctx)
 
 	if err != nil {
 		logger(fmt.Sprintf("error occurred during processing of Rebace rpc of %s/%s details: %v", req.RepoId.Org, req.RepoId.RepoName, err))
@@ -198,6 +274,12 @@ func (rs *RemoteChunkStore) Root(ctx context.Context, req *remotesapi.RootReques
 	logger := getReqLogger("GRPC", "Root")
 	defer func() { logger("finished") }()
 
+	err := rs.authorize(ctx, logger, req.RepoId, ReadPermission)
+
+	if err != nil {
+		return nil, err
+	}
+
 	cs := rs.getStore(req.RepoId, "Root")
 
 	if cs == nil {
@@ -218,6 +300,12 @@ func (rs *RemoteChunkStore) Commit(ctx context.Context, req *remotesapi.CommitRe
 	logger := getReqLogger("GRPC", "Commit")
 	defer func() { logger("finished") }()
 
+	err := rs.authorize(ctx, logger, req.RepoId, WritePermission)
+
+	if err != nil {
+		return nil, err
+	}
+
 	cs := rs.getStore(req.RepoId, "Commit")
 
 	if cs == nil {
@@ -232,9 +320,12 @@ func (rs *RemoteChunkStore) Commit(ctx context.Context, req *remotesapi.CommitRe
 		updates[hash.New(cti.Hash)] = cti.ChunkCount
 	}
 
-	_, err := cs.UpdateManifest(ctx, updates)
+	_, err = cs.UpdateManifestWithKeyID(ctx, updates, req.KeyId)
 
-	if err != nil {
+	if err == nbs.ErrEncryptionKeyMismatch || err == nbs.ErrEncryptionKeyRequired || err == nbs.ErrTableFilesUnencrypted {
+		logger(fmt.Sprintf("table files of %s/%s not added: %v", req.RepoId.Org, req.RepoId.RepoName, err))
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	} else if err != nil {
 		logger(fmt.Sprintf("error occurred updating the manifest: %s", err.Error()))
 		return nil, status.Error(codes.Internal, "manifest update error")
 	}
@@ -254,10 +345,170 @@ func (rs *RemoteChunkStore) Commit(ctx context.Context, req *remotesapi.CommitRe
 	return &remotesapi.CommitResponse{Success: ok}, nil
 }
 
+func (rs *RemoteChunkStore) ListRefs(ctx context.Context, req *remotesapi.ListRefsRequest) (*remotesapi.ListRefsResponse, error) {
+	logger := getReqLogger("GRPC", "ListRefs")
+	defer func() { logger("finished") }()
+
+	err := rs.authorize(ctx, logger, req.RepoId, ReadPermission)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cs := rs.getStore(req.RepoId, "ListRefs")
+
+	if cs == nil {
+		return nil, status.Error(codes.Internal, "Could not get chunkstore")
+	}
+
+	datasets, err := datas.NewDatabase(cs).Datasets(ctx)
+
+	if err != nil {
+		logger(fmt.Sprintf("error occurred reading the refs of %s/%s details: %v", req.RepoId.Org, req.RepoId.RepoName, err))
+		return nil, status.Error(codes.Internal, "Failed to read refs")
+	}
+
+	refs := make([]*remotesapi.Ref, 0, datasets.Len())
+	err = datasets.IterAll(ctx, func(k, v types.Value) error {
+		h := v.(types.Ref).TargetHash()
+		refs = append(refs, &remotesapi.Ref{Name: string(k.(types.String)), Hash: h[:]})
+		return nil
+	})
+
+	if err != nil {
+		logger(fmt.Sprintf("error occurred reading the refs of %s/%s details: %v", req.RepoId.Org, req.RepoId.RepoName, err))
+		return nil, status.Error(codes.Internal, "Failed to read refs")
+	}
+
+	return &remotesapi.ListRefsResponse{Refs: refs}, nil
+}
+
+func (rs *RemoteChunkStore) UpdateRefs(ctx context.Context, req *remotesapi.UpdateRefsRequest) (*remotesapi.UpdateRefsResponse, error) {
+	logger := getReqLogger("GRPC", "UpdateRefs")
+	defer func() { logger("finished") }()
+
+	err := rs.authorize(ctx, logger, req.RepoId, WritePermission)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cs := rs.getStore(req.RepoId, "UpdateRefs")
+
+	if cs == nil {
+		return nil, status.Error(codes.Internal, "Could not get chunkstore")
+	}
+
+	logger(fmt.Sprintf("found %s/%s", req.RepoId.Org, req.RepoId.RepoName))
+
+	headUpdates := make([]datas.HeadUpdate, len(req.Updates))
+	for i, update := range req.Updates {
+		expected, err := parseRefHash(update.ExpectedHash)
+
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		newHash, err := parseRefHash(update.NewHash)
+
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		headUpdates[i] = datas.HeadUpdate{ID: update.Name, Expected: expected, New: newHash}
+	}
+
+	updates := make(map[hash.Hash]uint32)
+	for _, cti := range req.ChunkTableInfo {
+		updates[hash.New(cti.Hash)] = cti.ChunkCount
+	}
+
+	_, err = cs.UpdateManifestWithKeyID(ctx, updates, req.KeyId)
+
+	if err == nbs.ErrEncryptionKeyMismatch || err == nbs.ErrEncryptionKeyRequired || err == nbs.ErrTableFilesUnencrypted {
+		logger(fmt.Sprintf("table files of %s/%s not added: %v", req.RepoId.Org, req.RepoId.RepoName, err))
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	} else if err != nil {
+		logger(fmt.Sprintf("error occurred updating the manifest: %s", err.Error()))
+		return nil, status.Error(codes.Internal, "manifest update error")
+	}
+
+	err = datas.NewDatabase(cs).UpdateHeads(ctx, headUpdates)
+
+	if err == datas.ErrHeadChanged {
+		logger(fmt.Sprintf("refs of %s/%s changed, not updating", req.RepoId.Org, req.RepoId.RepoName))
+		return &remotesapi.UpdateRefsResponse{Success: false}, nil
+	} else if err != nil {
+		logger(fmt.Sprintf("error occurred during processing of UpdateRefs of %s/%s details: %v", req.RepoId.Org, req.RepoId.RepoName, err))
+		return nil, status.Error(codes.Internal, "Failed to update refs")
+	}
+
+	logger(fmt.Sprintf("updated %d refs of %s/%s", len(headUpdates), req.RepoId.Org, req.RepoId.RepoName))
+	return &remotesapi.UpdateRefsResponse{Success: true}, nil
+}
+
+func (rs *RemoteChunkStore) ListTableFiles(ctx context.Context, req *remotesapi.ListTableFilesRequest) (*remotesapi.ListTableFilesResponse, error) {
+	logger := getReqLogger("GRPC", "ListTableFiles")
+	defer func() { logger("finished") }()
+
+	err := rs.authorize(ctx, logger, req.RepoId, ReadPermission)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cs := rs.getStore(req.RepoId, "ListTableFiles")
+
+	if cs == nil {
+		return nil, status.Error(codes.Internal, "Could not get chunkstore")
+	}
+
+	logger(fmt.Sprintf("found repo %s/%s", req.RepoId.Org, req.RepoId.RepoName))
+
+	tfs, err := cs.TableFiles(ctx)
+
+	if err != nil {
+		logger(fmt.Sprintf("error occurred listing the table files of %s/%s details: %v", req.RepoId.Org, req.RepoId.RepoName, err))
+		return nil, status.Error(codes.Internal, "Failed to list table files")
+	}
+
+	tableFileInfo := make([]*remotesapi.TableFileInfo, len(tfs))
+	for i, tf := range tfs {
+		h := tf.Name()
+		url, err := rs.getDownloadUrl(logger, req.RepoId.Org, req.RepoId.RepoName, h.String())
+
+		if err != nil {
+			return nil, status.Error(codes.Internal, "Failed to get download Url.")
+		}
+
+		tableFileInfo[i] = &remotesapi.TableFileInfo{Hash: h[:], ChunkCount: tf.ChunkCount(), Url: url}
+	}
+
+	logger(fmt.Sprintf("listed %d table files of %s/%s", len(tableFileInfo), req.RepoId.Org, req.RepoId.RepoName))
+	return &remotesapi.ListTableFilesResponse{TableFileInfo: tableFileInfo}, nil
+}
+
+// parseRefHash parses the bytes of a ref hash sent by a client, where no bytes is the empty hash
+func parseRefHash(b []byte) (hash.Hash, error) {
+	if len(b) == 0 {
+		return hash.Hash{}, nil
+	} else if len(b) != hash.ByteLen {
+		return hash.Hash{}, fmt.Errorf("invalid ref hash of %d bytes", len(b))
+	}
+
+	return hash.New(b), nil
+}
+
 func (rs *RemoteChunkStore) GetRepoMetadata(ctx context.Context, req *remotesapi.GetRepoMetadataRequest) (*remotesapi.GetRepoMetadataResponse, error) {
 	logger := getReqLogger("GRPC", "GetRepoMetadata")
 	defer func() { logger("finished") }()
 
+	err := rs.authorize(ctx, logger, req.RepoId, ReadPermission)
+
+	if err != nil {
+		return nil, err
+	}
+
 	cs := rs.getStore(req.RepoId, "GetRepoMetadata")
 	if cs == nil {
 		return nil, status.Error(codes.Internal, "Could not get chunkstore")
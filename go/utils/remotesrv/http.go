@@ -15,6 +15,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -25,11 +26,18 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/liquidata-inc/dolt/go/libraries/utils/iohelp"
+	"github.com/liquidata-inc/dolt/go/store/blobstore"
 	"github.com/liquidata-inc/dolt/go/store/hash"
 )
 
-func ServeHTTP(respWr http.ResponseWriter, req *http.Request) {
+// fileHandler is the http file server from which clients read and to which they write the files of the repositories.
+// If it has a URLSigner, only requests with urls signed by the grpc server are served.
+type fileHandler struct {
+	signer *URLSigner
+	cache  *DBCache
+}
+
+func (fh fileHandler) ServeHTTP(respWr http.ResponseWriter, req *http.Request) {
 	logger := getReqLogger("HTTP_"+req.Method, req.RequestURI)
 	defer func() { logger("finished") }()
 
@@ -39,20 +47,50 @@ func ServeHTTP(respWr http.ResponseWriter, req *http.Request) {
 	if len(tokens) != 3 {
 		logger(fmt.Sprintf("response to: %v method: %v http response code: %v", req.RequestURI, req.Method, http.StatusNotFound))
 		respWr.WriteHeader(http.StatusNotFound)
+		return
 	}
 
 	org := tokens[0]
 	repo := tokens[1]
 	hashStr := tokens[2]
 
+	if !validRepoName(org) || !validRepoName(repo) {
+		logger(fmt.Sprintf("response to: %v method: %v http response code: %v", req.RequestURI, req.Method, http.StatusBadRequest))
+		respWr.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if fh.signer != nil {
+		perm := ReadPermission
+		if req.Method != http.MethodGet {
+			perm = WritePermission
+		}
+
+		err := fh.signer.Verify(perm, req.URL.Path, req.URL.Query())
+
+		if err != nil {
+			logger(fmt.Sprintf("response to: %v method: %v http response code: %v error: %v", req.RequestURI, req.Method, http.StatusForbidden, err))
+			respWr.WriteHeader(http.StatusForbidden)
+			return
+		}
+	}
+
+	bs, err := fh.cache.Blobstore(req.Context(), org, repo)
+
+	if err != nil {
+		logger(fmt.Sprintf("failed to get the blobstore of %s/%s: %v", org, repo, err))
+		respWr.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
 	statusCode := http.StatusMethodNotAllowed
 	switch req.Method {
 	case http.MethodGet:
 		rangeStr := req.Header.Get("Range")
-		statusCode = readChunk(logger, org, repo, hashStr, rangeStr, respWr)
+		statusCode = readChunk(req.Context(), logger, bs, org, repo, hashStr, rangeStr, respWr)
 
 	case http.MethodPost, http.MethodPut:
-		statusCode = writeChunk(logger, org, repo, hashStr, req)
+		statusCode = writeChunk(logger, bs, org, repo, hashStr, req)
 	}
 
 	if statusCode != -1 {
@@ -60,7 +98,7 @@ func ServeHTTP(respWr http.ResponseWriter, req *http.Request) {
 	}
 }
 
-func writeChunk(logger func(string), org, repo, fileId string, request *http.Request) int {
+func writeChunk(logger func(string), bs blobstore.Blobstore, org, repo, fileId string, request *http.Request) int {
 	_, ok := hash.MaybeParse(fileId)
 
 	if !ok {
@@ -69,15 +107,14 @@ func writeChunk(logger func(string), org, repo, fileId string, request *http.Req
 	}
 
 	logger(fileId + " is valid")
-	data, err := ioutil.ReadAll(request.Body)
 
-	if err != nil {
-		logger("failed to read body " + err.Error())
-		return http.StatusInternalServerError
+	var err error
+	if bs != nil {
+		err = writeBlob(request.Context(), logger, bs, fileId, request.Body)
+	} else {
+		err = writeLocal(logger, org, repo, fileId, request.Body)
 	}
 
-	err = writeLocal(logger, org, repo, fileId, data)
-
 	if err != nil {
 		return http.StatusInternalServerError
 	}
@@ -85,17 +122,47 @@ func writeChunk(logger func(string), org, repo, fileId string, request *http.Req
 	return http.StatusOK
 }
 
-func writeLocal(logger func(string), org, repo, fileId string, data []byte) error {
+// writeLocal streams the body of a request to a file, which is written under a temporary name and renamed once it is
+// complete so that a partial upload is never read.
+func writeLocal(logger func(string), org, repo, fileId string, rd io.Reader) error {
 	path := filepath.Join(org, repo, fileId)
+	f, err := ioutil.TempFile(filepath.Join(org, repo), "upload")
 
-	err := ioutil.WriteFile(path, data, os.ModePerm)
+	if err != nil {
+		logger(fmt.Sprintf("failed to create a temporary file for %s: %v", path, err))
+		return err
+	}
+
+	n, err := io.Copy(f, rd)
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+
+	if err != nil {
+		_ = os.Remove(f.Name())
+		logger(fmt.Sprintf("failed to write file %s: %v", path, err))
+		return err
+	}
+
+	logger(fmt.Sprintf("Successfully wrote %d bytes to storage", n))
+
+	return nil
+}
+
+func writeBlob(ctx context.Context, logger func(string), bs blobstore.Blobstore, fileId string, rd io.Reader) error {
+	_, err := bs.Put(ctx, fileId, rd)
 
 	if err != nil {
-		logger(fmt.Sprintf("failed to write file %s", path))
+		logger(fmt.Sprintf("failed to write blob %s: %v", fileId, err))
 		return err
 	}
 
-	logger("Successfully wrote object to storage")
+	logger("Successfully wrote object to blobstore")
 
 	return nil
 }
@@ -130,7 +197,7 @@ func offsetAndLenFromRange(rngStr string) (int64, int64, error) {
 	return int64(start), int64(end-start) + 1, nil
 }
 
-func readChunk(logger func(string), org, repo, fileId, rngStr string, writer io.Writer) int {
+func readChunk(ctx context.Context, logger func(string), bs blobstore.Blobstore, org, repo, fileId, rngStr string, respWr http.ResponseWriter) int {
 	offset, length, err := offsetAndLenFromRange(rngStr)
 
 	if err != nil {
@@ -138,67 +205,96 @@ func readChunk(logger func(string), org, repo, fileId, rngStr string, writer io.
 		return http.StatusBadRequest
 	}
 
-	data, retVal := readLocalRange(logger, org, repo, fileId, int64(offset), int64(length))
-
-	if retVal != -1 {
-		return retVal
+	if bs != nil {
+		return readBlobRange(ctx, logger, bs, fileId, offset, length, respWr)
 	}
 
-	logger(fmt.Sprintf("writing %d bytes", len(data)))
-	err = iohelp.WriteAll(writer, data)
-
-	if err != nil {
-		logger("failed to write data to response " + err.Error())
-		return -1
-	}
-
-	logger("Successfully wrote data")
-	return -1
+	return readLocalRange(logger, org, repo, fileId, offset, length, respWr)
 }
 
-func readLocalRange(logger func(string), org, repo, fileId string, offset, length int64) ([]byte, int) {
+// readLocalRange streams a range of a file to the response, or the whole file if offset is -1. Returns the status code
+// of the response, or -1 once the response has been written.
+func readLocalRange(logger func(string), org, repo, fileId string, offset, length int64, respWr http.ResponseWriter) int {
 	path := filepath.Join(org, repo, fileId)
 
 	logger(fmt.Sprintf("Attempting to read bytes %d to %d from %s", offset, offset+length, path))
-	info, err := os.Stat(path)
+	f, err := os.Open(path)
 
-	if err != nil {
+	if os.IsNotExist(err) {
 		logger(fmt.Sprintf("file %s not found", path))
-		return nil, http.StatusNotFound
+		return http.StatusNotFound
+	} else if err != nil {
+		logger(fmt.Sprintf("Failed to open %s: %v", path, err))
+		return http.StatusInternalServerError
+	}
+
+	defer f.Close()
+
+	info, err := f.Stat()
+
+	if err != nil {
+		logger(fmt.Sprintf("Failed to stat %s: %v", path, err))
+		return http.StatusInternalServerError
 	}
 
-	logger(fmt.Sprintf("Verified file %s exists", path))
+	if offset == -1 {
+		// no range was requested, so the whole file is read
+		offset, length = 0, info.Size()
+	}
 
-	if info.Size() < int64(offset+length) {
+	if info.Size() < offset+length {
 		logger(fmt.Sprintf("Attempted to read bytes %d to %d, but the file is only %d bytes in size", offset, offset+length, info.Size()))
-		return nil, http.StatusBadRequest
+		return http.StatusBadRequest
 	}
 
-	logger(fmt.Sprintf("Verified the file is large enough to contain the range"))
-	f, err := os.Open(path)
+	respWr.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+	n, err := io.Copy(respWr, io.NewSectionReader(f, offset, length))
 
 	if err != nil {
-		logger(fmt.Sprintf("Failed to open %s", path))
-		return nil, http.StatusInternalServerError
+		logger(fmt.Sprintf("failed to write data to response after %d bytes: %v", n, err))
+		return -1
 	}
 
-	logger(fmt.Sprintf("Successfully opened file"))
-	pos, err := f.Seek(int64(offset), 0)
+	logger(fmt.Sprintf("Successfully wrote %d bytes", n))
+	return -1
+}
+
+// readBlobRange streams a range of a blob to the response, or the whole blob if offset is -1. Returns the status code
+// of the response, or -1 once the response has been written.
+func readBlobRange(ctx context.Context, logger func(string), bs blobstore.Blobstore, fileId string, offset, length int64, respWr http.ResponseWriter) int {
+	br := blobstore.AllRange
+	if offset != -1 {
+		br = blobstore.NewBlobRange(offset, length)
+	}
 
-	if err != nil {
-		logger(fmt.Sprintf("Failed to seek to %d", offset))
-		return nil, http.StatusInternalServerError
+	logger(fmt.Sprintf("Attempting to read bytes %d to %d from blob %s", offset, offset+length, fileId))
+	rc, _, err := bs.Get(ctx, fileId, br)
+
+	if blobstore.IsNotFoundError(err) {
+		logger(fmt.Sprintf("blob %s not found", fileId))
+		return http.StatusNotFound
+	} else if err != nil {
+		logger(fmt.Sprintf("Failed to read blob %s: %v", fileId, err))
+		return http.StatusInternalServerError
+	}
+
+	defer rc.Close()
+
+	if offset != -1 {
+		// a blob too short for the range is cut short, which the client detects by the content length
+		respWr.Header().Set("Content-Length", strconv.FormatInt(length, 10))
 	}
 
-	logger(fmt.Sprintf("Seek succeeded.  Current position is %d", pos))
-	diff := int64(offset) - pos
-	data, err := iohelp.ReadNBytes(f, int(diff+int64(length)))
+	n, err := io.Copy(respWr, rc)
 
 	if err != nil {
-		logger(fmt.Sprintf("Failed to read %d bytes", diff+int64(length)))
-		return nil, http.StatusInternalServerError
+		logger(fmt.Sprintf("failed to write data to response after %d bytes: %v", n, err))
+		return -1
+	} else if offset != -1 && n != length {
+		logger(fmt.Sprintf("Attempted to read %d bytes, but only %d could be read", length, n))
+		return -1
 	}
 
-	logger(fmt.Sprintf("Successfully read %d bytes", len(data)))
-	return data[diff:], -1
+	logger(fmt.Sprintf("Successfully wrote %d bytes", n))
+	return -1
 }
@@ -24,6 +24,7 @@ import (
 	"os"
 	"os/signal"
 	"sync"
+	"time"
 
 	"google.golang.org/grpc"
 
@@ -35,6 +36,9 @@ func main() {
 	dirParam := flag.String("dir", "", "root directory that this command will run in.")
 	grpcPortParam := flag.Int("grpc-port", -1, "root directory that this command will run in.")
 	httpPortParam := flag.Int("http-port", -1, "root directory that this command will run in.")
+	authConfigParam := flag.String("auth-config", "", "json file listing the public keys of the clients allowed to access the server, and their permissions on each repository.")
+	blobstoreParam := flag.String("blobstore", "", "blobstore the repositories are stored in instead of the local filesystem. One of 'mem', 'file:///path/to/dir' or 'gs://bucket/path'.")
+	urlTTLParam := flag.Duration("url-ttl", 15*time.Minute, "how long the signed urls of the http file server are valid for.")
 	flag.Parse()
 
 	if dirParam != nil && len(*dirParam) > 0 {
@@ -65,7 +69,38 @@ func main() {
 		log.Println("'grpc-port' parameter not provided. Using default port 50051")
 	}
 
-	stopChan, wg := startServer(httpHost, *httpPortParam, *grpcPortParam)
+	var auth *Authorizer
+	var signer *URLSigner
+	if len(*authConfigParam) > 0 {
+		var err error
+		auth, err = LoadAuthorizer(*authConfigParam)
+
+		if err != nil {
+			log.Fatalln("failed to load auth config:", err.Error())
+		}
+
+		signer, err = NewURLSigner(*urlTTLParam)
+
+		if err != nil {
+			log.Fatalln("failed to create url signing key:", err.Error())
+		}
+	} else {
+		log.Println("'auth-config' parameter not provided. Every request will be allowed.")
+	}
+
+	dbCache := NewLocalCSCache(filesys.LocalFS)
+	if len(*blobstoreParam) > 0 {
+		blobstores, err := NewBlobstoreProvider(context.Background(), *blobstoreParam)
+
+		if err != nil {
+			log.Fatalln("failed to open blobstore:", err.Error())
+		}
+
+		dbCache = NewBlobstoreCSCache(blobstores)
+		log.Println("serving repositories from blobstore " + *blobstoreParam)
+	}
+
+	stopChan, wg := startServer(httpHost, *httpPortParam, *grpcPortParam, dbCache, auth, signer)
 	waitForSignal()
 
 	close(stopChan)
@@ -80,32 +115,31 @@ func waitForSignal() {
 	<-c
 }
 
-func startServer(httpHost string, httpPort, grpcPort int) (chan interface{}, *sync.WaitGroup) {
+func startServer(httpHost string, httpPort, grpcPort int, dbCache *DBCache, auth *Authorizer, signer *URLSigner) (chan interface{}, *sync.WaitGroup) {
 	wg := sync.WaitGroup{}
 	stopChan := make(chan interface{})
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		httpServer(httpPort, stopChan)
+		httpServer(httpPort, dbCache, signer, stopChan)
 	}()
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		grpcServer(httpHost, grpcPort, stopChan)
+		grpcServer(httpHost, grpcPort, dbCache, auth, signer, stopChan)
 	}()
 
 	return stopChan, &wg
 }
 
-func grpcServer(httpHost string, grpcPort int, stopChan chan interface{}) {
+func grpcServer(httpHost string, grpcPort int, dbCache *DBCache, auth *Authorizer, signer *URLSigner, stopChan chan interface{}) {
 	defer func() {
 		log.Println("exiting grpc Server go routine")
 	}()
 
-	dbCache := NewLocalCSCache(filesys.LocalFS)
-	chnkSt := NewHttpFSBackedChunkStore(httpHost, dbCache)
+	chnkSt := NewHttpFSBackedChunkStore(httpHost, dbCache, auth, signer)
 
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", grpcPort))
 	if err != nil {
@@ -125,14 +159,14 @@ func grpcServer(httpHost string, grpcPort int, stopChan chan interface{}) {
 	grpcServer.GracefulStop()
 }
 
-func httpServer(httpPort int, stopChan chan interface{}) {
+func httpServer(httpPort int, dbCache *DBCache, signer *URLSigner, stopChan chan interface{}) {
 	defer func() {
 		log.Println("exiting http Server go routine")
 	}()
 
 	server := http.Server{
 		Addr:    fmt.Sprintf(":%d", httpPort),
-		Handler: http.HandlerFunc(ServeHTTP),
+		Handler: fileHandler{signer, dbCache},
 	}
 
 	go func() {